@@ -0,0 +1,51 @@
+package main
+
+import (
+	"log/slog"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/ernestngugi/sil-backend/internal/db"
+	"github.com/ernestngugi/sil-backend/internal/env"
+	"github.com/ernestngugi/sil-backend/internal/health"
+	"github.com/ernestngugi/sil-backend/providers"
+)
+
+// newReadiness builds the /readyz checks. The database and migration checks
+// always run; the OIDC and Africa's Talking probes are opt-in because an
+// outage there should not necessarily take every replica out of rotation.
+func newReadiness(dB *db.AppDB, logger *slog.Logger) (*health.Readiness, error) {
+
+	migrator, err := db.NewMigrator(dB.DB, logger)
+	if err != nil {
+		return &health.Readiness{}, err
+	}
+
+	checks := []health.Check{
+		health.DatabaseCheck(dB),
+		health.MigrationCheck(migrator),
+	}
+
+	client := &http.Client{Timeout: 3 * time.Second}
+
+	checkOIDC, err := env.Bool("READINESS_CHECK_OIDC", false)
+	if err != nil {
+		return &health.Readiness{}, err
+	}
+
+	if checkOIDC {
+		checks = append(checks, health.HTTPCheck("oidc", providers.OIDCIssuerURL+"/.well-known/openid-configuration", client))
+	}
+
+	checkAT, err := env.Bool("READINESS_CHECK_AT", false)
+	if err != nil {
+		return &health.Readiness{}, err
+	}
+
+	if checkAT {
+		checks = append(checks, health.HTTPCheck("africastalking", os.Getenv("AT_BASE_URL"), client))
+	}
+
+	return health.NewReadiness(checks...), nil
+}
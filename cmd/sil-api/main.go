@@ -1,24 +1,50 @@
 package main
 
 import (
+	"context"
 	"errors"
 	"flag"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
+	// Quiet hours are kept in the customer's time zone, which must resolve
+	// in images without a zoneinfo database.
+	_ "time/tzdata"
 
+	"github.com/ernestngugi/sil-backend/internal/carts"
 	"github.com/ernestngugi/sil-backend/internal/db"
+	"github.com/ernestngugi/sil-backend/internal/invoices"
+	"github.com/ernestngugi/sil-backend/internal/logging"
+	"github.com/ernestngugi/sil-backend/internal/loyalty"
+	"github.com/ernestngugi/sil-backend/internal/metrics"
+	"github.com/ernestngugi/sil-backend/internal/notifications"
+	"github.com/ernestngugi/sil-backend/internal/orderevents"
+	"github.com/ernestngugi/sil-backend/internal/payments"
+	"github.com/ernestngugi/sil-backend/internal/repos"
+	"github.com/ernestngugi/sil-backend/internal/tax"
+	"github.com/ernestngugi/sil-backend/internal/tracing"
+	"github.com/ernestngugi/sil-backend/internal/web/auth"
 	"github.com/ernestngugi/sil-backend/internal/web/router"
+	"github.com/ernestngugi/sil-backend/internal/webhooks"
 	"github.com/ernestngugi/sil-backend/providers"
 	"github.com/joho/godotenv"
 )
 
 const defaultPort = "3000"
 
+var errUsage = errors.New("invalid usage")
+
 func main() {
 
 	var envFilePath string
+	var noMigrate bool
 	flag.StringVar(&envFilePath, "e", "", "Path to env file")
+	flag.BoolVar(&noMigrate, "no-migrate", false, "Do not apply pending migrations on startup")
+	flag.Usage = usage
 	flag.Parse()
 
 	if envFilePath != "" {
@@ -28,31 +54,286 @@ func main() {
 		}
 	}
 
-	dB := db.InitDB()
+	logConfig, err := logging.ConfigFromEnv()
+	if err != nil {
+		panic(err)
+	}
+
+	logger := logging.New(os.Stdout, logConfig)
+	slog.SetDefault(logger)
+
+	err = run(logger, noMigrate)
+	if err != nil {
+		if errors.Is(err, errUsage) {
+			fmt.Fprintln(os.Stderr, err)
+			flag.Usage()
+		} else {
+			logger.Error("sil-api failed", slog.String("error", err.Error()))
+		}
+		os.Exit(1)
+	}
+}
+
+func run(logger *slog.Logger, noMigrate bool) error {
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	shutdownTracing, err := tracing.Setup(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to set up tracing: %w", err)
+	}
+
+	defer func() {
+		err := shutdownTracing(context.Background())
+		if err != nil {
+			logger.Error("failed to flush traces", slog.String("error", err.Error()))
+		}
+	}()
+
+	dbConfig, err := db.ConfigFromEnv()
+	if err != nil {
+		return fmt.Errorf("invalid database configuration: %w", err)
+	}
+
+	dB, err := db.InitDBWithConfig(ctx, dbConfig, logger)
+	if err != nil {
+		return fmt.Errorf("failed to initialise database: %w", err)
+	}
 	defer dB.Close()
 
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = defaultPort
+	if flag.NArg() > 0 {
+		switch flag.Arg(0) {
+		case "migrate":
+			return runMigrate(ctx, dB, logger, flag.Args()[1:])
+		case "reconcile":
+			return runReconcile(ctx, dB, logger, noMigrate, flag.Args()[1:])
+		default:
+			return fmt.Errorf("%w: unknown command %q", errUsage, flag.Arg(0))
+		}
+	}
+
+	if !noMigrate {
+		err = db.RunMigrations(ctx, dB)
+		if err != nil {
+			return fmt.Errorf("failed to migrate database: %w", err)
+		}
+	}
+
+	config, err := serverConfigFromEnv()
+	if err != nil {
+		return fmt.Errorf("invalid server configuration: %w", err)
 	}
 
-	atProvider := providers.NewATProvider()
-	oidcProvider := providers.NewOpenID()
+	appMetrics := metrics.New()
 
-	appRouter := router.BuildRouter(dB, atProvider, oidcProvider)
+	err = appMetrics.RegisterDB(dB.DB)
+	if err != nil {
+		return fmt.Errorf("failed to register database metrics: %w", err)
+	}
 
-	server := &http.Server{
-		Addr:    ":" + port,
-		Handler: appRouter,
+	smsSender, err := newSMSSender(appMetrics, logger)
+	if err != nil {
+		return fmt.Errorf("invalid sms configuration: %w", err)
 	}
 
-	fmt.Printf("api starting, listening on :%v", port)
+	oidcProvider := tracing.NewOpenID(providers.NewOpenID())
 
-	if err := server.ListenAndServe(); err != nil {
-		if errors.Is(err, http.ErrServerClosed) {
-			fmt.Println("server shutdown")
-		} else {
-			fmt.Printf("server shutdown unexpectedly %v", err)
+	smsSender = notifications.NewSuppressionFilter(dB, repos.NewSMSSuppressionRepository(), smsSender, logger)
+
+	smsDispatcher := notifications.NewSMSDispatcher(dB, smsSender, repos.NewSMSOutboxRepository(), logger)
+	smsDispatcher.Start()
+
+	emailEnabled := true
+
+	emailSender, err := providers.NewSMTPProvider(logger)
+	switch {
+	case errors.Is(err, providers.ErrSMTPNotConfigured):
+		logger.Warn("SMTP_HOST is not set, customers who chose email are sent sms")
+		emailSender = providers.NewNoopEmailSender()
+		emailEnabled = false
+	case err != nil:
+		return fmt.Errorf("invalid email configuration: %w", err)
+	}
+
+	emailDispatcher := notifications.NewEmailDispatcher(metrics.NewEmailSender(providers.ProviderSMTP, emailSender, appMetrics), logger)
+
+	notifier := notifications.NewNotifier(
+		repos.NewNotificationPreferenceRepository(),
+		repos.NewPaymentRepository(),
+		notifications.NewSMSTemplates(repos.NewSMSTemplateRepository(), logger),
+		smsDispatcher,
+		emailDispatcher,
+		emailEnabled,
+	)
+
+	mpesa, err := providers.NewMPesaProvider(logger)
+	if err != nil {
+		return fmt.Errorf("invalid mpesa configuration: %w", err)
+	}
+
+	paymentConfig, err := payments.ConfigFromEnv()
+	if err != nil {
+		return fmt.Errorf("invalid payment configuration: %w", err)
+	}
+
+	taxConfig, err := tax.ConfigFromEnv()
+	if err != nil {
+		return fmt.Errorf("invalid tax configuration: %w", err)
+	}
+
+	invoiceConfig, err := invoices.ConfigFromEnv()
+	if err != nil {
+		return fmt.Errorf("invalid invoice configuration: %w", err)
+	}
+
+	loyaltyConfig, err := loyalty.ConfigFromEnv()
+	if err != nil {
+		return fmt.Errorf("invalid loyalty configuration: %w", err)
+	}
+
+	ledger := loyalty.NewLedger(repos.NewLoyaltyRepository(), loyaltyConfig)
+
+	invoicer := invoices.NewInvoicer(repos.NewCustomerRepository(), repos.NewPaymentRepository(), repos.NewInvoiceRepository(), invoiceConfig)
+
+	paymentProcessor := payments.NewProcessor(mpesa, repos.NewCustomerRepository(), repos.NewOrderRepository(), repos.NewPaymentRepository(), notifier, invoicer, ledger, paymentConfig, logger)
+
+	paymentPoller := payments.NewPoller(dB, paymentProcessor, paymentConfig, logger)
+	paymentPoller.Start()
+
+	cartConfig, err := carts.ConfigFromEnv()
+	if err != nil {
+		return fmt.Errorf("invalid cart configuration: %w", err)
+	}
+
+	cartReminder := carts.NewReminder(dB, repos.NewCartRepository(), repos.NewCustomerRepository(), notifier, taxConfig, cartConfig, logger)
+	cartReminder.Start()
+
+	appReadiness, err := newReadiness(dB, logger)
+	if err != nil {
+		return fmt.Errorf("failed to set up readiness checks: %w", err)
+	}
+
+	limiter, err := newLimiter(dB)
+	if err != nil {
+		return fmt.Errorf("invalid rate limit configuration: %w", err)
+	}
+
+	apiKeys, err := auth.APIKeysFromEnv("API_KEYS")
+	if err != nil {
+		return err
+	}
+
+	adminKeys, err := auth.APIKeysFromEnv("ADMIN_API_KEYS")
+	if err != nil {
+		return err
+	}
+
+	webhookConfig, err := webhooks.ConfigFromEnv()
+	if err != nil {
+		return fmt.Errorf("invalid webhook configuration: %w", err)
+	}
+
+	webhookDeliverer := webhooks.NewDeliverer(dB, repos.NewWebhookRepository(), webhookConfig, logger)
+	webhookDeliverer.Start()
+
+	orderEventBroker := orderevents.NewBroker(dbConfig.URL, logger)
+	orderEventBroker.Start()
+
+	appRouter := router.BuildRouter(dB, notifier, paymentProcessor, invoicer, ledger, taxConfig, orderEventBroker, oidcProvider, appReadiness, appMetrics, limiter, apiKeys, adminKeys, logger)
+
+	err = appRouter.SetTrustedProxies(config.trustedProxies)
+	if err != nil {
+		return fmt.Errorf("invalid TRUSTED_PROXIES: %w", err)
+	}
+
+	server := config.newServer(appRouter)
+	server.ErrorLog = slog.NewLogLogger(logger.Handler(), slog.LevelWarn)
+
+	// Event streams never go idle, so they are ended as soon as shutdown
+	// starts rather than holding it up until the timeout.
+	server.RegisterOnShutdown(func() {
+		err := orderEventBroker.Shutdown(context.Background())
+		if err != nil {
+			logger.Error("failed to stop order event listener", slog.String("error", err.Error()))
 		}
+	})
+
+	serverErrors := make(chan error, 1)
+
+	go func() {
+		logger.Info("api starting", slog.String("port", config.port))
+		serverErrors <- server.ListenAndServe()
+	}()
+
+	// A server that stops on its own, failing to listen say, still shuts
+	// the rest down before its error is returned.
+	var serverErr error
+
+	select {
+	case err := <-serverErrors:
+		if !errors.Is(err, http.ErrServerClosed) {
+			serverErr = fmt.Errorf("server shutdown unexpectedly: %w", err)
+		}
+	case <-ctx.Done():
+		logger.Info("shutdown signal received, draining connections")
+	}
+
+	// Fail readiness first and give load balancers a moment to notice before
+	// the listener closes.
+	appReadiness.SetShuttingDown()
+	time.Sleep(config.shutdownDelay)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), config.shutdownTimeout)
+	defer cancel()
+
+	err = server.Shutdown(shutdownCtx)
+	if err != nil {
+		logger.Error("failed to drain http connections", slog.String("error", err.Error()))
+	}
+
+	// Payments completed while polling stops still queue their SMS, so the
+	// poller stops before the dispatchers, as does the cart reminder.
+	err = paymentPoller.Shutdown(shutdownCtx)
+	if err != nil {
+		logger.Error("failed to finish payment reconciliation", slog.String("error", err.Error()))
+	}
+
+	err = cartReminder.Shutdown(shutdownCtx)
+	if err != nil {
+		logger.Error("failed to finish cart reminders", slog.String("error", err.Error()))
+	}
+
+	err = smsDispatcher.Shutdown(shutdownCtx)
+	if err != nil {
+		logger.Error("failed to flush pending sms", slog.String("error", err.Error()))
 	}
+
+	err = emailDispatcher.Shutdown(shutdownCtx)
+	if err != nil {
+		logger.Error("failed to flush pending email", slog.String("error", err.Error()))
+	}
+
+	err = webhookDeliverer.Shutdown(shutdownCtx)
+	if err != nil {
+		logger.Error("failed to finish webhook deliveries", slog.String("error", err.Error()))
+	}
+
+	logger.Info("server shutdown")
+
+	return serverErr
+}
+
+func usage() {
+	fmt.Fprintf(flag.CommandLine.Output(), `Usage: sil-api [flags] [command]
+
+Without a command the API server is started.
+
+Commands:
+  migrate up|down|status|version|redo   manage database migrations
+  reconcile [-o report.csv] statement   match an M-Pesa statement CSV with orders
+
+Flags:
+`)
+	flag.PrintDefaults()
 }
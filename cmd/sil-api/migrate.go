@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"text/tabwriter"
+
+	"github.com/ernestngugi/sil-backend/internal/db"
+)
+
+func runMigrate(ctx context.Context, dB *db.AppDB, logger *slog.Logger, args []string) error {
+
+	if len(args) != 1 {
+		return fmt.Errorf("%w: migrate expects one of up, down, status, version or redo", errUsage)
+	}
+
+	migrator, err := db.NewMigrator(dB.DB, logger)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "up":
+		return migrator.Up(ctx)
+	case "down":
+		return migrator.Down(ctx)
+	case "redo":
+		return migrator.Redo(ctx)
+	case "status":
+		return printMigrationStatus(ctx, migrator)
+	case "version":
+		current, latest, err := migrator.Version(ctx)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("current version %v, latest version %v\n", current, latest)
+
+		return nil
+	default:
+		return fmt.Errorf("%w: unknown migrate command %q", errUsage, args[0])
+	}
+}
+
+func printMigrationStatus(ctx context.Context, migrator *db.Migrator) error {
+
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		return err
+	}
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "VERSION\tSTATE\tAPPLIED AT\tFILE")
+
+	for _, status := range statuses {
+
+		appliedAt := "-"
+		if !status.AppliedAt.IsZero() {
+			appliedAt = status.AppliedAt.Format("2006-01-02 15:04:05")
+		}
+
+		fmt.Fprintf(writer, "%v\t%v\t%v\t%v\n", status.Source.Version, status.State, appliedAt, status.Source.Path)
+	}
+
+	return writer.Flush()
+}
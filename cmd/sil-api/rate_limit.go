@@ -0,0 +1,27 @@
+package main
+
+import (
+	"github.com/ernestngugi/sil-backend/internal/db"
+	"github.com/ernestngugi/sil-backend/internal/ratelimit"
+)
+
+// newLimiter returns the configured rate limiter, or nil when rate limiting is
+// disabled.
+func newLimiter(dB db.DB) (*ratelimit.Limiter, error) {
+
+	config, err := ratelimit.ConfigFromEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	if !config.Enabled {
+		return nil, nil
+	}
+
+	store := ratelimit.NewMemoryStore()
+	if config.Store == ratelimit.StorePostgres {
+		store = ratelimit.NewPostgresStore(dB)
+	}
+
+	return ratelimit.NewLimiter(store, config.Policies), nil
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+
+	"github.com/ernestngugi/sil-backend/internal/db"
+	"github.com/ernestngugi/sil-backend/internal/model"
+	"github.com/ernestngugi/sil-backend/internal/reconciliation"
+	"github.com/ernestngugi/sil-backend/internal/repos"
+)
+
+// runReconcile imports an M-Pesa statement, matches its transactions with
+// orders and optionally writes the report to a file, or stdout for "-".
+func runReconcile(ctx context.Context, dB *db.AppDB, logger *slog.Logger, noMigrate bool, args []string) error {
+
+	flags := flag.NewFlagSet("reconcile", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+
+	var output string
+	flags.StringVar(&output, "o", "", "write the report CSV to this file, - for stdout")
+
+	err := flags.Parse(args)
+	if err != nil || flags.NArg() != 1 {
+		return fmt.Errorf("%w: reconcile expects [-o report.csv] statement.csv", errUsage)
+	}
+
+	statement, err := os.ReadFile(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	if !noMigrate {
+		err = db.RunMigrations(ctx, dB)
+		if err != nil {
+			return fmt.Errorf("failed to migrate database: %w", err)
+		}
+	}
+
+	reconciliationRepository := repos.NewReconciliationRepository()
+
+	reconciler := reconciliation.NewReconciler(repos.NewOrderRepository(), repos.NewPaymentRepository(), reconciliationRepository, logger)
+
+	run, created, err := reconciler.Import(ctx, dB, filepath.Base(flags.Arg(0)), statement)
+	if err != nil {
+		return err
+	}
+
+	summary := io.Writer(os.Stdout)
+	if output == "-" {
+		summary = os.Stderr
+	}
+
+	err = printReconciliation(summary, run, created)
+	if err != nil {
+		return err
+	}
+
+	if output == "" {
+		return nil
+	}
+
+	entries, err := reconciliationRepository.Entries(ctx, dB, run.ID)
+	if err != nil {
+		return err
+	}
+
+	if output == "-" {
+		return reconciliation.WriteReport(os.Stdout, entries)
+	}
+
+	file, err := os.Create(output)
+	if err != nil {
+		return err
+	}
+
+	err = reconciliation.WriteReport(file, entries)
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
+
+func printReconciliation(writer io.Writer, run *model.ReconciliationRun, created bool) error {
+
+	if !created {
+		fmt.Fprintf(writer, "statement already imported as reconciliation %v on %v\n", run.ID, run.DateCreated.Format("2006-01-02 15:04:05"))
+	} else {
+		fmt.Fprintf(writer, "imported %v as reconciliation %v\n", run.FileName, run.ID)
+	}
+
+	tabWriter := tabwriter.NewWriter(writer, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tabWriter, "TRANSACTIONS\tMATCHED\tUNMATCHED\tDUPLICATE\tAMOUNT MISMATCH")
+	fmt.Fprintf(tabWriter, "%v\t%v\t%v\t%v\t%v\n", run.Transactions, run.Matched, run.Unmatched, run.Duplicates, run.AmountMismatches)
+
+	return tabWriter.Flush()
+}
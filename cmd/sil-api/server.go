@@ -0,0 +1,87 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ernestngugi/sil-backend/internal/env"
+)
+
+const (
+	defaultReadTimeout       = 15 * time.Second
+	defaultReadHeaderTimeout = 5 * time.Second
+	defaultWriteTimeout      = 30 * time.Second
+	defaultIdleTimeout       = 120 * time.Second
+	defaultShutdownTimeout   = 30 * time.Second
+	defaultShutdownDelay     = 0
+)
+
+type serverConfig struct {
+	port              string
+	readTimeout       time.Duration
+	readHeaderTimeout time.Duration
+	writeTimeout      time.Duration
+	idleTimeout       time.Duration
+	// shutdownTimeout bounds how long in-flight requests and queued
+	// notifications are given to finish once a stop signal arrives.
+	shutdownTimeout time.Duration
+	// shutdownDelay is how long /readyz reports failing before the listener
+	// stops accepting connections.
+	shutdownDelay time.Duration
+	// trustedProxies are the addresses allowed to set X-Forwarded-For. The
+	// client IP decides rate limit buckets, so no proxy is trusted by default.
+	trustedProxies []string
+}
+
+func serverConfigFromEnv() (*serverConfig, error) {
+
+	config := &serverConfig{
+		port: env.String("PORT", defaultPort),
+	}
+
+	for _, proxy := range strings.Split(env.String("TRUSTED_PROXIES", ""), ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			config.trustedProxies = append(config.trustedProxies, proxy)
+		}
+	}
+
+	var err error
+
+	if config.readTimeout, err = env.Duration("SERVER_READ_TIMEOUT", defaultReadTimeout); err != nil {
+		return &serverConfig{}, err
+	}
+
+	if config.readHeaderTimeout, err = env.Duration("SERVER_READ_HEADER_TIMEOUT", defaultReadHeaderTimeout); err != nil {
+		return &serverConfig{}, err
+	}
+
+	if config.writeTimeout, err = env.Duration("SERVER_WRITE_TIMEOUT", defaultWriteTimeout); err != nil {
+		return &serverConfig{}, err
+	}
+
+	if config.idleTimeout, err = env.Duration("SERVER_IDLE_TIMEOUT", defaultIdleTimeout); err != nil {
+		return &serverConfig{}, err
+	}
+
+	if config.shutdownTimeout, err = env.Duration("SERVER_SHUTDOWN_TIMEOUT", defaultShutdownTimeout); err != nil {
+		return &serverConfig{}, err
+	}
+
+	if config.shutdownDelay, err = env.Duration("SERVER_SHUTDOWN_DELAY", defaultShutdownDelay); err != nil {
+		return &serverConfig{}, err
+	}
+
+	return config, nil
+}
+
+func (c *serverConfig) newServer(handler http.Handler) *http.Server {
+	return &http.Server{
+		Addr:              ":" + c.port,
+		Handler:           handler,
+		ReadTimeout:       c.readTimeout,
+		ReadHeaderTimeout: c.readHeaderTimeout,
+		WriteTimeout:      c.writeTimeout,
+		IdleTimeout:       c.idleTimeout,
+	}
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/ernestngugi/sil-backend/internal/metrics"
+	"github.com/ernestngugi/sil-backend/internal/sms"
+	"github.com/ernestngugi/sil-backend/providers"
+)
+
+// newSMSSender sets up the providers named in SMS_PROVIDERS behind a router
+// that picks between them per message.
+func newSMSSender(appMetrics *metrics.Metrics, logger *slog.Logger) (providers.SMSSender, error) {
+
+	config, err := sms.ConfigFromEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	senders := make(map[string]providers.SMSSender)
+
+	for _, name := range config.Providers {
+
+		var sender providers.SMSSender
+
+		switch name {
+		case providers.ProviderAT:
+			sender = providers.NewATProvider(logger)
+		case providers.ProviderTwilio:
+			sender = providers.NewTwilioProvider(logger)
+		default:
+			return nil, fmt.Errorf("unknown sms provider %q", name)
+		}
+
+		senders[name] = metrics.NewSMSSender(name, sender, appMetrics)
+	}
+
+	return sms.NewRouter(senders, config, appMetrics, logger)
+}
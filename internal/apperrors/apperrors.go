@@ -0,0 +1,176 @@
+package apperrors
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+type Kind string
+
+const (
+	KindValidation   Kind = "validation"
+	KindUnauthorized Kind = "unauthorized"
+	KindForbidden    Kind = "forbidden"
+	KindNotFound     Kind = "not_found"
+	KindConflict     Kind = "conflict"
+	KindRateLimited  Kind = "rate_limited"
+	KindUnavailable  Kind = "unavailable"
+	KindInternal     Kind = "internal"
+)
+
+type (
+	// Error is a domain error carrying a stable, machine readable code that
+	// clients can switch on, and a human readable message.
+	Error struct {
+		Kind    Kind
+		Code    string
+		Message string
+		Fields  []FieldError
+		Err     error
+	}
+
+	FieldError struct {
+		Field   string `json:"field"`
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	}
+)
+
+func New(kind Kind, code, message string) *Error {
+	return &Error{
+		Kind:    kind,
+		Code:    code,
+		Message: message,
+	}
+}
+
+func Validation(code, message string, fields ...FieldError) *Error {
+	return &Error{
+		Kind:    KindValidation,
+		Code:    code,
+		Message: message,
+		Fields:  fields,
+	}
+}
+
+func Unauthorized(code, message string) *Error {
+	return New(KindUnauthorized, code, message)
+}
+
+func Forbidden(code, message string) *Error {
+	return New(KindForbidden, code, message)
+}
+
+func NotFound(code, message string) *Error {
+	return New(KindNotFound, code, message)
+}
+
+func Conflict(code, message string) *Error {
+	return New(KindConflict, code, message)
+}
+
+func RateLimited(code, message string) *Error {
+	return New(KindRateLimited, code, message)
+}
+
+func Unavailable(code, message string) *Error {
+	return New(KindUnavailable, code, message)
+}
+
+func Internal(err error) *Error {
+	return &Error{
+		Kind:    KindInternal,
+		Code:    "internal_error",
+		Message: "internal server error",
+		Err:     err,
+	}
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Wrap returns a copy of e recording err as its cause.
+func (e *Error) Wrap(err error) *Error {
+	wrapped := *e
+	wrapped.Err = err
+	return &wrapped
+}
+
+// Is reports whether err is a domain error of the given kind.
+func Is(err error, kind Kind) bool {
+	var appErr *Error
+	return errors.As(err, &appErr) && appErr.Kind == kind
+}
+
+// From converts any error into a domain error. Errors that are not already
+// domain errors are classified from well known database and network errors,
+// falling back to an internal error.
+func From(err error) *Error {
+
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return NotFound("not_found", "resource not found").Wrap(err)
+	}
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch {
+		case pqErr.Code.Name() == "unique_violation":
+			return Conflict("conflict", "resource already exists").Wrap(err)
+		case pqErr.Code.Name() == "foreign_key_violation":
+			return Validation("invalid_reference", "referenced resource does not exist").Wrap(err)
+		case pqErr.Code.Name() == "query_canceled",
+			strings.HasPrefix(string(pqErr.Code), "08"),
+			strings.HasPrefix(string(pqErr.Code), "53"),
+			strings.HasPrefix(string(pqErr.Code), "57"):
+			return Unavailable("database_unavailable", "database unavailable").Wrap(err)
+		}
+	}
+
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) ||
+		errors.Is(err, driver.ErrBadConn) ||
+		errors.Is(err, sql.ErrConnDone) ||
+		errors.As(err, &netErr) {
+		return Unavailable("service_unavailable", "service temporarily unavailable").Wrap(err)
+	}
+
+	return Internal(err)
+}
+
+func (k Kind) HTTPStatus() int {
+	switch k {
+	case KindValidation:
+		return http.StatusBadRequest
+	case KindUnauthorized:
+		return http.StatusUnauthorized
+	case KindForbidden:
+		return http.StatusForbidden
+	case KindNotFound:
+		return http.StatusNotFound
+	case KindConflict:
+		return http.StatusConflict
+	case KindRateLimited:
+		return http.StatusTooManyRequests
+	case KindUnavailable:
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
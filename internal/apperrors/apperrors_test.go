@@ -0,0 +1,49 @@
+package apperrors
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFrom(t *testing.T) {
+
+	t.Run("keeps domain errors", func(t *testing.T) {
+
+		err := NotFound("order_not_found", "order not found").Wrap(sql.ErrNoRows)
+
+		appErr := From(err)
+
+		assert.Equal(t, KindNotFound, appErr.Kind)
+		assert.Equal(t, "order_not_found", appErr.Code)
+		assert.True(t, errors.Is(err, sql.ErrNoRows))
+		assert.True(t, Is(err, KindNotFound))
+		assert.Equal(t, http.StatusNotFound, appErr.Kind.HTTPStatus())
+	})
+
+	t.Run("classifies database errors", func(t *testing.T) {
+
+		assert.Equal(t, KindNotFound, From(sql.ErrNoRows).Kind)
+		assert.Equal(t, KindConflict, From(&pq.Error{Code: "23505"}).Kind)
+		assert.Equal(t, KindValidation, From(&pq.Error{Code: "23503"}).Kind)
+		assert.Equal(t, KindUnavailable, From(&pq.Error{Code: "08006"}).Kind)
+		assert.Equal(t, KindUnavailable, From(context.DeadlineExceeded).Kind)
+	})
+
+	t.Run("hides unexpected errors", func(t *testing.T) {
+
+		cause := errors.New("pq: relation \"orders\" does not exist")
+
+		appErr := From(cause)
+
+		assert.Equal(t, KindInternal, appErr.Kind)
+		assert.Equal(t, "internal server error", appErr.Error())
+		assert.True(t, errors.Is(appErr, cause))
+		assert.Equal(t, http.StatusInternalServerError, appErr.Kind.HTTPStatus())
+	})
+}
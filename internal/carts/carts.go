@@ -0,0 +1,76 @@
+package carts
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/ernestngugi/sil-backend/internal/model"
+	"github.com/ernestngugi/sil-backend/internal/tax"
+)
+
+// maxItemLength is the longest item description an order can be stored
+// with.
+const maxItemLength = 50
+
+// Order prices cart as an order by its customer, each line at its product's
+// current price with VAT worked out under the product's category. The
+// amounts of the cart's lines and its subtotal are filled in on the way.
+func Order(cart *model.Cart, taxConfig *tax.Config) (*model.Order, error) {
+
+	order := &model.Order{
+		CustomerID: cart.CustomerID,
+		Lines:      make([]*model.OrderLine, 0, len(cart.Lines)),
+	}
+
+	for _, cartLine := range cart.Lines {
+
+		category, err := taxConfig.Category(cartLine.TaxCategory)
+		if err != nil {
+			return &model.Order{}, err
+		}
+
+		cartLine.Amount = float64(int64(math.Round(cartLine.UnitPrice*100))*int64(cartLine.Quantity)) / 100
+
+		lineTax := tax.Inclusive(category, cartLine.Amount)
+
+		order.Lines = append(order.Lines, &model.OrderLine{
+			ProductID: cartLine.ProductID,
+			Item:      cartLine.Item,
+			Quantity:  cartLine.Quantity,
+			UnitPrice: cartLine.UnitPrice,
+			Amount:    lineTax.GrossAmount,
+			Tax:       lineTax,
+		})
+	}
+
+	order.Tax = tax.Sum(order.Lines)
+	order.Amount = order.Tax.GrossAmount
+	order.Item = Summary(order.Lines)
+
+	cart.Subtotal = order.Amount
+
+	return order, nil
+}
+
+// Summary describes the lines of an order in the few characters an order's
+// item allows, as in "Maize flour 2kg and 2 more".
+func Summary(lines []*model.OrderLine) string {
+
+	if len(lines) == 0 {
+		return ""
+	}
+
+	item := []rune(lines[0].Item)
+	if len(lines) == 1 {
+		return string(item)
+	}
+
+	more := fmt.Sprintf(" and %d more", len(lines)-1)
+
+	if len(item)+len(more) > maxItemLength {
+		item = item[:maxItemLength-len(more)]
+	}
+
+	return strings.TrimSpace(string(item)) + more
+}
@@ -0,0 +1,68 @@
+package carts
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/ernestngugi/sil-backend/internal/apperrors"
+	"github.com/ernestngugi/sil-backend/internal/model"
+	"github.com/ernestngugi/sil-backend/internal/tax"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrder(t *testing.T) {
+
+	taxConfig := &tax.Config{
+		StandardRate: 16,
+		Categories: map[string]*model.TaxCategory{
+			"standard":   {Code: "standard", Treatment: model.TaxTreatmentStandard, Rate: 16},
+			"zero_rated": {Code: "zero_rated", Treatment: model.TaxTreatmentZeroRated},
+		},
+		DefaultCategory: "standard",
+	}
+
+	t.Run("taxes each line under its product's category", func(t *testing.T) {
+
+		cart := &model.Cart{CustomerID: 3, Lines: []*model.CartLine{
+			{ProductID: 1, Item: "Maize flour 2kg", Quantity: 2, UnitPrice: 125, TaxCategory: "zero_rated"},
+			{ProductID: 2, Item: "Cooking oil 1l", Quantity: 3, UnitPrice: 333.33, TaxCategory: "standard"},
+		}}
+
+		order, err := Order(cart, taxConfig)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(3), order.CustomerID)
+		assert.Equal(t, "Maize flour 2kg and 1 more", order.Item)
+		assert.Len(t, order.Lines, 2)
+
+		assert.Equal(t, 250.0, order.Lines[0].Amount)
+		assert.Equal(t, 0.0, order.Lines[0].Tax.TaxAmount)
+		assert.Equal(t, 999.99, order.Lines[1].Amount)
+		assert.Equal(t, 137.93, order.Lines[1].Tax.TaxAmount)
+
+		assert.Equal(t, 1249.99, order.Amount)
+		assert.Equal(t, 137.93, order.Tax.TaxAmount)
+		assert.Empty(t, order.Tax.Category)
+
+		assert.Equal(t, 999.99, cart.Lines[1].Amount)
+		assert.Equal(t, 1249.99, cart.Subtotal)
+	})
+
+	t.Run("rejects products in unknown categories", func(t *testing.T) {
+
+		_, err := Order(&model.Cart{Lines: []*model.CartLine{{Item: "Fuel", Quantity: 1, UnitPrice: 200, TaxCategory: "fuel"}}}, taxConfig)
+		assert.Equal(t, "invalid_tax_category", apperrors.From(err).Code)
+	})
+}
+
+func TestSummary(t *testing.T) {
+
+	assert.Empty(t, Summary(nil))
+	assert.Equal(t, "Sugar 1kg", Summary([]*model.OrderLine{{Item: "Sugar 1kg"}}))
+
+	long := strings.Repeat("Unga wa mahindi ", 4)
+	summary := Summary([]*model.OrderLine{{Item: long}, {Item: "Sugar 1kg"}, {Item: "Salt"}})
+	assert.LessOrEqual(t, utf8.RuneCountInString(summary), maxItemLength)
+	assert.True(t, strings.HasSuffix(summary, " and 2 more"))
+	assert.False(t, strings.Contains(summary, "  "))
+}
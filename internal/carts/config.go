@@ -0,0 +1,42 @@
+package carts
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ernestngugi/sil-backend/internal/env"
+)
+
+type Config struct {
+	// AbandonedAfter is how long a cart may go unchanged before its
+	// customer is reminded of it.
+	AbandonedAfter   time.Duration
+	ReminderInterval time.Duration
+	BatchSize        int
+}
+
+// ConfigFromEnv reads the CART_* settings.
+func ConfigFromEnv() (*Config, error) {
+
+	config := &Config{}
+
+	var err error
+
+	if config.AbandonedAfter, err = env.Duration("CART_ABANDONED_AFTER", 24*time.Hour); err != nil {
+		return &Config{}, err
+	}
+
+	if config.AbandonedAfter <= 0 {
+		return &Config{}, fmt.Errorf("invalid CART_ABANDONED_AFTER: %v is not positive", config.AbandonedAfter)
+	}
+
+	if config.ReminderInterval, err = env.Duration("CART_REMINDER_INTERVAL", 15*time.Minute); err != nil {
+		return &Config{}, err
+	}
+
+	if config.BatchSize, err = env.Int("CART_REMINDER_BATCH_SIZE", 50); err != nil {
+		return &Config{}, err
+	}
+
+	return config, nil
+}
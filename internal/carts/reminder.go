@@ -0,0 +1,165 @@
+package carts
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/ernestngugi/sil-backend/internal/db"
+	"github.com/ernestngugi/sil-backend/internal/model"
+	"github.com/ernestngugi/sil-backend/internal/notifications"
+	"github.com/ernestngugi/sil-backend/internal/repos"
+	"github.com/ernestngugi/sil-backend/internal/tax"
+)
+
+type (
+	// Reminder reminds customers of carts they left without checking out.
+	Reminder interface {
+		Start()
+		Shutdown(ctx context.Context) error
+		// Remind sends a batch of reminders and returns how many carts
+		// were claimed.
+		Remind(ctx context.Context) (int, error)
+	}
+
+	reminder struct {
+		dB                 db.DB
+		cartRepository     repos.CartRepository
+		customerRepository repos.CustomerRepository
+		notifier           notifications.Notifier
+		taxConfig          *tax.Config
+		config             *Config
+		logger             *slog.Logger
+
+		once    sync.Once
+		stop    chan struct{}
+		stopped chan struct{}
+	}
+)
+
+func NewReminder(
+	dB db.DB,
+	cartRepository repos.CartRepository,
+	customerRepository repos.CustomerRepository,
+	notifier notifications.Notifier,
+	taxConfig *tax.Config,
+	config *Config,
+	logger *slog.Logger,
+) Reminder {
+	return &reminder{
+		dB:                 dB,
+		cartRepository:     cartRepository,
+		customerRepository: customerRepository,
+		notifier:           notifier,
+		taxConfig:          taxConfig,
+		config:             config,
+		logger:             logger,
+		stop:               make(chan struct{}),
+		stopped:            make(chan struct{}),
+	}
+}
+
+// Start looks for abandoned carts every ReminderInterval until Shutdown is
+// called.
+func (r *reminder) Start() {
+	go r.run()
+}
+
+// Shutdown stops looking for abandoned carts and waits for the reminders
+// being sent.
+func (r *reminder) Shutdown(ctx context.Context) error {
+
+	r.once.Do(func() { close(r.stop) })
+
+	select {
+	case <-r.stopped:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (r *reminder) run() {
+	defer close(r.stopped)
+
+	ticker := time.NewTicker(r.config.ReminderInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.stop:
+			return
+		case <-ticker.C:
+		}
+
+		_, err := r.Remind(context.Background())
+		if err != nil {
+			r.logger.Error("failed to remind customers of abandoned carts", slog.String("error", err.Error()))
+		}
+	}
+}
+
+// Remind claims carts that have gone unchanged for AbandonedAfter and
+// reminds their customers. A claimed cart is not reminded of again until it
+// changes, even if its reminder could not be sent.
+func (r *reminder) Remind(ctx context.Context) (int, error) {
+
+	customerIDs, err := r.cartRepository.ClaimAbandoned(ctx, r.dB, time.Now().Add(-r.config.AbandonedAfter), r.config.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, customerID := range customerIDs {
+
+		err := r.remind(ctx, customerID)
+		if err != nil {
+			r.logger.ErrorContext(ctx, "failed to remind customer of abandoned cart",
+				slog.Int64("customer_id", customerID),
+				slog.String("error", err.Error()),
+			)
+		}
+	}
+
+	return len(customerIDs), nil
+}
+
+func (r *reminder) remind(ctx context.Context, customerID int64) error {
+
+	customer, err := r.customerRepository.CustomerByID(ctx, r.dB, customerID)
+	if err != nil {
+		return err
+	}
+
+	cart, err := r.cartRepository.Cart(ctx, r.dB, customerID)
+	if err != nil {
+		return err
+	}
+
+	if len(cart.Lines) == 0 {
+		// Checked out or emptied since it was claimed.
+		return nil
+	}
+
+	order, err := Order(cart, r.taxConfig)
+	if err != nil {
+		return err
+	}
+
+	err = r.notifier.Notify(ctx, r.dB, &notifications.Notification{
+		Customer: customer,
+		Event:    model.NotificationCartAbandoned,
+		Data: &notifications.TemplateData{
+			Item:   order.Item,
+			Amount: model.FormatAmount(order.Amount),
+		},
+	})
+	if errors.Is(err, notifications.ErrNoPhoneNumber) {
+		// Only customers reminded by SMS need a number.
+		r.logger.DebugContext(ctx, "customer has no phone number to remind", slog.Int64("customer_id", customerID))
+		return nil
+	}
+
+	return err
+}
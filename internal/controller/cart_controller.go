@@ -0,0 +1,305 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ernestngugi/sil-backend/internal/apperrors"
+	"github.com/ernestngugi/sil-backend/internal/carts"
+	"github.com/ernestngugi/sil-backend/internal/db"
+	"github.com/ernestngugi/sil-backend/internal/forms"
+	"github.com/ernestngugi/sil-backend/internal/model"
+	"github.com/ernestngugi/sil-backend/internal/promotions"
+	"github.com/ernestngugi/sil-backend/internal/repos"
+	"github.com/ernestngugi/sil-backend/internal/tax"
+)
+
+// maxCartLines caps how many different products a cart can hold.
+const maxCartLines = 50
+
+var (
+	errCartFull         = apperrors.Conflict("cart_full", fmt.Sprintf("a cart can hold at most %d different products", maxCartLines))
+	errCartLineNotFound = apperrors.NotFound("cart_line_not_found", "product is not in the cart")
+)
+
+type (
+	CartController interface {
+		Cart(ctx context.Context, dB db.DB) (*model.Cart, error)
+		AddLine(ctx context.Context, dB db.DB, form *forms.AddCartLineForm) (*model.Cart, error)
+		UpdateLine(ctx context.Context, dB db.DB, productID int64, form *forms.UpdateCartLineForm) (*model.Cart, error)
+		RemoveLine(ctx context.Context, dB db.DB, productID int64) (*model.Cart, error)
+		ApplyPromoCode(ctx context.Context, dB db.DB, form *forms.ApplyPromoCodeForm) (*model.Cart, error)
+		RemovePromoCode(ctx context.Context, dB db.DB) (*model.Cart, error)
+	}
+
+	cartController struct {
+		cartRepository      repos.CartRepository
+		customerRepository  repos.CustomerRepository
+		productRepository   repos.ProductRepository
+		promotionRepository repos.PromotionRepository
+		taxConfig           *tax.Config
+	}
+)
+
+func NewCartController(
+	cartRepository repos.CartRepository,
+	customerRepository repos.CustomerRepository,
+	productRepository repos.ProductRepository,
+	promotionRepository repos.PromotionRepository,
+	taxConfig *tax.Config,
+) CartController {
+	return &cartController{
+		cartRepository:      cartRepository,
+		customerRepository:  customerRepository,
+		productRepository:   productRepository,
+		promotionRepository: promotionRepository,
+		taxConfig:           taxConfig,
+	}
+}
+
+// Cart returns the signed in customer's cart at current prices, with the
+// discount its promo code would give if they checked out now.
+func (c *cartController) Cart(
+	ctx context.Context,
+	dB db.DB,
+) (*model.Cart, error) {
+
+	customer, err := signedInCustomer(ctx, dB, c.customerRepository)
+	if err != nil {
+		return &model.Cart{}, err
+	}
+
+	return c.pricedCart(ctx, dB, customer)
+}
+
+// AddLine adds a quantity of a product to the cart, on top of any already
+// there.
+func (c *cartController) AddLine(
+	ctx context.Context,
+	dB db.DB,
+	form *forms.AddCartLineForm,
+) (*model.Cart, error) {
+
+	return c.changeCart(ctx, dB, func(operations db.SQLOperations, cart *model.Cart) error {
+
+		quantity := form.Quantity
+
+		line := cartLine(cart, form.ProductID)
+		if line != nil {
+			quantity += line.Quantity
+		} else if len(cart.Lines) >= maxCartLines {
+			return errCartFull
+		}
+
+		return c.setLine(ctx, operations, cart.CustomerID, form.ProductID, quantity)
+	})
+}
+
+// UpdateLine sets how much of a product in the cart is wanted.
+func (c *cartController) UpdateLine(
+	ctx context.Context,
+	dB db.DB,
+	productID int64,
+	form *forms.UpdateCartLineForm,
+) (*model.Cart, error) {
+
+	return c.changeCart(ctx, dB, func(operations db.SQLOperations, cart *model.Cart) error {
+
+		if cartLine(cart, productID) == nil {
+			return errCartLineNotFound
+		}
+
+		return c.setLine(ctx, operations, cart.CustomerID, productID, form.Quantity)
+	})
+}
+
+func (c *cartController) RemoveLine(
+	ctx context.Context,
+	dB db.DB,
+	productID int64,
+) (*model.Cart, error) {
+
+	return c.changeCart(ctx, dB, func(operations db.SQLOperations, cart *model.Cart) error {
+		return c.cartRepository.DeleteLine(ctx, operations, cart.CustomerID, productID)
+	})
+}
+
+// ApplyPromoCode keeps a promo code with the cart. Unknown codes are
+// rejected; a code that does not apply to the cart yet is kept, with the
+// reason in the cart's promo code error.
+func (c *cartController) ApplyPromoCode(
+	ctx context.Context,
+	dB db.DB,
+	form *forms.ApplyPromoCodeForm,
+) (*model.Cart, error) {
+
+	return c.changeCart(ctx, dB, func(operations db.SQLOperations, cart *model.Cart) error {
+
+		promotion, err := c.promotionRepository.PromotionByCode(ctx, operations, promotions.Code(form.PromoCode))
+		if err != nil {
+			if apperrors.Is(err, apperrors.KindNotFound) {
+				return promotions.ErrInvalidCode
+			}
+			return err
+		}
+
+		if !promotion.Active {
+			return promotions.ErrInvalidCode
+		}
+
+		return c.cartRepository.SetPromoCode(ctx, operations, cart.CustomerID, promotion.Code)
+	})
+}
+
+func (c *cartController) RemovePromoCode(
+	ctx context.Context,
+	dB db.DB,
+) (*model.Cart, error) {
+
+	return c.changeCart(ctx, dB, func(operations db.SQLOperations, cart *model.Cart) error {
+		return c.cartRepository.SetPromoCode(ctx, operations, cart.CustomerID, "")
+	})
+}
+
+// changeCart applies change to the signed in customer's cart, which is held
+// until the change is saved, and returns the cart as changed.
+func (c *cartController) changeCart(
+	ctx context.Context,
+	dB db.DB,
+	change func(operations db.SQLOperations, cart *model.Cart) error,
+) (*model.Cart, error) {
+
+	customer, err := signedInCustomer(ctx, dB, c.customerRepository)
+	if err != nil {
+		return &model.Cart{}, err
+	}
+
+	err = db.WithTransaction(ctx, dB, func(operations db.SQLOperations) error {
+
+		err := c.cartRepository.Touch(ctx, operations, customer.ID)
+		if err != nil {
+			return err
+		}
+
+		cart, err := c.cartRepository.Cart(ctx, operations, customer.ID)
+		if err != nil {
+			return err
+		}
+
+		return change(operations, cart)
+	})
+	if err != nil {
+		return &model.Cart{}, err
+	}
+
+	return c.pricedCart(ctx, dB, customer)
+}
+
+// setLine puts quantity of a product in the cart if it is on sale and there
+// is enough in stock.
+func (c *cartController) setLine(
+	ctx context.Context,
+	operations db.SQLOperations,
+	customerID, productID int64,
+	quantity int,
+) error {
+
+	product, err := c.productRepository.ProductByID(ctx, operations, productID)
+	if err != nil {
+		return err
+	}
+
+	if !product.Active {
+		return productUnavailable(product.Name)
+	}
+
+	if product.Stock < quantity {
+		return outOfStock(product)
+	}
+
+	return c.cartRepository.SetLine(ctx, operations, customerID, productID, quantity)
+}
+
+// pricedCart returns the customer's cart with its totals worked out. A promo
+// code that no longer applies is left in the cart without a discount.
+func (c *cartController) pricedCart(
+	ctx context.Context,
+	dB db.DB,
+	customer *model.Customer,
+) (*model.Cart, error) {
+
+	cart, err := c.cartRepository.Cart(ctx, dB, customer.ID)
+	if err != nil {
+		return &model.Cart{}, err
+	}
+
+	order, err := carts.Order(cart, c.taxConfig)
+	if err != nil {
+		return &model.Cart{}, err
+	}
+
+	if cart.PromoCode != "" && len(order.Lines) > 0 {
+
+		err = c.previewPromotion(ctx, dB, customer, order, cart.PromoCode)
+		if err != nil {
+
+			if !apperrors.Is(err, apperrors.KindValidation) && !apperrors.Is(err, apperrors.KindConflict) {
+				return &model.Cart{}, err
+			}
+
+			cart.PromoCodeError = apperrors.From(err).Message
+		}
+	}
+
+	cart.DiscountAmount = order.DiscountAmount
+	cart.TaxAmount = order.Tax.TaxAmount
+	cart.Total = order.Amount
+
+	return cart, nil
+}
+
+// previewPromotion takes the discount for the promo code off order without
+// redeeming it.
+func (c *cartController) previewPromotion(
+	ctx context.Context,
+	dB db.DB,
+	customer *model.Customer,
+	order *model.Order,
+	code string,
+) error {
+
+	promotion, err := c.promotionRepository.PromotionByCode(ctx, dB, code)
+	if err != nil {
+		if apperrors.Is(err, apperrors.KindNotFound) {
+			return promotions.ErrInvalidCode
+		}
+		return err
+	}
+
+	redeemed, err := c.promotionRepository.CustomerRedemptions(ctx, dB, promotion.ID, customer.ID)
+	if err != nil {
+		return err
+	}
+
+	return discountOrder(promotion, order, redeemed)
+}
+
+// cartLine is the line for the product in cart, if there is one.
+func cartLine(cart *model.Cart, productID int64) *model.CartLine {
+
+	for _, line := range cart.Lines {
+		if line.ProductID == productID {
+			return line
+		}
+	}
+
+	return nil
+}
+
+func productUnavailable(item string) error {
+	return apperrors.Conflict("product_unavailable", fmt.Sprintf("%s is no longer available", item))
+}
+
+func outOfStock(product *model.Product) error {
+	return apperrors.Conflict("out_of_stock", fmt.Sprintf("only %d of %s left in stock", product.Stock, product.Name))
+}
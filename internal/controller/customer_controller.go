@@ -2,10 +2,9 @@ package controller
 
 import (
 	"context"
-	"database/sql"
-	"errors"
 	"strings"
 
+	"github.com/ernestngugi/sil-backend/internal/apperrors"
 	"github.com/ernestngugi/sil-backend/internal/db"
 	"github.com/ernestngugi/sil-backend/internal/forms"
 	"github.com/ernestngugi/sil-backend/internal/model"
@@ -51,10 +50,11 @@ func (c *customerController) CreateCustomer(
 
 	_, err := c.customerRepository.CustomerByName(ctx, dB, strings.ToLower(form.Name))
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
+		if apperrors.Is(err, apperrors.KindNotFound) {
 
 			newCustomer := &model.Customer{
-				Name: form.Name,
+				Name:   form.Name,
+				Locale: form.Locale,
 			}
 
 			err := c.customerRepository.Save(ctx, dB, newCustomer)
@@ -67,5 +67,25 @@ func (c *customerController) CreateCustomer(
 		return &model.Customer{}, err
 	}
 
-	return &model.Customer{}, errors.New("customer exists")
+	return &model.Customer{}, apperrors.Conflict("customer_exists", "customer exists")
+}
+
+// signedInCustomer is the customer the request was authenticated as.
+func signedInCustomer(
+	ctx context.Context,
+	dB db.DB,
+	customerRepository repos.CustomerRepository,
+) (*model.Customer, error) {
+
+	exist := ctx.Value(model.CustomerKeyName)
+	if exist == nil {
+		return &model.Customer{}, errCredentialMissing
+	}
+
+	name, ok := exist.(string)
+	if !ok || name == "" {
+		return &model.Customer{}, errCredentialMissing
+	}
+
+	return customerRepository.CustomerByName(ctx, dB, strings.ToLower(name))
 }
@@ -6,6 +6,7 @@ import (
 
 	"github.com/ernestngugi/sil-backend/internal/db"
 	"github.com/ernestngugi/sil-backend/internal/forms"
+	"github.com/ernestngugi/sil-backend/internal/logging"
 	"github.com/ernestngugi/sil-backend/internal/repos"
 	"github.com/stretchr/testify/assert"
 )
@@ -16,9 +17,17 @@ func TestCustomerController(t *testing.T) {
 
 	ctx := context.Background()
 
-	dB := db.InitDB()
+	dB, err := db.InitDB(ctx, logging.Discard())
+	if err != nil {
+		t.Fatalf("failed to initialise database: %v", err)
+	}
 	defer dB.Close()
 
+	err = db.RunMigrations(ctx, dB)
+	if err != nil {
+		t.Fatalf("failed to migrate database: %v", err)
+	}
+
 	customerRepository := repos.NewCustomerRepository()
 
 	customerController := NewTestCustomerController()
@@ -37,7 +46,7 @@ func TestCustomerController(t *testing.T) {
 		assert.NotZero(t, customer.DateCreated)
 		assert.NotZero(t, customer.DateModified)
 
-		clearCustomerTable(ctx, dB)
+		clearCustomerTable(t, ctx, dB)
 	})
 
 	t.Run("cannot create customers with similar name", func(t *testing.T) {
@@ -59,7 +68,7 @@ func TestCustomerController(t *testing.T) {
 
 		assert.Equal(t, "customer exists", err.Error())
 
-		clearCustomerTable(ctx, dB)
+		clearCustomerTable(t, ctx, dB)
 	})
 
 	t.Run("can get customer by name", func(t *testing.T) {
@@ -81,11 +90,15 @@ func TestCustomerController(t *testing.T) {
 		assert.Equal(t, customer.ID, foundCustomer.ID)
 		assert.Equal(t, "test", foundCustomer.Name)
 
-		clearCustomerTable(ctx, dB)
+		clearCustomerTable(t, ctx, dB)
 	})
 }
 
-func clearCustomerTable(ctx context.Context, dB db.DB) {
-	dB.ExecContext(ctx, "DELETE FROM customers")
-	dB.ExecContext(ctx, "ALTER SEQUENCE customers_id_seq RESTART WITH 1")
+func clearCustomerTable(t *testing.T, ctx context.Context, dB db.DB) {
+	t.Helper()
+
+	// TRUNCATE gets past the loyalty ledger's append-only trigger, and CASCADE
+	// clears everything recorded against the customers.
+	_, err := dB.ExecContext(ctx, "TRUNCATE customers RESTART IDENTITY CASCADE")
+	assert.NoError(t, err)
 }
@@ -0,0 +1,87 @@
+package controller
+
+import (
+	"context"
+
+	"github.com/ernestngugi/sil-backend/internal/db"
+	"github.com/ernestngugi/sil-backend/internal/invoices"
+	"github.com/ernestngugi/sil-backend/internal/model"
+	"github.com/ernestngugi/sil-backend/internal/repos"
+)
+
+type (
+	InvoiceController interface {
+		Invoice(ctx context.Context, dB db.DB, orderID int64) (*model.Invoice, []byte, error)
+		Receipt(ctx context.Context, dB db.DB, orderID int64) (*model.Invoice, []byte, error)
+	}
+
+	invoiceController struct {
+		customerRepository repos.CustomerRepository
+		orderRepository    repos.OrderRepository
+		invoicer           invoices.Invoicer
+	}
+)
+
+func NewInvoiceController(
+	customerRepository repos.CustomerRepository,
+	orderRepository repos.OrderRepository,
+	invoicer invoices.Invoicer,
+) InvoiceController {
+	return &invoiceController{
+		customerRepository: customerRepository,
+		orderRepository:    orderRepository,
+		invoicer:           invoicer,
+	}
+}
+
+// Invoice returns the tax invoice for one of the signed in customer's orders
+// and its PDF, issuing it the first time it is asked for.
+func (c *invoiceController) Invoice(
+	ctx context.Context,
+	dB db.DB,
+	orderID int64,
+) (*model.Invoice, []byte, error) {
+
+	order, err := c.customerOrder(ctx, dB, orderID)
+	if err != nil {
+		return &model.Invoice{}, nil, err
+	}
+
+	invoice, err := c.invoicer.Invoice(ctx, dB, order)
+	if err != nil {
+		return &model.Invoice{}, nil, err
+	}
+
+	return invoice, c.invoicer.PDF(invoice), nil
+}
+
+// Receipt returns the receipt for one of the signed in customer's paid
+// orders and its PDF.
+func (c *invoiceController) Receipt(
+	ctx context.Context,
+	dB db.DB,
+	orderID int64,
+) (*model.Invoice, []byte, error) {
+
+	order, err := c.customerOrder(ctx, dB, orderID)
+	if err != nil {
+		return &model.Invoice{}, nil, err
+	}
+
+	receipt, err := c.invoicer.Receipt(ctx, dB, order)
+	if err != nil {
+		return &model.Invoice{}, nil, err
+	}
+
+	return receipt, c.invoicer.PDF(receipt), nil
+}
+
+func (c *invoiceController) customerOrder(ctx context.Context, dB db.DB, orderID int64) (*model.Order, error) {
+
+	customer, err := signedInCustomer(ctx, dB, c.customerRepository)
+	if err != nil {
+		return &model.Order{}, err
+	}
+
+	return orderPlacedBy(ctx, dB, c.orderRepository, customer, orderID)
+}
@@ -0,0 +1,45 @@
+package controller
+
+import (
+	"context"
+
+	"github.com/ernestngugi/sil-backend/internal/db"
+	"github.com/ernestngugi/sil-backend/internal/loyalty"
+	"github.com/ernestngugi/sil-backend/internal/model"
+	"github.com/ernestngugi/sil-backend/internal/repos"
+)
+
+type (
+	LoyaltyController interface {
+		Account(ctx context.Context, dB db.DB) (*model.LoyaltyAccount, error)
+	}
+
+	loyaltyController struct {
+		customerRepository repos.CustomerRepository
+		ledger             loyalty.Ledger
+	}
+)
+
+func NewLoyaltyController(
+	customerRepository repos.CustomerRepository,
+	ledger loyalty.Ledger,
+) LoyaltyController {
+	return &loyaltyController{
+		customerRepository: customerRepository,
+		ledger:             ledger,
+	}
+}
+
+// Account returns the signed in customer's points balance and history.
+func (c *loyaltyController) Account(
+	ctx context.Context,
+	dB db.DB,
+) (*model.LoyaltyAccount, error) {
+
+	customer, err := signedInCustomer(ctx, dB, c.customerRepository)
+	if err != nil {
+		return &model.LoyaltyAccount{}, err
+	}
+
+	return c.ledger.Account(ctx, dB, customer.ID)
+}
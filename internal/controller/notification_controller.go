@@ -0,0 +1,148 @@
+package controller
+
+import (
+	"context"
+
+	"github.com/ernestngugi/sil-backend/internal/db"
+	"github.com/ernestngugi/sil-backend/internal/forms"
+	"github.com/ernestngugi/sil-backend/internal/model"
+	"github.com/ernestngugi/sil-backend/internal/notifications"
+	"github.com/ernestngugi/sil-backend/internal/repos"
+)
+
+type (
+	NotificationController interface {
+		Preferences(ctx context.Context, dB db.DB) (*model.NotificationPreferences, error)
+		UpdatePreferences(ctx context.Context, dB db.DB, form *forms.UpdateNotificationPreferencesForm) (*model.NotificationPreferences, error)
+		OptOut(ctx context.Context, dB db.DB, form *forms.SMSOptOutForm) error
+	}
+
+	notificationController struct {
+		customerRepository               repos.CustomerRepository
+		notificationPreferenceRepository repos.NotificationPreferenceRepository
+		smsSuppressionRepository         repos.SMSSuppressionRepository
+	}
+)
+
+func NewNotificationController(
+	customerRepository repos.CustomerRepository,
+	notificationPreferenceRepository repos.NotificationPreferenceRepository,
+	smsSuppressionRepository repos.SMSSuppressionRepository,
+) NotificationController {
+	return &notificationController{
+		customerRepository:               customerRepository,
+		notificationPreferenceRepository: notificationPreferenceRepository,
+		smsSuppressionRepository:         smsSuppressionRepository,
+	}
+}
+
+// Preferences returns the signed in customer's channel for every event and
+// their quiet hours.
+func (c *notificationController) Preferences(
+	ctx context.Context,
+	dB db.DB,
+) (*model.NotificationPreferences, error) {
+
+	customer, err := signedInCustomer(ctx, dB, c.customerRepository)
+	if err != nil {
+		return &model.NotificationPreferences{}, err
+	}
+
+	return c.preferences(ctx, dB, customer)
+}
+
+func (c *notificationController) UpdatePreferences(
+	ctx context.Context,
+	dB db.DB,
+	form *forms.UpdateNotificationPreferencesForm,
+) (*model.NotificationPreferences, error) {
+
+	customer, err := signedInCustomer(ctx, dB, c.customerRepository)
+	if err != nil {
+		return &model.NotificationPreferences{}, err
+	}
+
+	err = db.WithTransaction(ctx, dB, func(operations db.SQLOperations) error {
+
+		for event, channel := range form.Channels {
+
+			err := c.notificationPreferenceRepository.SaveChannel(
+				ctx,
+				operations,
+				customer.ID,
+				model.NotificationEvent(event),
+				model.NotificationChannel(channel),
+			)
+			if err != nil {
+				return err
+			}
+		}
+
+		if form.PhoneNumber != nil {
+
+			err := c.customerRepository.UpdatePhoneNumber(ctx, operations, customer, *form.PhoneNumber)
+			if err != nil {
+				return err
+			}
+		}
+
+		if form.QuietHours == nil {
+			return nil
+		}
+
+		var quietHours *model.QuietHours
+
+		if form.QuietHours.Start != "" {
+
+			quietHours = &model.QuietHours{
+				Start:    form.QuietHours.Start,
+				End:      form.QuietHours.End,
+				TimeZone: form.QuietHours.TimeZone,
+			}
+
+			if quietHours.TimeZone == "" {
+				quietHours.TimeZone = model.DefaultTimeZone
+			}
+		}
+
+		return c.notificationPreferenceRepository.SaveQuietHours(ctx, operations, customer.ID, quietHours)
+	})
+	if err != nil {
+		return &model.NotificationPreferences{}, err
+	}
+
+	return c.preferences(ctx, dB, customer)
+}
+
+// OptOut adds the number to the suppression list. It stays there whatever
+// channel the customer picks afterwards.
+func (c *notificationController) OptOut(
+	ctx context.Context,
+	dB db.DB,
+	form *forms.SMSOptOutForm,
+) error {
+	return c.smsSuppressionRepository.Save(ctx, dB, &model.SMSSuppression{
+		PhoneNumber: notifications.NormalizePhoneNumber(form.PhoneNumber),
+		Reason:      model.SMSSuppressionOptOut,
+	})
+}
+
+func (c *notificationController) preferences(
+	ctx context.Context,
+	dB db.DB,
+	customer *model.Customer,
+) (*model.NotificationPreferences, error) {
+
+	preferences, err := c.notificationPreferenceRepository.Preferences(ctx, dB, customer.ID)
+	if err != nil {
+		return &model.NotificationPreferences{}, err
+	}
+
+	for _, event := range model.NotificationEvents {
+		preferences.Channels[event] = preferences.Channel(event)
+	}
+
+	preferences.PhoneNumber = customer.PhoneNumber
+
+	return preferences, nil
+}
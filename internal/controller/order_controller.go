@@ -2,58 +2,211 @@ package controller
 
 import (
 	"context"
-	"errors"
 	"fmt"
-	"strings"
+	"log/slog"
+	"math"
+	"time"
 
+	"github.com/ernestngugi/sil-backend/internal/apperrors"
+	"github.com/ernestngugi/sil-backend/internal/carts"
 	"github.com/ernestngugi/sil-backend/internal/db"
 	"github.com/ernestngugi/sil-backend/internal/forms"
+	"github.com/ernestngugi/sil-backend/internal/logging"
+	"github.com/ernestngugi/sil-backend/internal/loyalty"
+	"github.com/ernestngugi/sil-backend/internal/metrics"
 	"github.com/ernestngugi/sil-backend/internal/model"
+	"github.com/ernestngugi/sil-backend/internal/notifications"
+	"github.com/ernestngugi/sil-backend/internal/promotions"
 	"github.com/ernestngugi/sil-backend/internal/repos"
+	"github.com/ernestngugi/sil-backend/internal/tax"
+	"github.com/ernestngugi/sil-backend/internal/webhooks"
 	"github.com/ernestngugi/sil-backend/providers"
 )
 
+// maxOrderAmount is the largest amount an order can be stored with.
+const maxOrderAmount = 99999999.99
+
+// statusNotifications are the order statuses customers are sent a message
+// about.
+var statusNotifications = map[model.OrderStatus]model.NotificationEvent{
+	model.OrderStatusDispatched: model.NotificationOrderDispatched,
+	model.OrderStatusDelivered:  model.NotificationOrderDelivered,
+	model.OrderStatusCancelled:  model.NotificationOrderCancelled,
+}
+
+var (
+	errCredentialMissing = apperrors.Unauthorized("credentials_missing", "customer credential missing")
+	errOrderNotFound     = apperrors.NotFound("order_not_found", "order not found")
+	errOrderNotPaid      = apperrors.Conflict("order_not_paid", "only paid orders can be refunded")
+	errCartEmpty         = apperrors.Conflict("cart_empty", "there is nothing in the cart to order")
+	errCartChanged       = apperrors.Conflict("cart_changed", "the cart total has changed, review the cart before checking out")
+	errCartTooLarge      = apperrors.Conflict("cart_too_large", fmt.Sprintf("an order can be for at most KES %.2f", maxOrderAmount))
+	errOrderTooLarge     = apperrors.Validation("invalid_amount", "amount is too large once VAT is added", apperrors.FieldError{
+		Field:   "amount",
+		Code:    "lte",
+		Message: fmt.Sprintf("amount including VAT must be at most %.2f", maxOrderAmount),
+	})
+)
+
 type (
 	OrderController interface {
 		CreateOrder(ctx context.Context, dB db.DB, form *forms.CreateOrderForm) (*model.Order, error)
 		OrderByID(ctx context.Context, dB db.DB, orderID int64) (*model.Order, error)
+		UpdateOrderStatus(ctx context.Context, dB db.DB, orderID int64, form *forms.UpdateOrderStatusForm) (*model.Order, error)
+		CheckoutCart(ctx context.Context, dB db.DB, form *forms.CheckoutCartForm) (*model.Order, error)
+		RefundOrder(ctx context.Context, dB db.DB, orderID int64) (*model.Order, error)
+		OrderEventFilter(ctx context.Context, dB db.DB, orderID int64) (*model.OrderEventFilter, error)
+		OrderEvents(ctx context.Context, dB db.DB, filter *model.OrderEventFilter, afterID int64, limit int) ([]*model.OrderEvent, error)
 	}
 
 	orderController struct {
-		atProvider         providers.ATProvider
-		customerRepository repos.CustomerRepository
-		orderRepository    repos.OrderRepository
+		customerRepository   repos.CustomerRepository
+		orderRepository      repos.OrderRepository
+		orderEventRepository repos.OrderEventRepository
+		promotionRepository  repos.PromotionRepository
+		cartRepository       repos.CartRepository
+		productRepository    repos.ProductRepository
+		ledger               loyalty.Ledger
+		notifier             notifications.Notifier
+		webhookPublisher     webhooks.Publisher
+		taxConfig            *tax.Config
+		metrics              *metrics.Metrics
+		logger               *slog.Logger
+	}
+
+	// orderEvent is the data of order webhook events.
+	orderEvent struct {
+		Order          *model.Order      `json:"order"`
+		PreviousStatus model.OrderStatus `json:"previous_status,omitempty"`
 	}
 )
 
 func NewOrderController(
-	atProvider providers.ATProvider,
 	customerRepository repos.CustomerRepository,
 	orderRepository repos.OrderRepository,
+	orderEventRepository repos.OrderEventRepository,
+	promotionRepository repos.PromotionRepository,
+	cartRepository repos.CartRepository,
+	productRepository repos.ProductRepository,
+	ledger loyalty.Ledger,
+	notifier notifications.Notifier,
+	webhookPublisher webhooks.Publisher,
+	taxConfig *tax.Config,
+	appMetrics *metrics.Metrics,
+	logger *slog.Logger,
 ) OrderController {
 	return &orderController{
-		atProvider:         atProvider,
-		customerRepository: customerRepository,
-		orderRepository:    orderRepository,
+		customerRepository:   customerRepository,
+		orderRepository:      orderRepository,
+		orderEventRepository: orderEventRepository,
+		promotionRepository:  promotionRepository,
+		cartRepository:       cartRepository,
+		productRepository:    productRepository,
+		ledger:               ledger,
+		notifier:             notifier,
+		webhookPublisher:     webhookPublisher,
+		taxConfig:            taxConfig,
+		metrics:              appMetrics,
+		logger:               logger,
 	}
 }
 
 func NewTestOrderController(
-	atProvider providers.ATProvider,
+	dB db.DB,
+	smsSender providers.SMSSender,
+	emailSender providers.EmailSender,
 ) *orderController {
+	logger := logging.Discard()
+
 	return &orderController{
-		atProvider:         atProvider,
-		customerRepository: repos.NewCustomerRepository(),
-		orderRepository:    repos.NewOrderRepository(),
+		customerRepository:   repos.NewCustomerRepository(),
+		orderRepository:      repos.NewOrderRepository(),
+		orderEventRepository: repos.NewOrderEventRepository(),
+		promotionRepository:  repos.NewPromotionRepository(),
+		cartRepository:       repos.NewCartRepository(),
+		productRepository:    repos.NewProductRepository(),
+		ledger: loyalty.NewLedger(repos.NewLoyaltyRepository(), &loyalty.Config{
+			SpendPerPoint:    100,
+			PointValue:       1,
+			MaxRedeemPercent: 50,
+			ExpireAfter:      365 * 24 * time.Hour,
+		}),
+		notifier: notifications.NewNotifier(
+			repos.NewNotificationPreferenceRepository(),
+			repos.NewPaymentRepository(),
+			notifications.NewSMSTemplates(repos.NewSMSTemplateRepository(), logger),
+			notifications.NewSMSDispatcher(dB, smsSender, repos.NewSMSOutboxRepository(), logger),
+			notifications.NewEmailDispatcher(emailSender, logger),
+			true,
+		),
+		webhookPublisher: webhooks.NewPublisher(repos.NewWebhookRepository()),
+		taxConfig: &tax.Config{
+			StandardRate: 16,
+			Categories: map[string]*model.TaxCategory{
+				"standard":   {Code: "standard", Treatment: model.TaxTreatmentStandard, Rate: 16},
+				"zero_rated": {Code: "zero_rated", Treatment: model.TaxTreatmentZeroRated},
+				"exempt":     {Code: "exempt", Treatment: model.TaxTreatmentExempt},
+			},
+			DefaultCategory: "standard",
+		},
+		metrics: metrics.New(),
+		logger:  logger,
 	}
 }
 
+// OrderByID returns an order placed by the signed in customer.
 func (c *orderController) OrderByID(
 	ctx context.Context,
 	dB db.DB,
 	orderID int64,
 ) (*model.Order, error) {
-	return c.orderRepository.OrderByID(ctx, dB, orderID)
+
+	customer, err := c.currentCustomer(ctx, dB)
+	if err != nil {
+		return &model.Order{}, err
+	}
+
+	return c.customerOrder(ctx, dB, customer, orderID)
+}
+
+// OrderEventFilter selects the events the signed in customer may follow:
+// those of one of their orders, or of all of them when orderID is zero.
+func (c *orderController) OrderEventFilter(
+	ctx context.Context,
+	dB db.DB,
+	orderID int64,
+) (*model.OrderEventFilter, error) {
+
+	customer, err := c.currentCustomer(ctx, dB)
+	if err != nil {
+		return &model.OrderEventFilter{}, err
+	}
+
+	filter := &model.OrderEventFilter{CustomerID: customer.ID}
+
+	if orderID != 0 {
+
+		order, err := c.customerOrder(ctx, dB, customer, orderID)
+		if err != nil {
+			return &model.OrderEventFilter{}, err
+		}
+
+		filter.OrderID = order.ID
+	}
+
+	return filter, nil
+}
+
+// OrderEvents returns up to limit events matching filter recorded after
+// afterID. The filter must come from OrderEventFilter.
+func (c *orderController) OrderEvents(
+	ctx context.Context,
+	dB db.DB,
+	filter *model.OrderEventFilter,
+	afterID int64,
+	limit int,
+) ([]*model.OrderEvent, error) {
+	return c.orderEventRepository.EventsAfter(ctx, dB, filter, afterID, limit)
 }
 
 func (c *orderController) CreateOrder(
@@ -62,42 +215,549 @@ func (c *orderController) CreateOrder(
 	form *forms.CreateOrderForm,
 ) (*model.Order, error) {
 
-	exist := ctx.Value(model.CustomerKeyName)
-	if exist == nil {
-		return &model.Order{}, errors.New("customer credential missing")
-	}
-
-	name, ok := exist.(string)
-	if !ok {
-		return &model.Order{}, errors.New("customer credential missing")
+	customer, err := c.currentCustomer(ctx, dB)
+	if err != nil {
+		return &model.Order{}, err
 	}
 
-	customer, err := c.customerRepository.CustomerByName(ctx, dB, strings.ToLower(name))
+	category, err := c.taxConfig.Category(form.TaxCategory)
 	if err != nil {
 		return &model.Order{}, err
 	}
 
+	orderTax := tax.Inclusive(category, form.Amount)
+
+	if form.PricesIncludeTax != nil && !*form.PricesIncludeTax {
+
+		orderTax = tax.Exclusive(category, form.Amount)
+
+		if orderTax.GrossAmount > maxOrderAmount {
+			return &model.Order{}, errOrderTooLarge
+		}
+	}
+
 	order := &model.Order{
 		CustomerID: customer.ID,
-		Amount:     form.Amount,
+		Amount:     orderTax.GrossAmount,
+		Tax:        orderTax,
 		Item:       form.Item,
 	}
 
-	err = c.orderRepository.Save(ctx, dB, order)
+	err = db.WithTransaction(ctx, dB, func(operations db.SQLOperations) error {
+
+		var redemption *model.PromotionRedemption
+
+		if form.PromoCode != "" {
+
+			var err error
+
+			redemption, err = redeemPromotion(ctx, operations, c.promotionRepository, customer, order, form.PromoCode)
+			if err != nil {
+				return err
+			}
+		}
+
+		var pointsRedeemed *model.LoyaltyEntry
+
+		if form.LoyaltyPoints > 0 {
+
+			var err error
+
+			pointsRedeemed, err = redeemLoyaltyPoints(ctx, operations, c.ledger, customer, order, form.LoyaltyPoints)
+			if err != nil {
+				return err
+			}
+		}
+
+		return c.placeOrder(ctx, operations, order, redemption, pointsRedeemed)
+	})
+	if err != nil {
+		return &model.Order{}, err
+	}
+
+	c.metrics.ObserveOrderCreated(order.Amount)
+
+	c.notifyCustomer(ctx, dB, customer, order, model.NotificationOrderCreated)
+
+	return order, nil
+}
+
+// CheckoutCart places an order for everything in the signed in customer's
+// cart at current prices, redeeming the cart's promo code, and empties the
+// cart. The order is not placed if its total before loyalty points is no
+// longer the one the customer was shown.
+func (c *orderController) CheckoutCart(
+	ctx context.Context,
+	dB db.DB,
+	form *forms.CheckoutCartForm,
+) (*model.Order, error) {
+
+	customer, err := c.currentCustomer(ctx, dB)
+	if err != nil {
+		return &model.Order{}, err
+	}
+
+	var order *model.Order
+
+	err = db.WithTransaction(ctx, dB, func(operations db.SQLOperations) error {
+
+		cart, err := c.cartRepository.LockCart(ctx, operations, customer.ID)
+		if err != nil {
+			return err
+		}
+
+		if len(cart.Lines) == 0 {
+			return errCartEmpty
+		}
+
+		err = c.reserveStock(ctx, operations, cart)
+		if err != nil {
+			return err
+		}
+
+		order, err = carts.Order(cart, c.taxConfig)
+		if err != nil {
+			return err
+		}
+
+		var redemption *model.PromotionRedemption
+
+		if cart.PromoCode != "" {
+
+			redemption, err = redeemPromotion(ctx, operations, c.promotionRepository, customer, order, cart.PromoCode)
+			if err != nil {
+				return err
+			}
+		}
+
+		if math.Round(order.Amount*100) != math.Round(form.ExpectedTotal*100) {
+			return errCartChanged
+		}
+
+		if order.Amount > maxOrderAmount {
+			return errCartTooLarge
+		}
+
+		var pointsRedeemed *model.LoyaltyEntry
+
+		if form.LoyaltyPoints > 0 {
+
+			pointsRedeemed, err = redeemLoyaltyPoints(ctx, operations, c.ledger, customer, order, form.LoyaltyPoints)
+			if err != nil {
+				return err
+			}
+		}
+
+		err = c.placeOrder(ctx, operations, order, redemption, pointsRedeemed)
+		if err != nil {
+			return err
+		}
+
+		for _, line := range order.Lines {
+
+			err = c.productRepository.TakeStock(ctx, operations, line.ProductID, line.Quantity)
+			if err != nil {
+				return err
+			}
+		}
+
+		return c.cartRepository.Clear(ctx, operations, customer.ID)
+	})
+	if err != nil {
+		return &model.Order{}, err
+	}
+
+	c.metrics.ObserveOrderCreated(order.Amount)
+
+	c.notifyCustomer(ctx, dB, customer, order, model.NotificationOrderCreated)
+
+	return order, nil
+}
+
+// reserveStock locks the products in cart until the transaction ends and
+// checks that each can still be ordered in the quantity wanted. The cart's
+// lines are brought up to date with the locked products.
+func (c *orderController) reserveStock(
+	ctx context.Context,
+	operations db.SQLOperations,
+	cart *model.Cart,
+) error {
+
+	productIDs := make([]int64, 0, len(cart.Lines))
+	for _, line := range cart.Lines {
+		productIDs = append(productIDs, line.ProductID)
+	}
+
+	products, err := c.productRepository.LockProducts(ctx, operations, productIDs)
+	if err != nil {
+		return err
+	}
+
+	productsByID := make(map[int64]*model.Product, len(products))
+	for _, product := range products {
+		productsByID[product.ID] = product
+	}
+
+	for _, line := range cart.Lines {
+
+		product, ok := productsByID[line.ProductID]
+		if !ok || !product.Active {
+			return productUnavailable(line.Item)
+		}
+
+		if product.Stock < line.Quantity {
+			return outOfStock(product)
+		}
+
+		line.Item = product.Name
+		line.UnitPrice = product.Price
+		line.TaxCategory = product.TaxCategory
+		line.Available = true
+	}
+
+	return nil
+}
+
+// placeOrder saves order along with the promo code redemption and loyalty
+// points spent on it, and publishes that it was created.
+func (c *orderController) placeOrder(
+	ctx context.Context,
+	operations db.SQLOperations,
+	order *model.Order,
+	redemption *model.PromotionRedemption,
+	pointsRedeemed *model.LoyaltyEntry,
+) error {
+
+	err := c.orderRepository.Save(ctx, operations, order)
+	if err != nil {
+		return err
+	}
+
+	if redemption != nil {
+
+		redemption.OrderID = order.ID
+		redemption.OrderAmount = order.Amount
+
+		err = c.promotionRepository.SaveRedemption(ctx, operations, redemption)
+		if err != nil {
+			return err
+		}
+	}
+
+	if pointsRedeemed != nil {
+
+		pointsRedeemed.OrderID = &order.ID
+
+		err = c.ledger.Record(ctx, operations, pointsRedeemed)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = c.orderEventRepository.Save(ctx, operations, &model.OrderEvent{
+		OrderID:    order.ID,
+		CustomerID: order.CustomerID,
+		Status:     order.Status,
+	})
+	if err != nil {
+		return err
+	}
+
+	return c.webhookPublisher.Publish(ctx, operations, model.EventOrderCreated, &orderEvent{Order: order})
+}
+
+// UpdateOrderStatus moves an order along its lifecycle and notifies webhook
+// subscribers of the change.
+func (c *orderController) UpdateOrderStatus(
+	ctx context.Context,
+	dB db.DB,
+	orderID int64,
+	form *forms.UpdateOrderStatusForm,
+) (*model.Order, error) {
+
+	var order *model.Order
+
+	err := db.WithTransaction(ctx, dB, func(operations db.SQLOperations) error {
+
+		var err error
+
+		order, err = c.orderRepository.OrderByID(ctx, operations, orderID)
+		if err != nil {
+			return err
+		}
+
+		status := model.OrderStatus(form.Status)
+
+		// Cancellations are undone before the status changes, as the order
+		// event saved with it must come last. A transition that turns out
+		// not to be allowed rolls them back.
+		if status == model.OrderStatusCancelled {
+
+			err = c.ledger.Reverse(ctx, operations, order)
+			if err != nil {
+				return err
+			}
+
+			for _, line := range order.Lines {
+
+				err = c.productRepository.ReturnStock(ctx, operations, line.ProductID, line.Quantity)
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		return changeOrderStatus(ctx, operations, c.orderRepository, c.orderEventRepository, c.webhookPublisher, order, status)
+	})
 	if err != nil {
 		return &model.Order{}, err
 	}
 
-	atRequest := &model.ATRequest{
-		Number:  "254728389583",
-		Message: fmt.Sprintf("your order %v has been received and is on your way", order.ID),
+	event, ok := statusNotifications[order.Status]
+	if ok {
+
+		customer, err := c.customerRepository.CustomerByID(ctx, dB, order.CustomerID)
+		if err != nil {
+			c.logger.ErrorContext(ctx, "failed to load customer to notify", slog.Int64("order_id", order.ID), slog.String("error", err.Error()))
+		} else {
+			c.notifyCustomer(ctx, dB, customer, order, event)
+		}
+	}
+
+	return order, nil
+}
+
+// changeOrderStatus moves order to status, recording the order event and
+// publishing the change to webhook subscribers.
+func changeOrderStatus(
+	ctx context.Context,
+	operations db.SQLOperations,
+	orderRepository repos.OrderRepository,
+	orderEventRepository repos.OrderEventRepository,
+	webhookPublisher webhooks.Publisher,
+	order *model.Order,
+	status model.OrderStatus,
+) error {
+
+	previousStatus := order.Status
+
+	if !previousStatus.CanTransitionTo(status) {
+		return apperrors.Conflict(
+			"invalid_status_transition",
+			fmt.Sprintf("order cannot move from %v to %v", previousStatus, status),
+		)
+	}
+
+	err := orderRepository.UpdateStatus(ctx, operations, order, status)
+	if err != nil {
+		return err
+	}
+
+	err = orderEventRepository.Save(ctx, operations, &model.OrderEvent{
+		OrderID:        order.ID,
+		CustomerID:     order.CustomerID,
+		Status:         status,
+		PreviousStatus: previousStatus,
+	})
+	if err != nil {
+		return err
+	}
+
+	return webhookPublisher.Publish(ctx, operations, model.EventOrderStatusChanged, &orderEvent{
+		Order:          order,
+		PreviousStatus: previousStatus,
+	})
+}
+
+// RefundOrder records that the money for a paid order has been returned to
+// the customer, reverses the loyalty points it earned and returns those spent
+// on it.
+func (c *orderController) RefundOrder(
+	ctx context.Context,
+	dB db.DB,
+	orderID int64,
+) (*model.Order, error) {
+
+	var order *model.Order
+
+	err := db.WithTransaction(ctx, dB, func(operations db.SQLOperations) error {
+
+		var err error
+
+		order, err = c.orderRepository.OrderByID(ctx, operations, orderID)
+		if err != nil {
+			return err
+		}
+
+		if order.PaymentStatus != model.PaymentStatusPaid {
+			return errOrderNotPaid
+		}
+
+		err = c.orderRepository.UpdatePaymentStatus(ctx, operations, order, model.PaymentStatusRefunded)
+		if err != nil {
+			return err
+		}
+
+		return c.ledger.Reverse(ctx, operations, order)
+	})
+	if err != nil {
+		return &model.Order{}, err
+	}
+
+	customer, err := c.customerRepository.CustomerByID(ctx, dB, order.CustomerID)
+	if err != nil {
+		c.logger.ErrorContext(ctx, "failed to load customer to notify", slog.Int64("order_id", order.ID), slog.String("error", err.Error()))
+	} else {
+		c.notifyCustomer(ctx, dB, customer, order, model.NotificationOrderRefunded)
+	}
+
+	return order, nil
+}
+
+// redeemPromotion takes the discount for the promo code off order, with VAT
+// worked out again on the reduced amount, and returns the redemption to save
+// with it. The promotion stays locked until the transaction ends, so usage
+// limits hold when customers redeem the same code at once.
+func redeemPromotion(
+	ctx context.Context,
+	operations db.SQLOperations,
+	promotionRepository repos.PromotionRepository,
+	customer *model.Customer,
+	order *model.Order,
+	code string,
+) (*model.PromotionRedemption, error) {
+
+	promotion, err := promotionRepository.LockPromotionByCode(ctx, operations, promotions.Code(code))
+	if err != nil {
+		if apperrors.Is(err, apperrors.KindNotFound) {
+			return &model.PromotionRedemption{}, promotions.ErrInvalidCode
+		}
+		return &model.PromotionRedemption{}, err
+	}
+
+	redeemed, err := promotionRepository.CustomerRedemptions(ctx, operations, promotion.ID, customer.ID)
+	if err != nil {
+		return &model.PromotionRedemption{}, err
+	}
+
+	err = discountOrder(promotion, order, redeemed)
+	if err != nil {
+		return &model.PromotionRedemption{}, err
+	}
+
+	return &model.PromotionRedemption{
+		PromotionID:    promotion.ID,
+		CustomerID:     customer.ID,
+		DiscountAmount: order.DiscountAmount,
+		OrderAmount:    order.Amount,
+	}, nil
+}
+
+// discountOrder takes promotion's discount off order, given how many times
+// the customer has redeemed it, with VAT worked out again on what is left.
+// Only the lines the promotion applies to are discounted.
+func discountOrder(promotion *model.Promotion, order *model.Order, redeemed int) error {
+
+	discount, err := promotions.Discount(promotion, order, redeemed, time.Now())
+	if err != nil {
+		return err
 	}
 
-	go c.sendOrderSMS(atRequest)
+	tax.Discount(order, discount, func(line *model.OrderLine) bool {
+		return promotions.Applies(promotion, line.Item, line.Tax.Category)
+	})
+
+	order.DiscountAmount = discount
+	order.PromoCode = promotion.Code
+
+	return nil
+}
+
+// redeemLoyaltyPoints takes the value of points off order, shared across its
+// lines, with VAT worked out again on the reduced amount, and returns the
+// ledger entry to record with it. The customer's ledger stays locked until the
+// transaction ends, so points cannot be spent twice.
+func redeemLoyaltyPoints(
+	ctx context.Context,
+	operations db.SQLOperations,
+	ledger loyalty.Ledger,
+	customer *model.Customer,
+	order *model.Order,
+	points int,
+) (*model.LoyaltyEntry, error) {
+
+	entry, err := ledger.Redeem(ctx, operations, customer.ID, points, order.Amount)
+	if err != nil {
+		return &model.LoyaltyEntry{}, err
+	}
+
+	tax.Discount(order, entry.Amount, func(*model.OrderLine) bool { return true })
+
+	order.LoyaltyPoints = points
+	order.LoyaltyAmount = entry.Amount
+
+	return entry, nil
+}
+
+// currentCustomer is the customer the request was authenticated as.
+func (c *orderController) currentCustomer(ctx context.Context, dB db.DB) (*model.Customer, error) {
+
+	return signedInCustomer(ctx, dB, c.customerRepository)
+}
+
+// customerOrder returns the order if customer placed it.
+func (c *orderController) customerOrder(
+	ctx context.Context,
+	dB db.DB,
+	customer *model.Customer,
+	orderID int64,
+) (*model.Order, error) {
+
+	return orderPlacedBy(ctx, dB, c.orderRepository, customer, orderID)
+}
+
+// orderPlacedBy returns the order if customer placed it. Other customers'
+// orders are reported as not found so their IDs cannot be probed.
+func orderPlacedBy(
+	ctx context.Context,
+	dB db.DB,
+	orderRepository repos.OrderRepository,
+	customer *model.Customer,
+	orderID int64,
+) (*model.Order, error) {
+
+	order, err := orderRepository.OrderByID(ctx, dB, orderID)
+	if err != nil {
+		return &model.Order{}, err
+	}
+
+	if order.CustomerID != customer.ID {
+		return &model.Order{}, errOrderNotFound
+	}
 
 	return order, nil
 }
 
-func (c *orderController) sendOrderSMS(request *model.ATRequest) error {
-	return c.atProvider.Send(request)
+// notifyCustomer sends the message for event about order. Orders are not
+// failed because a message could not be sent.
+func (c *orderController) notifyCustomer(
+	ctx context.Context,
+	dB db.DB,
+	customer *model.Customer,
+	order *model.Order,
+	event model.NotificationEvent,
+) {
+
+	err := c.notifier.Notify(ctx, dB, &notifications.Notification{
+		Customer: customer,
+		Event:    event,
+		Data:     notifications.OrderTemplateData(order),
+	})
+	if err != nil {
+		c.logger.ErrorContext(ctx, "failed to notify customer",
+			slog.Int64("order_id", order.ID),
+			slog.String("event", string(event)),
+			slog.String("error", err.Error()),
+		)
+	}
 }
@@ -3,10 +3,14 @@ package controller
 import (
 	"context"
 	"testing"
+	"time"
 
+	"github.com/ernestngugi/sil-backend/internal/apperrors"
 	"github.com/ernestngugi/sil-backend/internal/db"
 	"github.com/ernestngugi/sil-backend/internal/forms"
+	"github.com/ernestngugi/sil-backend/internal/logging"
 	"github.com/ernestngugi/sil-backend/internal/model"
+	"github.com/ernestngugi/sil-backend/internal/notifications"
 	"github.com/ernestngugi/sil-backend/internal/repos"
 	"github.com/ernestngugi/sil-backend/mocks"
 	"github.com/stretchr/testify/assert"
@@ -18,14 +22,22 @@ func TestOrderController(t *testing.T) {
 
 	ctx := context.Background()
 
-	dB := db.InitDB()
+	dB, err := db.InitDB(ctx, logging.Discard())
+	if err != nil {
+		t.Fatalf("failed to initialise database: %v", err)
+	}
 	defer dB.Close()
 
-	atProvider := mocks.NewMockATProvider()
+	err = db.RunMigrations(ctx, dB)
+	if err != nil {
+		t.Fatalf("failed to migrate database: %v", err)
+	}
+
+	smsSender := mocks.NewMockSMSSender()
 
 	customerRepository := repos.NewCustomerRepository()
 
-	orderController := NewTestOrderController(atProvider)
+	orderController := NewTestOrderController(dB, smsSender, mocks.NewMockEmailSender())
 
 	t.Run("cannot create an order if credentials are missing", func(t *testing.T) {
 
@@ -39,7 +51,7 @@ func TestOrderController(t *testing.T) {
 
 		assert.Equal(t, "customer credential missing", err.Error())
 
-		clearOrderTable(ctx, dB)
+		clearOrderTable(t, ctx, dB)
 	})
 
 	t.Run("can create an order", func(t *testing.T) {
@@ -64,23 +76,163 @@ func TestOrderController(t *testing.T) {
 		assert.Equal(t, order.Amount, 100.00)
 		assert.Equal(t, order.Item, "item")
 
-		clearOrderTable(ctx, dB)
-		clearCustomerTable(ctx, dB)
+		clearOrderTable(t, ctx, dB)
+		clearCustomerTable(t, ctx, dB)
 	})
 
-	t.Run("can send an sms request to africas talking", func(t *testing.T) {
+	t.Run("computes vat for the order's tax category", func(t *testing.T) {
+
+		customer := model.BuildCustomer()
+
+		err := customerRepository.Save(ctx, dB, customer)
+		assert.NoError(t, err)
+
+		ctx := context.WithValue(ctx, model.CustomerKeyName, customer.Name)
+
+		order, err := orderController.CreateOrder(ctx, dB, &forms.CreateOrderForm{Amount: 1250, Item: "item"})
+		assert.NoError(t, err)
+		assert.Equal(t, model.OrderTax{
+			Category:    "standard",
+			Treatment:   model.TaxTreatmentStandard,
+			Rate:        16,
+			NetAmount:   1077.59,
+			TaxAmount:   172.41,
+			GrossAmount: 1250,
+		}, order.Tax)
+
+		stored, err := orderController.OrderByID(ctx, dB, order.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, order.Tax, stored.Tax)
+
+		pricesIncludeTax := false
+
+		order, err = orderController.CreateOrder(ctx, dB, &forms.CreateOrderForm{Amount: 1000, Item: "item", PricesIncludeTax: &pricesIncludeTax})
+		assert.NoError(t, err)
+		assert.Equal(t, 1160.0, order.Amount)
+		assert.Equal(t, 160.0, order.Tax.TaxAmount)
+
+		order, err = orderController.CreateOrder(ctx, dB, &forms.CreateOrderForm{Amount: 500, Item: "item", TaxCategory: "exempt"})
+		assert.NoError(t, err)
+		assert.Equal(t, model.TaxTreatmentExempt, order.Tax.Treatment)
+		assert.Equal(t, 500.0, order.Tax.NetAmount)
+		assert.Zero(t, order.Tax.TaxAmount)
+
+		_, err = orderController.CreateOrder(ctx, dB, &forms.CreateOrderForm{Amount: 500, Item: "item", TaxCategory: "luxury"})
+		assert.Equal(t, "invalid_tax_category", apperrors.From(err).Code)
+
+		_, err = orderController.CreateOrder(ctx, dB, &forms.CreateOrderForm{Amount: 99999999, Item: "item", PricesIncludeTax: &pricesIncludeTax})
+		assert.Equal(t, "invalid_amount", apperrors.From(err).Code)
 
-		atRequest := &model.ATRequest{
-			Number:  "254728389583",
-			Message: "test",
+		clearOrderTable(t, ctx, dB)
+		clearCustomerTable(t, ctx, dB)
+	})
+
+	t.Run("can move an order through its statuses and publish the changes", func(t *testing.T) {
+
+		webhookRepository := repos.NewWebhookRepository()
+
+		subscription := &model.WebhookSubscription{
+			URL:        "https://example.com/hooks",
+			Secret:     "whsec_0123456789abcdef",
+			EventTypes: []string{model.EventOrderCreated, model.EventOrderStatusChanged},
+			Active:     true,
 		}
 
-		err := orderController.sendOrderSMS(atRequest)
+		err := webhookRepository.SaveSubscription(ctx, dB, subscription)
+		assert.NoError(t, err)
+
+		customer := model.BuildCustomer()
+
+		err = customerRepository.Save(ctx, dB, customer)
 		assert.NoError(t, err)
+
+		ctx := context.WithValue(ctx, model.CustomerKeyName, customer.Name)
+
+		order, err := orderController.CreateOrder(ctx, dB, &forms.CreateOrderForm{Amount: 100, Item: "item"})
+		assert.NoError(t, err)
+		assert.Equal(t, model.OrderStatusPending, order.Status)
+
+		order, err = orderController.UpdateOrderStatus(ctx, dB, order.ID, &forms.UpdateOrderStatusForm{Status: string(model.OrderStatusConfirmed)})
+		assert.NoError(t, err)
+		assert.Equal(t, model.OrderStatusConfirmed, order.Status)
+
+		_, err = orderController.UpdateOrderStatus(ctx, dB, order.ID, &forms.UpdateOrderStatusForm{Status: string(model.OrderStatusPending)})
+		assert.Error(t, err)
+
+		deliveries, err := webhookRepository.Deliveries(ctx, dB, subscription.ID, "", 10)
+		assert.NoError(t, err)
+		assert.Len(t, deliveries, 2)
+
+		filter, err := orderController.OrderEventFilter(ctx, dB, order.ID)
+		assert.NoError(t, err)
+
+		events, err := orderController.OrderEvents(ctx, dB, filter, 0, 10)
+		assert.NoError(t, err)
+		assert.Len(t, events, 2)
+		assert.Equal(t, model.OrderStatusConfirmed, events[1].Status)
+		assert.Equal(t, model.OrderStatusPending, events[1].PreviousStatus)
+
+		events, err = orderController.OrderEvents(ctx, dB, filter, events[0].ID, 10)
+		assert.NoError(t, err)
+		assert.Len(t, events, 1)
+
+		dB.ExecContext(ctx, "DELETE FROM webhook_subscriptions")
+		clearOrderTable(t, ctx, dB)
+		clearCustomerTable(t, ctx, dB)
+	})
+
+	t.Run("hides orders from other customers", func(t *testing.T) {
+
+		owner := model.BuildCustomer()
+
+		err := customerRepository.Save(ctx, dB, owner)
+		assert.NoError(t, err)
+
+		other := model.BuildCustomer()
+
+		err = customerRepository.Save(ctx, dB, other)
+		assert.NoError(t, err)
+
+		order, err := orderController.CreateOrder(context.WithValue(ctx, model.CustomerKeyName, owner.Name), dB, &forms.CreateOrderForm{Amount: 100, Item: "item"})
+		assert.NoError(t, err)
+
+		otherCtx := context.WithValue(ctx, model.CustomerKeyName, other.Name)
+
+		_, err = orderController.OrderByID(otherCtx, dB, order.ID)
+		assert.Error(t, err)
+
+		_, err = orderController.OrderEventFilter(otherCtx, dB, order.ID)
+		assert.Error(t, err)
+
+		clearOrderTable(t, ctx, dB)
+		clearCustomerTable(t, ctx, dB)
+	})
+
+	t.Run("sends sms notifications to the given number", func(t *testing.T) {
+
+		sentSMS := len(smsSender.Sent())
+
+		err := orderController.notifier.Notify(ctx, dB, &notifications.Notification{
+			Customer:    &model.Customer{Locale: model.LocaleEnglish},
+			Event:       model.NotificationOrderDispatched,
+			Data:        notifications.SampleTemplateData(),
+			PhoneNumber: "254728389583",
+		})
+		assert.NoError(t, err)
+
+		assert.Eventually(t, func() bool { return len(smsSender.Sent()) == sentSMS+1 }, time.Second, 10*time.Millisecond)
+
+		message := smsSender.Sent()[sentSMS]
+		assert.Equal(t, "254728389583", message.Number)
+		assert.Equal(t, "Your order 1024 for Maize flour 2kg has been dispatched.", message.Message)
 	})
 }
 
-func clearOrderTable(ctx context.Context, dB db.DB) {
-	dB.ExecContext(ctx, "DELETE FROM orders")
-	dB.ExecContext(ctx, "ALTER SEQUENCE orders_id_seq RESTART WITH 1")
+func clearOrderTable(t *testing.T, ctx context.Context, dB db.DB) {
+	t.Helper()
+
+	// TRUNCATE gets past the loyalty ledger's append-only trigger, and CASCADE
+	// clears everything recorded against the orders.
+	_, err := dB.ExecContext(ctx, "TRUNCATE orders RESTART IDENTITY CASCADE")
+	assert.NoError(t, err)
 }
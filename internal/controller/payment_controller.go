@@ -0,0 +1,122 @@
+package controller
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/ernestngugi/sil-backend/internal/db"
+	"github.com/ernestngugi/sil-backend/internal/forms"
+	"github.com/ernestngugi/sil-backend/internal/model"
+	"github.com/ernestngugi/sil-backend/internal/payments"
+	"github.com/ernestngugi/sil-backend/internal/repos"
+)
+
+type (
+	PaymentController interface {
+		CreatePayment(ctx context.Context, dB db.DB, orderID int64, form *forms.CreatePaymentForm) (*model.Payment, error)
+		Payments(ctx context.Context, dB db.DB, orderID int64) ([]*model.Payment, error)
+		MPesaCallback(ctx context.Context, dB db.DB, form *forms.MPesaCallbackForm) error
+	}
+
+	paymentController struct {
+		customerRepository repos.CustomerRepository
+		orderRepository    repos.OrderRepository
+		paymentRepository  repos.PaymentRepository
+		paymentProcessor   payments.Processor
+	}
+)
+
+func NewPaymentController(
+	customerRepository repos.CustomerRepository,
+	orderRepository repos.OrderRepository,
+	paymentRepository repos.PaymentRepository,
+	paymentProcessor payments.Processor,
+) PaymentController {
+	return &paymentController{
+		customerRepository: customerRepository,
+		orderRepository:    orderRepository,
+		paymentRepository:  paymentRepository,
+		paymentProcessor:   paymentProcessor,
+	}
+}
+
+// CreatePayment prompts the phone number to pay for one of the signed in
+// customer's orders.
+func (c *paymentController) CreatePayment(
+	ctx context.Context,
+	dB db.DB,
+	orderID int64,
+	form *forms.CreatePaymentForm,
+) (*model.Payment, error) {
+
+	order, err := c.customerOrder(ctx, dB, orderID)
+	if err != nil {
+		return &model.Payment{}, err
+	}
+
+	return c.paymentProcessor.Initiate(ctx, dB, order, form.PhoneNumber)
+}
+
+// Payments returns the payments for one of the signed in customer's orders,
+// newest first.
+func (c *paymentController) Payments(
+	ctx context.Context,
+	dB db.DB,
+	orderID int64,
+) ([]*model.Payment, error) {
+
+	order, err := c.customerOrder(ctx, dB, orderID)
+	if err != nil {
+		return []*model.Payment{}, err
+	}
+
+	return c.paymentRepository.Payments(ctx, dB, order.ID)
+}
+
+// MPesaCallback records the result of an STK Push reported by M-Pesa.
+func (c *paymentController) MPesaCallback(
+	ctx context.Context,
+	dB db.DB,
+	form *forms.MPesaCallbackForm,
+) error {
+
+	callback := form.Body.STKCallback
+
+	result := &model.PaymentResult{
+		MerchantRequestID: callback.MerchantRequestID,
+		CheckoutRequestID: callback.CheckoutRequestID,
+		ResultCode:        *callback.ResultCode,
+		ResultDescription: callback.ResultDesc,
+	}
+
+	for _, item := range callback.CallbackMetadata.Item {
+		switch item.Name {
+		case "MpesaReceiptNumber":
+			result.ReceiptNumber, _ = item.Value.(string)
+		case "Amount":
+			result.Amount, _ = item.Value.(float64)
+		case "PhoneNumber":
+			// Daraja sends the number as a JSON number.
+			switch value := item.Value.(type) {
+			case float64:
+				result.PhoneNumber = strconv.FormatFloat(value, 'f', 0, 64)
+			case string:
+				result.PhoneNumber = value
+			}
+		}
+	}
+
+	_, err := c.paymentProcessor.Complete(ctx, dB, result)
+
+	return err
+}
+
+func (c *paymentController) customerOrder(ctx context.Context, dB db.DB, orderID int64) (*model.Order, error) {
+
+	customer, err := signedInCustomer(ctx, dB, c.customerRepository)
+	if err != nil {
+		return &model.Order{}, err
+	}
+
+	return orderPlacedBy(ctx, dB, c.orderRepository, customer, orderID)
+}
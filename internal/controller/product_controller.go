@@ -0,0 +1,130 @@
+package controller
+
+import (
+	"context"
+	"strings"
+
+	"github.com/ernestngugi/sil-backend/internal/db"
+	"github.com/ernestngugi/sil-backend/internal/forms"
+	"github.com/ernestngugi/sil-backend/internal/model"
+	"github.com/ernestngugi/sil-backend/internal/repos"
+	"github.com/ernestngugi/sil-backend/internal/tax"
+)
+
+type (
+	ProductController interface {
+		CreateProduct(ctx context.Context, dB db.DB, form *forms.CreateProductForm) (*model.Product, error)
+		// Products returns the catalogue, leaving out withdrawn products
+		// unless all is set.
+		Products(ctx context.Context, dB db.DB, all bool) ([]*model.Product, error)
+		ProductByID(ctx context.Context, dB db.DB, productID int64) (*model.Product, error)
+		UpdateProduct(ctx context.Context, dB db.DB, productID int64, form *forms.UpdateProductForm) (*model.Product, error)
+	}
+
+	productController struct {
+		productRepository repos.ProductRepository
+		taxConfig         *tax.Config
+	}
+)
+
+func NewProductController(
+	productRepository repos.ProductRepository,
+	taxConfig *tax.Config,
+) ProductController {
+	return &productController{
+		productRepository: productRepository,
+		taxConfig:         taxConfig,
+	}
+}
+
+func (c *productController) CreateProduct(
+	ctx context.Context,
+	dB db.DB,
+	form *forms.CreateProductForm,
+) (*model.Product, error) {
+
+	category, err := c.taxConfig.Category(form.TaxCategory)
+	if err != nil {
+		return &model.Product{}, err
+	}
+
+	product := &model.Product{
+		SKU:         strings.ToUpper(form.SKU),
+		Name:        strings.TrimSpace(form.Name),
+		Price:       form.Price,
+		TaxCategory: category.Code,
+		Stock:       form.Stock,
+		Active:      true,
+	}
+
+	err = c.productRepository.SaveProduct(ctx, dB, product)
+	if err != nil {
+		return &model.Product{}, err
+	}
+
+	return product, nil
+}
+
+func (c *productController) Products(
+	ctx context.Context,
+	dB db.DB,
+	all bool,
+) ([]*model.Product, error) {
+	return c.productRepository.Products(ctx, dB, all)
+}
+
+func (c *productController) ProductByID(
+	ctx context.Context,
+	dB db.DB,
+	productID int64,
+) (*model.Product, error) {
+	return c.productRepository.ProductByID(ctx, dB, productID)
+}
+
+// UpdateProduct changes a product's details. Carts always show the current
+// price, so a new price applies to products already in carts.
+func (c *productController) UpdateProduct(
+	ctx context.Context,
+	dB db.DB,
+	productID int64,
+	form *forms.UpdateProductForm,
+) (*model.Product, error) {
+
+	product, err := c.productRepository.ProductByID(ctx, dB, productID)
+	if err != nil {
+		return &model.Product{}, err
+	}
+
+	if form.Name != nil {
+		product.Name = strings.TrimSpace(*form.Name)
+	}
+
+	if form.Price != nil {
+		product.Price = *form.Price
+	}
+
+	if form.TaxCategory != nil {
+
+		category, err := c.taxConfig.Category(*form.TaxCategory)
+		if err != nil {
+			return &model.Product{}, err
+		}
+
+		product.TaxCategory = category.Code
+	}
+
+	if form.Stock != nil {
+		product.Stock = *form.Stock
+	}
+
+	if form.Active != nil {
+		product.Active = *form.Active
+	}
+
+	err = c.productRepository.UpdateProduct(ctx, dB, product)
+	if err != nil {
+		return &model.Product{}, err
+	}
+
+	return product, nil
+}
@@ -0,0 +1,245 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ernestngugi/sil-backend/internal/apperrors"
+	"github.com/ernestngugi/sil-backend/internal/db"
+	"github.com/ernestngugi/sil-backend/internal/forms"
+	"github.com/ernestngugi/sil-backend/internal/model"
+	"github.com/ernestngugi/sil-backend/internal/promotions"
+	"github.com/ernestngugi/sil-backend/internal/repos"
+	"github.com/ernestngugi/sil-backend/internal/tax"
+)
+
+// maxPromotionRedemptions caps how many redemptions are listed.
+const maxPromotionRedemptions = 100
+
+var errPromotionRedeemed = apperrors.Conflict("promotion_redeemed", "a promotion that has been redeemed can only be deactivated")
+
+type (
+	PromotionController interface {
+		CreatePromotion(ctx context.Context, dB db.DB, form *forms.CreatePromotionForm) (*model.Promotion, error)
+		Promotions(ctx context.Context, dB db.DB) ([]*model.Promotion, error)
+		PromotionByID(ctx context.Context, dB db.DB, promotionID int64) (*model.Promotion, error)
+		UpdatePromotion(ctx context.Context, dB db.DB, promotionID int64, form *forms.UpdatePromotionForm) (*model.Promotion, error)
+		DeletePromotion(ctx context.Context, dB db.DB, promotionID int64) error
+		Redemptions(ctx context.Context, dB db.DB, promotionID int64) ([]*model.PromotionRedemption, error)
+		Report(ctx context.Context, dB db.DB) ([]*model.PromotionReport, error)
+	}
+
+	promotionController struct {
+		promotionRepository repos.PromotionRepository
+		taxConfig           *tax.Config
+	}
+)
+
+func NewPromotionController(
+	promotionRepository repos.PromotionRepository,
+	taxConfig *tax.Config,
+) PromotionController {
+	return &promotionController{
+		promotionRepository: promotionRepository,
+		taxConfig:           taxConfig,
+	}
+}
+
+func (c *promotionController) CreatePromotion(
+	ctx context.Context,
+	dB db.DB,
+	form *forms.CreatePromotionForm,
+) (*model.Promotion, error) {
+
+	promotion := &model.Promotion{
+		Code:             promotions.Code(form.Code),
+		Description:      form.Description,
+		DiscountType:     model.DiscountType(form.DiscountType),
+		DiscountValue:    form.DiscountValue,
+		MinOrderAmount:   form.MinOrderAmount,
+		Items:            append([]string{}, form.Items...),
+		TaxCategories:    append([]string{}, form.TaxCategories...),
+		StartsAt:         form.StartsAt,
+		EndsAt:           form.EndsAt,
+		UsageLimit:       form.UsageLimit,
+		PerCustomerLimit: form.PerCustomerLimit,
+		Active:           true,
+	}
+
+	err := c.checkPromotion(promotion)
+	if err != nil {
+		return &model.Promotion{}, err
+	}
+
+	err = c.promotionRepository.SavePromotion(ctx, dB, promotion)
+	if err != nil {
+		return &model.Promotion{}, err
+	}
+
+	return promotion, nil
+}
+
+// Promotions returns every promotion, newest first.
+func (c *promotionController) Promotions(
+	ctx context.Context,
+	dB db.DB,
+) ([]*model.Promotion, error) {
+	return c.promotionRepository.Promotions(ctx, dB)
+}
+
+func (c *promotionController) PromotionByID(
+	ctx context.Context,
+	dB db.DB,
+	promotionID int64,
+) (*model.Promotion, error) {
+	return c.promotionRepository.PromotionByID(ctx, dB, promotionID)
+}
+
+func (c *promotionController) UpdatePromotion(
+	ctx context.Context,
+	dB db.DB,
+	promotionID int64,
+	form *forms.UpdatePromotionForm,
+) (*model.Promotion, error) {
+
+	promotion, err := c.promotionRepository.PromotionByID(ctx, dB, promotionID)
+	if err != nil {
+		return &model.Promotion{}, err
+	}
+
+	if form.Description != nil {
+		promotion.Description = *form.Description
+	}
+
+	if form.DiscountValue != nil {
+		promotion.DiscountValue = *form.DiscountValue
+	}
+
+	if form.MinOrderAmount != nil {
+		promotion.MinOrderAmount = *form.MinOrderAmount
+	}
+
+	if form.Items != nil {
+		promotion.Items = form.Items
+	}
+
+	if form.TaxCategories != nil {
+		promotion.TaxCategories = form.TaxCategories
+	}
+
+	if form.StartsAt != nil {
+		promotion.StartsAt = form.StartsAt
+	}
+
+	if form.EndsAt != nil {
+		promotion.EndsAt = form.EndsAt
+	}
+
+	if form.UsageLimit != nil {
+		promotion.UsageLimit = form.UsageLimit
+	}
+
+	if form.PerCustomerLimit != nil {
+		promotion.PerCustomerLimit = form.PerCustomerLimit
+	}
+
+	if form.Active != nil {
+		promotion.Active = *form.Active
+	}
+
+	err = c.checkPromotion(promotion)
+	if err != nil {
+		return &model.Promotion{}, err
+	}
+
+	// Redemptions are counted by the database, so saving the promotion does
+	// not undo any counted since it was read.
+	err = c.promotionRepository.UpdatePromotion(ctx, dB, promotion)
+	if err != nil {
+		return &model.Promotion{}, err
+	}
+
+	return promotion, nil
+}
+
+// DeletePromotion removes a promotion that was never redeemed. Redeemed
+// promotions are kept for reporting and can be deactivated instead.
+func (c *promotionController) DeletePromotion(
+	ctx context.Context,
+	dB db.DB,
+	promotionID int64,
+) error {
+
+	promotion, err := c.promotionRepository.PromotionByID(ctx, dB, promotionID)
+	if err != nil {
+		return err
+	}
+
+	if promotion.TimesRedeemed > 0 {
+		return errPromotionRedeemed
+	}
+
+	return c.promotionRepository.DeletePromotion(ctx, dB, promotionID)
+}
+
+// Redemptions returns the latest redemptions of a promotion.
+func (c *promotionController) Redemptions(
+	ctx context.Context,
+	dB db.DB,
+	promotionID int64,
+) ([]*model.PromotionRedemption, error) {
+
+	_, err := c.promotionRepository.PromotionByID(ctx, dB, promotionID)
+	if err != nil {
+		return []*model.PromotionRedemption{}, err
+	}
+
+	return c.promotionRepository.Redemptions(ctx, dB, promotionID, maxPromotionRedemptions)
+}
+
+// Report totals the redemptions of every promotion.
+func (c *promotionController) Report(
+	ctx context.Context,
+	dB db.DB,
+) ([]*model.PromotionReport, error) {
+	return c.promotionRepository.Report(ctx, dB)
+}
+
+// checkPromotion applies the rules that span fields or depend on
+// configuration, which the form tags cannot express.
+func (c *promotionController) checkPromotion(promotion *model.Promotion) error {
+
+	var fieldErrors []apperrors.FieldError
+
+	if promotion.DiscountType == model.DiscountPercentage && promotion.DiscountValue > 100 {
+		fieldErrors = append(fieldErrors, apperrors.FieldError{
+			Field:   "discount_value",
+			Code:    "lte",
+			Message: "discount_value must be at most 100 for a percentage discount",
+		})
+	}
+
+	if promotion.StartsAt != nil && promotion.EndsAt != nil && !promotion.EndsAt.After(*promotion.StartsAt) {
+		fieldErrors = append(fieldErrors, apperrors.FieldError{
+			Field:   "ends_at",
+			Code:    "gtfield",
+			Message: "ends_at must be after starts_at",
+		})
+	}
+
+	for i, category := range promotion.TaxCategories {
+		if _, err := c.taxConfig.Category(category); err != nil {
+			fieldErrors = append(fieldErrors, apperrors.FieldError{
+				Field:   fmt.Sprintf("tax_categories[%d]", i),
+				Code:    "oneof",
+				Message: fmt.Sprintf("tax category %q is not recognised", category),
+			})
+		}
+	}
+
+	if len(fieldErrors) > 0 {
+		return apperrors.Validation("invalid_promotion", "promotion is not valid", fieldErrors...)
+	}
+
+	return nil
+}
@@ -0,0 +1,64 @@
+package controller
+
+import (
+	"context"
+
+	"github.com/ernestngugi/sil-backend/internal/db"
+	"github.com/ernestngugi/sil-backend/internal/model"
+	"github.com/ernestngugi/sil-backend/internal/repos"
+)
+
+// reconciliationRunsLimit caps how many runs are listed.
+const reconciliationRunsLimit = 100
+
+type (
+	ReconciliationController interface {
+		Runs(ctx context.Context, dB db.DB) ([]*model.ReconciliationRun, error)
+		RunByID(ctx context.Context, dB db.DB, runID int64) (*model.ReconciliationRun, error)
+		Entries(ctx context.Context, dB db.DB, runID int64) ([]*model.ReconciliationEntry, error)
+	}
+
+	reconciliationController struct {
+		reconciliationRepository repos.ReconciliationRepository
+	}
+)
+
+func NewReconciliationController(
+	reconciliationRepository repos.ReconciliationRepository,
+) ReconciliationController {
+	return &reconciliationController{
+		reconciliationRepository: reconciliationRepository,
+	}
+}
+
+// Runs returns the latest imported statements, newest first.
+func (c *reconciliationController) Runs(
+	ctx context.Context,
+	dB db.DB,
+) ([]*model.ReconciliationRun, error) {
+	return c.reconciliationRepository.Runs(ctx, dB, reconciliationRunsLimit)
+}
+
+func (c *reconciliationController) RunByID(
+	ctx context.Context,
+	dB db.DB,
+	runID int64,
+) (*model.ReconciliationRun, error) {
+	return c.reconciliationRepository.RunByID(ctx, dB, runID)
+}
+
+// Entries returns how each transaction on a statement was reconciled, in
+// statement order.
+func (c *reconciliationController) Entries(
+	ctx context.Context,
+	dB db.DB,
+	runID int64,
+) ([]*model.ReconciliationEntry, error) {
+
+	run, err := c.reconciliationRepository.RunByID(ctx, dB, runID)
+	if err != nil {
+		return []*model.ReconciliationEntry{}, err
+	}
+
+	return c.reconciliationRepository.Entries(ctx, dB, run.ID)
+}
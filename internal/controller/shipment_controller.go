@@ -0,0 +1,508 @@
+package controller
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"time"
+
+	"github.com/ernestngugi/sil-backend/internal/apperrors"
+	"github.com/ernestngugi/sil-backend/internal/db"
+	"github.com/ernestngugi/sil-backend/internal/forms"
+	"github.com/ernestngugi/sil-backend/internal/model"
+	"github.com/ernestngugi/sil-backend/internal/notifications"
+	"github.com/ernestngugi/sil-backend/internal/repos"
+	"github.com/ernestngugi/sil-backend/internal/webhooks"
+)
+
+// maxDeliveryCodeAttempts is how many wrong delivery codes a rider can enter
+// before the customer has to be sent a new one.
+const maxDeliveryCodeAttempts = 5
+
+var (
+	errOrderNotShippable   = apperrors.Conflict("order_not_shippable", "only confirmed or dispatched orders can be shipped")
+	errShipmentDelivered   = apperrors.Conflict("shipment_delivered", "the shipment has already been delivered")
+	errDeliveryCodeLocked  = apperrors.Conflict("delivery_code_attempts_exceeded", "too many wrong delivery codes, send the customer a new code")
+	errNoPhoneNumber       = apperrors.Conflict("customer_phone_missing", "the customer has no phone number to send the delivery code to")
+	errInvalidDeliveryCode = apperrors.Validation("invalid_delivery_code", "delivery code is wrong", apperrors.FieldError{
+		Field:   "delivery_code",
+		Code:    "delivery_code",
+		Message: "delivery_code does not match the code sent to the customer",
+	})
+)
+
+type (
+	ShipmentController interface {
+		AssignShipment(ctx context.Context, dB db.DB, orderID int64, form *forms.AssignShipmentForm) (*model.Shipment, error)
+		ShipmentByID(ctx context.Context, dB db.DB, shipmentID int64) (*model.Shipment, error)
+		UpdateShipment(ctx context.Context, dB db.DB, shipmentID int64, form *forms.UpdateShipmentForm) (*model.Shipment, error)
+		AddEvent(ctx context.Context, dB db.DB, shipmentID int64, form *forms.AddShipmentEventForm) (*model.Shipment, error)
+		ConfirmDelivery(ctx context.Context, dB db.DB, shipmentID int64, form *forms.ConfirmDeliveryForm) (*model.Shipment, error)
+		OrderTracking(ctx context.Context, dB db.DB, orderID int64) (*model.OrderTracking, error)
+	}
+
+	shipmentController struct {
+		customerRepository   repos.CustomerRepository
+		orderRepository      repos.OrderRepository
+		orderEventRepository repos.OrderEventRepository
+		shipmentRepository   repos.ShipmentRepository
+		notifier             notifications.Notifier
+		webhookPublisher     webhooks.Publisher
+		logger               *slog.Logger
+	}
+)
+
+func NewShipmentController(
+	customerRepository repos.CustomerRepository,
+	orderRepository repos.OrderRepository,
+	orderEventRepository repos.OrderEventRepository,
+	shipmentRepository repos.ShipmentRepository,
+	notifier notifications.Notifier,
+	webhookPublisher webhooks.Publisher,
+	logger *slog.Logger,
+) ShipmentController {
+	return &shipmentController{
+		customerRepository:   customerRepository,
+		orderRepository:      orderRepository,
+		orderEventRepository: orderEventRepository,
+		shipmentRepository:   shipmentRepository,
+		notifier:             notifier,
+		webhookPublisher:     webhookPublisher,
+		logger:               logger,
+	}
+}
+
+// AssignShipment hands an order to a rider, dispatching it if it has only
+// been confirmed, and texts the customer the rider's details with the code
+// to give them on delivery. Orders of customers with no phone number cannot
+// be assigned, they would never get the code.
+func (c *shipmentController) AssignShipment(
+	ctx context.Context,
+	dB db.DB,
+	orderID int64,
+	form *forms.AssignShipmentForm,
+) (*model.Shipment, error) {
+
+	code, err := deliveryCode()
+	if err != nil {
+		return &model.Shipment{}, err
+	}
+
+	shipment := &model.Shipment{
+		OrderID:           orderID,
+		Carrier:           form.Carrier,
+		TrackingNumber:    form.TrackingNumber,
+		RiderName:         form.RiderName,
+		RiderPhone:        form.RiderPhone,
+		EstimatedDelivery: form.EstimatedDelivery,
+		Status:            model.ShipmentAssigned,
+		CodeHash:          hashDeliveryCode(code),
+	}
+
+	var order *model.Order
+	var phoneNumber string
+
+	err = db.WithTransaction(ctx, dB, func(operations db.SQLOperations) error {
+
+		var err error
+
+		order, err = c.orderRepository.OrderByID(ctx, operations, orderID)
+		if err != nil {
+			return err
+		}
+
+		switch order.Status {
+		case model.OrderStatusConfirmed:
+
+			err = changeOrderStatus(ctx, operations, c.orderRepository, c.orderEventRepository, c.webhookPublisher, order, model.OrderStatusDispatched)
+			if err != nil {
+				return err
+			}
+		case model.OrderStatusDispatched:
+		default:
+			return errOrderNotShippable
+		}
+
+		phoneNumber, err = c.deliveryCodeNumber(ctx, dB, order)
+		if err != nil {
+			return err
+		}
+
+		err = c.shipmentRepository.SaveShipment(ctx, operations, shipment)
+		if err != nil {
+			return err
+		}
+
+		return c.shipmentRepository.SaveEvent(ctx, operations, shipment, &model.ShipmentEvent{
+			Status:      model.ShipmentAssigned,
+			Description: fmt.Sprintf("Assigned to %v", shipment.RiderName),
+		})
+	})
+	if err != nil {
+		return &model.Shipment{}, err
+	}
+
+	c.notifyCustomer(ctx, dB, order, shipment, model.NotificationRiderAssigned, code, phoneNumber)
+
+	return shipment, nil
+}
+
+func (c *shipmentController) ShipmentByID(
+	ctx context.Context,
+	dB db.DB,
+	shipmentID int64,
+) (*model.Shipment, error) {
+
+	return c.shipmentRepository.ShipmentByID(ctx, dB, shipmentID)
+}
+
+// UpdateShipment changes the shipment's details. A change of rider, or a
+// request to resend the code, texts the customer a new delivery code with the
+// rider's details.
+func (c *shipmentController) UpdateShipment(
+	ctx context.Context,
+	dB db.DB,
+	shipmentID int64,
+	form *forms.UpdateShipmentForm,
+) (*model.Shipment, error) {
+
+	var shipment *model.Shipment
+	var order *model.Order
+	var code, phoneNumber string
+
+	err := db.WithTransaction(ctx, dB, func(operations db.SQLOperations) error {
+
+		var err error
+
+		shipment, err = c.shipmentRepository.LockShipment(ctx, operations, shipmentID)
+		if err != nil {
+			return err
+		}
+
+		if shipment.Status == model.ShipmentDelivered {
+			return errShipmentDelivered
+		}
+
+		if form.Carrier != nil {
+			shipment.Carrier = *form.Carrier
+		}
+
+		if form.TrackingNumber != nil {
+			shipment.TrackingNumber = *form.TrackingNumber
+		}
+
+		if form.EstimatedDelivery != nil {
+			shipment.EstimatedDelivery = form.EstimatedDelivery
+		}
+
+		riderChanged := false
+
+		if form.RiderName != nil && *form.RiderName != shipment.RiderName {
+			shipment.RiderName = *form.RiderName
+			riderChanged = true
+		}
+
+		if form.RiderPhone != nil && *form.RiderPhone != shipment.RiderPhone {
+			shipment.RiderPhone = *form.RiderPhone
+			riderChanged = true
+		}
+
+		if riderChanged || form.ResendCode {
+
+			order, err = c.orderRepository.OrderByID(ctx, operations, shipment.OrderID)
+			if err != nil {
+				return err
+			}
+
+			phoneNumber, err = c.deliveryCodeNumber(ctx, dB, order)
+			if err != nil {
+				return err
+			}
+
+			code, err = deliveryCode()
+			if err != nil {
+				return err
+			}
+
+			shipment.CodeHash = hashDeliveryCode(code)
+			shipment.CodeAttempts = 0
+		}
+
+		return c.shipmentRepository.UpdateShipment(ctx, operations, shipment)
+	})
+	if err != nil {
+		return &model.Shipment{}, err
+	}
+
+	if code != "" {
+		c.notifyCustomer(ctx, dB, order, shipment, model.NotificationRiderAssigned, code, phoneNumber)
+	}
+
+	return shipment, nil
+}
+
+// AddEvent records a step of the shipment's journey and moves the shipment
+// to its status.
+func (c *shipmentController) AddEvent(
+	ctx context.Context,
+	dB db.DB,
+	shipmentID int64,
+	form *forms.AddShipmentEventForm,
+) (*model.Shipment, error) {
+
+	var shipment *model.Shipment
+
+	err := db.WithTransaction(ctx, dB, func(operations db.SQLOperations) error {
+
+		var err error
+
+		shipment, err = c.shipmentRepository.LockShipment(ctx, operations, shipmentID)
+		if err != nil {
+			return err
+		}
+
+		status := model.ShipmentStatus(form.Status)
+
+		err = checkShipmentTransition(shipment, status)
+		if err != nil {
+			return err
+		}
+
+		shipment.Status = status
+
+		err = c.shipmentRepository.UpdateShipment(ctx, operations, shipment)
+		if err != nil {
+			return err
+		}
+
+		return c.shipmentRepository.SaveEvent(ctx, operations, shipment, &model.ShipmentEvent{
+			Status:      status,
+			Description: form.Description,
+			Location:    form.Location,
+		})
+	})
+	if err != nil {
+		return &model.Shipment{}, err
+	}
+
+	return shipment, nil
+}
+
+// ConfirmDelivery records the proof of delivery the rider collected and
+// marks the order delivered. Wrong codes are counted, and once there have
+// been maxDeliveryCodeAttempts of them no code is accepted until the
+// customer is sent a new one.
+func (c *shipmentController) ConfirmDelivery(
+	ctx context.Context,
+	dB db.DB,
+	shipmentID int64,
+	form *forms.ConfirmDeliveryForm,
+) (*model.Shipment, error) {
+
+	var shipment *model.Shipment
+	var order *model.Order
+
+	wrongCode := false
+
+	err := db.WithTransaction(ctx, dB, func(operations db.SQLOperations) error {
+
+		var err error
+
+		shipment, err = c.shipmentRepository.LockShipment(ctx, operations, shipmentID)
+		if err != nil {
+			return err
+		}
+
+		err = checkShipmentTransition(shipment, model.ShipmentDelivered)
+		if err != nil {
+			return err
+		}
+
+		if shipment.CodeAttempts >= maxDeliveryCodeAttempts {
+			return errDeliveryCodeLocked
+		}
+
+		// The wrong attempt is committed rather than rolled back, so it
+		// counts towards the limit.
+		if subtle.ConstantTimeCompare([]byte(hashDeliveryCode(form.DeliveryCode)), []byte(shipment.CodeHash)) != 1 {
+			wrongCode = true
+			shipment.CodeAttempts++
+			return c.shipmentRepository.UpdateShipment(ctx, operations, shipment)
+		}
+
+		order, err = c.orderRepository.OrderByID(ctx, operations, shipment.OrderID)
+		if err != nil {
+			return err
+		}
+
+		err = changeOrderStatus(ctx, operations, c.orderRepository, c.orderEventRepository, c.webhookPublisher, order, model.OrderStatusDelivered)
+		if err != nil {
+			return err
+		}
+
+		timeNow := time.Now()
+
+		shipment.Status = model.ShipmentDelivered
+		shipment.RecipientName = form.RecipientName
+		shipment.DateDelivered = &timeNow
+
+		err = c.shipmentRepository.UpdateShipment(ctx, operations, shipment)
+		if err != nil {
+			return err
+		}
+
+		return c.shipmentRepository.SaveEvent(ctx, operations, shipment, &model.ShipmentEvent{
+			Status:      model.ShipmentDelivered,
+			Description: fmt.Sprintf("Received by %v", form.RecipientName),
+			Location:    form.Location,
+		})
+	})
+	if err != nil {
+		return &model.Shipment{}, err
+	}
+
+	if wrongCode {
+		return &model.Shipment{}, errInvalidDeliveryCode
+	}
+
+	c.notifyCustomer(ctx, dB, order, shipment, model.NotificationOrderDelivered, "", "")
+
+	return shipment, nil
+}
+
+// OrderTracking returns where an order placed by the signed in customer is.
+func (c *shipmentController) OrderTracking(
+	ctx context.Context,
+	dB db.DB,
+	orderID int64,
+) (*model.OrderTracking, error) {
+
+	customer, err := signedInCustomer(ctx, dB, c.customerRepository)
+	if err != nil {
+		return &model.OrderTracking{}, err
+	}
+
+	order, err := orderPlacedBy(ctx, dB, c.orderRepository, customer, orderID)
+	if err != nil {
+		return &model.OrderTracking{}, err
+	}
+
+	tracking := &model.OrderTracking{
+		OrderID: order.ID,
+		Status:  order.Status,
+	}
+
+	shipment, err := c.shipmentRepository.ShipmentByOrderID(ctx, dB, order.ID)
+	if err != nil {
+		if apperrors.Is(err, apperrors.KindNotFound) {
+			return tracking, nil
+		}
+		return &model.OrderTracking{}, err
+	}
+
+	tracking.Shipment = shipment
+
+	return tracking, nil
+}
+
+// notifyCustomer sends the message for event about the shipment of order.
+// A delivery code, when one is given, is texted to phoneNumber whatever
+// channel the customer chose, and even if they opted out of SMS. Shipments
+// are not failed because a message could not be sent.
+func (c *shipmentController) notifyCustomer(
+	ctx context.Context,
+	dB db.DB,
+	order *model.Order,
+	shipment *model.Shipment,
+	event model.NotificationEvent,
+	code string,
+	phoneNumber string,
+) {
+
+	customer, err := c.customerRepository.CustomerByID(ctx, dB, order.CustomerID)
+	if err != nil {
+		c.logger.ErrorContext(ctx, "failed to load customer to notify", slog.Int64("order_id", order.ID), slog.String("error", err.Error()))
+		return
+	}
+
+	data := notifications.OrderTemplateData(order)
+	data.RiderName = shipment.RiderName
+	data.RiderPhone = shipment.RiderPhone
+	data.DeliveryCode = code
+
+	err = c.notifier.Notify(ctx, dB, &notifications.Notification{
+		Customer:      customer,
+		Event:         event,
+		Data:          data,
+		PhoneNumber:   phoneNumber,
+		Transactional: code != "",
+	})
+	if err != nil {
+		c.logger.ErrorContext(ctx, "failed to notify customer",
+			slog.Int64("order_id", order.ID),
+			slog.Int64("shipment_id", shipment.ID),
+			slog.String("event", string(event)),
+			slog.String("error", err.Error()),
+		)
+	}
+}
+
+// deliveryCodeNumber returns the number the delivery code for order is texted
+// to.
+func (c *shipmentController) deliveryCodeNumber(ctx context.Context, dB db.DB, order *model.Order) (string, error) {
+
+	customer, err := c.customerRepository.CustomerByID(ctx, dB, order.CustomerID)
+	if err != nil {
+		return "", err
+	}
+
+	phoneNumber, err := c.notifier.PhoneNumber(ctx, dB, customer)
+	if err != nil {
+		if errors.Is(err, notifications.ErrNoPhoneNumber) {
+			return "", errNoPhoneNumber
+		}
+		return "", err
+	}
+
+	return phoneNumber, nil
+}
+
+func checkShipmentTransition(shipment *model.Shipment, status model.ShipmentStatus) error {
+
+	if shipment.Status == model.ShipmentDelivered {
+		return errShipmentDelivered
+	}
+
+	if !shipment.Status.CanTransitionTo(status) {
+		return apperrors.Conflict(
+			"invalid_shipment_transition",
+			fmt.Sprintf("shipment cannot move from %v to %v", shipment.Status, status),
+		)
+	}
+
+	return nil
+}
+
+// deliveryCode returns a random six digit code.
+func deliveryCode() (string, error) {
+
+	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+	if err != nil {
+		return "", fmt.Errorf("failed to generate delivery code: %w", err)
+	}
+
+	return fmt.Sprintf("%06d", n.Int64()), nil
+}
+
+func hashDeliveryCode(code string) string {
+
+	sum := sha256.Sum256([]byte(code))
+
+	return hex.EncodeToString(sum[:])
+}
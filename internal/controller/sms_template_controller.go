@@ -0,0 +1,143 @@
+package controller
+
+import (
+	"context"
+	"slices"
+
+	"github.com/ernestngugi/sil-backend/internal/apperrors"
+	"github.com/ernestngugi/sil-backend/internal/db"
+	"github.com/ernestngugi/sil-backend/internal/forms"
+	"github.com/ernestngugi/sil-backend/internal/model"
+	"github.com/ernestngugi/sil-backend/internal/notifications"
+	"github.com/ernestngugi/sil-backend/internal/repos"
+)
+
+var errSMSTemplateNotFound = apperrors.NotFound("sms_template_not_found", "sms template not found")
+
+type (
+	SMSTemplateController interface {
+		Templates(ctx context.Context, dB db.DB) ([]*model.SMSTemplate, error)
+		SaveTemplate(ctx context.Context, dB db.DB, event model.NotificationEvent, locale string, form *forms.SaveSMSTemplateForm) (*model.SMSTemplate, error)
+		DeleteTemplate(ctx context.Context, dB db.DB, event model.NotificationEvent, locale string) error
+		Preview(ctx context.Context, dB db.DB, form *forms.PreviewSMSTemplateForm) (*model.SMSPreview, error)
+	}
+
+	smsTemplateController struct {
+		smsTemplateRepository repos.SMSTemplateRepository
+		smsTemplates          notifications.SMSTemplates
+	}
+)
+
+func NewSMSTemplateController(
+	smsTemplateRepository repos.SMSTemplateRepository,
+	smsTemplates notifications.SMSTemplates,
+) SMSTemplateController {
+	return &smsTemplateController{
+		smsTemplateRepository: smsTemplateRepository,
+		smsTemplates:          smsTemplates,
+	}
+}
+
+// Templates returns the template used for every event and locale, customised
+// or built in.
+func (c *smsTemplateController) Templates(
+	ctx context.Context,
+	dB db.DB,
+) ([]*model.SMSTemplate, error) {
+
+	custom, err := c.smsTemplateRepository.Templates(ctx, dB)
+	if err != nil {
+		return []*model.SMSTemplate{}, err
+	}
+
+	templates := make([]*model.SMSTemplate, 0, len(model.NotificationEvents)*len(model.Locales))
+
+	for _, event := range model.NotificationEvents {
+		for _, locale := range model.Locales {
+
+			index := slices.IndexFunc(custom, func(template *model.SMSTemplate) bool {
+				return template.Event == event && template.Locale == locale
+			})
+
+			if index >= 0 {
+				templates = append(templates, custom[index])
+				continue
+			}
+
+			body, _ := notifications.DefaultSMSTemplate(event, locale)
+
+			templates = append(templates, &model.SMSTemplate{
+				Event:  event,
+				Locale: locale,
+				Body:   body,
+			})
+		}
+	}
+
+	return templates, nil
+}
+
+// SaveTemplate customises the template for event and locale once it renders
+// with sample order details.
+func (c *smsTemplateController) SaveTemplate(
+	ctx context.Context,
+	dB db.DB,
+	event model.NotificationEvent,
+	locale string,
+	form *forms.SaveSMSTemplateForm,
+) (*model.SMSTemplate, error) {
+
+	if !knownSMSTemplate(event, locale) {
+		return &model.SMSTemplate{}, errSMSTemplateNotFound
+	}
+
+	_, err := notifications.RenderSMS(form.Body, notifications.SampleTemplateData())
+	if err != nil {
+		return &model.SMSTemplate{}, err
+	}
+
+	template := &model.SMSTemplate{
+		Event:  event,
+		Locale: locale,
+		Body:   form.Body,
+	}
+
+	err = c.smsTemplateRepository.Save(ctx, dB, template)
+	if err != nil {
+		return &model.SMSTemplate{}, err
+	}
+
+	return template, nil
+}
+
+// DeleteTemplate reverts event and locale to the built in template.
+func (c *smsTemplateController) DeleteTemplate(
+	ctx context.Context,
+	dB db.DB,
+	event model.NotificationEvent,
+	locale string,
+) error {
+
+	if !knownSMSTemplate(event, locale) {
+		return errSMSTemplateNotFound
+	}
+
+	return c.smsTemplateRepository.Delete(ctx, dB, event, locale)
+}
+
+func (c *smsTemplateController) Preview(
+	ctx context.Context,
+	dB db.DB,
+	form *forms.PreviewSMSTemplateForm,
+) (*model.SMSPreview, error) {
+
+	if form.Body != "" {
+		return notifications.RenderSMS(form.Body, notifications.SampleTemplateData())
+	}
+
+	return c.smsTemplates.Render(ctx, dB, model.NotificationEvent(form.Event), form.Locale, notifications.SampleTemplateData())
+}
+
+func knownSMSTemplate(event model.NotificationEvent, locale string) bool {
+	return slices.Contains(model.NotificationEvents, event) && slices.Contains(model.Locales, locale)
+}
@@ -0,0 +1,197 @@
+package controller
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/ernestngugi/sil-backend/internal/db"
+	"github.com/ernestngugi/sil-backend/internal/forms"
+	"github.com/ernestngugi/sil-backend/internal/model"
+	"github.com/ernestngugi/sil-backend/internal/repos"
+)
+
+const maxWebhookDeliveries = 100
+
+type (
+	WebhookController interface {
+		CreateSubscription(ctx context.Context, dB db.DB, form *forms.CreateWebhookSubscriptionForm) (*model.WebhookSubscription, error)
+		Subscriptions(ctx context.Context, dB db.DB) ([]*model.WebhookSubscription, error)
+		SubscriptionByID(ctx context.Context, dB db.DB, subscriptionID int64) (*model.WebhookSubscription, error)
+		UpdateSubscription(ctx context.Context, dB db.DB, subscriptionID int64, form *forms.UpdateWebhookSubscriptionForm) (*model.WebhookSubscription, error)
+		DeleteSubscription(ctx context.Context, dB db.DB, subscriptionID int64) error
+		Deliveries(ctx context.Context, dB db.DB, subscriptionID int64, status model.WebhookDeliveryStatus) ([]*model.WebhookDelivery, error)
+		Redeliver(ctx context.Context, dB db.DB, subscriptionID, deliveryID int64) (*model.WebhookDelivery, error)
+	}
+
+	webhookController struct {
+		webhookRepository repos.WebhookRepository
+	}
+)
+
+func NewWebhookController(webhookRepository repos.WebhookRepository) WebhookController {
+	return &webhookController{
+		webhookRepository: webhookRepository,
+	}
+}
+
+// CreateSubscription is the only call that returns the signing secret.
+func (c *webhookController) CreateSubscription(
+	ctx context.Context,
+	dB db.DB,
+	form *forms.CreateWebhookSubscriptionForm,
+) (*model.WebhookSubscription, error) {
+
+	subscription := &model.WebhookSubscription{
+		URL:        form.URL,
+		Secret:     form.Secret,
+		EventTypes: form.EventTypes,
+		Active:     true,
+	}
+
+	if subscription.Secret == "" {
+		subscription.Secret = newWebhookSecret()
+	}
+
+	err := c.webhookRepository.SaveSubscription(ctx, dB, subscription)
+	if err != nil {
+		return &model.WebhookSubscription{}, err
+	}
+
+	return subscription, nil
+}
+
+func (c *webhookController) Subscriptions(
+	ctx context.Context,
+	dB db.DB,
+) ([]*model.WebhookSubscription, error) {
+
+	subscriptions, err := c.webhookRepository.Subscriptions(ctx, dB)
+	if err != nil {
+		return []*model.WebhookSubscription{}, err
+	}
+
+	for _, subscription := range subscriptions {
+		subscription.Secret = ""
+	}
+
+	return subscriptions, nil
+}
+
+func (c *webhookController) SubscriptionByID(
+	ctx context.Context,
+	dB db.DB,
+	subscriptionID int64,
+) (*model.WebhookSubscription, error) {
+
+	subscription, err := c.webhookRepository.SubscriptionByID(ctx, dB, subscriptionID)
+	if err != nil {
+		return &model.WebhookSubscription{}, err
+	}
+
+	subscription.Secret = ""
+
+	return subscription, nil
+}
+
+func (c *webhookController) UpdateSubscription(
+	ctx context.Context,
+	dB db.DB,
+	subscriptionID int64,
+	form *forms.UpdateWebhookSubscriptionForm,
+) (*model.WebhookSubscription, error) {
+
+	subscription, err := c.webhookRepository.SubscriptionByID(ctx, dB, subscriptionID)
+	if err != nil {
+		return &model.WebhookSubscription{}, err
+	}
+
+	if form.URL != nil {
+		subscription.URL = *form.URL
+	}
+
+	if len(form.EventTypes) > 0 {
+		subscription.EventTypes = form.EventTypes
+	}
+
+	if form.Active != nil {
+		if *form.Active && !subscription.Active {
+			subscription.ConsecutiveFailures = 0
+			subscription.DateDisabled = nil
+		}
+		subscription.Active = *form.Active
+	}
+
+	err = c.webhookRepository.UpdateSubscription(ctx, dB, subscription)
+	if err != nil {
+		return &model.WebhookSubscription{}, err
+	}
+
+	subscription.Secret = ""
+
+	return subscription, nil
+}
+
+func (c *webhookController) DeleteSubscription(
+	ctx context.Context,
+	dB db.DB,
+	subscriptionID int64,
+) error {
+	return c.webhookRepository.DeleteSubscription(ctx, dB, subscriptionID)
+}
+
+// Deliveries returns the most recent deliveries to a subscription.
+func (c *webhookController) Deliveries(
+	ctx context.Context,
+	dB db.DB,
+	subscriptionID int64,
+	status model.WebhookDeliveryStatus,
+) ([]*model.WebhookDelivery, error) {
+
+	_, err := c.webhookRepository.SubscriptionByID(ctx, dB, subscriptionID)
+	if err != nil {
+		return []*model.WebhookDelivery{}, err
+	}
+
+	return c.webhookRepository.Deliveries(ctx, dB, subscriptionID, status, maxWebhookDeliveries)
+}
+
+// Redeliver queues a new delivery of the same event. The event ID is kept so
+// receivers can tell it is a repeat.
+func (c *webhookController) Redeliver(
+	ctx context.Context,
+	dB db.DB,
+	subscriptionID, deliveryID int64,
+) (*model.WebhookDelivery, error) {
+
+	delivery, err := c.webhookRepository.DeliveryByID(ctx, dB, subscriptionID, deliveryID)
+	if err != nil {
+		return &model.WebhookDelivery{}, err
+	}
+
+	redelivery := &model.WebhookDelivery{
+		SubscriptionID: delivery.SubscriptionID,
+		EventID:        delivery.EventID,
+		EventType:      delivery.EventType,
+		Payload:        delivery.Payload,
+	}
+
+	err = c.webhookRepository.SaveDelivery(ctx, dB, redelivery)
+	if err != nil {
+		return &model.WebhookDelivery{}, err
+	}
+
+	return redelivery, nil
+}
+
+func newWebhookSecret() string {
+
+	b := make([]byte, 32)
+
+	_, err := rand.Read(b)
+	if err != nil {
+		panic(err)
+	}
+
+	return "whsec_" + hex.EncodeToString(b)
+}
@@ -3,19 +3,31 @@ package db
 import (
 	"context"
 	"database/sql"
-	"embed"
+	"errors"
 	"fmt"
-	"log"
-	"os"
+	"log/slog"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/ernestngugi/sil-backend/internal/env"
+	"github.com/ernestngugi/sil-backend/internal/tracing"
 	_ "github.com/lib/pq"
-	"github.com/pressly/goose/v3"
 )
 
-var db DB
+const (
+	defaultMaxOpenConns     = 25
+	defaultMaxIdleConns     = 25
+	defaultConnMaxLifetime  = 30 * time.Minute
+	defaultConnMaxIdleTime  = 5 * time.Minute
+	defaultStatementTimeout = 30 * time.Second
+	defaultConnectTimeout   = 30 * time.Second
+	defaultSlowQuery        = 500 * time.Millisecond
 
-//go:embed migrations/*.sql
-var embedMigrations embed.FS
+	initialRetryInterval = 500 * time.Millisecond
+	maxRetryInterval     = 5 * time.Second
+)
 
 type SQLOperations interface {
 	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
@@ -27,54 +39,225 @@ type DB interface {
 	SQLOperations
 	Close() error
 	Ping() error
+	PingContext(ctx context.Context) error
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (Tx, error)
 }
 
 type AppDB struct {
 	*sql.DB
-	valid bool
+	logger             *slog.Logger
+	slowQueryThreshold time.Duration
+}
+
+// Config holds the connection and pool settings for the application database.
+type Config struct {
+	URL              string
+	MaxOpenConns     int
+	MaxIdleConns     int
+	ConnMaxLifetime  time.Duration
+	ConnMaxIdleTime  time.Duration
+	StatementTimeout time.Duration
+	// ConnectTimeout is how long InitDB keeps retrying before giving up on an
+	// unreachable database.
+	ConnectTimeout time.Duration
+	// SlowQueryThreshold is the duration above which queries are logged at
+	// warn level. Faster queries are logged at debug level.
+	SlowQueryThreshold time.Duration
+}
+
+// ConfigFromEnv reads the database configuration from the environment.
+func ConfigFromEnv() (*Config, error) {
+
+	config := &Config{
+		URL: env.String("DATABASE_URL", ""),
+	}
+
+	var err error
+
+	if config.MaxOpenConns, err = env.Int("DATABASE_MAX_OPEN_CONNS", defaultMaxOpenConns); err != nil {
+		return &Config{}, err
+	}
+
+	if config.MaxIdleConns, err = env.Int("DATABASE_MAX_IDLE_CONNS", defaultMaxIdleConns); err != nil {
+		return &Config{}, err
+	}
+
+	if config.ConnMaxLifetime, err = env.Duration("DATABASE_CONN_MAX_LIFETIME", defaultConnMaxLifetime); err != nil {
+		return &Config{}, err
+	}
+
+	if config.ConnMaxIdleTime, err = env.Duration("DATABASE_CONN_MAX_IDLE_TIME", defaultConnMaxIdleTime); err != nil {
+		return &Config{}, err
+	}
+
+	if config.StatementTimeout, err = env.Duration("DATABASE_STATEMENT_TIMEOUT", defaultStatementTimeout); err != nil {
+		return &Config{}, err
+	}
+
+	if config.ConnectTimeout, err = env.Duration("DATABASE_CONNECT_TIMEOUT", defaultConnectTimeout); err != nil {
+		return &Config{}, err
+	}
+
+	if config.SlowQueryThreshold, err = env.Duration("DATABASE_SLOW_QUERY_THRESHOLD", defaultSlowQuery); err != nil {
+		return &Config{}, err
+	}
+
+	return config, nil
 }
 
-func InitDB() DB {
-	return InitDBWithURL(
-		os.Getenv("DATABASE_URL"),
-	)
+func InitDB(ctx context.Context, logger *slog.Logger) (*AppDB, error) {
+
+	config, err := ConfigFromEnv()
+	if err != nil {
+		return &AppDB{}, err
+	}
+
+	return InitDBWithConfig(ctx, config, logger)
 }
 
-func InitDBWithURL(databaseURL string) DB {
-	if databaseURL == "" {
-		fmt.Println("database url is required")
+// InitDBWithConfig opens the database and waits for it to accept connections.
+// Migrations are applied separately, see RunMigrations.
+func InitDBWithConfig(ctx context.Context, config *Config, logger *slog.Logger) (*AppDB, error) {
+
+	if config.URL == "" {
+		return &AppDB{}, errors.New("database url is required")
 	}
 
-	dB, err := sql.Open("postgres", databaseURL)
+	dataSourceName, err := withStatementTimeout(config.URL, config.StatementTimeout)
 	if err != nil {
-		fmt.Printf("sql open failed: %v", err)
+		return &AppDB{}, err
 	}
 
-	db = &AppDB{
-		DB:    dB,
-		valid: true,
+	dB, err := sql.Open("postgres", dataSourceName)
+	if err != nil {
+		return &AppDB{}, fmt.Errorf("sql open failed: %w", err)
 	}
 
-	err = db.Ping()
+	dB.SetMaxOpenConns(config.MaxOpenConns)
+	dB.SetMaxIdleConns(config.MaxIdleConns)
+	dB.SetConnMaxLifetime(config.ConnMaxLifetime)
+	dB.SetConnMaxIdleTime(config.ConnMaxIdleTime)
+
+	err = waitForDB(ctx, dB, config.ConnectTimeout, logger)
 	if err != nil {
-		fmt.Printf("db ping failed: %v", err)
+		dB.Close()
+		return &AppDB{}, err
 	}
 
-	runDBMigration(dB)
+	return &AppDB{
+		DB:                 dB,
+		logger:             logger,
+		slowQueryThreshold: config.SlowQueryThreshold,
+	}, nil
+}
+
+func (d *AppDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	ctx, end := tracing.StartSQLSpan(ctx, query)
+	start := time.Now()
+	result, err := d.DB.ExecContext(ctx, query, args...)
+	d.logQuery(ctx, query, start, err)
+	end(err)
+	return result, err
+}
+
+func (d *AppDB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	ctx, end := tracing.StartSQLSpan(ctx, query)
+	start := time.Now()
+	rows, err := d.DB.QueryContext(ctx, query, args...)
+	d.logQuery(ctx, query, start, err)
+	end(err)
+	return rows, err
+}
+
+func (d *AppDB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	ctx, end := tracing.StartSQLSpan(ctx, query)
+	start := time.Now()
+	row := d.DB.QueryRowContext(ctx, query, args...)
+	d.logQuery(ctx, query, start, row.Err())
+	end(row.Err())
+	return row
+}
+
+// logQuery records the statement and its duration. Arguments are never
+// logged since they carry customer data.
+func (d *AppDB) logQuery(ctx context.Context, query string, start time.Time, err error) {
+
+	if d.logger == nil {
+		return
+	}
+
+	duration := time.Since(start)
+
+	attrs := []any{
+		slog.String("query", query),
+		slog.Duration("duration", duration),
+	}
+
+	switch {
+	case err != nil:
+		d.logger.WarnContext(ctx, "sql query failed", append(attrs, slog.String("error", err.Error()))...)
+	case duration >= d.slowQueryThreshold:
+		d.logger.WarnContext(ctx, "slow sql query", attrs...)
+	default:
+		d.logger.DebugContext(ctx, "sql query", attrs...)
+	}
+}
+
+// waitForDB pings the database with exponential backoff until it responds or
+// the timeout elapses. Containers frequently start before Postgres is ready.
+func waitForDB(ctx context.Context, dB *sql.DB, timeout time.Duration, logger *slog.Logger) error {
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	interval := initialRetryInterval
+
+	for attempt := 1; ; attempt++ {
+
+		err := dB.PingContext(ctx)
+		if err == nil {
+			return nil
+		}
+
+		logger.WarnContext(ctx, "db ping failed", slog.Int("attempt", attempt), slog.String("error", err.Error()))
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("db not reachable after %v: %w", timeout, err)
+		case <-time.After(interval):
+		}
 
-	return db
+		interval *= 2
+		if interval > maxRetryInterval {
+			interval = maxRetryInterval
+		}
+	}
 }
 
-func runDBMigration(db *sql.DB) {
-	goose.SetBaseFS(embedMigrations)
+// withStatementTimeout adds a statement_timeout run-time parameter to the data
+// source name, which lib/pq forwards to the server on connect.
+func withStatementTimeout(dataSourceName string, timeout time.Duration) (string, error) {
+
+	if timeout <= 0 {
+		return dataSourceName, nil
+	}
+
+	milliseconds := strconv.FormatInt(timeout.Milliseconds(), 10)
 
-	if err := goose.SetDialect("postgres"); err != nil {
-		log.Fatal("cannot set goose dialect: ", err)
+	if !strings.HasPrefix(dataSourceName, "postgres://") && !strings.HasPrefix(dataSourceName, "postgresql://") {
+		return dataSourceName + " statement_timeout=" + milliseconds, nil
+	}
+
+	parsedURL, err := url.Parse(dataSourceName)
+	if err != nil {
+		return "", fmt.Errorf("invalid database url: %w", err)
 	}
 
-	if err := goose.Up(db, "migrations"); err != nil {
-		log.Fatal("failed to run migrate up: ", err)
+	query := parsedURL.Query()
+	if query.Get("statement_timeout") == "" {
+		query.Set("statement_timeout", milliseconds)
 	}
+	parsedURL.RawQuery = query.Encode()
 
-	log.Print("db migrated successfully")
+	return parsedURL.String(), nil
 }
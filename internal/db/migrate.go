@@ -0,0 +1,133 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+
+	"github.com/pressly/goose/v3"
+	"github.com/pressly/goose/v3/lock"
+)
+
+//go:embed migrations/*.sql
+var embedMigrations embed.FS
+
+// Migrator applies the embedded migrations/*.sql files. Every operation holds
+// a Postgres advisory lock so replicas starting together do not race.
+type Migrator struct {
+	provider *goose.Provider
+	logger   *slog.Logger
+}
+
+func NewMigrator(dB *sql.DB, logger *slog.Logger) (*Migrator, error) {
+
+	migrations, err := fs.Sub(embedMigrations, "migrations")
+	if err != nil {
+		return &Migrator{}, err
+	}
+
+	sessionLocker, err := lock.NewPostgresSessionLocker()
+	if err != nil {
+		return &Migrator{}, fmt.Errorf("cannot create migration lock: %w", err)
+	}
+
+	provider, err := goose.NewProvider(
+		goose.DialectPostgres,
+		dB,
+		migrations,
+		goose.WithSessionLocker(sessionLocker),
+	)
+	if err != nil {
+		return &Migrator{}, fmt.Errorf("cannot load migrations: %w", err)
+	}
+
+	return &Migrator{provider: provider, logger: logger}, nil
+}
+
+// RunMigrations applies all pending migrations.
+func RunMigrations(ctx context.Context, dB *AppDB) error {
+
+	migrator, err := NewMigrator(dB.DB, dB.logger)
+	if err != nil {
+		return err
+	}
+
+	return migrator.Up(ctx)
+}
+
+func (m *Migrator) Up(ctx context.Context) error {
+
+	results, err := m.provider.Up(ctx)
+	m.logResults(ctx, results...)
+	if err != nil {
+		return fmt.Errorf("failed to run migrate up: %w", err)
+	}
+
+	m.logger.InfoContext(ctx, "db migrated successfully")
+
+	return nil
+}
+
+// Down rolls back the most recently applied migration.
+func (m *Migrator) Down(ctx context.Context) error {
+
+	result, err := m.provider.Down(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to run migrate down: %w", err)
+	}
+
+	m.logResults(ctx, result)
+
+	return nil
+}
+
+// Redo rolls back the most recently applied migration and applies it again.
+func (m *Migrator) Redo(ctx context.Context) error {
+
+	err := m.Down(ctx)
+	if err != nil {
+		return err
+	}
+
+	result, err := m.provider.UpByOne(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to reapply migration: %w", err)
+	}
+
+	m.logResults(ctx, result)
+
+	return nil
+}
+
+func (m *Migrator) Status(ctx context.Context) ([]*goose.MigrationStatus, error) {
+	return m.provider.Status(ctx)
+}
+
+// Version returns the version the database is at and the latest version
+// embedded in the binary.
+func (m *Migrator) Version(ctx context.Context) (current int64, latest int64, err error) {
+
+	current, err = m.provider.GetDBVersion(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	sources := m.provider.ListSources()
+	if len(sources) == 0 {
+		return current, 0, errors.New("no migrations embedded")
+	}
+
+	return current, sources[len(sources)-1].Version, nil
+}
+
+func (m *Migrator) logResults(ctx context.Context, results ...*goose.MigrationResult) {
+	for _, result := range results {
+		if result != nil {
+			m.logger.InfoContext(ctx, "migration", slog.String("result", result.String()))
+		}
+	}
+}
@@ -0,0 +1,10 @@
+-- +goose Up
+CREATE TABLE sms_outbox (
+    id              BIGSERIAL       PRIMARY KEY,
+    phone_number    VARCHAR(20)     NOT NULL,
+    message         TEXT            NOT NULL,
+    date_created    TIMESTAMPTZ     NOT NULL DEFAULT clock_timestamp()
+);
+
+-- +goose Down
+drop table if exists sms_outbox;
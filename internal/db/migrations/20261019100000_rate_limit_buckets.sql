@@ -0,0 +1,13 @@
+-- +goose Up
+CREATE TABLE rate_limit_buckets (
+    key             VARCHAR(255)        PRIMARY KEY,
+    tokens          DOUBLE PRECISION    NOT NULL,
+    allowed         BOOLEAN             NOT NULL,
+    date_modified   TIMESTAMPTZ         NOT NULL,
+    date_expires    TIMESTAMPTZ         NOT NULL
+);
+
+CREATE INDEX rate_limit_buckets_date_expires_idx ON rate_limit_buckets (date_expires);
+
+-- +goose Down
+drop table if exists rate_limit_buckets;
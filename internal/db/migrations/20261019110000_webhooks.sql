@@ -0,0 +1,37 @@
+-- +goose Up
+ALTER TABLE orders ADD COLUMN status VARCHAR(20) NOT NULL DEFAULT 'pending';
+
+CREATE TABLE webhook_subscriptions (
+    id                      BIGSERIAL       PRIMARY KEY,
+    url                     VARCHAR(2048)   NOT NULL,
+    secret                  VARCHAR(255)    NOT NULL,
+    event_types             TEXT[]          NOT NULL,
+    active                  BOOLEAN         NOT NULL DEFAULT true,
+    consecutive_failures    INTEGER         NOT NULL DEFAULT 0,
+    date_disabled           TIMESTAMPTZ,
+    date_created            TIMESTAMPTZ     NOT NULL DEFAULT clock_timestamp(),
+    date_modified           TIMESTAMPTZ     NOT NULL DEFAULT clock_timestamp()
+);
+
+CREATE TABLE webhook_deliveries (
+    id                  BIGSERIAL       PRIMARY KEY,
+    subscription_id     BIGINT          NOT NULL REFERENCES webhook_subscriptions(id) ON DELETE CASCADE,
+    event_id            VARCHAR(64)     NOT NULL,
+    event_type          VARCHAR(64)     NOT NULL,
+    payload             JSONB           NOT NULL,
+    status              VARCHAR(20)     NOT NULL DEFAULT 'pending',
+    attempts            INTEGER         NOT NULL DEFAULT 0,
+    next_attempt_at     TIMESTAMPTZ     NOT NULL DEFAULT clock_timestamp(),
+    last_status_code    INTEGER,
+    last_error          TEXT,
+    date_created        TIMESTAMPTZ     NOT NULL DEFAULT clock_timestamp(),
+    date_delivered      TIMESTAMPTZ
+);
+
+CREATE INDEX webhook_deliveries_due_idx ON webhook_deliveries (next_attempt_at) WHERE status = 'pending';
+CREATE INDEX webhook_deliveries_subscription_idx ON webhook_deliveries (subscription_id, id DESC);
+
+-- +goose Down
+drop table if exists webhook_deliveries;
+drop table if exists webhook_subscriptions;
+ALTER TABLE orders DROP COLUMN IF EXISTS status;
@@ -0,0 +1,35 @@
+-- +goose Up
+CREATE TABLE order_events (
+    id                  BIGSERIAL       PRIMARY KEY,
+    order_id            BIGINT          NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    customer_id         BIGINT          NOT NULL,
+    status              VARCHAR(20)     NOT NULL,
+    previous_status     VARCHAR(20),
+    date_created        TIMESTAMPTZ     NOT NULL DEFAULT clock_timestamp()
+);
+
+CREATE INDEX order_events_order_idx ON order_events (order_id, id);
+CREATE INDEX order_events_customer_idx ON order_events (customer_id, id);
+
+-- Listeners are told which customer an event belongs to and read the event
+-- itself from the table, so the payload stays well under the NOTIFY limit.
+-- +goose StatementBegin
+CREATE FUNCTION notify_order_event() RETURNS trigger AS $$
+BEGIN
+    PERFORM pg_notify('order_events', json_build_object(
+        'id', NEW.id,
+        'order_id', NEW.order_id,
+        'customer_id', NEW.customer_id
+    )::text);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER order_events_notify AFTER INSERT ON order_events
+    FOR EACH ROW EXECUTE FUNCTION notify_order_event();
+
+-- +goose Down
+drop trigger if exists order_events_notify on order_events;
+drop function if exists notify_order_event();
+drop table if exists order_events;
@@ -0,0 +1,17 @@
+-- +goose Up
+ALTER TABLE customers ADD COLUMN locale VARCHAR(10) NOT NULL DEFAULT 'en';
+
+-- Templates edited by admins. Events and locales without a row use the
+-- templates built into the application.
+CREATE TABLE sms_templates (
+    event               VARCHAR(64)     NOT NULL,
+    locale              VARCHAR(10)     NOT NULL,
+    body                TEXT            NOT NULL,
+    date_created        TIMESTAMPTZ     NOT NULL DEFAULT clock_timestamp(),
+    date_modified       TIMESTAMPTZ     NOT NULL DEFAULT clock_timestamp(),
+    PRIMARY KEY (event, locale)
+);
+
+-- +goose Down
+drop table if exists sms_templates;
+ALTER TABLE customers DROP COLUMN IF EXISTS locale;
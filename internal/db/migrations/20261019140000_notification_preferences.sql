@@ -0,0 +1,37 @@
+-- +goose Up
+-- Channels customers chose per event. Events without a row use SMS.
+CREATE TABLE notification_preferences (
+    customer_id         BIGINT          NOT NULL REFERENCES customers(id) ON DELETE CASCADE,
+    event               VARCHAR(64)     NOT NULL,
+    channel             VARCHAR(10)     NOT NULL,
+    date_modified       TIMESTAMPTZ     NOT NULL DEFAULT clock_timestamp(),
+    PRIMARY KEY (customer_id, event)
+);
+
+CREATE TABLE quiet_hours (
+    customer_id         BIGINT          PRIMARY KEY REFERENCES customers(id) ON DELETE CASCADE,
+    start_time          VARCHAR(5)      NOT NULL,
+    end_time            VARCHAR(5)      NOT NULL,
+    time_zone           VARCHAR(64)     NOT NULL,
+    date_modified       TIMESTAMPTZ     NOT NULL DEFAULT clock_timestamp()
+);
+
+-- Numbers that opted out of SMS. Nothing is sent to them whatever the
+-- customer's preferences say.
+CREATE TABLE sms_suppressions (
+    phone_number        VARCHAR(20)     PRIMARY KEY,
+    reason              VARCHAR(32)     NOT NULL,
+    date_created        TIMESTAMPTZ     NOT NULL DEFAULT clock_timestamp()
+);
+
+-- Messages deferred until the end of a customer's quiet hours wait in the
+-- outbox alongside those persisted at shutdown.
+ALTER TABLE sms_outbox ADD COLUMN send_after TIMESTAMPTZ NOT NULL DEFAULT clock_timestamp();
+CREATE INDEX sms_outbox_send_after_idx ON sms_outbox (send_after);
+
+-- +goose Down
+DROP INDEX IF EXISTS sms_outbox_send_after_idx;
+ALTER TABLE sms_outbox DROP COLUMN IF EXISTS send_after;
+drop table if exists sms_suppressions;
+drop table if exists quiet_hours;
+drop table if exists notification_preferences;
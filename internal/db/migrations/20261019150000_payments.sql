@@ -0,0 +1,24 @@
+-- +goose Up
+ALTER TABLE orders ADD COLUMN payment_status VARCHAR(20) NOT NULL DEFAULT 'unpaid';
+
+CREATE TABLE payments (
+    id                      BIGSERIAL       PRIMARY KEY,
+    order_id                BIGINT          NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    phone_number            VARCHAR(20)     NOT NULL,
+    amount                  NUMERIC(10,2)   NOT NULL,
+    status                  VARCHAR(20)     NOT NULL DEFAULT 'pending',
+    merchant_request_id     VARCHAR(64),
+    checkout_request_id     VARCHAR(64)     UNIQUE,
+    result_code             INTEGER,
+    result_description      TEXT,
+    receipt_number          VARCHAR(32)     UNIQUE,
+    date_created            TIMESTAMPTZ     NOT NULL DEFAULT clock_timestamp(),
+    date_completed          TIMESTAMPTZ
+);
+
+CREATE INDEX payments_order_idx ON payments (order_id, id DESC);
+CREATE INDEX payments_pending_idx ON payments (date_created) WHERE status = 'pending';
+
+-- +goose Down
+drop table if exists payments;
+ALTER TABLE orders DROP COLUMN IF EXISTS payment_status;
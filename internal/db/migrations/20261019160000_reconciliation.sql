@@ -0,0 +1,36 @@
+-- +goose Up
+CREATE TABLE reconciliation_runs (
+    id                  BIGSERIAL       PRIMARY KEY,
+    provider            VARCHAR(20)     NOT NULL,
+    file_name           VARCHAR(255)    NOT NULL,
+    statement_sha256    CHAR(64)        NOT NULL UNIQUE,
+    transactions        INTEGER         NOT NULL DEFAULT 0,
+    matched             INTEGER         NOT NULL DEFAULT 0,
+    unmatched           INTEGER         NOT NULL DEFAULT 0,
+    duplicates          INTEGER         NOT NULL DEFAULT 0,
+    amount_mismatches   INTEGER         NOT NULL DEFAULT 0,
+    date_created        TIMESTAMPTZ     NOT NULL DEFAULT clock_timestamp()
+);
+
+CREATE TABLE reconciliation_entries (
+    id                  BIGSERIAL       PRIMARY KEY,
+    run_id              BIGINT          NOT NULL REFERENCES reconciliation_runs(id) ON DELETE CASCADE,
+    line                INTEGER         NOT NULL,
+    receipt_number      VARCHAR(32)     NOT NULL,
+    completed_at        TIMESTAMPTZ     NOT NULL,
+    amount              NUMERIC(10,2)   NOT NULL,
+    phone_number        VARCHAR(20)     NOT NULL,
+    account_reference   VARCHAR(64)     NOT NULL,
+    status              VARCHAR(20)     NOT NULL,
+    order_id            BIGINT          REFERENCES orders(id) ON DELETE SET NULL,
+    payment_id          BIGINT          REFERENCES payments(id) ON DELETE SET NULL,
+    expected_amount     NUMERIC(10,2),
+    note                TEXT            NOT NULL DEFAULT ''
+);
+
+CREATE INDEX reconciliation_entries_run_idx ON reconciliation_entries (run_id, line);
+CREATE INDEX reconciliation_entries_order_idx ON reconciliation_entries (order_id) WHERE status = 'matched';
+
+-- +goose Down
+drop table if exists reconciliation_entries;
+drop table if exists reconciliation_runs;
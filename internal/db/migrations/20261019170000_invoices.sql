@@ -0,0 +1,34 @@
+-- +goose Up
+-- Invoice numbers come from a counter row rather than a sequence, which
+-- would leave gaps when a transaction rolls back. Issuing locks the row, so
+-- numbers are allocated one at a time in the order invoices are committed.
+CREATE TABLE invoice_sequences (
+    kind            VARCHAR(20)     PRIMARY KEY,
+    last_number     BIGINT          NOT NULL DEFAULT 0
+);
+
+INSERT INTO invoice_sequences (kind) VALUES ('invoice'), ('receipt');
+
+CREATE TABLE invoices (
+    id                  BIGSERIAL       PRIMARY KEY,
+    kind                VARCHAR(20)     NOT NULL REFERENCES invoice_sequences(kind),
+    sequence_number     BIGINT          NOT NULL,
+    number              VARCHAR(32)     NOT NULL,
+    order_id            BIGINT          NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    payment_id          BIGINT          REFERENCES payments(id) ON DELETE SET NULL,
+    customer_name       VARCHAR(255)    NOT NULL,
+    lines               JSONB           NOT NULL,
+    net_amount          NUMERIC(10,2)   NOT NULL,
+    tax_amount          NUMERIC(10,2)   NOT NULL,
+    gross_amount        NUMERIC(10,2)   NOT NULL,
+    payment_reference   VARCHAR(32),
+    payment_phone       VARCHAR(20),
+    date_paid           TIMESTAMPTZ,
+    date_issued         TIMESTAMPTZ     NOT NULL,
+    UNIQUE (kind, sequence_number),
+    UNIQUE (kind, order_id)
+);
+
+-- +goose Down
+drop table if exists invoices;
+drop table if exists invoice_sequences;
@@ -0,0 +1,27 @@
+-- +goose Up
+-- Orders placed before tax categories were priced including VAT at the
+-- standard 16%, so they are backfilled on that basis.
+ALTER TABLE orders
+    ADD COLUMN tax_category     VARCHAR(50)     NOT NULL DEFAULT 'standard',
+    ADD COLUMN tax_treatment    VARCHAR(20)     NOT NULL DEFAULT 'standard',
+    ADD COLUMN tax_rate         NUMERIC(5,2)    NOT NULL DEFAULT 16,
+    ADD COLUMN tax_amount       NUMERIC(10,2),
+    ADD COLUMN net_amount       NUMERIC(10,2);
+
+UPDATE orders SET tax_amount = ROUND(amount * 16 / 116, 2);
+UPDATE orders SET net_amount = amount - tax_amount;
+
+ALTER TABLE orders
+    ALTER COLUMN tax_amount SET NOT NULL,
+    ALTER COLUMN net_amount SET NOT NULL,
+    ALTER COLUMN tax_category DROP DEFAULT,
+    ALTER COLUMN tax_treatment DROP DEFAULT,
+    ALTER COLUMN tax_rate DROP DEFAULT;
+
+-- +goose Down
+ALTER TABLE orders
+    DROP COLUMN IF EXISTS tax_category,
+    DROP COLUMN IF EXISTS tax_treatment,
+    DROP COLUMN IF EXISTS tax_rate,
+    DROP COLUMN IF EXISTS tax_amount,
+    DROP COLUMN IF EXISTS net_amount;
@@ -0,0 +1,45 @@
+-- +goose Up
+CREATE TABLE promotions (
+    id                  BIGSERIAL       PRIMARY KEY,
+    code                VARCHAR(32)     NOT NULL UNIQUE,
+    description         VARCHAR(255)    NOT NULL DEFAULT '',
+    discount_type       VARCHAR(20)     NOT NULL,
+    discount_value      NUMERIC(10,2)   NOT NULL,
+    min_order_amount    NUMERIC(10,2)   NOT NULL DEFAULT 0,
+    items               TEXT[]          NOT NULL DEFAULT '{}',
+    tax_categories      TEXT[]          NOT NULL DEFAULT '{}',
+    starts_at           TIMESTAMPTZ,
+    ends_at             TIMESTAMPTZ,
+    usage_limit         INTEGER,
+    per_customer_limit  INTEGER,
+    times_redeemed      INTEGER         NOT NULL DEFAULT 0,
+    active              BOOLEAN         NOT NULL DEFAULT TRUE,
+    date_created        TIMESTAMPTZ     NOT NULL DEFAULT clock_timestamp(),
+    date_modified       TIMESTAMPTZ     NOT NULL DEFAULT clock_timestamp()
+);
+
+-- Redemptions keep their promotion, so a promotion that has been used can
+-- only be deactivated, not deleted.
+CREATE TABLE promotion_redemptions (
+    id                  BIGSERIAL       PRIMARY KEY,
+    promotion_id        BIGINT          NOT NULL REFERENCES promotions(id) ON DELETE RESTRICT,
+    order_id            BIGINT          NOT NULL UNIQUE REFERENCES orders(id) ON DELETE CASCADE,
+    customer_id         BIGINT          NOT NULL REFERENCES customers(id),
+    discount_amount     NUMERIC(10,2)   NOT NULL,
+    order_amount        NUMERIC(10,2)   NOT NULL,
+    date_created        TIMESTAMPTZ     NOT NULL DEFAULT clock_timestamp()
+);
+
+CREATE INDEX promotion_redemptions_promotion_customer_idx ON promotion_redemptions (promotion_id, customer_id);
+
+ALTER TABLE orders
+    ADD COLUMN promo_code       VARCHAR(32),
+    ADD COLUMN discount_amount  NUMERIC(10,2)   NOT NULL DEFAULT 0;
+
+-- +goose Down
+ALTER TABLE orders
+    DROP COLUMN IF EXISTS promo_code,
+    DROP COLUMN IF EXISTS discount_amount;
+
+drop table if exists promotion_redemptions;
+drop table if exists promotions;
@@ -0,0 +1,42 @@
+-- +goose Up
+-- The ledger is append-only: balances are the sum of a customer's entries,
+-- so entries are corrected by writing new ones, never by changing old ones.
+CREATE TABLE loyalty_entries (
+    id                  BIGSERIAL       PRIMARY KEY,
+    customer_id         BIGINT          NOT NULL REFERENCES customers(id),
+    order_id            BIGINT          REFERENCES orders(id),
+    kind                VARCHAR(20)     NOT NULL,
+    points              INTEGER         NOT NULL CHECK (points <> 0),
+    amount              NUMERIC(10,2)   NOT NULL DEFAULT 0,
+    expires_at          TIMESTAMPTZ,
+    date_created        TIMESTAMPTZ     NOT NULL DEFAULT clock_timestamp()
+);
+
+CREATE INDEX loyalty_entries_customer_idx ON loyalty_entries (customer_id, id);
+
+-- An order earns, spends, reverses and returns points at most once each.
+CREATE UNIQUE INDEX loyalty_entries_order_kind_idx ON loyalty_entries (order_id, kind) WHERE order_id IS NOT NULL;
+
+-- +goose StatementBegin
+CREATE FUNCTION loyalty_entries_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'loyalty_entries is append-only';
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER loyalty_entries_append_only
+    BEFORE UPDATE OR DELETE ON loyalty_entries
+    FOR EACH ROW EXECUTE FUNCTION loyalty_entries_append_only();
+
+ALTER TABLE orders
+    ADD COLUMN loyalty_points   INTEGER         NOT NULL DEFAULT 0,
+    ADD COLUMN loyalty_amount   NUMERIC(10,2)   NOT NULL DEFAULT 0;
+
+-- +goose Down
+ALTER TABLE orders
+    DROP COLUMN IF EXISTS loyalty_points,
+    DROP COLUMN IF EXISTS loyalty_amount;
+
+drop table if exists loyalty_entries;
+drop function if exists loyalty_entries_append_only;
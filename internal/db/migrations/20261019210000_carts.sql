@@ -0,0 +1,56 @@
+-- +goose Up
+CREATE TABLE products (
+    id                  BIGSERIAL       PRIMARY KEY,
+    sku                 VARCHAR(32)     NOT NULL UNIQUE,
+    name                VARCHAR(50)     NOT NULL,
+    price               NUMERIC(10,2)   NOT NULL CHECK (price > 0),
+    tax_category        VARCHAR(50)     NOT NULL,
+    stock               INTEGER         NOT NULL DEFAULT 0 CHECK (stock >= 0),
+    active              BOOLEAN         NOT NULL DEFAULT TRUE,
+    date_created        TIMESTAMPTZ     NOT NULL DEFAULT clock_timestamp(),
+    date_modified       TIMESTAMPTZ     NOT NULL DEFAULT clock_timestamp()
+);
+
+-- Each customer has one cart, created when they first add to it and removed
+-- when they check out.
+CREATE TABLE carts (
+    customer_id         BIGINT          PRIMARY KEY REFERENCES customers(id) ON DELETE CASCADE,
+    promo_code          VARCHAR(32),
+    reminded_at         TIMESTAMPTZ,
+    date_created        TIMESTAMPTZ     NOT NULL DEFAULT clock_timestamp(),
+    date_modified       TIMESTAMPTZ     NOT NULL DEFAULT clock_timestamp()
+);
+
+CREATE INDEX carts_date_modified_idx ON carts (date_modified);
+
+CREATE TABLE cart_lines (
+    customer_id         BIGINT          NOT NULL REFERENCES carts(customer_id) ON DELETE CASCADE,
+    product_id          BIGINT          NOT NULL REFERENCES products(id),
+    quantity            INTEGER         NOT NULL CHECK (quantity > 0),
+    date_added          TIMESTAMPTZ     NOT NULL DEFAULT clock_timestamp(),
+    PRIMARY KEY (customer_id, product_id)
+);
+
+CREATE TABLE order_lines (
+    id                  BIGSERIAL       PRIMARY KEY,
+    order_id            BIGINT          NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    product_id          BIGINT          NOT NULL REFERENCES products(id),
+    item                VARCHAR(50)     NOT NULL,
+    quantity            INTEGER         NOT NULL,
+    unit_price          NUMERIC(10,2)   NOT NULL,
+    discount_amount     NUMERIC(10,2)   NOT NULL,
+    amount              NUMERIC(10,2)   NOT NULL,
+    tax_category        VARCHAR(50)     NOT NULL,
+    tax_treatment       VARCHAR(20)     NOT NULL,
+    tax_rate            NUMERIC(5,2)    NOT NULL,
+    net_amount          NUMERIC(10,2)   NOT NULL,
+    tax_amount          NUMERIC(10,2)   NOT NULL
+);
+
+CREATE INDEX order_lines_order_idx ON order_lines (order_id, id);
+
+-- +goose Down
+drop table if exists order_lines;
+drop table if exists cart_lines;
+drop table if exists carts;
+drop table if exists products;
@@ -0,0 +1,32 @@
+-- +goose Up
+CREATE TABLE shipments (
+    id                  BIGSERIAL       PRIMARY KEY,
+    order_id            BIGINT          NOT NULL UNIQUE REFERENCES orders(id) ON DELETE CASCADE,
+    carrier             VARCHAR(50)     NOT NULL,
+    tracking_number     VARCHAR(64),
+    rider_name          VARCHAR(50)     NOT NULL,
+    rider_phone         VARCHAR(15)     NOT NULL,
+    estimated_delivery  TIMESTAMPTZ,
+    status              VARCHAR(20)     NOT NULL,
+    code_hash           CHAR(64)        NOT NULL,
+    code_attempts       INTEGER         NOT NULL DEFAULT 0,
+    recipient_name      VARCHAR(50),
+    date_delivered      TIMESTAMPTZ,
+    date_created        TIMESTAMPTZ     NOT NULL DEFAULT clock_timestamp(),
+    date_modified       TIMESTAMPTZ     NOT NULL DEFAULT clock_timestamp()
+);
+
+CREATE TABLE shipment_events (
+    id                  BIGSERIAL       PRIMARY KEY,
+    shipment_id         BIGINT          NOT NULL REFERENCES shipments(id) ON DELETE CASCADE,
+    status              VARCHAR(20)     NOT NULL,
+    description         VARCHAR(255),
+    location            VARCHAR(100),
+    date_created        TIMESTAMPTZ     NOT NULL DEFAULT clock_timestamp()
+);
+
+CREATE INDEX shipment_events_shipment_idx ON shipment_events (shipment_id, id);
+
+-- +goose Down
+drop table if exists shipment_events;
+drop table if exists shipments;
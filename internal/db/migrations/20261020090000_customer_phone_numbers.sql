@@ -0,0 +1,7 @@
+-- +goose Up
+-- The number customers asked to be texted on. Customers without one are
+-- texted on the number they last paid from.
+ALTER TABLE customers ADD COLUMN phone_number VARCHAR(15);
+
+-- +goose Down
+ALTER TABLE customers DROP COLUMN IF EXISTS phone_number;
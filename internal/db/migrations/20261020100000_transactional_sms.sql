@@ -0,0 +1,7 @@
+-- +goose Up
+-- Transactional messages, such as delivery codes, skip the suppression list
+-- when they are sent after a restart.
+ALTER TABLE sms_outbox ADD COLUMN transactional BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- +goose Down
+ALTER TABLE sms_outbox DROP COLUMN IF EXISTS transactional;
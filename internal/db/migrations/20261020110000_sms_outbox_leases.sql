@@ -0,0 +1,8 @@
+-- +goose Up
+-- Claimed messages stay in the outbox until they are sent. If the replica
+-- that claimed one stops before sending it, another claims it once
+-- claimed_until has passed.
+ALTER TABLE sms_outbox ADD COLUMN claimed_until TIMESTAMPTZ;
+
+-- +goose Down
+ALTER TABLE sms_outbox DROP COLUMN IF EXISTS claimed_until;
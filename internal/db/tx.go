@@ -0,0 +1,88 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/ernestngugi/sil-backend/internal/tracing"
+)
+
+// Tx is a transaction whose statements are traced and logged like those run
+// directly on the AppDB.
+type Tx interface {
+	SQLOperations
+	Commit() error
+	Rollback() error
+}
+
+type appTx struct {
+	*sql.Tx
+	dB *AppDB
+}
+
+func (d *AppDB) BeginTx(ctx context.Context, opts *sql.TxOptions) (Tx, error) {
+
+	tx, err := d.DB.BeginTx(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return &appTx{Tx: tx, dB: d}, nil
+}
+
+// WithTransaction runs fn in a transaction, committing when it returns nil and
+// rolling back otherwise.
+func WithTransaction(ctx context.Context, dB DB, fn func(operations SQLOperations) error) (err error) {
+
+	tx, err := dB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	err = fn(tx)
+	if err != nil {
+		rollbackErr := tx.Rollback()
+		if rollbackErr != nil && !errors.Is(rollbackErr, sql.ErrTxDone) {
+			return errors.Join(err, rollbackErr)
+		}
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (t *appTx) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	ctx, end := tracing.StartSQLSpan(ctx, query)
+	start := time.Now()
+	result, err := t.Tx.ExecContext(ctx, query, args...)
+	t.dB.logQuery(ctx, query, start, err)
+	end(err)
+	return result, err
+}
+
+func (t *appTx) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	ctx, end := tracing.StartSQLSpan(ctx, query)
+	start := time.Now()
+	rows, err := t.Tx.QueryContext(ctx, query, args...)
+	t.dB.logQuery(ctx, query, start, err)
+	end(err)
+	return rows, err
+}
+
+func (t *appTx) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	ctx, end := tracing.StartSQLSpan(ctx, query)
+	start := time.Now()
+	row := t.Tx.QueryRowContext(ctx, query, args...)
+	t.dB.logQuery(ctx, query, start, row.Err())
+	end(row.Err())
+	return row
+}
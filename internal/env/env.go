@@ -0,0 +1,84 @@
+package env
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// String returns the value of the environment variable key, or fallback when
+// it is unset or empty.
+func String(key, fallback string) string {
+	value := strings.TrimSpace(os.Getenv(key))
+	if value == "" {
+		return fallback
+	}
+
+	return value
+}
+
+// Int parses the environment variable key as an integer, returning fallback
+// when it is unset.
+func Int(key string, fallback int) (int, error) {
+	value := strings.TrimSpace(os.Getenv(key))
+	if value == "" {
+		return fallback, nil
+	}
+
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %v: %v", key, err)
+	}
+
+	return parsed, nil
+}
+
+// Duration parses the environment variable key as a time.Duration (e.g. "5s"),
+// returning fallback when it is unset.
+func Duration(key string, fallback time.Duration) (time.Duration, error) {
+	value := strings.TrimSpace(os.Getenv(key))
+	if value == "" {
+		return fallback, nil
+	}
+
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %v: %v", key, err)
+	}
+
+	return parsed, nil
+}
+
+// Bool parses the environment variable key as a boolean, returning fallback
+// when it is unset.
+func Bool(key string, fallback bool) (bool, error) {
+	value := strings.TrimSpace(os.Getenv(key))
+	if value == "" {
+		return fallback, nil
+	}
+
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("invalid %v: %v", key, err)
+	}
+
+	return parsed, nil
+}
+
+// Float parses the environment variable key as a decimal number, returning
+// fallback when it is unset.
+func Float(key string, fallback float64) (float64, error) {
+	value := strings.TrimSpace(os.Getenv(key))
+	if value == "" {
+		return fallback, nil
+	}
+
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %v: %v", key, err)
+	}
+
+	return parsed, nil
+}
@@ -0,0 +1,28 @@
+package forms
+
+type (
+	// AddCartLineForm adds quantity of a product to the cart, on top of any
+	// already there.
+	AddCartLineForm struct {
+		ProductID int64 `json:"product_id" validate:"gt=0"`
+		Quantity  int   `json:"quantity" validate:"gt=0,lte=1000"`
+	}
+
+	UpdateCartLineForm struct {
+		Quantity int `json:"quantity" validate:"gt=0,lte=1000"`
+	}
+
+	// ApplyPromoCodeForm keeps a promo code with the cart to be redeemed at
+	// checkout.
+	ApplyPromoCodeForm struct {
+		PromoCode string `json:"promo_code" validate:"required,max=32,pattern=promo_code"`
+	}
+
+	// CheckoutCartForm places an order for the cart. ExpectedTotal is the
+	// total the customer was shown, before any loyalty points, so the
+	// order is not placed if prices or discounts have changed since.
+	CheckoutCartForm struct {
+		ExpectedTotal float64 `json:"expected_total" validate:"gt=0"`
+		LoyaltyPoints int     `json:"loyalty_points" validate:"omitempty,gt=0"`
+	}
+)
@@ -1,5 +1,6 @@
 package forms
 
 type CustomerCreateForm struct {
-	Name string `json:"name"`
+	Name   string `json:"name" validate:"required,notblank,max=255"`
+	Locale string `json:"locale" validate:"omitempty,oneof=en sw"`
 }
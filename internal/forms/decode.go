@@ -0,0 +1,90 @@
+package forms
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/ernestngugi/sil-backend/internal/apperrors"
+)
+
+// DecodeJSON decodes a single JSON object from r into form, rejecting unknown
+// fields and trailing data, then validates it.
+func DecodeJSON(r io.Reader, form any, locale string) error {
+	return decodeJSON(r, form, locale, true)
+}
+
+// DecodeCallbackJSON decodes a provider callback like DecodeJSON but ignores
+// unknown fields, which providers add without notice.
+func DecodeCallbackJSON(r io.Reader, form any, locale string) error {
+	return decodeJSON(r, form, locale, false)
+}
+
+func decodeJSON(r io.Reader, form any, locale string, strict bool) error {
+
+	messages := catalogFor(locale)
+
+	decoder := json.NewDecoder(r)
+	if strict {
+		decoder.DisallowUnknownFields()
+	}
+
+	err := decoder.Decode(form)
+	if err != nil {
+		return decodeError(err, messages)
+	}
+
+	if decoder.More() {
+		return invalidBody(messages, errors.New("unexpected data after json object"))
+	}
+
+	return Validate(form, locale)
+}
+
+func decodeError(err error, messages *catalog) error {
+
+	var typeError *json.UnmarshalTypeError
+	if errors.As(err, &typeError) {
+		return apperrors.Validation("validation_failed", messages.summary, apperrors.FieldError{
+			Field:   typeError.Field,
+			Code:    "type",
+			Message: messages.render("type", typeError.Field, jsonType(typeError.Type.Kind().String()), ""),
+		}).Wrap(err)
+	}
+
+	// encoding/json reports unknown fields as `json: unknown field "name"`.
+	if field, found := strings.CutPrefix(err.Error(), "json: unknown field "); found {
+		if unquoted, err := strconv.Unquote(field); err == nil {
+			field = unquoted
+		}
+
+		return apperrors.Validation("validation_failed", messages.summary, apperrors.FieldError{
+			Field:   field,
+			Code:    "unknown",
+			Message: messages.render("unknown", field, "", ""),
+		}).Wrap(err)
+	}
+
+	return invalidBody(messages, err)
+}
+
+func invalidBody(messages *catalog, err error) error {
+	return apperrors.Validation("invalid_body", messages.render("body", "", "", "")).Wrap(err)
+}
+
+func jsonType(kind string) string {
+	switch {
+	case strings.HasPrefix(kind, "int"), strings.HasPrefix(kind, "uint"), strings.HasPrefix(kind, "float"):
+		return "number"
+	case kind == "slice" || kind == "array":
+		return "array"
+	case kind == "struct" || kind == "map":
+		return "object"
+	case kind == "bool":
+		return "boolean"
+	default:
+		return kind
+	}
+}
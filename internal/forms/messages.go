@@ -0,0 +1,138 @@
+package forms
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+const DefaultLocale = "en"
+
+type catalog struct {
+	summary string
+	// messages are keyed by validation tag. A "tag.string" or "tag.list"
+	// entry, when present, is used for string or list fields where the
+	// parameter is a length.
+	messages map[string]string
+	fallback string
+}
+
+var catalogs = map[string]*catalog{
+	"en": {
+		summary: "request validation failed",
+		messages: map[string]string{
+			"required":   "{field} is required",
+			"notblank":   "{field} must not be blank",
+			"min":        "{field} must be at least {param}",
+			"min.string": "{field} must be at least {param} characters long",
+			"max":        "{field} must be at most {param}",
+			"max.string": "{field} must be at most {param} characters long",
+			"len.string": "{field} must be exactly {param} characters long",
+			"min.list":   "{field} must contain at least {param} items",
+			"max.list":   "{field} must contain at most {param} items",
+			"gt":         "{field} must be greater than {param}",
+			"gte":        "{field} must be at least {param}",
+			"lt":         "{field} must be less than {param}",
+			"lte":        "{field} must be at most {param}",
+			"oneof":      "{field} must be one of: {param}",
+			"email":      "{field} must be a valid email address",
+			"url":        "{field} must be a valid url",
+			"timezone":   "{field} must be a time zone such as Africa/Nairobi",
+			"pattern":    "{field} has an invalid format",
+			"unknown":    "{field} is not a recognised field",
+			"type":       "{field} must be a {param}",
+			"body":       "request body must be a valid json object",
+		},
+		fallback: "{field} is invalid",
+	},
+	"sw": {
+		summary: "uthibitishaji wa ombi umeshindwa",
+		messages: map[string]string{
+			"required":   "{field} inahitajika",
+			"notblank":   "{field} haipaswi kuwa tupu",
+			"min":        "{field} lazima iwe angalau {param}",
+			"min.string": "{field} lazima iwe na angalau herufi {param}",
+			"max":        "{field} isizidi {param}",
+			"max.string": "{field} isizidi herufi {param}",
+			"len.string": "{field} lazima iwe na herufi {param} kamili",
+			"min.list":   "{field} lazima iwe na angalau vipengee {param}",
+			"max.list":   "{field} isizidi vipengee {param}",
+			"gt":         "{field} lazima iwe zaidi ya {param}",
+			"gte":        "{field} lazima iwe angalau {param}",
+			"lt":         "{field} lazima iwe chini ya {param}",
+			"lte":        "{field} isizidi {param}",
+			"oneof":      "{field} lazima iwe mojawapo ya: {param}",
+			"email":      "{field} lazima iwe barua pepe halali",
+			"url":        "{field} lazima iwe url halali",
+			"timezone":   "{field} lazima iwe eneo la saa kama Africa/Nairobi",
+			"pattern":    "{field} ina muundo usio sahihi",
+			"unknown":    "{field} si sehemu inayotambulika",
+			"type":       "{field} lazima iwe {param}",
+			"body":       "ombi lazima liwe kitu halali cha json",
+		},
+		fallback: "{field} si sahihi",
+	},
+}
+
+// Locale picks the first supported language from an Accept-Language style
+// value such as "sw-KE,sw;q=0.9,en;q=0.8", falling back to DefaultLocale.
+func Locale(acceptLanguage string) string {
+
+	for _, part := range strings.Split(acceptLanguage, ",") {
+
+		tag, _, _ := strings.Cut(strings.TrimSpace(part), ";")
+		language, _, _ := strings.Cut(strings.ToLower(tag), "-")
+
+		if _, ok := catalogs[language]; ok {
+			return language
+		}
+	}
+
+	return DefaultLocale
+}
+
+func catalogFor(locale string) *catalog {
+
+	if c, ok := catalogs[Locale(locale)]; ok {
+		return c
+	}
+
+	return catalogs[DefaultLocale]
+}
+
+func (c *catalog) fieldMessage(fieldError validator.FieldError) string {
+
+	variant := ""
+
+	switch fieldError.Kind() {
+	case reflect.String:
+		variant = "string"
+	case reflect.Slice, reflect.Array, reflect.Map:
+		variant = "list"
+	}
+
+	return c.render(fieldError.Tag(), fieldError.Field(), fieldError.Param(), variant)
+}
+
+func (c *catalog) render(tag, field, param, variant string) string {
+
+	message, ok := "", false
+
+	if variant != "" {
+		message, ok = c.messages[tag+"."+variant]
+	}
+
+	if !ok {
+		message, ok = c.messages[tag]
+	}
+
+	if !ok {
+		message = c.fallback
+	}
+
+	return strings.NewReplacer(
+		"{field}", field,
+		"{param}", strings.ReplaceAll(param, " ", ", "),
+	).Replace(message)
+}
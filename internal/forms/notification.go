@@ -0,0 +1,29 @@
+package forms
+
+type (
+	// UpdateNotificationPreferencesForm changes only what is set.
+	UpdateNotificationPreferencesForm struct {
+		// Channels maps events to the channel the customer wants to hear
+		// about them on.
+		Channels   map[string]string `json:"channels" validate:"omitempty,dive,keys,oneof=order.created order.dispatched order.cancelled order.refunded order.paid cart.abandoned order.rider_assigned order.delivered,endkeys,oneof=sms email none"`
+		QuietHours *QuietHoursForm   `json:"quiet_hours"`
+		// PhoneNumber is where SMS are sent. Until it is set they go to
+		// the number the customer last paid from.
+		PhoneNumber *string `json:"phone_number" validate:"omitempty,pattern=phone"`
+	}
+
+	// QuietHoursForm replaces the customer's quiet hours. Leaving out start
+	// and end turns them off.
+	QuietHoursForm struct {
+		Start    string `json:"start" validate:"required_with=End,omitempty,pattern=clock"`
+		End      string `json:"end" validate:"required_with=Start,omitempty,pattern=clock,nefield=Start"`
+		TimeZone string `json:"time_zone" validate:"omitempty,timezone"`
+	}
+
+	// SMSOptOutForm is the opt-out notification Africa's Talking posts when
+	// a number replies STOP.
+	SMSOptOutForm struct {
+		SenderID    string `json:"senderId" validate:"max=20"`
+		PhoneNumber string `json:"phoneNumber" validate:"required,max=20"`
+	}
+)
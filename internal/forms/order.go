@@ -1,6 +1,20 @@
 package forms
 
 type CreateOrderForm struct {
-	Amount float64 `json:"amount"`
-	Item string `json:"item"`
+	Amount float64 `json:"amount" validate:"gt=0,lte=99999999.99"`
+	Item   string  `json:"item" validate:"required,notblank,max=50,pattern=printable"`
+	// TaxCategory is the product category VAT is charged under. The
+	// configured default applies when it is left out.
+	TaxCategory string `json:"tax_category" validate:"omitempty,max=50"`
+	// PricesIncludeTax says whether Amount already includes VAT. It does
+	// unless this is false, in which case VAT is added on top.
+	PricesIncludeTax *bool `json:"prices_include_tax"`
+	// PromoCode is matched without regard to case.
+	PromoCode string `json:"promo_code" validate:"omitempty,max=32,pattern=promo_code"`
+	// LoyaltyPoints are spent on the order after any promo code discount.
+	LoyaltyPoints int `json:"loyalty_points" validate:"omitempty,gt=0"`
+}
+
+type UpdateOrderStatusForm struct {
+	Status string `json:"status" validate:"required,oneof=pending confirmed dispatched delivered cancelled"`
 }
@@ -0,0 +1,30 @@
+package forms
+
+type (
+	CreatePaymentForm struct {
+		PhoneNumber string `json:"phone_number" validate:"required,pattern=phone"`
+	}
+
+	// MPesaCallbackForm is the body M-Pesa posts to the callback URL once
+	// the customer has answered, or failed to answer, an STK Push.
+	MPesaCallbackForm struct {
+		Body struct {
+			STKCallback struct {
+				MerchantRequestID string `json:"MerchantRequestID"`
+				CheckoutRequestID string `json:"CheckoutRequestID" validate:"required"`
+				ResultCode        *int   `json:"ResultCode" validate:"required"`
+				ResultDesc        string `json:"ResultDesc"`
+				CallbackMetadata  struct {
+					Item []MPesaCallbackItem `json:"Item"`
+				} `json:"CallbackMetadata"`
+			} `json:"stkCallback"`
+		} `json:"Body"`
+	}
+
+	// MPesaCallbackItem is one named value of a successful payment, e.g. its
+	// MpesaReceiptNumber. Values are numbers or strings.
+	MPesaCallbackItem struct {
+		Name  string `json:"Name"`
+		Value any    `json:"Value"`
+	}
+)
@@ -0,0 +1,24 @@
+package forms
+
+type (
+	// CreateProductForm adds a product to the catalogue. Price includes any
+	// VAT, and the configured default tax category applies when it is left
+	// out.
+	CreateProductForm struct {
+		SKU         string  `json:"sku" validate:"required,max=32,pattern=sku"`
+		Name        string  `json:"name" validate:"required,notblank,max=50,pattern=printable"`
+		Price       float64 `json:"price" validate:"gt=0,lte=99999999.99"`
+		TaxCategory string  `json:"tax_category" validate:"omitempty,max=50"`
+		Stock       int     `json:"stock" validate:"gte=0"`
+	}
+
+	// UpdateProductForm changes only the fields that are set. The SKU of a
+	// product is fixed once it is created.
+	UpdateProductForm struct {
+		Name        *string  `json:"name" validate:"omitempty,notblank,max=50,pattern=printable"`
+		Price       *float64 `json:"price" validate:"omitempty,gt=0,lte=99999999.99"`
+		TaxCategory *string  `json:"tax_category" validate:"omitempty,max=50"`
+		Stock       *int     `json:"stock" validate:"omitempty,gte=0"`
+		Active      *bool    `json:"active"`
+	}
+)
@@ -0,0 +1,37 @@
+package forms
+
+import "time"
+
+type (
+	// CreatePromotionForm sets up a promo code. Percentage discounts are
+	// given in percent, fixed ones in shillings. Leaving both Items and
+	// TaxCategories out makes the code apply to every order.
+	CreatePromotionForm struct {
+		Code             string     `json:"code" validate:"required,min=3,max=32,pattern=promo_code"`
+		Description      string     `json:"description" validate:"omitempty,max=255,pattern=printable"`
+		DiscountType     string     `json:"discount_type" validate:"required,oneof=percentage fixed"`
+		DiscountValue    float64    `json:"discount_value" validate:"gt=0,lte=99999999.99"`
+		MinOrderAmount   float64    `json:"min_order_amount" validate:"gte=0,lte=99999999.99"`
+		Items            []string   `json:"items" validate:"omitempty,max=100,dive,required,notblank,max=50"`
+		TaxCategories    []string   `json:"tax_categories" validate:"omitempty,max=20,dive,required,max=50"`
+		StartsAt         *time.Time `json:"starts_at"`
+		EndsAt           *time.Time `json:"ends_at"`
+		UsageLimit       *int       `json:"usage_limit" validate:"omitempty,gt=0"`
+		PerCustomerLimit *int       `json:"per_customer_limit" validate:"omitempty,gt=0"`
+	}
+
+	// UpdatePromotionForm changes only the fields that are set. The code and
+	// discount type of a promotion are fixed once it is created.
+	UpdatePromotionForm struct {
+		Description      *string    `json:"description" validate:"omitempty,max=255,pattern=printable"`
+		DiscountValue    *float64   `json:"discount_value" validate:"omitempty,gt=0,lte=99999999.99"`
+		MinOrderAmount   *float64   `json:"min_order_amount" validate:"omitempty,gte=0,lte=99999999.99"`
+		Items            []string   `json:"items" validate:"omitempty,max=100,dive,required,notblank,max=50"`
+		TaxCategories    []string   `json:"tax_categories" validate:"omitempty,max=20,dive,required,max=50"`
+		StartsAt         *time.Time `json:"starts_at"`
+		EndsAt           *time.Time `json:"ends_at"`
+		UsageLimit       *int       `json:"usage_limit" validate:"omitempty,gt=0"`
+		PerCustomerLimit *int       `json:"per_customer_limit" validate:"omitempty,gt=0"`
+		Active           *bool      `json:"active"`
+	}
+)
@@ -0,0 +1,42 @@
+package forms
+
+import "time"
+
+type (
+	// AssignShipmentForm hands a confirmed or dispatched order to a rider.
+	AssignShipmentForm struct {
+		Carrier           string     `json:"carrier" validate:"required,notblank,max=50,pattern=printable"`
+		TrackingNumber    string     `json:"tracking_number" validate:"omitempty,max=64,pattern=printable"`
+		RiderName         string     `json:"rider_name" validate:"required,notblank,max=50,pattern=printable"`
+		RiderPhone        string     `json:"rider_phone" validate:"required,pattern=phone"`
+		EstimatedDelivery *time.Time `json:"estimated_delivery"`
+	}
+
+	// UpdateShipmentForm changes only the fields that are set. The customer
+	// is texted a new delivery code when the rider changes or ResendCode is
+	// set, e.g. after the rider entered too many wrong codes.
+	UpdateShipmentForm struct {
+		Carrier           *string    `json:"carrier" validate:"omitempty,notblank,max=50,pattern=printable"`
+		TrackingNumber    *string    `json:"tracking_number" validate:"omitempty,max=64,pattern=printable"`
+		RiderName         *string    `json:"rider_name" validate:"omitempty,notblank,max=50,pattern=printable"`
+		RiderPhone        *string    `json:"rider_phone" validate:"omitempty,pattern=phone"`
+		EstimatedDelivery *time.Time `json:"estimated_delivery"`
+		ResendCode        bool       `json:"resend_code"`
+	}
+
+	// AddShipmentEventForm records a step of the shipment's journey.
+	// Deliveries are recorded with ConfirmDeliveryForm instead.
+	AddShipmentEventForm struct {
+		Status      string `json:"status" validate:"required,oneof=in_transit attempted"`
+		Description string `json:"description" validate:"omitempty,max=255,pattern=printable"`
+		Location    string `json:"location" validate:"omitempty,max=100,pattern=printable"`
+	}
+
+	// ConfirmDeliveryForm is the proof of delivery the rider collects: who
+	// received the order and the code the customer was texted.
+	ConfirmDeliveryForm struct {
+		RecipientName string `json:"recipient_name" validate:"required,notblank,max=50,pattern=printable"`
+		DeliveryCode  string `json:"delivery_code" validate:"required,pattern=delivery_code"`
+		Location      string `json:"location" validate:"omitempty,max=100,pattern=printable"`
+	}
+)
@@ -0,0 +1,15 @@
+package forms
+
+type (
+	SaveSMSTemplateForm struct {
+		Body string `json:"body" validate:"required,notblank,max=1600"`
+	}
+
+	// PreviewSMSTemplateForm renders Body, or the current template for the
+	// event and locale when Body is left out, with sample order details.
+	PreviewSMSTemplateForm struct {
+		Event  string `json:"event" validate:"required,oneof=order.created order.dispatched order.cancelled order.refunded order.paid cart.abandoned order.rider_assigned order.delivered"`
+		Locale string `json:"locale" validate:"required,oneof=en sw"`
+		Body   string `json:"body" validate:"omitempty,max=1600"`
+	}
+)
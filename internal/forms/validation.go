@@ -0,0 +1,124 @@
+package forms
+
+import (
+	"errors"
+	"reflect"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/ernestngugi/sil-backend/internal/apperrors"
+	"github.com/go-playground/validator/v10"
+)
+
+// Forms declare their rules in a `validate` struct tag using the
+// go-playground/validator syntax, e.g. `validate:"required,max=50"`. On top of
+// the built-in rules two are registered here:
+//
+//	notblank      the string contains something other than whitespace
+//	pattern=name  the string matches the named regular expression in patterns
+var patterns = map[string]*regexp.Regexp{
+	// phone is a Kenyan MSISDN in international format without the plus.
+	"phone": regexp.MustCompile(`^254[17]\d{8}$`),
+	// printable rejects control characters such as newlines in short labels.
+	"printable": regexp.MustCompile(`^[^\p{Cc}]*$`),
+	// promo_code is letters, digits, dashes and underscores, e.g. KARIBU-10.
+	"promo_code": regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]*$`),
+	// sku is letters, digits, dots, dashes and underscores, e.g. MF-2KG.
+	"sku": regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`),
+	// clock is a 24 hour time of day, e.g. 07:30.
+	"clock": regexp.MustCompile(`^([01]\d|2[0-3]):[0-5]\d$`),
+	// delivery_code is the six digit code texted to a customer whose order
+	// is out for delivery.
+	"delivery_code": regexp.MustCompile(`^\d{6}$`),
+}
+
+var validate = newValidator()
+
+func newValidator() *validator.Validate {
+
+	v := validator.New()
+
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		if name == "" {
+			return field.Name
+		}
+		return name
+	})
+
+	mustRegister(v, "notblank", func(fl validator.FieldLevel) bool {
+		return strings.TrimFunc(fl.Field().String(), unicode.IsSpace) != ""
+	})
+
+	mustRegister(v, "pattern", func(fl validator.FieldLevel) bool {
+		pattern, ok := patterns[fl.Param()]
+		if !ok {
+			panic("forms: unknown validation pattern " + fl.Param())
+		}
+		return pattern.MatchString(fl.Field().String())
+	})
+
+	return v
+}
+
+func mustRegister(v *validator.Validate, tag string, fn validator.Func) {
+	err := v.RegisterValidation(tag, fn)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// Validate checks form against the rules declared on its struct tags and
+// returns a validation error listing every failing field with a message in
+// the requested locale. It does not depend on HTTP so the CLI and background
+// jobs can validate input the same way handlers do.
+func Validate(form any, locale string) error {
+
+	err := validate.Struct(form)
+	if err == nil {
+		return nil
+	}
+
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		return apperrors.Internal(err)
+	}
+
+	messages := catalogFor(locale)
+
+	fieldErrors := make([]apperrors.FieldError, 0, len(validationErrors))
+
+	for _, fieldError := range validationErrors {
+		fieldErrors = append(fieldErrors, apperrors.FieldError{
+			Field:   fieldPath(fieldError),
+			Code:    fieldError.Tag(),
+			Message: messages.fieldMessage(fieldError),
+		})
+	}
+
+	return apperrors.Validation("validation_failed", messages.summary, fieldErrors...)
+}
+
+// fieldPath returns the JSON path of the field without the form's type name,
+// e.g. "items[0].quantity".
+func fieldPath(fieldError validator.FieldError) string {
+	_, path, found := strings.Cut(fieldError.Namespace(), ".")
+	if !found {
+		return fieldError.Field()
+	}
+	return path
+}
+
+// Pattern returns the regular expression behind a pattern=name rule so that
+// API documentation can publish the same constraint.
+func Pattern(name string) (string, bool) {
+	pattern, ok := patterns[name]
+	if !ok {
+		return "", false
+	}
+	return pattern.String(), true
+}
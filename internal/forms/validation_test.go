@@ -0,0 +1,137 @@
+package forms
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ernestngugi/sil-backend/internal/apperrors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidation(t *testing.T) {
+
+	fieldErrors := func(err error) map[string]apperrors.FieldError {
+
+		appErr := apperrors.From(err)
+		assert.Equal(t, apperrors.KindValidation, appErr.Kind)
+
+		byField := make(map[string]apperrors.FieldError)
+		for _, fieldError := range appErr.Fields {
+			byField[fieldError.Field] = fieldError
+		}
+
+		return byField
+	}
+
+	t.Run("accepts a valid order", func(t *testing.T) {
+
+		err := Validate(&CreateOrderForm{Amount: 100, Item: "item"}, "en")
+		assert.NoError(t, err)
+	})
+
+	t.Run("rejects invalid orders", func(t *testing.T) {
+
+		err := Validate(&CreateOrderForm{Amount: -5, Item: strings.Repeat("x", 51)}, "en")
+		assert.Error(t, err)
+
+		errs := fieldErrors(err)
+
+		assert.Equal(t, "gt", errs["amount"].Code)
+		assert.Equal(t, "amount must be greater than 0", errs["amount"].Message)
+		assert.Equal(t, "max", errs["item"].Code)
+		assert.Equal(t, "item must be at most 50 characters long", errs["item"].Message)
+	})
+
+	t.Run("rejects blank and control characters", func(t *testing.T) {
+
+		errs := fieldErrors(Validate(&CreateOrderForm{Amount: 1, Item: "   "}, "en"))
+		assert.Equal(t, "notblank", errs["item"].Code)
+
+		errs = fieldErrors(Validate(&CreateOrderForm{Amount: 1, Item: "line\nbreak"}, "en"))
+		assert.Equal(t, "pattern", errs["item"].Code)
+
+		errs = fieldErrors(Validate(&CreateOrderForm{Amount: 1, Item: "item", PromoCode: "KARIBU 10"}, "en"))
+		assert.Equal(t, "pattern", errs["promo_code"].Code)
+	})
+
+	t.Run("translates messages", func(t *testing.T) {
+
+		errs := fieldErrors(Validate(&CreateOrderForm{Amount: 1}, "sw-KE,sw;q=0.9"))
+		assert.Equal(t, "item inahitajika", errs["item"].Message)
+
+		assert.Equal(t, "sw", Locale("sw-KE,en;q=0.5"))
+		assert.Equal(t, "en", Locale("fr-FR"))
+		assert.Equal(t, "en", Locale(""))
+	})
+
+	t.Run("rejects unknown json fields", func(t *testing.T) {
+
+		var form CreateOrderForm
+
+		err := DecodeJSON(strings.NewReader(`{"amount": 100, "item": "item", "discount": 50}`), &form, "en")
+
+		errs := fieldErrors(err)
+		assert.Equal(t, "unknown", errs["discount"].Code)
+	})
+
+	t.Run("reports type errors and malformed bodies", func(t *testing.T) {
+
+		var form CreateOrderForm
+
+		errs := fieldErrors(DecodeJSON(strings.NewReader(`{"amount": "100", "item": "item"}`), &form, "en"))
+		assert.Equal(t, "amount must be a number", errs["amount"].Message)
+
+		err := DecodeJSON(strings.NewReader(`{"amount": 100`), &form, "en")
+		assert.Equal(t, "invalid_body", apperrors.From(err).Code)
+
+		err = DecodeJSON(strings.NewReader(`{"amount": 100, "item": "item"} {}`), &form, "en")
+		assert.Equal(t, "invalid_body", apperrors.From(err).Code)
+	})
+
+	t.Run("validates notification preferences", func(t *testing.T) {
+
+		var form UpdateNotificationPreferencesForm
+
+		err := DecodeJSON(strings.NewReader(`{"channels": {"order.dispatched": "none"}, "quiet_hours": {"start": "22:00", "end": "07:00"}}`), &form, "en")
+		assert.NoError(t, err)
+
+		err = DecodeJSON(strings.NewReader(`{"quiet_hours": {}}`), &form, "en")
+		assert.NoError(t, err)
+
+		errs := fieldErrors(Validate(&UpdateNotificationPreferencesForm{
+			Channels:   map[string]string{"order.dispatched": "pigeon"},
+			QuietHours: &QuietHoursForm{Start: "25:00", End: "07:00", TimeZone: "Mars/Olympus"},
+		}, "en"))
+		assert.Equal(t, "oneof", errs["channels[order.dispatched]"].Code)
+		assert.Equal(t, "pattern", errs["quiet_hours.start"].Code)
+		assert.Equal(t, "timezone", errs["quiet_hours.time_zone"].Code)
+
+		errs = fieldErrors(Validate(&UpdateNotificationPreferencesForm{
+			Channels:   map[string]string{"order.lost": "sms"},
+			QuietHours: &QuietHoursForm{Start: "22:00"},
+		}, "en"))
+		assert.Equal(t, "oneof", errs["channels[order.lost]"].Code)
+		assert.Equal(t, "required_with", errs["quiet_hours.end"].Code)
+	})
+
+	t.Run("decodes m-pesa callbacks with fields it does not know", func(t *testing.T) {
+
+		var form MPesaCallbackForm
+
+		err := DecodeCallbackJSON(strings.NewReader(`{"Body": {"stkCallback": {"CheckoutRequestID": "ws_CO_1", "ResultCode": 1032, "ResultDesc": "Request cancelled by user", "Extra": true}}}`), &form, "en")
+		assert.NoError(t, err)
+		assert.Equal(t, 1032, *form.Body.STKCallback.ResultCode)
+
+		err = DecodeCallbackJSON(strings.NewReader(`{"Body": {"stkCallback": {"CheckoutRequestID": "ws_CO_1"}}}`), &MPesaCallbackForm{}, "en")
+		assert.Equal(t, "required", fieldErrors(err)["Body.stkCallback.ResultCode"].Code)
+	})
+
+	t.Run("decodes and validates a valid body", func(t *testing.T) {
+
+		var form CreateOrderForm
+
+		err := DecodeJSON(strings.NewReader(`{"amount": 100, "item": "item"}`), &form, "en")
+		assert.NoError(t, err)
+		assert.Equal(t, 100.0, form.Amount)
+	})
+}
@@ -0,0 +1,18 @@
+package forms
+
+type (
+	CreateWebhookSubscriptionForm struct {
+		URL string `json:"url" validate:"required,url,max=2048"`
+		// Secret signs deliveries. One is generated when it is left out.
+		Secret     string   `json:"secret" validate:"omitempty,min=16,max=255"`
+		EventTypes []string `json:"event_types" validate:"required,min=1,dive,oneof=order.created order.status_changed"`
+	}
+
+	// UpdateWebhookSubscriptionForm changes only the fields that are set.
+	// Re-activating a disabled subscription clears its failure count.
+	UpdateWebhookSubscriptionForm struct {
+		URL        *string  `json:"url" validate:"omitempty,url,max=2048"`
+		EventTypes []string `json:"event_types" validate:"omitempty,min=1,dive,oneof=order.created order.status_changed"`
+		Active     *bool    `json:"active"`
+	}
+)
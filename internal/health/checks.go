@@ -0,0 +1,36 @@
+package health
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ernestngugi/sil-backend/internal/db"
+)
+
+func DatabaseCheck(dB db.DB) Check {
+	return Check{
+		Name:  "database",
+		Probe: dB.PingContext,
+	}
+}
+
+// MigrationCheck fails until the database is at the latest migration
+// embedded in the binary, e.g. while another replica is still migrating.
+func MigrationCheck(migrator *db.Migrator) Check {
+	return Check{
+		Name: "migrations",
+		Probe: func(ctx context.Context) error {
+
+			current, latest, err := migrator.Version(ctx)
+			if err != nil {
+				return err
+			}
+
+			if current != latest {
+				return fmt.Errorf("database at version %v, expected %v", current, latest)
+			}
+
+			return nil
+		},
+	}
+}
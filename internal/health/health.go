@@ -0,0 +1,143 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	StatusOK      = "ok"
+	StatusFailing = "failing"
+
+	defaultCheckTimeout = 3 * time.Second
+)
+
+var ErrShuttingDown = errors.New("server is shutting down")
+
+type (
+	// Check is a single dependency probed by the readiness endpoint.
+	Check struct {
+		Name  string
+		Probe func(ctx context.Context) error
+	}
+
+	CheckResult struct {
+		Status    string  `json:"status"`
+		LatencyMS float64 `json:"latency_ms"`
+		Error     string  `json:"error,omitempty"`
+	}
+
+	Report struct {
+		Status string                 `json:"status"`
+		Checks map[string]CheckResult `json:"checks"`
+	}
+
+	// Readiness reports whether the instance should receive traffic.
+	Readiness struct {
+		checks       []Check
+		timeout      time.Duration
+		shuttingDown atomic.Bool
+	}
+)
+
+func NewReadiness(checks ...Check) *Readiness {
+	return &Readiness{
+		checks:  checks,
+		timeout: defaultCheckTimeout,
+	}
+}
+
+// SetShuttingDown makes every subsequent readiness report fail so load
+// balancers stop routing new requests while in-flight ones drain.
+func (r *Readiness) SetShuttingDown() {
+	r.shuttingDown.Store(true)
+}
+
+// Check runs all dependency checks concurrently.
+func (r *Readiness) Check(ctx context.Context) *Report {
+
+	report := &Report{
+		Status: StatusOK,
+		Checks: make(map[string]CheckResult, len(r.checks)),
+	}
+
+	if r.shuttingDown.Load() {
+		report.Status = StatusFailing
+		report.Checks["shutdown"] = CheckResult{Status: StatusFailing, Error: ErrShuttingDown.Error()}
+		return report
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for _, check := range r.checks {
+		wg.Add(1)
+
+		go func(check Check) {
+			defer wg.Done()
+
+			result := r.run(ctx, check)
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			report.Checks[check.Name] = result
+			if result.Status != StatusOK {
+				report.Status = StatusFailing
+			}
+		}(check)
+	}
+
+	wg.Wait()
+
+	return report
+}
+
+func (r *Readiness) run(ctx context.Context, check Check) CheckResult {
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := check.Probe(ctx)
+	latency := float64(time.Since(start).Microseconds()) / 1000
+
+	if err != nil {
+		return CheckResult{Status: StatusFailing, LatencyMS: latency, Error: err.Error()}
+	}
+
+	return CheckResult{Status: StatusOK, LatencyMS: latency}
+}
+
+// HTTPCheck probes that url answers without a server error. Any response
+// below 500 counts as reachable since most APIs reject unauthenticated calls.
+func HTTPCheck(name, url string, client *http.Client) Check {
+	return Check{
+		Name: name,
+		Probe: func(ctx context.Context) error {
+
+			request, err := http.NewRequestWithContext(ctx, http.MethodHead, url, nil)
+			if err != nil {
+				return err
+			}
+
+			response, err := client.Do(request)
+			if err != nil {
+				return err
+			}
+
+			defer response.Body.Close()
+
+			if response.StatusCode >= http.StatusInternalServerError {
+				return fmt.Errorf("unexpected status %v", response.StatusCode)
+			}
+
+			return nil
+		},
+	}
+}
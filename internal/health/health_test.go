@@ -0,0 +1,66 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadiness(t *testing.T) {
+
+	ctx := context.Background()
+
+	passing := Check{Name: "passing", Probe: func(ctx context.Context) error { return nil }}
+	failing := Check{Name: "failing", Probe: func(ctx context.Context) error { return errors.New("down") }}
+
+	t.Run("is ready when every check passes", func(t *testing.T) {
+
+		report := NewReadiness(passing).Check(ctx)
+
+		assert.Equal(t, StatusOK, report.Status)
+		assert.Equal(t, StatusOK, report.Checks["passing"].Status)
+	})
+
+	t.Run("is not ready when a check fails", func(t *testing.T) {
+
+		report := NewReadiness(passing, failing).Check(ctx)
+
+		assert.Equal(t, StatusFailing, report.Status)
+		assert.Equal(t, StatusOK, report.Checks["passing"].Status)
+		assert.Equal(t, StatusFailing, report.Checks["failing"].Status)
+		assert.Equal(t, "down", report.Checks["failing"].Error)
+	})
+
+	t.Run("is not ready while shutting down", func(t *testing.T) {
+
+		readiness := NewReadiness(passing)
+		readiness.SetShuttingDown()
+
+		report := readiness.Check(ctx)
+
+		assert.Equal(t, StatusFailing, report.Status)
+		assert.Equal(t, ErrShuttingDown.Error(), report.Checks["shutdown"].Error)
+	})
+
+	t.Run("probes http dependencies", func(t *testing.T) {
+
+		status := http.StatusUnauthorized
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(status)
+		}))
+		defer server.Close()
+
+		check := HTTPCheck("upstream", server.URL, server.Client())
+
+		assert.NoError(t, check.Probe(ctx))
+
+		status = http.StatusBadGateway
+
+		assert.Error(t, check.Probe(ctx))
+	})
+}
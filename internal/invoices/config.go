@@ -0,0 +1,41 @@
+package invoices
+
+import (
+	"strings"
+
+	"github.com/ernestngugi/sil-backend/internal/env"
+)
+
+type Config struct {
+	CompanyName string
+	// CompanyAddress is printed one element per line.
+	CompanyAddress []string
+	CompanyTaxPIN  string
+	CompanyEmail   string
+	CompanyPhone   string
+	InvoicePrefix  string
+	ReceiptPrefix  string
+}
+
+// ConfigFromEnv reads the COMPANY_* details printed on invoices and the
+// INVOICE_NUMBER_PREFIX and RECEIPT_NUMBER_PREFIX series prefixes.
+func ConfigFromEnv() (*Config, error) {
+
+	config := &Config{
+		CompanyName:   env.String("COMPANY_NAME", "SIL Shop"),
+		CompanyTaxPIN: env.String("COMPANY_KRA_PIN", ""),
+		CompanyEmail:  env.String("COMPANY_EMAIL", ""),
+		CompanyPhone:  env.String("COMPANY_PHONE", ""),
+		InvoicePrefix: env.String("INVOICE_NUMBER_PREFIX", "INV"),
+		ReceiptPrefix: env.String("RECEIPT_NUMBER_PREFIX", "RCT"),
+	}
+
+	// Addresses are written on one line with "|" between address lines.
+	for _, line := range strings.Split(env.String("COMPANY_ADDRESS", ""), "|") {
+		if line = strings.TrimSpace(line); line != "" {
+			config.CompanyAddress = append(config.CompanyAddress, line)
+		}
+	}
+
+	return config, nil
+}
@@ -0,0 +1,234 @@
+package invoices
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/ernestngugi/sil-backend/internal/apperrors"
+	"github.com/ernestngugi/sil-backend/internal/db"
+	"github.com/ernestngugi/sil-backend/internal/model"
+	"github.com/ernestngugi/sil-backend/internal/repos"
+)
+
+var (
+	errOrderNotPaid   = apperrors.Conflict("order_not_paid", "a receipt is issued once the order is paid")
+	errOrderCancelled = apperrors.Conflict("order_cancelled", "cancelled orders are not invoiced")
+)
+
+type (
+	// Invoicer issues the tax invoice and receipt for an order. Each is
+	// numbered when it is first asked for and never changes afterwards.
+	Invoicer interface {
+		Invoice(ctx context.Context, dB db.DB, order *model.Order) (*model.Invoice, error)
+		Receipt(ctx context.Context, dB db.DB, order *model.Order) (*model.Invoice, error)
+		PDF(invoice *model.Invoice) []byte
+	}
+
+	invoicer struct {
+		customerRepository repos.CustomerRepository
+		paymentRepository  repos.PaymentRepository
+		invoiceRepository  repos.InvoiceRepository
+		config             *Config
+	}
+)
+
+func NewInvoicer(
+	customerRepository repos.CustomerRepository,
+	paymentRepository repos.PaymentRepository,
+	invoiceRepository repos.InvoiceRepository,
+	config *Config,
+) Invoicer {
+	return &invoicer{
+		customerRepository: customerRepository,
+		paymentRepository:  paymentRepository,
+		invoiceRepository:  invoiceRepository,
+		config:             config,
+	}
+}
+
+func (i *invoicer) Invoice(
+	ctx context.Context,
+	dB db.DB,
+	order *model.Order,
+) (*model.Invoice, error) {
+
+	invoice, err := i.invoiceRepository.InvoiceByOrderID(ctx, dB, order.ID, model.InvoiceKindInvoice)
+	if !apperrors.Is(err, apperrors.KindNotFound) {
+		return invoice, err
+	}
+
+	if order.Status == model.OrderStatusCancelled {
+		return &model.Invoice{}, errOrderCancelled
+	}
+
+	return i.issue(ctx, dB, order, model.InvoiceKindInvoice, nil)
+}
+
+// Receipt issues the receipt for a paid order against the payment that
+// settled it.
+func (i *invoicer) Receipt(
+	ctx context.Context,
+	dB db.DB,
+	order *model.Order,
+) (*model.Invoice, error) {
+
+	receipt, err := i.invoiceRepository.InvoiceByOrderID(ctx, dB, order.ID, model.InvoiceKindReceipt)
+	if !apperrors.Is(err, apperrors.KindNotFound) {
+		return receipt, err
+	}
+
+	if order.PaymentStatus != model.PaymentStatusPaid {
+		return &model.Invoice{}, errOrderNotPaid
+	}
+
+	payments, err := i.paymentRepository.Payments(ctx, dB, order.ID)
+	if err != nil {
+		return &model.Invoice{}, err
+	}
+
+	// Payments are newest first. An order paid twice is receipted for the
+	// earliest payment, which is the one that settled it.
+	var settled *model.Payment
+
+	for _, payment := range payments {
+		if payment.Status == model.PaymentStatusPaid {
+			settled = payment
+		}
+	}
+
+	return i.issue(ctx, dB, order, model.InvoiceKindReceipt, settled)
+}
+
+func (i *invoicer) PDF(invoice *model.Invoice) []byte {
+	return Render(invoice, i.config)
+}
+
+// issue numbers and saves a new invoice of kind for order. Numbers are only
+// taken once nothing else can fail, which keeps the series free of gaps.
+func (i *invoicer) issue(
+	ctx context.Context,
+	dB db.DB,
+	order *model.Order,
+	kind model.InvoiceKind,
+	payment *model.Payment,
+) (*model.Invoice, error) {
+
+	customer, err := i.customerRepository.CustomerByID(ctx, dB, order.CustomerID)
+	if err != nil {
+		return &model.Invoice{}, err
+	}
+
+	invoice := &model.Invoice{
+		Kind:         kind,
+		OrderID:      order.ID,
+		CustomerName: customer.Name,
+		Lines:        invoiceLines(order),
+	}
+
+	for _, line := range invoice.Lines {
+		invoice.NetAmount += line.NetAmount
+		invoice.TaxAmount += line.TaxAmount
+		invoice.GrossAmount += line.GrossAmount
+	}
+
+	invoice.NetAmount = roundCents(invoice.NetAmount)
+	invoice.TaxAmount = roundCents(invoice.TaxAmount)
+	invoice.GrossAmount = roundCents(invoice.GrossAmount)
+
+	if payment != nil {
+		invoice.PaymentID = &payment.ID
+		invoice.PaymentReference = payment.ReceiptNumber
+		invoice.PaymentPhone = payment.PhoneNumber
+		invoice.DatePaid = payment.DateCompleted
+	}
+
+	prefix := i.config.InvoicePrefix
+	if kind == model.InvoiceKindReceipt {
+		prefix = i.config.ReceiptPrefix
+	}
+
+	err = db.WithTransaction(ctx, dB, func(operations db.SQLOperations) error {
+
+		// Holding the series while checking means a concurrent request for
+		// the same order waits, then finds the invoice issued here.
+		err := i.invoiceRepository.LockSequence(ctx, operations, kind)
+		if err != nil {
+			return err
+		}
+
+		existing, err := i.invoiceRepository.InvoiceByOrderID(ctx, operations, order.ID, kind)
+		if err == nil {
+			invoice = existing
+			return nil
+		}
+
+		if !apperrors.Is(err, apperrors.KindNotFound) {
+			return err
+		}
+
+		sequenceNumber, err := i.invoiceRepository.NextNumber(ctx, operations, kind)
+		if err != nil {
+			return err
+		}
+
+		invoice.Number = fmt.Sprintf("%s-%06d", prefix, sequenceNumber)
+		invoice.DateIssued = time.Now()
+
+		return i.invoiceRepository.Save(ctx, operations, invoice, sequenceNumber)
+	})
+	if err != nil {
+		return &model.Invoice{}, err
+	}
+
+	return invoice, nil
+}
+
+// invoiceLines are the lines of order, or a single line for an order placed
+// without any.
+func invoiceLines(order *model.Order) []*model.InvoiceLine {
+
+	if len(order.Lines) == 0 {
+		return []*model.InvoiceLine{orderLine(order)}
+	}
+
+	lines := make([]*model.InvoiceLine, 0, len(order.Lines))
+
+	for _, line := range order.Lines {
+		lines = append(lines, &model.InvoiceLine{
+			Description: line.Item,
+			Quantity:    line.Quantity,
+			// The unit price is after the line's share of any discount,
+			// so the line adds up to what the customer paid for it.
+			UnitPrice:    roundCents(line.Amount / float64(line.Quantity)),
+			TaxCategory:  line.Tax.Category,
+			TaxTreatment: line.Tax.Treatment,
+			TaxRate:      line.Tax.Rate,
+			NetAmount:    line.Tax.NetAmount,
+			TaxAmount:    line.Tax.TaxAmount,
+			GrossAmount:  line.Amount,
+		})
+	}
+
+	return lines
+}
+
+// orderLine is the invoice line for order, taxed as it was when placed.
+func orderLine(order *model.Order) *model.InvoiceLine {
+	return &model.InvoiceLine{
+		Description:  order.Item,
+		Quantity:     1,
+		UnitPrice:    order.Amount,
+		TaxCategory:  order.Tax.Category,
+		TaxTreatment: order.Tax.Treatment,
+		TaxRate:      order.Tax.Rate,
+		NetAmount:    order.Tax.NetAmount,
+		TaxAmount:    order.Tax.TaxAmount,
+		GrossAmount:  order.Amount,
+	}
+}
+
+func roundCents(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
@@ -0,0 +1,234 @@
+package invoices
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ernestngugi/sil-backend/internal/model"
+	"github.com/ernestngugi/sil-backend/internal/tax"
+	"github.com/stretchr/testify/assert"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+var (
+	textPattern   = regexp.MustCompile(`(?m)^BT .* Td \((.*)\) Tj ET$`)
+	xrefPattern   = regexp.MustCompile(`(?m)^(\d{10}) 00000 n $`)
+	escapePattern = regexp.MustCompile(`\\([0-7]{3}|.)`)
+)
+
+func testConfig() *Config {
+	return &Config{
+		CompanyName:    "SIL Shop Ltd",
+		CompanyAddress: []string{"Westlands Business Park", "P.O. Box 1234-00100, Nairobi"},
+		CompanyTaxPIN:  "P051234567X",
+		CompanyEmail:   "accounts@sil.example",
+		CompanyPhone:   "+254 20 123 4567",
+		InvoicePrefix:  "INV",
+		ReceiptPrefix:  "RCT",
+	}
+}
+
+var (
+	standardRated = &model.TaxCategory{Code: "standard", Treatment: model.TaxTreatmentStandard, Rate: 16}
+	zeroRated     = &model.TaxCategory{Code: "zero_rated", Treatment: model.TaxTreatmentZeroRated}
+	exempt        = &model.TaxCategory{Code: "exempt", Treatment: model.TaxTreatmentExempt}
+)
+
+func testOrder(item string, amount float64, category *model.TaxCategory) *model.Order {
+	return &model.Order{Item: item, Amount: amount, Tax: tax.Inclusive(category, amount)}
+}
+
+func testInvoice(kind model.InvoiceKind, number string) *model.Invoice {
+
+	line := orderLine(testOrder("Maize flour 2kg (bale of 12) – Jogoo", 1250, standardRated))
+
+	invoice := &model.Invoice{
+		Kind:         kind,
+		Number:       number,
+		OrderID:      1024,
+		CustomerName: "procurement@acme.example",
+		Lines:        []*model.InvoiceLine{line},
+		NetAmount:    line.NetAmount,
+		TaxAmount:    line.TaxAmount,
+		GrossAmount:  line.GrossAmount,
+		DateIssued:   time.Date(2026, 10, 19, 6, 15, 2, 0, time.UTC),
+	}
+
+	if kind == model.InvoiceKindReceipt {
+		paid := time.Date(2026, 10, 19, 6, 14, 40, 0, time.UTC)
+		invoice.PaymentReference = "SJK3H2L9QX"
+		invoice.PaymentPhone = "254708374149"
+		invoice.DatePaid = &paid
+	}
+
+	return invoice
+}
+
+func TestRender(t *testing.T) {
+
+	for name, invoice := range map[string]*model.Invoice{
+		"invoice": testInvoice(model.InvoiceKindInvoice, "INV-000042"),
+		"receipt": testInvoice(model.InvoiceKindReceipt, "RCT-000007"),
+	} {
+		t.Run(name, func(t *testing.T) {
+
+			pdf := Render(invoice, testConfig())
+
+			assertValidPDF(t, pdf)
+
+			golden := filepath.Join("testdata", name+".golden")
+			text := pdfText(pdf)
+
+			if *update {
+				err := os.WriteFile(golden, []byte(text), 0o644)
+				assert.NoError(t, err)
+			}
+
+			expected, err := os.ReadFile(golden)
+			assert.NoError(t, err)
+			assert.Equal(t, string(expected), text)
+		})
+	}
+
+	t.Run("starts new pages for long invoices", func(t *testing.T) {
+
+		invoice := testInvoice(model.InvoiceKindInvoice, "INV-000043")
+		for i := 0; i < 100; i++ {
+			invoice.Lines = append(invoice.Lines, invoice.Lines[0])
+		}
+
+		pdf := Render(invoice, testConfig())
+
+		assertValidPDF(t, pdf)
+		assert.True(t, bytes.Contains(pdf, []byte("/Count 3 >>")))
+		assert.Equal(t, 1, strings.Count(pdfText(pdf), "Total (KES)"))
+	})
+
+	t.Run("summarises vat by rate", func(t *testing.T) {
+
+		invoice := testInvoice(model.InvoiceKindInvoice, "INV-000044")
+		invoice.Lines = append(
+			invoice.Lines,
+			orderLine(testOrder("Unga wa ngano 2kg", 200, zeroRated)),
+			orderLine(testOrder("Paracetamol 500mg", 80, exempt)),
+			orderLine(testOrder("Cooking oil 1l", 348, standardRated)),
+		)
+
+		text := pdfText(Render(invoice, testConfig()))
+
+		assert.Contains(t, text, "Standard 16%\n1,377.59\n220.41\n1,598.00")
+		assert.Contains(t, text, "Zero rated 0%\n200.00\n0.00\n200.00")
+		assert.Contains(t, text, "Exempt\n80.00\n0.00\n80.00")
+	})
+}
+
+func TestOrderLine(t *testing.T) {
+
+	order := testOrder("Paracetamol 500mg", 80, exempt)
+	order.ID = 7
+
+	line := orderLine(order)
+	assert.Equal(t, &model.InvoiceLine{
+		Description:  "Paracetamol 500mg",
+		Quantity:     1,
+		UnitPrice:    80,
+		TaxCategory:  "exempt",
+		TaxTreatment: model.TaxTreatmentExempt,
+		NetAmount:    80,
+		GrossAmount:  80,
+	}, line)
+}
+
+func TestInvoiceLines(t *testing.T) {
+
+	order := testOrder("Sugar 1kg and 1 more", 460, exempt)
+	order.Lines = []*model.OrderLine{
+		{Item: "Sugar 1kg", Quantity: 3, Amount: 400, Tax: model.OrderTax{Category: "exempt", Treatment: model.TaxTreatmentExempt, NetAmount: 400, GrossAmount: 400}},
+		{Item: "Salt 500g", Quantity: 2, Amount: 60, Tax: model.OrderTax{Category: "exempt", Treatment: model.TaxTreatmentExempt, NetAmount: 60, GrossAmount: 60}},
+	}
+
+	lines := invoiceLines(order)
+	assert.Len(t, lines, 2)
+	assert.Equal(t, "Sugar 1kg", lines[0].Description)
+	assert.Equal(t, 3, lines[0].Quantity)
+	assert.Equal(t, 133.33, lines[0].UnitPrice)
+	assert.Equal(t, 400.0, lines[0].GrossAmount)
+	assert.Equal(t, 30.0, lines[1].UnitPrice)
+
+	single := testOrder("Paracetamol 500mg", 80, exempt)
+	assert.Equal(t, []*model.InvoiceLine{orderLine(single)}, invoiceLines(single))
+}
+
+func TestWrap(t *testing.T) {
+
+	assert.Equal(t, []string{""}, wrap("", fontRegular, 9, 100))
+	assert.Equal(t, []string{"Sugar 1kg"}, wrap("Sugar  1kg", fontRegular, 9, 100))
+	assert.Equal(t, []string{"Maize flour 2kg bale of", "twelve packets"}, wrap("Maize flour 2kg bale of twelve packets", fontRegular, 9, 100))
+
+	for _, line := range wrap(strings.Repeat("W", 40), fontBold, 9, 100) {
+		assert.LessOrEqual(t, textWidth(fontBold, 9, line), 100.0)
+	}
+}
+
+func TestEncodeText(t *testing.T) {
+
+	assert.Equal(t, `Order \(1\) \\ 50% off`, encodeText(`Order (1) \ 50% off`))
+	assert.Equal(t, `Caf\351 \200 5 \226 \223ok\224 ?`, encodeText("Café € 5 – “ok” 😊"))
+}
+
+// assertValidPDF checks the cross-reference table points at each object,
+// which viewers rely on to open the file.
+func assertValidPDF(t *testing.T, pdf []byte) {
+
+	assert.True(t, bytes.HasPrefix(pdf, []byte("%PDF-1.4\n")))
+	assert.True(t, bytes.HasSuffix(pdf, []byte("%%EOF\n")))
+
+	for i, match := range xrefPattern.FindAllSubmatch(pdf, -1) {
+
+		offset, err := strconv.Atoi(string(match[1]))
+		assert.NoError(t, err)
+		assert.True(t, bytes.HasPrefix(pdf[offset:], []byte(fmt.Sprintf("%d 0 obj\n", i+1))), "object %d", i+1)
+	}
+
+	startxref := bytes.LastIndex(pdf, []byte("startxref\n"))
+	offset, err := strconv.Atoi(strings.TrimSpace(strings.TrimSuffix(string(pdf[startxref+10:]), "%%EOF\n")))
+	assert.NoError(t, err)
+	assert.True(t, bytes.HasPrefix(pdf[offset:], []byte("xref\n")))
+}
+
+// pdfText returns the text drawn on each page, one string per line, with
+// WinAnsi escapes decoded.
+func pdfText(pdf []byte) string {
+
+	var text strings.Builder
+
+	for _, match := range textPattern.FindAllSubmatch(pdf, -1) {
+
+		decoded := escapePattern.ReplaceAllStringFunc(string(match[1]), func(escape string) string {
+			if len(escape) == 4 {
+				code, _ := strconv.ParseUint(escape[1:], 8, 8)
+				for r, b := range winAnsi {
+					if byte(code) == b {
+						return string(r)
+					}
+				}
+				return string(rune(code))
+			}
+			return escape[1:]
+		})
+
+		text.WriteString(decoded)
+		text.WriteByte('\n')
+	}
+
+	return text.String()
+}
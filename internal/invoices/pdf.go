@@ -0,0 +1,194 @@
+package invoices
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// A4 in points.
+const (
+	pageWidth  = 595.28
+	pageHeight = 841.89
+)
+
+type font int
+
+const (
+	fontRegular font = iota
+	fontBold
+)
+
+var fontNames = [...]string{"Helvetica", "Helvetica-Bold"}
+
+// Glyph widths of the printable ASCII characters in the standard Helvetica
+// fonts, in thousandths of the font size. Anything else is treated as 556
+// wide, the width of a digit.
+var glyphWidths = [...][95]int{
+	fontRegular: {
+		278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+		556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+		1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+		667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+		333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+		556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+	},
+	fontBold: {
+		278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278,
+		556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 333, 333, 584, 584, 584, 611,
+		975, 722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833, 722, 778,
+		667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 333, 278, 333, 584, 556,
+		333, 556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, 611, 611,
+		611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500, 389, 280, 389, 584,
+	},
+}
+
+// winAnsi maps the characters outside Latin-1 that WinAnsiEncoding has.
+var winAnsi = map[rune]byte{
+	'€': 0x80, '‚': 0x82, '„': 0x84, '…': 0x85, '‘': 0x91, '’': 0x92,
+	'“': 0x93, '”': 0x94, '•': 0x95, '–': 0x96, '—': 0x97, '™': 0x99,
+}
+
+// document is a PDF of text and lines in the standard Helvetica fonts, which
+// every viewer has, so no fonts are embedded. Positions are in points from
+// the top left of the page.
+type document struct {
+	title   string
+	created time.Time
+	pages   []*bytes.Buffer
+}
+
+func newDocument(title string, created time.Time) *document {
+
+	d := &document{title: title, created: created}
+	d.addPage()
+
+	return d
+}
+
+func (d *document) addPage() {
+	d.pages = append(d.pages, &bytes.Buffer{})
+}
+
+func (d *document) page() *bytes.Buffer {
+	return d.pages[len(d.pages)-1]
+}
+
+func (d *document) text(x, y float64, f font, size float64, text string) {
+	fmt.Fprintf(d.page(), "BT /F%d %s Tf %s %s Td (%s) Tj ET\n", f+1, number(size), number(x), number(pageHeight-y), encodeText(text))
+}
+
+// textRight writes text ending at x.
+func (d *document) textRight(x, y float64, f font, size float64, text string) {
+	d.text(x-textWidth(f, size, text), y, f, size, text)
+}
+
+func (d *document) line(x1, y1, x2, y2 float64) {
+	fmt.Fprintf(d.page(), "0.5 w %s %s m %s %s l S\n", number(x1), number(pageHeight-y1), number(x2), number(pageHeight-y2))
+}
+
+// shade fills a light grey box with its top left corner at x, y.
+func (d *document) shade(x, y, width, height float64) {
+	fmt.Fprintf(d.page(), "0.92 g %s %s %s %s re f 0 g\n", number(x), number(pageHeight-y-height), number(width), number(height))
+}
+
+// bytes lays the pages out as a PDF file. Content streams are left
+// uncompressed, which keeps the text searchable by tools and tests.
+func (d *document) bytes() []byte {
+
+	var out bytes.Buffer
+
+	offsets := make([]int, 0)
+
+	object := func(body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	// Objects 1 to 5 are fixed; each page then takes a page object
+	// followed by its content stream.
+	kids := make([]string, 0, len(d.pages))
+	for i := range d.pages {
+		kids = append(kids, fmt.Sprintf("%d 0 R", 6+2*i))
+	}
+
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+	object(fmt.Sprintf("<< /Type /Font /Subtype /Type1 /BaseFont /%s /Encoding /WinAnsiEncoding >>", fontNames[fontRegular]))
+	object(fmt.Sprintf("<< /Type /Font /Subtype /Type1 /BaseFont /%s /Encoding /WinAnsiEncoding >>", fontNames[fontBold]))
+	object(fmt.Sprintf("<< /Title (%s) /Creator (sil-api) /CreationDate (D:%s) >>", encodeText(d.title), pdfDate(d.created)))
+
+	for i, page := range d.pages {
+		object(fmt.Sprintf(
+			"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %s %s] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+			number(pageWidth), number(pageHeight), 7+2*i,
+		))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", page.Len(), page.String()))
+	}
+
+	xref := out.Len()
+
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R /Info 5 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	return out.Bytes()
+}
+
+// textWidth is how wide text is in points.
+func textWidth(f font, size float64, text string) float64 {
+
+	width := 0
+
+	for _, r := range text {
+		if r >= ' ' && r <= '~' {
+			width += glyphWidths[f][r-' ']
+		} else {
+			width += 556
+		}
+	}
+
+	return float64(width) * size / 1000
+}
+
+// encodeText converts text to WinAnsiEncoding and escapes it for a PDF
+// string. Characters the encoding lacks are printed as "?".
+func encodeText(text string) string {
+
+	var encoded strings.Builder
+
+	for _, r := range text {
+
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			encoded.WriteByte('\\')
+			encoded.WriteRune(r)
+		case r >= ' ' && r <= '~':
+			encoded.WriteRune(r)
+		case r >= 0xa0 && r <= 0xff:
+			fmt.Fprintf(&encoded, "\\%03o", r)
+		default:
+			if b, ok := winAnsi[r]; ok {
+				fmt.Fprintf(&encoded, "\\%03o", b)
+			} else {
+				encoded.WriteByte('?')
+			}
+		}
+	}
+
+	return encoded.String()
+}
+
+func number(value float64) string {
+	return strings.TrimSuffix(strings.TrimRight(fmt.Sprintf("%.2f", value), "0"), ".")
+}
+
+func pdfDate(t time.Time) string {
+	return t.Format("20060102150405-07'00'")
+}
@@ -0,0 +1,298 @@
+package invoices
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ernestngugi/sil-backend/internal/model"
+	"github.com/ernestngugi/sil-backend/internal/tax"
+)
+
+const (
+	marginLeft   = 50.0
+	marginRight  = pageWidth - 50
+	marginTop    = 60.0
+	marginBottom = pageHeight - 80
+
+	descriptionWidth = 240.0
+	lineHeight       = 14.0
+)
+
+// Right edges of the numeric columns of the line item table.
+const (
+	columnQuantity  = 330.0
+	columnUnitPrice = 400.0
+	columnTaxRate   = 445.0
+	columnTax       = 490.0
+	columnAmount    = marginRight
+)
+
+// Right edges of the numeric columns of the VAT summary, which sits to the
+// left of the totals.
+const (
+	columnSummaryNet   = 180.0
+	columnSummaryTax   = 245.0
+	columnSummaryGross = 310.0
+)
+
+// Documents are dated in Nairobi time wherever they are rendered.
+var eastAfricaTime = time.FixedZone("EAT", 3*60*60)
+
+// Render lays an invoice or receipt out as a PDF with the company details
+// from config.
+func Render(invoice *model.Invoice, config *Config) []byte {
+
+	d := newDocument(invoice.Number, invoice.DateIssued.In(eastAfricaTime))
+
+	title, numberLabel := "TAX INVOICE", "Invoice no."
+	if invoice.Kind == model.InvoiceKindReceipt {
+		title, numberLabel = "RECEIPT", "Receipt no."
+	}
+
+	d.text(marginLeft, marginTop, fontBold, 16, config.CompanyName)
+	d.textRight(marginRight, marginTop, fontBold, 18, title)
+
+	left := marginTop + 18
+
+	company := append([]string{}, config.CompanyAddress...)
+	if config.CompanyTaxPIN != "" {
+		company = append(company, "KRA PIN: "+config.CompanyTaxPIN)
+	}
+	for _, contact := range []string{config.CompanyEmail, config.CompanyPhone} {
+		if contact != "" {
+			company = append(company, contact)
+		}
+	}
+
+	for _, line := range company {
+		d.text(marginLeft, left, fontRegular, 9, line)
+		left += 13
+	}
+
+	right := marginTop + 20
+
+	for _, detail := range [][2]string{
+		{numberLabel, invoice.Number},
+		{"Date", formatDate(invoice.DateIssued)},
+		{"Order", strconv.FormatInt(invoice.OrderID, 10)},
+	} {
+		d.text(380, right, fontBold, 10, detail[0])
+		d.textRight(marginRight, right, fontRegular, 10, detail[1])
+		right += lineHeight
+	}
+
+	y := max(left, right) + 20
+
+	d.text(marginLeft, y, fontBold, 10, "Bill to")
+	d.text(marginLeft, y+lineHeight, fontRegular, 10, invoice.CustomerName)
+
+	y += 2*lineHeight + 20
+
+	summaries := tax.Summarise(invoice.Lines)
+
+	// The totals take three lines and the VAT summary one more than it
+	// has rows, side by side.
+	y = lineItems(d, invoice.Lines, y, max(3, len(summaries)+1))
+
+	taxSummary(d, summaries, y)
+
+	for _, total := range []struct {
+		label  string
+		amount float64
+		font   font
+	}{
+		{"Net amount", invoice.NetAmount, fontRegular},
+		{"VAT", invoice.TaxAmount, fontRegular},
+		{"Total (KES)", invoice.GrossAmount, fontBold},
+	} {
+		d.text(380, y, total.font, 10, total.label)
+		d.textRight(columnAmount, y, total.font, 10, model.FormatAmount(total.amount))
+		y += lineHeight
+	}
+
+	if invoice.Kind == model.InvoiceKindReceipt {
+
+		y += 20
+
+		d.text(marginLeft, y, fontBold, 10, "Payment")
+		y += lineHeight
+
+		payment := []string{"Paid by M-Pesa"}
+		if invoice.DatePaid != nil {
+			payment[0] += " on " + formatDate(*invoice.DatePaid) + " at " + invoice.DatePaid.In(eastAfricaTime).Format("15:04")
+		}
+		if invoice.PaymentReference != "" {
+			payment = append(payment, "M-Pesa receipt "+invoice.PaymentReference)
+		}
+		if invoice.PaymentPhone != "" {
+			payment = append(payment, "From "+maskPhoneNumber(invoice.PaymentPhone))
+		}
+
+		for _, line := range payment {
+			d.text(marginLeft, y, fontRegular, 10, line)
+			y += lineHeight
+		}
+	}
+
+	footer := "Amounts are in Kenya shillings and include VAT where it is charged."
+	if invoice.Kind == model.InvoiceKindReceipt {
+		footer += " Thank you for your payment."
+	}
+
+	d.line(marginLeft, marginBottom+20, marginRight, marginBottom+20)
+	d.text(marginLeft, marginBottom+34, fontRegular, 8, footer)
+
+	return d.bytes()
+}
+
+// lineItems draws the line item table from y, starting new pages as it
+// fills them, and returns where the totals go. The totals are kept on one
+// page with room for totalLines lines.
+func lineItems(d *document, lines []*model.InvoiceLine, y float64, totalLines int) float64 {
+
+	header := func(y float64) float64 {
+
+		d.shade(marginLeft, y-12, marginRight-marginLeft, 18)
+		d.text(marginLeft+5, y, fontBold, 9, "Description")
+		d.textRight(columnQuantity, y, fontBold, 9, "Qty")
+		d.textRight(columnUnitPrice, y, fontBold, 9, "Unit price")
+		d.textRight(columnTaxRate, y, fontBold, 9, "VAT %")
+		d.textRight(columnTax, y, fontBold, 9, "VAT")
+		d.textRight(columnAmount, y, fontBold, 9, "Amount")
+
+		return y + lineHeight + 6
+	}
+
+	y = header(y)
+
+	for _, line := range lines {
+
+		description := wrap(line.Description, fontRegular, 9, descriptionWidth)
+
+		if y+float64(len(description))*lineHeight > marginBottom {
+			d.addPage()
+			y = header(marginTop)
+		}
+
+		d.text(marginLeft+5, y, fontRegular, 9, description[0])
+		d.textRight(columnQuantity, y, fontRegular, 9, strconv.Itoa(line.Quantity))
+		d.textRight(columnUnitPrice, y, fontRegular, 9, model.FormatAmount(line.UnitPrice))
+		d.textRight(columnTaxRate, y, fontRegular, 9, rateLabel(line.TaxTreatment, line.TaxRate))
+		d.textRight(columnTax, y, fontRegular, 9, model.FormatAmount(line.TaxAmount))
+		d.textRight(columnAmount, y, fontRegular, 9, model.FormatAmount(line.GrossAmount))
+
+		for _, text := range description[1:] {
+			y += lineHeight
+			d.text(marginLeft+5, y, fontRegular, 9, text)
+		}
+
+		y += lineHeight
+	}
+
+	// Totals move to a new page rather than run into the footer.
+	if y+float64(totalLines+1)*lineHeight > marginBottom {
+		d.addPage()
+		y = marginTop
+	}
+
+	d.line(marginLeft, y-6, marginRight, y-6)
+
+	return y + 10
+}
+
+// taxSummary draws the net amount, VAT and gross amount for each rate the
+// lines were taxed at, from y.
+func taxSummary(d *document, summaries []*model.TaxSummary, y float64) {
+
+	d.text(marginLeft, y, fontBold, 9, "VAT rate")
+	d.textRight(columnSummaryNet, y, fontBold, 9, "Net")
+	d.textRight(columnSummaryTax, y, fontBold, 9, "VAT")
+	d.textRight(columnSummaryGross, y, fontBold, 9, "Gross")
+
+	for _, summary := range summaries {
+
+		y += lineHeight
+
+		label := rateLabel(summary.Treatment, summary.Rate)
+		switch summary.Treatment {
+		case model.TaxTreatmentStandard:
+			label = "Standard " + label + "%"
+		case model.TaxTreatmentZeroRated:
+			label = "Zero rated 0%"
+		}
+
+		d.text(marginLeft, y, fontRegular, 9, label)
+		d.textRight(columnSummaryNet, y, fontRegular, 9, model.FormatAmount(summary.NetAmount))
+		d.textRight(columnSummaryTax, y, fontRegular, 9, model.FormatAmount(summary.TaxAmount))
+		d.textRight(columnSummaryGross, y, fontRegular, 9, model.FormatAmount(summary.GrossAmount))
+	}
+}
+
+// rateLabel is how a VAT rate is printed. Exempt supplies carry no rate at
+// all, which sets them apart from zero rated ones.
+func rateLabel(treatment model.TaxTreatment, rate float64) string {
+
+	if treatment == model.TaxTreatmentExempt {
+		return "Exempt"
+	}
+
+	return strconv.FormatFloat(rate, 'f', -1, 64)
+}
+
+// wrap breaks text into lines no wider than width, splitting words that are
+// wider on their own.
+func wrap(text string, f font, size, width float64) []string {
+
+	lines := make([]string, 0, 1)
+	line := ""
+
+	for _, word := range strings.Fields(text) {
+
+		candidate := word
+		if line != "" {
+			candidate = line + " " + word
+		}
+
+		if textWidth(f, size, candidate) <= width {
+			line = candidate
+			continue
+		}
+
+		if line != "" {
+			lines = append(lines, line)
+		}
+
+		line = ""
+
+		for _, r := range word {
+			if line != "" && textWidth(f, size, line+string(r)) > width {
+				lines = append(lines, line)
+				line = ""
+			}
+			line += string(r)
+		}
+	}
+
+	return append(lines, line)
+}
+
+func formatDate(t time.Time) string {
+	return t.In(eastAfricaTime).Format("2 Jan 2006")
+}
+
+// maskPhoneNumber hides all but the first four and last three digits, as
+// M-Pesa does on statements.
+func maskPhoneNumber(phoneNumber string) string {
+
+	if len(phoneNumber) <= 7 {
+		return phoneNumber
+	}
+
+	masked := []byte(phoneNumber)
+	for i := 4; i < len(masked)-3; i++ {
+		masked[i] = '*'
+	}
+
+	return string(masked)
+}
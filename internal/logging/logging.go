@@ -0,0 +1,94 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+
+	"github.com/ernestngugi/sil-backend/internal/env"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+
+	requestIDKey = "request_id"
+	traceIDKey   = "trace_id"
+)
+
+type Config struct {
+	Level  slog.Level
+	Format string
+}
+
+// ConfigFromEnv reads LOG_LEVEL (debug, info, warn, error) and LOG_FORMAT
+// (json, text), defaulting to info level JSON output.
+func ConfigFromEnv() (*Config, error) {
+
+	config := &Config{
+		Format: strings.ToLower(env.String("LOG_FORMAT", FormatJSON)),
+	}
+
+	err := config.Level.UnmarshalText([]byte(env.String("LOG_LEVEL", "info")))
+	if err != nil {
+		return &Config{}, fmt.Errorf("invalid LOG_LEVEL: %w", err)
+	}
+
+	if config.Format != FormatJSON && config.Format != FormatText {
+		return &Config{}, fmt.Errorf("invalid LOG_FORMAT %q", config.Format)
+	}
+
+	return config, nil
+}
+
+// New returns a logger that redacts sensitive attributes and tags every record
+// logged with a request-scoped context with its request and trace IDs.
+func New(w io.Writer, config *Config) *slog.Logger {
+
+	options := &slog.HandlerOptions{
+		Level:       config.Level,
+		ReplaceAttr: redactAttr,
+	}
+
+	var handler slog.Handler
+	if config.Format == FormatText {
+		handler = slog.NewTextHandler(w, options)
+	} else {
+		handler = slog.NewJSONHandler(w, options)
+	}
+
+	return slog.New(&contextHandler{Handler: handler})
+}
+
+// Discard returns a logger that drops everything, for tests and tools.
+func Discard() *slog.Logger {
+	return slog.New(&contextHandler{Handler: slog.NewTextHandler(io.Discard, &slog.HandlerOptions{Level: slog.LevelError + 1})})
+}
+
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h *contextHandler) Handle(ctx context.Context, record slog.Record) error {
+
+	if requestID := RequestIDFromContext(ctx); requestID != "" {
+		record.AddAttrs(slog.String(requestIDKey, requestID))
+	}
+
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.HasTraceID() {
+		record.AddAttrs(slog.String(traceIDKey, spanContext.TraceID().String()))
+	}
+
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithGroup(name)}
+}
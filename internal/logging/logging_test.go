@@ -0,0 +1,81 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogger(t *testing.T) {
+
+	t.Run("adds the request id from the context", func(t *testing.T) {
+
+		var buf bytes.Buffer
+
+		logger := New(&buf, &Config{Level: slog.LevelInfo, Format: FormatJSON})
+
+		ctx := WithRequestID(context.Background(), "abc-123")
+		logger.InfoContext(ctx, "hello")
+
+		var record map[string]any
+
+		err := json.Unmarshal(buf.Bytes(), &record)
+		assert.NoError(t, err)
+
+		assert.Equal(t, "hello", record["msg"])
+		assert.Equal(t, "abc-123", record["request_id"])
+	})
+
+	t.Run("redacts secrets and masks phone numbers", func(t *testing.T) {
+
+		var buf bytes.Buffer
+
+		logger := New(&buf, &Config{Level: slog.LevelInfo, Format: FormatJSON})
+
+		logger.Info(
+			"sending",
+			slog.String("access_token", "ya29.secret"),
+			slog.String("apikey", "atsk_secret"),
+			slog.String("phone_number", "254728389583"),
+		)
+
+		var record map[string]any
+
+		err := json.Unmarshal(buf.Bytes(), &record)
+		assert.NoError(t, err)
+
+		assert.Equal(t, redacted, record["access_token"])
+		assert.Equal(t, redacted, record["apikey"])
+		assert.Equal(t, "*********583", record["phone_number"])
+		assert.NotContains(t, buf.String(), "secret")
+	})
+
+	t.Run("reads level and format from the environment", func(t *testing.T) {
+
+		t.Setenv("LOG_LEVEL", "debug")
+		t.Setenv("LOG_FORMAT", "text")
+
+		config, err := ConfigFromEnv()
+		assert.NoError(t, err)
+		assert.Equal(t, slog.LevelDebug, config.Level)
+		assert.Equal(t, FormatText, config.Format)
+
+		t.Setenv("LOG_FORMAT", "xml")
+
+		_, err = ConfigFromEnv()
+		assert.Error(t, err)
+	})
+
+	t.Run("validates client supplied request ids", func(t *testing.T) {
+
+		assert.True(t, ValidRequestID("0f8fad5b-d9cb-469f-a165-70867728950e"))
+		assert.False(t, ValidRequestID(""))
+		assert.False(t, ValidRequestID("has space"))
+		assert.False(t, ValidRequestID("line\nbreak"))
+		assert.Len(t, NewRequestID(), 32)
+	})
+}
@@ -0,0 +1,56 @@
+package logging
+
+import (
+	"log/slog"
+	"strings"
+)
+
+const redacted = "[REDACTED]"
+
+// secretKeys are attribute names whose values are never logged.
+var secretKeys = []string{
+	"token",
+	"authorization",
+	"apikey",
+	"api_key",
+	"secret",
+	"password",
+	"x-sil-token",
+}
+
+// phoneKeys are attribute names holding phone numbers, which are masked.
+var phoneKeys = []string{
+	"phone",
+	"phone_number",
+	"number",
+	"to",
+}
+
+func redactAttr(groups []string, attr slog.Attr) slog.Attr {
+
+	key := strings.ToLower(attr.Key)
+
+	for _, secretKey := range secretKeys {
+		if strings.Contains(key, secretKey) {
+			return slog.String(attr.Key, redacted)
+		}
+	}
+
+	for _, phoneKey := range phoneKeys {
+		if key == phoneKey {
+			return slog.String(attr.Key, MaskPhone(attr.Value.String()))
+		}
+	}
+
+	return attr
+}
+
+// MaskPhone hides all but the last three digits of a phone number.
+func MaskPhone(number string) string {
+
+	if len(number) <= 3 {
+		return strings.Repeat("*", len(number))
+	}
+
+	return strings.Repeat("*", len(number)-3) + number[len(number)-3:]
+}
@@ -0,0 +1,54 @@
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+)
+
+const (
+	RequestIDHeader = "X-Request-ID"
+
+	maxRequestIDLength = 128
+)
+
+type requestIDContextKey struct{}
+
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDContextKey{}, requestID)
+}
+
+func RequestIDFromContext(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDContextKey{}).(string)
+	return requestID
+}
+
+// NewRequestID returns a random 128-bit identifier encoded as hex.
+func NewRequestID() string {
+
+	b := make([]byte, 16)
+
+	_, err := rand.Read(b)
+	if err != nil {
+		panic("crypto/rand unavailable: " + err.Error())
+	}
+
+	return hex.EncodeToString(b)
+}
+
+// ValidRequestID reports whether a client supplied request ID is safe to echo
+// back and log: non-empty, bounded and limited to printable ASCII.
+func ValidRequestID(requestID string) bool {
+
+	if requestID == "" || len(requestID) > maxRequestIDLength {
+		return false
+	}
+
+	for _, r := range requestID {
+		if r < '!' || r > '~' {
+			return false
+		}
+	}
+
+	return true
+}
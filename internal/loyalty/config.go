@@ -0,0 +1,63 @@
+package loyalty
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ernestngugi/sil-backend/internal/env"
+)
+
+type Config struct {
+	// SpendPerPoint is how many shillings a paid order needs to earn a
+	// point.
+	SpendPerPoint float64
+	// PointValue is how many shillings a point takes off an order.
+	PointValue float64
+	// MaxRedeemPercent caps the share of an order that may be paid with
+	// points.
+	MaxRedeemPercent float64
+	// ExpireAfter is how long points may go unspent once earned.
+	ExpireAfter time.Duration
+}
+
+// ConfigFromEnv reads the LOYALTY_* settings.
+func ConfigFromEnv() (*Config, error) {
+
+	config := &Config{}
+
+	var err error
+
+	if config.SpendPerPoint, err = env.Float("LOYALTY_SPEND_PER_POINT", 100); err != nil {
+		return &Config{}, err
+	}
+
+	if config.SpendPerPoint < 0.01 {
+		return &Config{}, fmt.Errorf("invalid LOYALTY_SPEND_PER_POINT: %v is less than a cent", config.SpendPerPoint)
+	}
+
+	if config.PointValue, err = env.Float("LOYALTY_POINT_VALUE", 1); err != nil {
+		return &Config{}, err
+	}
+
+	if config.PointValue < 0.01 {
+		return &Config{}, fmt.Errorf("invalid LOYALTY_POINT_VALUE: %v is less than a cent", config.PointValue)
+	}
+
+	if config.MaxRedeemPercent, err = env.Float("LOYALTY_MAX_REDEEM_PERCENT", 50); err != nil {
+		return &Config{}, err
+	}
+
+	if config.MaxRedeemPercent <= 0 || config.MaxRedeemPercent > 100 {
+		return &Config{}, fmt.Errorf("invalid LOYALTY_MAX_REDEEM_PERCENT: %v is not a percentage", config.MaxRedeemPercent)
+	}
+
+	if config.ExpireAfter, err = env.Duration("LOYALTY_EXPIRE_AFTER", 365*24*time.Hour); err != nil {
+		return &Config{}, err
+	}
+
+	if config.ExpireAfter <= 0 {
+		return &Config{}, fmt.Errorf("invalid LOYALTY_EXPIRE_AFTER: %v is not positive", config.ExpireAfter)
+	}
+
+	return config, nil
+}
@@ -0,0 +1,249 @@
+package loyalty
+
+import (
+	"context"
+	"time"
+
+	"github.com/ernestngugi/sil-backend/internal/db"
+	"github.com/ernestngugi/sil-backend/internal/model"
+	"github.com/ernestngugi/sil-backend/internal/repos"
+)
+
+// maxHistory caps how many ledger entries an account lists.
+const maxHistory = 100
+
+type (
+	// Ledger keeps customers' loyalty points. Every change is a new entry,
+	// and expired points are written off whenever a customer's ledger is
+	// read or written.
+	Ledger interface {
+		// Account returns the customer's balance and latest entries.
+		Account(ctx context.Context, dB db.DB, customerID int64) (*model.LoyaltyAccount, error)
+		// Redeem checks that the customer can spend points on an order of
+		// amount and returns the entry debiting them, to be recorded once
+		// the order is saved. The customer's ledger stays locked until the
+		// transaction ends.
+		Redeem(ctx context.Context, operations db.SQLOperations, customerID int64, points int, amount float64) (*model.LoyaltyEntry, error)
+		Record(ctx context.Context, operations db.SQLOperations, entry *model.LoyaltyEntry) error
+		// Earn credits the points a paid order earned.
+		Earn(ctx context.Context, operations db.SQLOperations, order *model.Order) error
+		// Reverse takes back the points a refunded or cancelled order
+		// earned and returns those spent on it. Points already spent
+		// elsewhere cannot be taken back, so no more than the balance is
+		// reversed. Reversing an order twice changes nothing.
+		Reverse(ctx context.Context, operations db.SQLOperations, order *model.Order) error
+	}
+
+	ledger struct {
+		loyaltyRepository repos.LoyaltyRepository
+		config            *Config
+	}
+)
+
+func NewLedger(
+	loyaltyRepository repos.LoyaltyRepository,
+	config *Config,
+) Ledger {
+	return &ledger{
+		loyaltyRepository: loyaltyRepository,
+		config:            config,
+	}
+}
+
+func (l *ledger) Account(
+	ctx context.Context,
+	dB db.DB,
+	customerID int64,
+) (*model.LoyaltyAccount, error) {
+
+	var entries []*model.LoyaltyEntry
+
+	err := db.WithTransaction(ctx, dB, func(operations db.SQLOperations) error {
+
+		var err error
+
+		entries, err = l.writeOff(ctx, operations, customerID, time.Now())
+
+		return err
+	})
+	if err != nil {
+		return &model.LoyaltyAccount{}, err
+	}
+
+	balance := Balance(entries)
+
+	history := make([]*model.LoyaltyEntry, 0, min(len(entries), maxHistory))
+	for i := len(entries) - 1; i >= 0 && len(history) < maxHistory; i-- {
+		history = append(history, entries[i])
+	}
+
+	return &model.LoyaltyAccount{
+		Balance: balance,
+		Value:   float64(cents(float64(balance)*l.config.PointValue)) / 100,
+		History: history,
+	}, nil
+}
+
+func (l *ledger) Redeem(
+	ctx context.Context,
+	operations db.SQLOperations,
+	customerID int64,
+	points int,
+	amount float64,
+) (*model.LoyaltyEntry, error) {
+
+	entries, err := l.writeOff(ctx, operations, customerID, time.Now())
+	if err != nil {
+		return &model.LoyaltyEntry{}, err
+	}
+
+	discount, err := l.config.Discount(points, Balance(entries), amount)
+	if err != nil {
+		return &model.LoyaltyEntry{}, err
+	}
+
+	return &model.LoyaltyEntry{
+		CustomerID: customerID,
+		Kind:       model.LoyaltyRedeemed,
+		Points:     -points,
+		Amount:     discount,
+	}, nil
+}
+
+// Record appends an entry Redeem returned.
+func (l *ledger) Record(
+	ctx context.Context,
+	operations db.SQLOperations,
+	entry *model.LoyaltyEntry,
+) error {
+	return l.loyaltyRepository.SaveEntry(ctx, operations, entry)
+}
+
+func (l *ledger) Earn(
+	ctx context.Context,
+	operations db.SQLOperations,
+	order *model.Order,
+) error {
+
+	points := l.config.Earned(order.Amount)
+	if points == 0 {
+		return nil
+	}
+
+	now := time.Now()
+
+	_, err := l.writeOff(ctx, operations, order.CustomerID, now)
+	if err != nil {
+		return err
+	}
+
+	return l.loyaltyRepository.SaveEntry(ctx, operations, l.credit(order, model.LoyaltyEarned, points, now))
+}
+
+func (l *ledger) Reverse(
+	ctx context.Context,
+	operations db.SQLOperations,
+	order *model.Order,
+) error {
+
+	now := time.Now()
+
+	entries, err := l.writeOff(ctx, operations, order.CustomerID, now)
+	if err != nil {
+		return err
+	}
+
+	orderEntries, err := l.loyaltyRepository.OrderEntries(ctx, operations, order.ID)
+	if err != nil {
+		return err
+	}
+
+	written := map[model.LoyaltyEntryKind]*model.LoyaltyEntry{}
+	for _, entry := range orderEntries {
+		written[entry.Kind] = entry
+	}
+
+	balance := Balance(entries)
+
+	if redeemed, ok := written[model.LoyaltyRedeemed]; ok && written[model.LoyaltyReturned] == nil {
+
+		err = l.loyaltyRepository.SaveEntry(ctx, operations, l.credit(order, model.LoyaltyReturned, -redeemed.Points, now))
+		if err != nil {
+			return err
+		}
+
+		balance -= redeemed.Points
+	}
+
+	if earned, ok := written[model.LoyaltyEarned]; ok && written[model.LoyaltyReversed] == nil {
+
+		points := min(earned.Points, balance)
+		if points > 0 {
+
+			err = l.loyaltyRepository.SaveEntry(ctx, operations, &model.LoyaltyEntry{
+				CustomerID: order.CustomerID,
+				OrderID:    &order.ID,
+				Kind:       model.LoyaltyReversed,
+				Points:     -points,
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// writeOff locks the customer's ledger, records any points that have
+// expired by now, and returns the ledger oldest entry first.
+func (l *ledger) writeOff(
+	ctx context.Context,
+	operations db.SQLOperations,
+	customerID int64,
+	now time.Time,
+) ([]*model.LoyaltyEntry, error) {
+
+	err := l.loyaltyRepository.LockAccount(ctx, operations, customerID)
+	if err != nil {
+		return []*model.LoyaltyEntry{}, err
+	}
+
+	entries, err := l.loyaltyRepository.Entries(ctx, operations, customerID)
+	if err != nil {
+		return []*model.LoyaltyEntry{}, err
+	}
+
+	expired := Expired(entries, now)
+	if expired == 0 {
+		return entries, nil
+	}
+
+	entry := &model.LoyaltyEntry{
+		CustomerID: customerID,
+		Kind:       model.LoyaltyExpired,
+		Points:     -expired,
+	}
+
+	err = l.loyaltyRepository.SaveEntry(ctx, operations, entry)
+	if err != nil {
+		return []*model.LoyaltyEntry{}, err
+	}
+
+	return append(entries, entry), nil
+}
+
+// credit is an entry giving the customer who placed order points that
+// expire ExpireAfter from now.
+func (l *ledger) credit(order *model.Order, kind model.LoyaltyEntryKind, points int, now time.Time) *model.LoyaltyEntry {
+
+	expiresAt := now.Add(l.config.ExpireAfter)
+
+	return &model.LoyaltyEntry{
+		CustomerID: order.CustomerID,
+		OrderID:    &order.ID,
+		Kind:       kind,
+		Points:     points,
+		ExpiresAt:  &expiresAt,
+	}
+}
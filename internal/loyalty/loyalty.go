@@ -0,0 +1,108 @@
+package loyalty
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/ernestngugi/sil-backend/internal/apperrors"
+	"github.com/ernestngugi/sil-backend/internal/model"
+)
+
+// Earned is how many points a paid order of amount earns. Part points are
+// not given.
+func (c *Config) Earned(amount float64) int {
+	return int(cents(amount) / cents(c.SpendPerPoint))
+}
+
+// Discount checks that points can be redeemed on an order of amount by a
+// customer holding balance, and returns the shillings they take off it.
+// Like promo codes, points always leave model.MinPaymentAmount to pay.
+func (c *Config) Discount(points, balance int, amount float64) (float64, error) {
+
+	if points > balance {
+		return 0, pointsError(
+			"insufficient_loyalty_points",
+			fmt.Sprintf("you have %d loyalty points", balance),
+		)
+	}
+
+	maxDiscount := float64(cents(amount*c.MaxRedeemPercent/100)) / 100
+	maxDiscount = math.Min(maxDiscount, math.Max(amount-model.MinPaymentAmount, 0))
+	discount := float64(cents(float64(points)*c.PointValue)) / 100
+
+	if discount > maxDiscount {
+		return 0, pointsError(
+			"loyalty_points_limit_exceeded",
+			fmt.Sprintf("at most %d loyalty points can be redeemed on this order", int(cents(maxDiscount)/cents(c.PointValue))),
+		)
+	}
+
+	return discount, nil
+}
+
+// Balance is the sum of a customer's ledger.
+func Balance(entries []*model.LoyaltyEntry) int {
+
+	balance := 0
+
+	for _, entry := range entries {
+		balance += entry.Points
+	}
+
+	return balance
+}
+
+// Expired is how many points in entries have expired by now without being
+// written off. Points are spent oldest first, so credits that have expired
+// are used up by every debit before any later credit is. Reversals are not
+// spending: they take back the points their order earned.
+func Expired(entries []*model.LoyaltyEntry, now time.Time) int {
+
+	reversed := map[int64]int{}
+
+	for _, entry := range entries {
+		if entry.Kind == model.LoyaltyReversed && entry.OrderID != nil {
+			reversed[*entry.OrderID] -= entry.Points
+		}
+	}
+
+	expired, debited := 0, 0
+
+	for _, entry := range entries {
+
+		if entry.Kind == model.LoyaltyReversed {
+			continue
+		}
+
+		if entry.Points < 0 {
+			debited -= entry.Points
+			continue
+		}
+
+		if entry.ExpiresAt == nil || now.Before(*entry.ExpiresAt) {
+			continue
+		}
+
+		points := entry.Points
+		if entry.Kind == model.LoyaltyEarned && entry.OrderID != nil {
+			points = max(points-reversed[*entry.OrderID], 0)
+		}
+
+		expired += points
+	}
+
+	return max(expired-debited, 0)
+}
+
+func cents(amount float64) int64 {
+	return int64(math.Round(amount * 100))
+}
+
+func pointsError(code, message string) *apperrors.Error {
+	return apperrors.Validation(code, message, apperrors.FieldError{
+		Field:   "loyalty_points",
+		Code:    code,
+		Message: message,
+	})
+}
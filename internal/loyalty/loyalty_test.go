@@ -0,0 +1,145 @@
+package loyalty
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ernestngugi/sil-backend/internal/apperrors"
+	"github.com/ernestngugi/sil-backend/internal/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEarned(t *testing.T) {
+
+	config := &Config{SpendPerPoint: 100}
+
+	assert.Equal(t, 0, config.Earned(99.99))
+	assert.Equal(t, 1, config.Earned(100))
+	assert.Equal(t, 12, config.Earned(1250))
+
+	config.SpendPerPoint = 0.1
+
+	assert.Equal(t, 3, config.Earned(0.3))
+}
+
+func TestDiscount(t *testing.T) {
+
+	config := &Config{PointValue: 0.5, MaxRedeemPercent: 50}
+
+	discount, err := config.Discount(200, 250, 1000)
+	assert.NoError(t, err)
+	assert.Equal(t, 100.0, discount)
+
+	discount, err = config.Discount(1000, 1000, 1000)
+	assert.NoError(t, err)
+	assert.Equal(t, 500.0, discount)
+
+	_, err = config.Discount(300, 250, 1000)
+	assert.Equal(t, "insufficient_loyalty_points", apperrors.From(err).Code)
+	assert.Equal(t, "loyalty_points", apperrors.From(err).Fields[0].Field)
+
+	_, err = config.Discount(1001, 2000, 1000)
+	assert.Equal(t, "loyalty_points_limit_exceeded", apperrors.From(err).Code)
+	assert.Equal(t, "at most 1000 loyalty points can be redeemed on this order", apperrors.From(err).Message)
+	assert.True(t, apperrors.Is(err, apperrors.KindValidation))
+
+	config.MaxRedeemPercent = 100
+
+	discount, err = config.Discount(1998, 2000, 1000)
+	assert.NoError(t, err)
+	assert.Equal(t, 999.0, discount)
+
+	_, err = config.Discount(2000, 2000, 1000)
+	assert.Equal(t, "at most 1998 loyalty points can be redeemed on this order", apperrors.From(err).Message)
+}
+
+func TestExpired(t *testing.T) {
+
+	now := time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)
+
+	at := func(days int) *time.Time {
+		t := now.AddDate(0, 0, days)
+		return &t
+	}
+
+	entry := func(kind model.LoyaltyEntryKind, points int, expiresAt *time.Time) *model.LoyaltyEntry {
+		return &model.LoyaltyEntry{Kind: kind, Points: points, ExpiresAt: expiresAt}
+	}
+
+	orderEntry := func(orderID int64, kind model.LoyaltyEntryKind, points int, expiresAt *time.Time) *model.LoyaltyEntry {
+		return &model.LoyaltyEntry{OrderID: &orderID, Kind: kind, Points: points, ExpiresAt: expiresAt}
+	}
+
+	t.Run("expires credits that were not spent", func(t *testing.T) {
+
+		entries := []*model.LoyaltyEntry{
+			entry(model.LoyaltyEarned, 10, at(-1)),
+			entry(model.LoyaltyEarned, 5, at(30)),
+		}
+
+		assert.Equal(t, 10, Expired(entries, now))
+		assert.Equal(t, 15, Balance(entries))
+	})
+
+	t.Run("spends the oldest points first", func(t *testing.T) {
+
+		entries := []*model.LoyaltyEntry{
+			entry(model.LoyaltyEarned, 10, at(-1)),
+			entry(model.LoyaltyRedeemed, -4, nil),
+			entry(model.LoyaltyEarned, 5, at(30)),
+		}
+
+		assert.Equal(t, 6, Expired(entries, now))
+
+		entries = append(entries, entry(model.LoyaltyExpired, -6, nil))
+
+		assert.Zero(t, Expired(entries, now))
+		assert.Equal(t, 5, Balance(entries))
+		assert.Equal(t, 5, Expired(entries, now.AddDate(0, 0, 30)))
+	})
+
+	t.Run("expires nothing when the old points were spent", func(t *testing.T) {
+
+		entries := []*model.LoyaltyEntry{
+			entry(model.LoyaltyEarned, 10, at(-1)),
+			entry(model.LoyaltyEarned, 5, at(30)),
+			entry(model.LoyaltyRedeemed, -12, nil),
+		}
+
+		assert.Zero(t, Expired(entries, now))
+		assert.Equal(t, 3, Balance(entries))
+	})
+
+	t.Run("takes reversals off the credit they reverse", func(t *testing.T) {
+
+		entries := []*model.LoyaltyEntry{
+			orderEntry(1, model.LoyaltyEarned, 10, at(-1)),
+			orderEntry(2, model.LoyaltyEarned, 5, at(30)),
+			orderEntry(2, model.LoyaltyReversed, -5, nil),
+		}
+
+		assert.Equal(t, 10, Expired(entries, now))
+
+		entries = []*model.LoyaltyEntry{
+			orderEntry(1, model.LoyaltyEarned, 10, at(-1)),
+			orderEntry(2, model.LoyaltyEarned, 5, at(30)),
+			orderEntry(1, model.LoyaltyReversed, -10, nil),
+		}
+
+		assert.Zero(t, Expired(entries, now))
+		assert.Equal(t, 5, Balance(entries))
+	})
+}
+
+func TestConfigFromEnv(t *testing.T) {
+
+	config, err := ConfigFromEnv()
+	assert.NoError(t, err)
+	assert.Equal(t, 100.0, config.SpendPerPoint)
+	assert.Equal(t, 365*24*time.Hour, config.ExpireAfter)
+
+	t.Setenv("LOYALTY_MAX_REDEEM_PERCENT", "120")
+
+	_, err = ConfigFromEnv()
+	assert.Error(t, err)
+}
@@ -0,0 +1,36 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/ernestngugi/sil-backend/internal/model"
+	"github.com/ernestngugi/sil-backend/providers"
+)
+
+type emailSender struct {
+	provider string
+	next     providers.EmailSender
+	metrics  *Metrics
+}
+
+// NewEmailSender decorates any providers.EmailSender with send counters and
+// latency histograms labelled by provider and outcome.
+func NewEmailSender(provider string, next providers.EmailSender, metrics *Metrics) providers.EmailSender {
+	return &emailSender{
+		provider: provider,
+		next:     next,
+		metrics:  metrics,
+	}
+}
+
+func (p *emailSender) Send(ctx context.Context, message *model.EmailMessage) error {
+
+	start := time.Now()
+
+	err := p.next.Send(ctx, message)
+
+	p.metrics.ObserveEmail(p.provider, sendOutcome(err), time.Since(start))
+
+	return err
+}
@@ -0,0 +1,202 @@
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "sil"
+
+// Outcomes of SMS and email sends.
+const (
+	SMSOutcomeSent    = "sent"
+	SMSOutcomeFailed  = "failed"
+	SMSOutcomeTimeout = "timeout"
+)
+
+// Metrics owns the Prometheus registry exposed at /metrics. A dedicated
+// registry keeps tests independent of each other and of the global default.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	httpRequests *prometheus.CounterVec
+	httpDuration *prometheus.HistogramVec
+	httpInFlight prometheus.Gauge
+	rateLimited  *prometheus.CounterVec
+
+	smsRequests    *prometheus.CounterVec
+	smsDuration    *prometheus.HistogramVec
+	smsCircuitOpen *prometheus.GaugeVec
+
+	emailRequests *prometheus.CounterVec
+	emailDuration *prometheus.HistogramVec
+
+	ordersCreated prometheus.Counter
+	orderValue    prometheus.Counter
+	orderAmount   prometheus.Histogram
+}
+
+func New() *Metrics {
+
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "requests_total",
+			Help:      "HTTP requests by method, route template and status code.",
+		}, []string{"method", "route", "status"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "request_duration_seconds",
+			Help:      "HTTP request latency by method, route template and status code.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		httpInFlight: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "requests_in_flight",
+			Help:      "HTTP requests currently being served.",
+		}),
+		rateLimited: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "rate_limited_total",
+			Help:      "HTTP requests rejected by rate limiting, by policy.",
+		}, []string{"policy"}),
+		smsRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "sms",
+			Name:      "send_total",
+			Help:      "SMS send attempts by provider and outcome.",
+		}, []string{"provider", "outcome"}),
+		smsDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "sms",
+			Name:      "send_duration_seconds",
+			Help:      "SMS provider call latency by provider and outcome.",
+			Buckets:   []float64{.05, .1, .25, .5, 1, 2.5, 5, 10},
+		}, []string{"provider", "outcome"}),
+		smsCircuitOpen: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "sms",
+			Name:      "circuit_open",
+			Help:      "Whether the circuit breaker of an SMS provider is open.",
+		}, []string{"provider"}),
+		emailRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "email",
+			Name:      "send_total",
+			Help:      "Email send attempts by provider and outcome.",
+		}, []string{"provider", "outcome"}),
+		emailDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "email",
+			Name:      "send_duration_seconds",
+			Help:      "Email provider call latency by provider and outcome.",
+			Buckets:   []float64{.05, .1, .25, .5, 1, 2.5, 5, 10},
+		}, []string{"provider", "outcome"}),
+		ordersCreated: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "orders",
+			Name:      "created_total",
+			Help:      "Orders created.",
+		}),
+		orderValue: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "orders",
+			Name:      "value_total",
+			Help:      "Sum of the amounts of created orders.",
+		}),
+		orderAmount: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "orders",
+			Name:      "amount",
+			Help:      "Distribution of created order amounts.",
+			Buckets:   []float64{100, 250, 500, 1000, 2500, 5000, 10000, 25000, 50000, 100000},
+		}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.httpRequests,
+		m.httpDuration,
+		m.httpInFlight,
+		m.rateLimited,
+		m.smsRequests,
+		m.smsDuration,
+		m.smsCircuitOpen,
+		m.emailRequests,
+		m.emailDuration,
+		m.ordersCreated,
+		m.orderValue,
+		m.orderAmount,
+	)
+
+	return m
+}
+
+// RegisterDB exports the connection pool statistics of dB.
+func (m *Metrics) RegisterDB(dB *sql.DB) error {
+	return m.registry.Register(collectors.NewDBStatsCollector(dB, namespace))
+}
+
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+// HTTPRequestStarted tracks an in-flight request. The returned function must
+// be called once the response has been written.
+func (m *Metrics) HTTPRequestStarted() func(method, route string, status int) {
+
+	start := time.Now()
+	m.httpInFlight.Inc()
+
+	return func(method, route string, status int) {
+		m.httpInFlight.Dec()
+
+		statusLabel := strconv.Itoa(status)
+
+		m.httpRequests.WithLabelValues(method, route, statusLabel).Inc()
+		m.httpDuration.WithLabelValues(method, route, statusLabel).Observe(time.Since(start).Seconds())
+	}
+}
+
+func (m *Metrics) ObserveRateLimited(policy string) {
+	m.rateLimited.WithLabelValues(policy).Inc()
+}
+
+func (m *Metrics) ObserveSMS(provider, outcome string, duration time.Duration) {
+	m.smsRequests.WithLabelValues(provider, outcome).Inc()
+	m.smsDuration.WithLabelValues(provider, outcome).Observe(duration.Seconds())
+}
+
+// ObserveSMSCircuit records whether provider's circuit breaker is open.
+func (m *Metrics) ObserveSMSCircuit(provider string, open bool) {
+
+	value := 0.0
+	if open {
+		value = 1
+	}
+
+	m.smsCircuitOpen.WithLabelValues(provider).Set(value)
+}
+
+func (m *Metrics) ObserveEmail(provider, outcome string, duration time.Duration) {
+	m.emailRequests.WithLabelValues(provider, outcome).Inc()
+	m.emailDuration.WithLabelValues(provider, outcome).Observe(duration.Seconds())
+}
+
+func (m *Metrics) ObserveOrderCreated(amount float64) {
+	m.ordersCreated.Inc()
+	m.orderValue.Add(amount)
+	m.orderAmount.Observe(amount)
+}
@@ -0,0 +1,85 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ernestngugi/sil-backend/internal/model"
+	"github.com/ernestngugi/sil-backend/mocks"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+type failingSMSSender struct {
+	err error
+}
+
+func (p *failingSMSSender) Send(ctx context.Context, request *model.SMSMessage) error {
+	return p.err
+}
+
+func TestMetrics(t *testing.T) {
+
+	ctx := context.Background()
+
+	t.Run("counts sms sends by provider and outcome", func(t *testing.T) {
+
+		appMetrics := New()
+
+		sender := NewSMSSender("at", mocks.NewMockSMSSender(), appMetrics)
+		assert.NoError(t, sender.Send(ctx, &model.SMSMessage{Number: "254728389583", Message: "test"}))
+
+		failing := NewSMSSender("at", &failingSMSSender{err: errors.New("boom")}, appMetrics)
+		assert.Error(t, failing.Send(ctx, &model.SMSMessage{}))
+
+		timingOut := NewSMSSender("at", &failingSMSSender{err: context.DeadlineExceeded}, appMetrics)
+		assert.Error(t, timingOut.Send(ctx, &model.SMSMessage{}))
+
+		assert.Equal(t, 1.0, testutil.ToFloat64(appMetrics.smsRequests.WithLabelValues("at", SMSOutcomeSent)))
+		assert.Equal(t, 1.0, testutil.ToFloat64(appMetrics.smsRequests.WithLabelValues("at", SMSOutcomeFailed)))
+		assert.Equal(t, 1.0, testutil.ToFloat64(appMetrics.smsRequests.WithLabelValues("at", SMSOutcomeTimeout)))
+	})
+
+	t.Run("counts email sends by provider and outcome", func(t *testing.T) {
+
+		appMetrics := New()
+
+		sender := NewEmailSender("smtp", mocks.NewMockEmailSender(), appMetrics)
+		assert.NoError(t, sender.Send(ctx, &model.EmailMessage{To: "jane@example.com"}))
+
+		assert.Equal(t, 1.0, testutil.ToFloat64(appMetrics.emailRequests.WithLabelValues("smtp", SMSOutcomeSent)))
+	})
+
+	t.Run("records http requests and orders", func(t *testing.T) {
+
+		appMetrics := New()
+
+		done := appMetrics.HTTPRequestStarted()
+		assert.Equal(t, 1.0, testutil.ToFloat64(appMetrics.httpInFlight))
+
+		done(http.MethodPost, "/v1/orders", http.StatusOK)
+		assert.Equal(t, 0.0, testutil.ToFloat64(appMetrics.httpInFlight))
+
+		appMetrics.ObserveOrderCreated(150)
+		appMetrics.ObserveOrderCreated(50)
+
+		assert.Equal(t, 1.0, testutil.ToFloat64(appMetrics.httpRequests.WithLabelValues(http.MethodPost, "/v1/orders", "200")))
+		assert.Equal(t, 2.0, testutil.ToFloat64(appMetrics.ordersCreated))
+		assert.Equal(t, 200.0, testutil.ToFloat64(appMetrics.orderValue))
+	})
+
+	t.Run("serves the registry", func(t *testing.T) {
+
+		appMetrics := New()
+		appMetrics.ObserveOrderCreated(100)
+
+		w := httptest.NewRecorder()
+		appMetrics.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "sil_orders_created_total 1")
+	})
+}
@@ -0,0 +1,53 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net"
+	"time"
+
+	"github.com/ernestngugi/sil-backend/internal/model"
+	"github.com/ernestngugi/sil-backend/providers"
+)
+
+type smsSender struct {
+	provider string
+	next     providers.SMSSender
+	metrics  *Metrics
+}
+
+// NewSMSSender decorates any providers.SMSSender with send counters and
+// latency histograms labelled by provider and outcome.
+func NewSMSSender(provider string, next providers.SMSSender, metrics *Metrics) providers.SMSSender {
+	return &smsSender{
+		provider: provider,
+		next:     next,
+		metrics:  metrics,
+	}
+}
+
+func (p *smsSender) Send(ctx context.Context, request *model.SMSMessage) error {
+
+	start := time.Now()
+
+	err := p.next.Send(ctx, request)
+
+	p.metrics.ObserveSMS(p.provider, sendOutcome(err), time.Since(start))
+
+	return err
+}
+
+// sendOutcome classifies the result of a call to an SMS or email provider.
+func sendOutcome(err error) string {
+
+	if err == nil {
+		return SMSOutcomeSent
+	}
+
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return SMSOutcomeTimeout
+	}
+
+	return SMSOutcomeFailed
+}
@@ -0,0 +1,31 @@
+package model
+
+import (
+	"strconv"
+	"strings"
+)
+
+// FormatAmount formats amount with thousands separators and two decimals,
+// e.g. 1,250.00.
+func FormatAmount(amount float64) string {
+
+	formatted := strconv.FormatFloat(amount, 'f', 2, 64)
+
+	whole, fraction, _ := strings.Cut(formatted, ".")
+
+	sign := ""
+	if strings.HasPrefix(whole, "-") {
+		sign, whole = "-", whole[1:]
+	}
+
+	var grouped strings.Builder
+
+	for i, digit := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			grouped.WriteByte(',')
+		}
+		grouped.WriteRune(digit)
+	}
+
+	return sign + grouped.String() + "." + fraction
+}
@@ -0,0 +1,20 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatAmount(t *testing.T) {
+
+	for amount, expected := range map[float64]string{
+		0:          "0.00",
+		12.5:       "12.50",
+		1250:       "1,250.00",
+		1234567.89: "1,234,567.89",
+		-1000:      "-1,000.00",
+	} {
+		assert.Equal(t, expected, FormatAmount(amount))
+	}
+}
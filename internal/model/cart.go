@@ -0,0 +1,34 @@
+package model
+
+import "time"
+
+type (
+	// Cart is what a customer means to order, kept between sessions until
+	// they check out. Prices and totals are always the current ones.
+	Cart struct {
+		CustomerID int64       `json:"customer_id"`
+		Lines      []*CartLine `json:"lines"`
+		PromoCode  string      `json:"promo_code,omitempty"`
+		// PromoCodeError says why the promo code would not be applied
+		// if the customer checked out now.
+		PromoCodeError string     `json:"promo_code_error,omitempty"`
+		Subtotal       float64    `json:"subtotal"`
+		DiscountAmount float64    `json:"discount_amount"`
+		TaxAmount      float64    `json:"tax_amount"`
+		Total          float64    `json:"total"`
+		DateModified   *time.Time `json:"date_modified,omitempty"`
+	}
+
+	// CartLine is a quantity of a product in a cart. Available is false
+	// when the product has been withdrawn or there is not enough stock.
+	CartLine struct {
+		ProductID   int64   `json:"product_id"`
+		SKU         string  `json:"sku"`
+		Item        string  `json:"item"`
+		Quantity    int     `json:"quantity"`
+		UnitPrice   float64 `json:"unit_price"`
+		Amount      float64 `json:"amount"`
+		TaxCategory string  `json:"tax_category"`
+		Available   bool    `json:"available"`
+	}
+)
@@ -1,24 +1,38 @@
 package model
 
 import (
+	"fmt"
 	"time"
 
 	"syreclabs.com/go/faker"
 )
 
 type Customer struct {
-	ID           int64     `json:"id"`
-	Name         string    `json:"name"`
+	ID     int64  `json:"id"`
+	Name   string `json:"name"`
+	Locale string `json:"locale"`
+	// PhoneNumber is where SMS are sent, when the customer gave one.
+	PhoneNumber  string    `json:"phone_number,omitempty"`
 	DateCreated  time.Time `json:"date_created"`
 	DateModified time.Time `json:"date_modified"`
 }
 
+// Locales messages to customers are written in.
+const (
+	LocaleEnglish = "en"
+	LocaleSwahili = "sw"
+	DefaultLocale = LocaleEnglish
+)
+
+var Locales = []string{LocaleEnglish, LocaleSwahili}
+
 type CustomerKey string
 
 const CustomerKeyName CustomerKey = "customer_name"
 
 func BuildCustomer() *Customer {
 	return &Customer{
-		Name: faker.Internet().Email(),
+		Name:        faker.Internet().Email(),
+		PhoneNumber: fmt.Sprintf("2547%08d", faker.RandomInt(0, 99999999)),
 	}
 }
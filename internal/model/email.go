@@ -0,0 +1,10 @@
+package model
+
+// EmailMessage is an email with a plain text body and the same content as
+// HTML for clients that display it.
+type EmailMessage struct {
+	To      string
+	Subject string
+	Text    string
+	HTML    string
+}
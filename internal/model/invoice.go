@@ -0,0 +1,44 @@
+package model
+
+import "time"
+
+// InvoiceKind is the document issued for an order. Invoices and receipts are
+// numbered in separate series.
+type InvoiceKind string
+
+const (
+	InvoiceKindInvoice InvoiceKind = "invoice"
+	InvoiceKindReceipt InvoiceKind = "receipt"
+)
+
+// Invoice is an issued tax invoice or receipt. Everything printed on it is
+// kept as issued, so the document reads the same every time it is rendered.
+type Invoice struct {
+	ID               int64          `json:"id"`
+	Kind             InvoiceKind    `json:"kind"`
+	Number           string         `json:"number"`
+	OrderID          int64          `json:"order_id"`
+	PaymentID        *int64         `json:"payment_id,omitempty"`
+	CustomerName     string         `json:"customer_name"`
+	Lines            []*InvoiceLine `json:"lines"`
+	NetAmount        float64        `json:"net_amount"`
+	TaxAmount        float64        `json:"tax_amount"`
+	GrossAmount      float64        `json:"gross_amount"`
+	PaymentReference string         `json:"payment_reference,omitempty"`
+	PaymentPhone     string         `json:"payment_phone,omitempty"`
+	DatePaid         *time.Time     `json:"date_paid,omitempty"`
+	DateIssued       time.Time      `json:"date_issued"`
+}
+
+// InvoiceLine is one item on an invoice. Amounts are for the whole line.
+type InvoiceLine struct {
+	Description  string       `json:"description"`
+	Quantity     int          `json:"quantity"`
+	UnitPrice    float64      `json:"unit_price"`
+	TaxCategory  string       `json:"tax_category,omitempty"`
+	TaxTreatment TaxTreatment `json:"tax_treatment,omitempty"`
+	TaxRate      float64      `json:"tax_rate"`
+	NetAmount    float64      `json:"net_amount"`
+	TaxAmount    float64      `json:"tax_amount"`
+	GrossAmount  float64      `json:"gross_amount"`
+}
@@ -0,0 +1,47 @@
+package model
+
+import "time"
+
+type LoyaltyEntryKind string
+
+const (
+	// LoyaltyEarned credits the points a paid order earned.
+	LoyaltyEarned LoyaltyEntryKind = "earned"
+	// LoyaltyRedeemed debits the points spent on an order.
+	LoyaltyRedeemed LoyaltyEntryKind = "redeemed"
+	// LoyaltyReversed debits the points earned by an order that was
+	// refunded or cancelled.
+	LoyaltyReversed LoyaltyEntryKind = "reversed"
+	// LoyaltyReturned credits back the points spent on an order that was
+	// refunded or cancelled.
+	LoyaltyReturned LoyaltyEntryKind = "returned"
+	// LoyaltyExpired debits points that were not spent in time.
+	LoyaltyExpired LoyaltyEntryKind = "expired"
+)
+
+type (
+	// LoyaltyEntry is one line of a customer's loyalty ledger. Credits have
+	// positive points and an expiry, debits have negative points. Entries
+	// are never changed once written, so a balance is the sum of the
+	// entries.
+	LoyaltyEntry struct {
+		ID         int64            `json:"id"`
+		CustomerID int64            `json:"customer_id"`
+		OrderID    *int64           `json:"order_id,omitempty"`
+		Kind       LoyaltyEntryKind `json:"kind"`
+		Points     int              `json:"points"`
+		// Amount is the shillings taken off the order points were
+		// redeemed on.
+		Amount      float64    `json:"amount,omitempty"`
+		ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+		DateCreated time.Time  `json:"date_created"`
+	}
+
+	// LoyaltyAccount is a customer's points balance, what the points are
+	// worth, and their latest ledger entries.
+	LoyaltyAccount struct {
+		Balance int             `json:"balance"`
+		Value   float64         `json:"value"`
+		History []*LoyaltyEntry `json:"history"`
+	}
+)
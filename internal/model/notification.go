@@ -0,0 +1,71 @@
+package model
+
+import "time"
+
+// NotificationChannel is how a customer wants to hear about an event.
+type NotificationChannel string
+
+const (
+	NotificationChannelSMS   NotificationChannel = "sms"
+	NotificationChannelEmail NotificationChannel = "email"
+	NotificationChannelNone  NotificationChannel = "none"
+
+	DefaultNotificationChannel = NotificationChannelSMS
+)
+
+// DefaultTimeZone is used for quiet hours set without a time zone.
+const DefaultTimeZone = "Africa/Nairobi"
+
+// SMSSuppressionOptOut marks numbers that replied STOP to a message.
+const SMSSuppressionOptOut = "opt_out"
+
+// urgentNotifications are sent during quiet hours: the customer has just
+// placed the order, a rider is on the way with it, or their money is
+// affected.
+var urgentNotifications = map[NotificationEvent]bool{
+	NotificationOrderCreated:   true,
+	NotificationRiderAssigned:  true,
+	NotificationOrderCancelled: true,
+	NotificationOrderRefunded:  true,
+	NotificationOrderPaid:      true,
+}
+
+// NotificationPreferences are the channels a customer chose per event, the
+// number SMS go to and the hours they do not want to be disturbed.
+type NotificationPreferences struct {
+	Channels    map[NotificationEvent]NotificationChannel `json:"channels"`
+	PhoneNumber string                                    `json:"phone_number,omitempty"`
+	QuietHours  *QuietHours                               `json:"quiet_hours"`
+}
+
+// QuietHours run from Start to End, both "HH:MM" in TimeZone, and may span
+// midnight.
+type QuietHours struct {
+	Start    string `json:"start"`
+	End      string `json:"end"`
+	TimeZone string `json:"time_zone"`
+}
+
+// SMSSuppression is a number nothing may be sent to.
+type SMSSuppression struct {
+	PhoneNumber string    `json:"phone_number"`
+	Reason      string    `json:"reason"`
+	DateCreated time.Time `json:"date_created"`
+}
+
+// Urgent reports whether messages about e are sent during quiet hours.
+func (e NotificationEvent) Urgent() bool {
+	return urgentNotifications[e]
+}
+
+// Channel returns the channel for event, SMS unless the customer chose
+// otherwise.
+func (p *NotificationPreferences) Channel(event NotificationEvent) NotificationChannel {
+
+	channel, ok := p.Channels[event]
+	if !ok {
+		return DefaultNotificationChannel
+	}
+
+	return channel
+}
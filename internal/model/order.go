@@ -2,15 +2,63 @@ package model
 
 import "time"
 
+type OrderStatus string
+
+const (
+	OrderStatusPending    OrderStatus = "pending"
+	OrderStatusConfirmed  OrderStatus = "confirmed"
+	OrderStatusDispatched OrderStatus = "dispatched"
+	OrderStatusDelivered  OrderStatus = "delivered"
+	OrderStatusCancelled  OrderStatus = "cancelled"
+)
+
+// orderTransitions lists the statuses an order may move to from each status.
+// Delivered and cancelled orders are final.
+var orderTransitions = map[OrderStatus][]OrderStatus{
+	OrderStatusPending:    {OrderStatusConfirmed, OrderStatusCancelled},
+	OrderStatusConfirmed:  {OrderStatusDispatched, OrderStatusCancelled},
+	OrderStatusDispatched: {OrderStatusDelivered, OrderStatusCancelled},
+}
+
+// Order is a customer's order. Amount is the gross amount the customer
+// pays, including any VAT and after any promo code discount and loyalty
+// points redeemed.
 type Order struct {
-	ID          int64     `json:"id"`
-	Amount      float64   `json:"amount"`
-	CustomerID  int64     `json:"customer_id"`
-	Item        string    `json:"item"`
-	DateCreated time.Time `json:"date_created"`
+	ID             int64         `json:"id"`
+	Amount         float64       `json:"amount"`
+	Tax            OrderTax      `json:"tax"`
+	PromoCode      string        `json:"promo_code,omitempty"`
+	DiscountAmount float64       `json:"discount_amount"`
+	LoyaltyPoints  int           `json:"loyalty_points,omitempty"`
+	LoyaltyAmount  float64       `json:"loyalty_amount,omitempty"`
+	CustomerID     int64         `json:"customer_id"`
+	Item           string        `json:"item"`
+	Status         OrderStatus   `json:"status"`
+	PaymentStatus  PaymentStatus `json:"payment_status"`
+	DateCreated    time.Time     `json:"date_created"`
+	// Lines are the products of an order placed from a cart. Orders
+	// placed directly have none.
+	Lines []*OrderLine `json:"lines,omitempty"`
+}
+
+// OrderLine is a product on an order. Amount and Tax are for the whole line,
+// after its share of any discounts.
+type OrderLine struct {
+	ProductID      int64    `json:"product_id"`
+	Item           string   `json:"item"`
+	Quantity       int      `json:"quantity"`
+	UnitPrice      float64  `json:"unit_price"`
+	DiscountAmount float64  `json:"discount_amount"`
+	Amount         float64  `json:"amount"`
+	Tax            OrderTax `json:"tax"`
 }
 
-type ATRequest struct {
-	Number  string
-	Message string
+// CanTransitionTo reports whether an order in status s may move to next.
+func (s OrderStatus) CanTransitionTo(next OrderStatus) bool {
+	for _, allowed := range orderTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
 }
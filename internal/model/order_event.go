@@ -0,0 +1,20 @@
+package model
+
+import "time"
+
+// OrderEvent records an order reaching a status, starting with its creation.
+type OrderEvent struct {
+	ID             int64       `json:"id"`
+	OrderID        int64       `json:"order_id"`
+	CustomerID     int64       `json:"customer_id"`
+	Status         OrderStatus `json:"status"`
+	PreviousStatus OrderStatus `json:"previous_status,omitempty"`
+	DateCreated    time.Time   `json:"date_created"`
+}
+
+// OrderEventFilter selects the events of a customer's orders, or of one of
+// them when OrderID is set.
+type OrderEventFilter struct {
+	CustomerID int64
+	OrderID    int64
+}
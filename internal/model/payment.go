@@ -0,0 +1,70 @@
+package model
+
+import "time"
+
+// PaymentStatus tracks the money for an order. Payments themselves are only
+// ever pending, paid or failed.
+type PaymentStatus string
+
+const (
+	PaymentStatusUnpaid  PaymentStatus = "unpaid"
+	PaymentStatusPending PaymentStatus = "pending"
+	PaymentStatusPaid    PaymentStatus = "paid"
+	PaymentStatusFailed  PaymentStatus = "failed"
+	// PaymentStatusRefunded is only ever set on orders, once the money for a
+	// paid order has been returned.
+	PaymentStatusRefunded PaymentStatus = "refunded"
+)
+
+// STKResultSuccess is the M-Pesa result code of a completed payment.
+const STKResultSuccess = 0
+
+// MinPaymentAmount is the least M-Pesa collects, in shillings. Discounts
+// never take an order below it.
+const MinPaymentAmount = 1.0
+
+// Payment is one M-Pesa STK Push prompt sent to a customer for an order.
+type Payment struct {
+	ID                int64         `json:"id"`
+	OrderID           int64         `json:"order_id"`
+	PhoneNumber       string        `json:"phone_number"`
+	Amount            float64       `json:"amount"`
+	Status            PaymentStatus `json:"status"`
+	MerchantRequestID string        `json:"merchant_request_id,omitempty"`
+	CheckoutRequestID string        `json:"checkout_request_id,omitempty"`
+	ResultCode        *int          `json:"result_code,omitempty"`
+	ResultDescription string        `json:"result_description,omitempty"`
+	ReceiptNumber     string        `json:"receipt_number,omitempty"`
+	DateCreated       time.Time     `json:"date_created"`
+	DateCompleted     *time.Time    `json:"date_completed,omitempty"`
+}
+
+// STKPushRequest asks a customer to approve a payment on their phone.
+// Amount is in whole shillings.
+type STKPushRequest struct {
+	PhoneNumber      string
+	Amount           int64
+	AccountReference string
+	Description      string
+}
+
+// STKPushResponse identifies an accepted STK Push in its callback and status
+// queries.
+type STKPushResponse struct {
+	MerchantRequestID string
+	CheckoutRequestID string
+	CustomerMessage   string
+}
+
+// PaymentResult is the outcome of an STK Push, from its callback or a status
+// query. Only callbacks of successful payments report the amount and the
+// number that paid.
+type PaymentResult struct {
+	MerchantRequestID string
+	CheckoutRequestID string
+	ResultCode        int
+	ResultDescription string
+	ReceiptNumber     string
+	Amount            float64
+	PhoneNumber       string
+}
@@ -0,0 +1,17 @@
+package model
+
+import "time"
+
+// Product is something customers can add to their cart. Price is per unit
+// and includes any VAT, like order amounts.
+type Product struct {
+	ID           int64     `json:"id"`
+	SKU          string    `json:"sku"`
+	Name         string    `json:"name"`
+	Price        float64   `json:"price"`
+	TaxCategory  string    `json:"tax_category"`
+	Stock        int       `json:"stock"`
+	Active       bool      `json:"active"`
+	DateCreated  time.Time `json:"date_created"`
+	DateModified time.Time `json:"date_modified"`
+}
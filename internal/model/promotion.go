@@ -0,0 +1,64 @@
+package model
+
+import "time"
+
+type DiscountType string
+
+const (
+	// DiscountPercentage takes DiscountValue percent off the order.
+	DiscountPercentage DiscountType = "percentage"
+	// DiscountFixed takes DiscountValue shillings off the order.
+	DiscountFixed DiscountType = "fixed"
+)
+
+type (
+	// Promotion is a promo code customers can apply when ordering. A
+	// promotion naming items or tax categories only applies to orders for
+	// one of them; one naming neither applies to every order.
+	Promotion struct {
+		ID             int64        `json:"id"`
+		Code           string       `json:"code"`
+		Description    string       `json:"description"`
+		DiscountType   DiscountType `json:"discount_type"`
+		DiscountValue  float64      `json:"discount_value"`
+		MinOrderAmount float64      `json:"min_order_amount"`
+		Items          []string     `json:"items"`
+		TaxCategories  []string     `json:"tax_categories"`
+		StartsAt       *time.Time   `json:"starts_at,omitempty"`
+		EndsAt         *time.Time   `json:"ends_at,omitempty"`
+		// UsageLimit caps redemptions across all customers.
+		UsageLimit *int `json:"usage_limit,omitempty"`
+		// PerCustomerLimit caps redemptions by any one customer.
+		PerCustomerLimit *int      `json:"per_customer_limit,omitempty"`
+		TimesRedeemed    int       `json:"times_redeemed"`
+		Active           bool      `json:"active"`
+		DateCreated      time.Time `json:"date_created"`
+		DateModified     time.Time `json:"date_modified"`
+	}
+
+	// PromotionRedemption records a promo code applied to an order.
+	// OrderAmount is what the customer was charged after the discount.
+	PromotionRedemption struct {
+		ID             int64     `json:"id"`
+		PromotionID    int64     `json:"promotion_id"`
+		OrderID        int64     `json:"order_id"`
+		CustomerID     int64     `json:"customer_id"`
+		DiscountAmount float64   `json:"discount_amount"`
+		OrderAmount    float64   `json:"order_amount"`
+		DateCreated    time.Time `json:"date_created"`
+	}
+
+	// PromotionReport totals the redemptions of a promotion.
+	PromotionReport struct {
+		PromotionID    int64      `json:"promotion_id"`
+		Code           string     `json:"code"`
+		Active         bool       `json:"active"`
+		Redemptions    int        `json:"redemptions"`
+		Customers      int        `json:"customers"`
+		PaidOrders     int        `json:"paid_orders"`
+		DiscountAmount float64    `json:"discount_amount"`
+		OrderAmount    float64    `json:"order_amount"`
+		FirstRedeemed  *time.Time `json:"first_redeemed,omitempty"`
+		LastRedeemed   *time.Time `json:"last_redeemed,omitempty"`
+	}
+)
@@ -0,0 +1,75 @@
+package model
+
+import "time"
+
+// ReconciliationStatus is how a statement transaction compares with our
+// orders.
+type ReconciliationStatus string
+
+const (
+	ReconciliationMatched        ReconciliationStatus = "matched"
+	ReconciliationUnmatched      ReconciliationStatus = "unmatched"
+	ReconciliationDuplicate      ReconciliationStatus = "duplicate"
+	ReconciliationAmountMismatch ReconciliationStatus = "amount_mismatch"
+)
+
+// StatementProviderMPesa is the only statement format understood so far.
+const StatementProviderMPesa = "mpesa"
+
+// StatementTransaction is money received according to a provider statement.
+type StatementTransaction struct {
+	Line             int
+	ReceiptNumber    string
+	CompletedAt      time.Time
+	Amount           float64
+	PhoneNumber      string
+	AccountReference string
+}
+
+// ReconciliationRun is one imported statement and the tally of its entries.
+type ReconciliationRun struct {
+	ID               int64     `json:"id"`
+	Provider         string    `json:"provider"`
+	FileName         string    `json:"file_name"`
+	StatementSHA256  string    `json:"statement_sha256"`
+	Transactions     int       `json:"transactions"`
+	Matched          int       `json:"matched"`
+	Unmatched        int       `json:"unmatched"`
+	Duplicates       int       `json:"duplicates"`
+	AmountMismatches int       `json:"amount_mismatches"`
+	DateCreated      time.Time `json:"date_created"`
+}
+
+// ReconciliationEntry is the outcome of matching one statement transaction.
+type ReconciliationEntry struct {
+	ID               int64                `json:"id"`
+	RunID            int64                `json:"run_id"`
+	Line             int                  `json:"line"`
+	ReceiptNumber    string               `json:"receipt_number"`
+	CompletedAt      time.Time            `json:"completed_at"`
+	Amount           float64              `json:"amount"`
+	PhoneNumber      string               `json:"phone_number"`
+	AccountReference string               `json:"account_reference"`
+	Status           ReconciliationStatus `json:"status"`
+	OrderID          *int64               `json:"order_id,omitempty"`
+	PaymentID        *int64               `json:"payment_id,omitempty"`
+	ExpectedAmount   *float64             `json:"expected_amount,omitempty"`
+	Note             string               `json:"note,omitempty"`
+}
+
+// Tally counts entry in the run's totals.
+func (r *ReconciliationRun) Tally(entry *ReconciliationEntry) {
+
+	r.Transactions++
+
+	switch entry.Status {
+	case ReconciliationMatched:
+		r.Matched++
+	case ReconciliationUnmatched:
+		r.Unmatched++
+	case ReconciliationDuplicate:
+		r.Duplicates++
+	case ReconciliationAmountMismatch:
+		r.AmountMismatches++
+	}
+}
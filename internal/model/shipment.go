@@ -0,0 +1,78 @@
+package model
+
+import "time"
+
+type ShipmentStatus string
+
+const (
+	// ShipmentAssigned is a shipment handed to a rider.
+	ShipmentAssigned ShipmentStatus = "assigned"
+	// ShipmentInTransit is a shipment on its way to the customer.
+	ShipmentInTransit ShipmentStatus = "in_transit"
+	// ShipmentAttempted is a shipment the rider could not deliver on their
+	// last attempt.
+	ShipmentAttempted ShipmentStatus = "attempted"
+	// ShipmentDelivered is a shipment handed to the customer.
+	ShipmentDelivered ShipmentStatus = "delivered"
+)
+
+// shipmentTransitions lists the statuses a shipment may move to from each
+// status. Delivered shipments are final.
+var shipmentTransitions = map[ShipmentStatus][]ShipmentStatus{
+	ShipmentAssigned:  {ShipmentInTransit, ShipmentAttempted, ShipmentDelivered},
+	ShipmentInTransit: {ShipmentInTransit, ShipmentAttempted, ShipmentDelivered},
+	ShipmentAttempted: {ShipmentInTransit, ShipmentAttempted, ShipmentDelivered},
+}
+
+type (
+	// Shipment is the delivery of an order. The customer is sent a delivery
+	// code when a rider is assigned, which the rider collects as proof of
+	// delivery.
+	Shipment struct {
+		ID                int64            `json:"id"`
+		OrderID           int64            `json:"order_id"`
+		Carrier           string           `json:"carrier"`
+		TrackingNumber    string           `json:"tracking_number,omitempty"`
+		RiderName         string           `json:"rider_name"`
+		RiderPhone        string           `json:"rider_phone"`
+		EstimatedDelivery *time.Time       `json:"estimated_delivery,omitempty"`
+		Status            ShipmentStatus   `json:"status"`
+		RecipientName     string           `json:"recipient_name,omitempty"`
+		DateDelivered     *time.Time       `json:"date_delivered,omitempty"`
+		Events            []*ShipmentEvent `json:"events"`
+		DateCreated       time.Time        `json:"date_created"`
+		DateModified      time.Time        `json:"date_modified"`
+		// CodeHash is the SHA-256 of the delivery code. The code itself is
+		// only ever sent to the customer.
+		CodeHash     string `json:"-"`
+		CodeAttempts int    `json:"-"`
+	}
+
+	// ShipmentEvent is a step of a shipment's journey, in the order they
+	// were recorded.
+	ShipmentEvent struct {
+		ID          int64          `json:"id"`
+		Status      ShipmentStatus `json:"status"`
+		Description string         `json:"description,omitempty"`
+		Location    string         `json:"location,omitempty"`
+		DateCreated time.Time      `json:"date_created"`
+	}
+
+	// OrderTracking is where a customer's order is. Shipment is left out
+	// until a rider is assigned.
+	OrderTracking struct {
+		OrderID  int64       `json:"order_id"`
+		Status   OrderStatus `json:"status"`
+		Shipment *Shipment   `json:"shipment,omitempty"`
+	}
+)
+
+// CanTransitionTo reports whether a shipment in status s may move to next.
+func (s ShipmentStatus) CanTransitionTo(next ShipmentStatus) bool {
+	for _, allowed := range shipmentTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,73 @@
+package model
+
+import "time"
+
+// NotificationEvent is something customers are sent a message about.
+type NotificationEvent string
+
+const (
+	NotificationOrderCreated    NotificationEvent = "order.created"
+	NotificationOrderDispatched NotificationEvent = "order.dispatched"
+	NotificationOrderCancelled  NotificationEvent = "order.cancelled"
+	NotificationOrderRefunded   NotificationEvent = "order.refunded"
+	NotificationOrderPaid       NotificationEvent = "order.paid"
+	NotificationCartAbandoned   NotificationEvent = "cart.abandoned"
+	NotificationRiderAssigned   NotificationEvent = "order.rider_assigned"
+	NotificationOrderDelivered  NotificationEvent = "order.delivered"
+)
+
+var NotificationEvents = []NotificationEvent{
+	NotificationOrderCreated,
+	NotificationOrderDispatched,
+	NotificationOrderCancelled,
+	NotificationOrderRefunded,
+	NotificationOrderPaid,
+	NotificationCartAbandoned,
+	NotificationRiderAssigned,
+	NotificationOrderDelivered,
+}
+
+// SMSMessage is a text message to a phone number in international format,
+// with or without the leading plus.
+type SMSMessage struct {
+	Number  string
+	Message string
+	// Transactional messages, such as delivery codes, are sent even to
+	// numbers that opted out of SMS.
+	Transactional bool
+}
+
+// OutboxSMS is a message claimed from the SMS outbox. It is removed from the
+// outbox once it has been sent.
+type OutboxSMS struct {
+	ID      int64
+	Request *SMSMessage
+}
+
+// SMSTemplate is the text sent for an event in a locale. Custom templates
+// were edited by an admin and replace the built in ones.
+type SMSTemplate struct {
+	Event        NotificationEvent `json:"event"`
+	Locale       string            `json:"locale"`
+	Body         string            `json:"body"`
+	Custom       bool              `json:"custom"`
+	DateModified *time.Time        `json:"date_modified,omitempty"`
+}
+
+// SMSEncoding is the character set a message is sent in, which decides how
+// many characters fit in a segment.
+type SMSEncoding string
+
+const (
+	SMSEncodingGSM7 SMSEncoding = "GSM-7"
+	SMSEncodingUCS2 SMSEncoding = "UCS-2"
+)
+
+// SMSPreview is a rendered message and what sending it costs.
+type SMSPreview struct {
+	Text       string      `json:"text"`
+	Encoding   SMSEncoding `json:"encoding"`
+	Characters int         `json:"characters"`
+	Segments   int         `json:"segments"`
+	Warnings   []string    `json:"warnings"`
+}
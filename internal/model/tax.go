@@ -0,0 +1,40 @@
+package model
+
+// TaxTreatment is how VAT applies to a product category. Zero rated goods
+// are taxable at 0%, while exempt goods are outside VAT altogether, which is
+// why invoices list the two separately.
+type TaxTreatment string
+
+const (
+	TaxTreatmentStandard  TaxTreatment = "standard"
+	TaxTreatmentZeroRated TaxTreatment = "zero_rated"
+	TaxTreatmentExempt    TaxTreatment = "exempt"
+)
+
+// TaxCategory is a product category and the VAT charged on it.
+type TaxCategory struct {
+	Code      string       `json:"code"`
+	Treatment TaxTreatment `json:"treatment"`
+	Rate      float64      `json:"rate"`
+}
+
+// OrderTax is the VAT on an order or order line, worked out when it was
+// placed. The gross amount is what the customer pays. An order whose lines
+// are in different categories has no category, treatment or rate of its own.
+type OrderTax struct {
+	Category    string       `json:"category"`
+	Treatment   TaxTreatment `json:"treatment"`
+	Rate        float64      `json:"rate"`
+	NetAmount   float64      `json:"net_amount"`
+	TaxAmount   float64      `json:"tax_amount"`
+	GrossAmount float64      `json:"gross_amount"`
+}
+
+// TaxSummary totals the lines of an invoice taxed the same way.
+type TaxSummary struct {
+	Treatment   TaxTreatment `json:"treatment"`
+	Rate        float64      `json:"rate"`
+	NetAmount   float64      `json:"net_amount"`
+	TaxAmount   float64      `json:"tax_amount"`
+	GrossAmount float64      `json:"gross_amount"`
+}
@@ -0,0 +1,60 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	EventOrderCreated       = "order.created"
+	EventOrderStatusChanged = "order.status_changed"
+)
+
+// EventTypes are the events webhook subscriptions can be notified of.
+var EventTypes = []string{EventOrderCreated, EventOrderStatusChanged}
+
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryPending   WebhookDeliveryStatus = "pending"
+	WebhookDeliverySucceeded WebhookDeliveryStatus = "succeeded"
+	WebhookDeliveryFailed    WebhookDeliveryStatus = "failed"
+)
+
+type (
+	WebhookSubscription struct {
+		ID  int64  `json:"id"`
+		URL string `json:"url"`
+		// Secret is only returned when the subscription is created.
+		Secret              string     `json:"secret,omitempty"`
+		EventTypes          []string   `json:"event_types"`
+		Active              bool       `json:"active"`
+		ConsecutiveFailures int        `json:"consecutive_failures"`
+		DateDisabled        *time.Time `json:"date_disabled,omitempty"`
+		DateCreated         time.Time  `json:"date_created"`
+		DateModified        time.Time  `json:"date_modified"`
+	}
+
+	WebhookDelivery struct {
+		ID             int64                 `json:"id"`
+		SubscriptionID int64                 `json:"subscription_id"`
+		EventID        string                `json:"event_id"`
+		EventType      string                `json:"event_type"`
+		Payload        json.RawMessage       `json:"payload"`
+		Status         WebhookDeliveryStatus `json:"status"`
+		Attempts       int                   `json:"attempts"`
+		NextAttemptAt  time.Time             `json:"next_attempt_at"`
+		LastStatusCode *int                  `json:"last_status_code,omitempty"`
+		LastError      *string               `json:"last_error,omitempty"`
+		DateCreated    time.Time             `json:"date_created"`
+		DateDelivered  *time.Time            `json:"date_delivered,omitempty"`
+	}
+
+	// WebhookEvent is the body posted to subscribers.
+	WebhookEvent struct {
+		ID          string    `json:"id"`
+		Type        string    `json:"type"`
+		DateCreated time.Time `json:"date_created"`
+		Data        any       `json:"data"`
+	}
+)
@@ -0,0 +1,76 @@
+package notifications
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/ernestngugi/sil-backend/internal/model"
+	"github.com/ernestngugi/sil-backend/providers"
+)
+
+var ErrEmailDispatcherClosed = errors.New("email dispatcher is shut down")
+
+type (
+	// EmailDispatcher sends email in the background so request handlers do
+	// not wait on the mail server.
+	EmailDispatcher interface {
+		Dispatch(ctx context.Context, message *model.EmailMessage) error
+		Shutdown(ctx context.Context) error
+	}
+
+	emailDispatcher struct {
+		emailSender providers.EmailSender
+		logger      *slog.Logger
+		pool        *workerPool[*queuedEmail]
+	}
+
+	queuedEmail struct {
+		// ctx carries request-scoped values such as the request ID but is
+		// detached from the request's cancellation.
+		ctx     context.Context
+		message *model.EmailMessage
+	}
+)
+
+func NewEmailDispatcher(emailSender providers.EmailSender, logger *slog.Logger) EmailDispatcher {
+
+	dispatcher := &emailDispatcher{
+		emailSender: emailSender,
+		logger:      logger,
+	}
+
+	dispatcher.pool = newWorkerPool(defaultQueueSize, defaultWorkers, dispatcher.send)
+
+	return dispatcher
+}
+
+// Dispatch queues an email for delivery. It blocks when the queue is full.
+func (d *emailDispatcher) Dispatch(ctx context.Context, message *model.EmailMessage) error {
+
+	if !d.pool.submit(&queuedEmail{ctx: context.WithoutCancel(ctx), message: message}) {
+		return ErrEmailDispatcherClosed
+	}
+
+	return nil
+}
+
+// Shutdown stops accepting email and waits for the queue to drain. Unlike SMS,
+// email still queued when ctx is done is dropped and logged.
+func (d *emailDispatcher) Shutdown(ctx context.Context) error {
+
+	dropped := d.pool.shutdown(ctx)
+	if len(dropped) > 0 {
+		d.logger.WarnContext(ctx, "dropped unsent email", slog.Int("count", len(dropped)))
+	}
+
+	return nil
+}
+
+func (d *emailDispatcher) send(queued *queuedEmail) {
+
+	err := d.emailSender.Send(queued.ctx, queued.message)
+	if err != nil {
+		d.logger.ErrorContext(queued.ctx, "failed to send email", slog.String("error", err.Error()))
+	}
+}
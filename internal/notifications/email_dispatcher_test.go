@@ -0,0 +1,44 @@
+package notifications
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ernestngugi/sil-backend/internal/logging"
+	"github.com/ernestngugi/sil-backend/internal/model"
+	"github.com/ernestngugi/sil-backend/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEmailDispatcher(t *testing.T) {
+
+	ctx := context.Background()
+
+	t.Run("sends queued email before shutdown returns", func(t *testing.T) {
+
+		emailSender := mocks.NewMockEmailSender()
+
+		dispatcher := NewEmailDispatcher(emailSender, logging.Discard())
+
+		for i := 0; i < 10; i++ {
+			err := dispatcher.Dispatch(ctx, &model.EmailMessage{To: "jane@example.com", Subject: "test"})
+			assert.NoError(t, err)
+		}
+
+		err := dispatcher.Shutdown(ctx)
+		assert.NoError(t, err)
+
+		assert.Len(t, emailSender.Sent(), 10)
+	})
+
+	t.Run("rejects email after shutdown", func(t *testing.T) {
+
+		dispatcher := NewEmailDispatcher(mocks.NewMockEmailSender(), logging.Discard())
+
+		err := dispatcher.Shutdown(ctx)
+		assert.NoError(t, err)
+
+		err = dispatcher.Dispatch(ctx, &model.EmailMessage{To: "jane@example.com", Subject: "test"})
+		assert.Equal(t, ErrEmailDispatcherClosed, err)
+	})
+}
@@ -0,0 +1,233 @@
+package notifications
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"strings"
+	"text/template"
+
+	"github.com/ernestngugi/sil-backend/internal/model"
+)
+
+//go:embed email
+var emailLayouts embed.FS
+
+var (
+	htmlEmailLayout = htmltemplate.Must(htmltemplate.ParseFS(emailLayouts, "email/layout.html"))
+	textEmailLayout = template.Must(template.ParseFS(emailLayouts, "email/layout.txt"))
+)
+
+type (
+	// emailContent is what an email says about an event. Each field is a
+	// text template using the same variables as SMS templates.
+	emailContent struct {
+		Subject string
+		Heading string
+		Body    string
+	}
+
+	emailLabels struct {
+		Order  string
+		Item   string
+		Amount string
+		Footer string
+	}
+
+	emailView struct {
+		Locale  string
+		Subject string
+		Heading string
+		Body    string
+		Labels  emailLabels
+		Data    *TemplateData
+	}
+)
+
+var defaultEmailContent = map[model.NotificationEvent]map[string]emailContent{
+	model.NotificationOrderCreated: {
+		model.LocaleEnglish: {
+			Subject: "We have received your order {{.OrderID}}",
+			Heading: "Thank you for your order",
+			Body:    "Your order for {{.Item}} has been received and we will let you know when it is on its way.",
+		},
+		model.LocaleSwahili: {
+			Subject: "Tumepokea oda yako {{.OrderID}}",
+			Heading: "Asante kwa oda yako",
+			Body:    "Oda yako ya {{.Item}} imepokelewa na tutakujulisha ikitumwa.",
+		},
+	},
+	model.NotificationOrderDispatched: {
+		model.LocaleEnglish: {
+			Subject: "Your order {{.OrderID}} is on its way",
+			Heading: "Your order is on its way",
+			Body:    "Your order for {{.Item}} has been dispatched.",
+		},
+		model.LocaleSwahili: {
+			Subject: "Oda yako {{.OrderID}} iko njiani",
+			Heading: "Oda yako iko njiani",
+			Body:    "Oda yako ya {{.Item}} imetumwa.",
+		},
+	},
+	model.NotificationOrderCancelled: {
+		model.LocaleEnglish: {
+			Subject: "Your order {{.OrderID}} has been cancelled",
+			Heading: "Your order has been cancelled",
+			Body:    "Your order for {{.Item}} has been cancelled. Contact us if you did not expect this.",
+		},
+		model.LocaleSwahili: {
+			Subject: "Oda yako {{.OrderID}} imeghairiwa",
+			Heading: "Oda yako imeghairiwa",
+			Body:    "Oda yako ya {{.Item}} imeghairiwa. Wasiliana nasi kama hukutarajia hili.",
+		},
+	},
+	model.NotificationOrderRefunded: {
+		model.LocaleEnglish: {
+			Subject: "KES {{.Amount}} refunded for order {{.OrderID}}",
+			Heading: "Your refund is on its way",
+			Body:    "We have refunded KES {{.Amount}} for your order for {{.Item}}.",
+		},
+		model.LocaleSwahili: {
+			Subject: "KES {{.Amount}} zimerejeshwa kwa oda {{.OrderID}}",
+			Heading: "Pesa zako zimerejeshwa",
+			Body:    "Tumerejesha KES {{.Amount}} za oda yako ya {{.Item}}.",
+		},
+	},
+	model.NotificationOrderPaid: {
+		model.LocaleEnglish: {
+			Subject: "Payment received for order {{.OrderID}}",
+			Heading: "Thank you for your payment",
+			Body:    "We have received KES {{.Amount}} for your order for {{.Item}}.{{if .Receipt}} Your M-Pesa receipt number is {{.Receipt}}.{{end}}",
+		},
+		model.LocaleSwahili: {
+			Subject: "Malipo ya oda {{.OrderID}} yamepokelewa",
+			Heading: "Asante kwa malipo yako",
+			Body:    "Tumepokea KES {{.Amount}} za oda yako ya {{.Item}}.{{if .Receipt}} Nambari ya risiti yako ya M-Pesa ni {{.Receipt}}.{{end}}",
+		},
+	},
+	model.NotificationRiderAssigned: {
+		model.LocaleEnglish: {
+			Subject: "A rider is delivering your order {{.OrderID}}",
+			Heading: "Your order is on its way",
+			Body:    "{{.RiderName}} ({{.RiderPhone}}) is delivering your order for {{.Item}}. Give the rider the code {{.DeliveryCode}} when you receive it.",
+		},
+		model.LocaleSwahili: {
+			Subject: "Msafirishaji analeta oda yako {{.OrderID}}",
+			Heading: "Oda yako iko njiani",
+			Body:    "{{.RiderName}} ({{.RiderPhone}}) analeta oda yako ya {{.Item}}. Mpe msafirishaji nambari {{.DeliveryCode}} ukiipokea.",
+		},
+	},
+	model.NotificationOrderDelivered: {
+		model.LocaleEnglish: {
+			Subject: "Your order {{.OrderID}} has been delivered",
+			Heading: "Your order has been delivered",
+			Body:    "Your order for {{.Item}} has been delivered. Contact us if you did not receive it.",
+		},
+		model.LocaleSwahili: {
+			Subject: "Oda yako {{.OrderID}} imefikishwa",
+			Heading: "Oda yako imefikishwa",
+			Body:    "Oda yako ya {{.Item}} imefikishwa. Wasiliana nasi kama hukuipokea.",
+		},
+	},
+	model.NotificationCartAbandoned: {
+		model.LocaleEnglish: {
+			Subject: "You left {{.Item}} in your cart",
+			Heading: "Your cart is waiting",
+			Body:    "The items in your cart come to KES {{.Amount}}. Check out before they sell out.",
+		},
+		model.LocaleSwahili: {
+			Subject: "Umeacha {{.Item}} kwenye kikapu chako",
+			Heading: "Kikapu chako kinakusubiri",
+			Body:    "Bidhaa kwenye kikapu chako ni KES {{.Amount}}. Kamilisha oda kabla hazijaisha.",
+		},
+	},
+}
+
+var defaultEmailLabels = map[string]emailLabels{
+	model.LocaleEnglish: {
+		Order:  "Order",
+		Item:   "Item",
+		Amount: "Amount",
+		Footer: "You can choose how we contact you about your orders in your account's notification preferences.",
+	},
+	model.LocaleSwahili: {
+		Order:  "Oda",
+		Item:   "Bidhaa",
+		Amount: "Kiasi",
+		Footer: "Unaweza kuchagua jinsi tunavyowasiliana nawe kuhusu oda zako katika mapendeleo ya arifa ya akaunti yako.",
+	},
+}
+
+// RenderEmail builds the email for event in locale, falling back to the
+// default locale, with plain text and HTML bodies. The recipient is left for
+// the caller to set.
+func RenderEmail(event model.NotificationEvent, locale string, data *TemplateData) (*model.EmailMessage, error) {
+
+	if _, ok := defaultEmailContent[event][locale]; !ok {
+		locale = model.DefaultLocale
+	}
+
+	content, ok := defaultEmailContent[event][locale]
+	if !ok {
+		return &model.EmailMessage{}, fmt.Errorf("no email template for %v", event)
+	}
+
+	view := &emailView{
+		Locale: locale,
+		Labels: defaultEmailLabels[locale],
+		Data:   data,
+	}
+
+	for _, field := range []struct {
+		text   string
+		result *string
+	}{
+		{content.Subject, &view.Subject},
+		{content.Heading, &view.Heading},
+		{content.Body, &view.Body},
+	} {
+
+		rendered, err := renderText(field.text, data)
+		if err != nil {
+			return &model.EmailMessage{}, err
+		}
+
+		*field.result = rendered
+	}
+
+	var html, text bytes.Buffer
+
+	err := htmlEmailLayout.Execute(&html, view)
+	if err != nil {
+		return &model.EmailMessage{}, err
+	}
+
+	err = textEmailLayout.Execute(&text, view)
+	if err != nil {
+		return &model.EmailMessage{}, err
+	}
+
+	return &model.EmailMessage{
+		Subject: view.Subject,
+		Text:    text.String(),
+		HTML:    html.String(),
+	}, nil
+}
+
+func renderText(body string, data *TemplateData) (string, error) {
+
+	parsed, err := template.New("email").Option("missingkey=error").Parse(body)
+	if err != nil {
+		return "", err
+	}
+
+	var text strings.Builder
+
+	err = parsed.Execute(&text, data)
+	if err != nil {
+		return "", err
+	}
+
+	return text.String(), nil
+}
@@ -0,0 +1,177 @@
+package notifications
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"time"
+
+	"github.com/ernestngugi/sil-backend/internal/db"
+	"github.com/ernestngugi/sil-backend/internal/model"
+	"github.com/ernestngugi/sil-backend/internal/repos"
+)
+
+// ErrNoPhoneNumber is returned for SMS to customers with no number on record
+// who have never paid.
+var ErrNoPhoneNumber = errors.New("customer has no phone number")
+
+type (
+	// Notification is a message to a customer about one of their orders or
+	// their cart.
+	Notification struct {
+		Customer *model.Customer
+		Event    model.NotificationEvent
+		Data     *TemplateData
+		// PhoneNumber receives the message when the customer prefers SMS.
+		// When empty the customer's own number is used, or failing that
+		// the number they last paid from.
+		PhoneNumber string
+		// Transactional notifications are ones the customer cannot do
+		// without, such as delivery codes. They are texted straight away
+		// whatever channel the customer chose for the event.
+		Transactional bool
+	}
+
+	// Notifier sends customers messages on the channel they chose for the
+	// event, in their language.
+	Notifier interface {
+		Notify(ctx context.Context, dB db.DB, notification *Notification) error
+		PhoneNumber(ctx context.Context, dB db.DB, customer *model.Customer) (string, error)
+	}
+
+	notifier struct {
+		preferenceRepository repos.NotificationPreferenceRepository
+		paymentRepository    repos.PaymentRepository
+		smsTemplates         SMSTemplates
+		smsDispatcher        SMSDispatcher
+		emailDispatcher      EmailDispatcher
+		emailEnabled         bool
+	}
+)
+
+// NewNotifier returns a Notifier. Until emailEnabled is set customers who
+// chose email are texted instead.
+func NewNotifier(
+	preferenceRepository repos.NotificationPreferenceRepository,
+	paymentRepository repos.PaymentRepository,
+	smsTemplates SMSTemplates,
+	smsDispatcher SMSDispatcher,
+	emailDispatcher EmailDispatcher,
+	emailEnabled bool,
+) Notifier {
+	return &notifier{
+		preferenceRepository: preferenceRepository,
+		paymentRepository:    paymentRepository,
+		smsTemplates:         smsTemplates,
+		smsDispatcher:        smsDispatcher,
+		emailDispatcher:      emailDispatcher,
+		emailEnabled:         emailEnabled,
+	}
+}
+
+func (n *notifier) Notify(ctx context.Context, dB db.DB, notification *Notification) error {
+
+	if notification.Transactional {
+		return n.notifyBySMS(ctx, dB, notification, &model.NotificationPreferences{})
+	}
+
+	preferences, err := n.preferenceRepository.Preferences(ctx, dB, notification.Customer.ID)
+	if err != nil {
+		return fmt.Errorf("failed to load notification preferences: %w", err)
+	}
+
+	switch preferences.Channel(notification.Event) {
+	case model.NotificationChannelSMS:
+		return n.notifyBySMS(ctx, dB, notification, preferences)
+	case model.NotificationChannelEmail:
+		if !n.emailEnabled {
+			return n.notifyBySMS(ctx, dB, notification, preferences)
+		}
+		return n.notifyByEmail(ctx, notification)
+	}
+
+	return nil
+}
+
+// notifyBySMS queues the SMS for the event. Messages that are neither urgent
+// nor transactional wait until the customer's quiet hours are over.
+func (n *notifier) notifyBySMS(
+	ctx context.Context,
+	dB db.DB,
+	notification *Notification,
+	preferences *model.NotificationPreferences,
+) error {
+
+	phoneNumber := notification.PhoneNumber
+	if phoneNumber == "" {
+
+		var err error
+
+		phoneNumber, err = n.PhoneNumber(ctx, dB, notification.Customer)
+		if err != nil {
+			return err
+		}
+	}
+
+	preview, err := n.smsTemplates.Render(ctx, dB, notification.Event, notification.Customer.Locale, notification.Data)
+	if err != nil {
+		return err
+	}
+
+	message := &model.SMSMessage{
+		Number:        phoneNumber,
+		Message:       preview.Text,
+		Transactional: notification.Transactional,
+	}
+
+	if !notification.Event.Urgent() && !notification.Transactional {
+
+		sendAfter, quiet := QuietUntil(preferences.QuietHours, time.Now())
+		if quiet {
+			return n.smsDispatcher.Defer(ctx, message, sendAfter)
+		}
+	}
+
+	return n.smsDispatcher.Dispatch(ctx, message)
+}
+
+// PhoneNumber returns where SMS to customer go: their own number, or failing
+// that the number they last paid from. It returns ErrNoPhoneNumber when there
+// is neither.
+func (n *notifier) PhoneNumber(ctx context.Context, dB db.DB, customer *model.Customer) (string, error) {
+
+	if customer.PhoneNumber != "" {
+		return customer.PhoneNumber, nil
+	}
+
+	phoneNumber, err := n.paymentRepository.CustomerPhoneNumber(ctx, dB, customer.ID)
+	if err != nil {
+		return "", fmt.Errorf("failed to load phone number: %w", err)
+	}
+
+	if phoneNumber == "" {
+		return "", ErrNoPhoneNumber
+	}
+
+	return phoneNumber, nil
+}
+
+// notifyByEmail queues the email for the event to the address the customer
+// signed in with.
+func (n *notifier) notifyByEmail(ctx context.Context, notification *Notification) error {
+
+	address, err := mail.ParseAddress(notification.Customer.Name)
+	if err != nil {
+		return fmt.Errorf("customer has no usable email address: %w", err)
+	}
+
+	message, err := RenderEmail(notification.Event, notification.Customer.Locale, notification.Data)
+	if err != nil {
+		return err
+	}
+
+	message.To = address.Address
+
+	return n.emailDispatcher.Dispatch(ctx, message)
+}
@@ -0,0 +1,128 @@
+package notifications
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ernestngugi/sil-backend/internal/db"
+	"github.com/ernestngugi/sil-backend/internal/logging"
+	"github.com/ernestngugi/sil-backend/internal/model"
+	"github.com/ernestngugi/sil-backend/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeSMSSuppressionRepository struct {
+	numbers map[string]bool
+}
+
+func (r *fakeSMSSuppressionRepository) Suppressed(ctx context.Context, operations db.SQLOperations, phoneNumber string) (bool, error) {
+	return r.numbers[phoneNumber], nil
+}
+
+func (r *fakeSMSSuppressionRepository) Save(ctx context.Context, operations db.SQLOperations, suppression *model.SMSSuppression) error {
+	r.numbers[suppression.PhoneNumber] = true
+	return nil
+}
+
+func TestQuietUntil(t *testing.T) {
+
+	nairobi, err := time.LoadLocation("Africa/Nairobi")
+	if err != nil {
+		t.Fatalf("failed to load time zone: %v", err)
+	}
+
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2026, time.October, day, hour, minute, 0, 0, nairobi)
+	}
+
+	overnight := &model.QuietHours{Start: "22:00", End: "07:00", TimeZone: "Africa/Nairobi"}
+
+	t.Run("defers until the morning over midnight", func(t *testing.T) {
+
+		until, quiet := QuietUntil(overnight, at(19, 23, 30))
+		assert.True(t, quiet)
+		assert.True(t, at(20, 7, 0).Equal(until))
+
+		until, quiet = QuietUntil(overnight, at(20, 2, 15))
+		assert.True(t, quiet)
+		assert.True(t, at(20, 7, 0).Equal(until))
+	})
+
+	t.Run("sends outside quiet hours", func(t *testing.T) {
+
+		_, quiet := QuietUntil(overnight, at(19, 7, 0))
+		assert.False(t, quiet)
+
+		_, quiet = QuietUntil(overnight, at(19, 21, 59))
+		assert.False(t, quiet)
+	})
+
+	t.Run("handles quiet hours within a day", func(t *testing.T) {
+
+		afternoon := &model.QuietHours{Start: "13:00", End: "15:00", TimeZone: "Africa/Nairobi"}
+
+		until, quiet := QuietUntil(afternoon, at(19, 14, 0))
+		assert.True(t, quiet)
+		assert.True(t, at(19, 15, 0).Equal(until))
+
+		_, quiet = QuietUntil(afternoon, at(19, 15, 0))
+		assert.False(t, quiet)
+	})
+
+	t.Run("uses the customer's time zone", func(t *testing.T) {
+
+		// 20:30 UTC is 23:30 in Nairobi.
+		until, quiet := QuietUntil(overnight, time.Date(2026, time.October, 19, 20, 30, 0, 0, time.UTC))
+		assert.True(t, quiet)
+		assert.True(t, at(20, 7, 0).Equal(until))
+	})
+
+	t.Run("ignores missing or unusable quiet hours", func(t *testing.T) {
+
+		_, quiet := QuietUntil(nil, at(19, 23, 0))
+		assert.False(t, quiet)
+
+		_, quiet = QuietUntil(&model.QuietHours{Start: "22:00", End: "22:00", TimeZone: "Africa/Nairobi"}, at(19, 22, 0))
+		assert.False(t, quiet)
+
+		_, quiet = QuietUntil(&model.QuietHours{Start: "22:00", End: "07:00", TimeZone: "Mars/Olympus"}, at(19, 23, 0))
+		assert.False(t, quiet)
+	})
+}
+
+func TestSuppressionFilter(t *testing.T) {
+
+	ctx := context.Background()
+
+	t.Run("drops messages to opted out numbers", func(t *testing.T) {
+
+		smsSender := mocks.NewMockSMSSender()
+		suppressions := &fakeSMSSuppressionRepository{numbers: map[string]bool{"254728389583": true}}
+
+		filter := NewSuppressionFilter(nil, suppressions, smsSender, logging.Discard())
+
+		err := filter.Send(ctx, &model.SMSMessage{Number: "+254728389583", Message: "test"})
+		assert.NoError(t, err)
+
+		err = filter.Send(ctx, &model.SMSMessage{Number: "254700000000", Message: "test"})
+		assert.NoError(t, err)
+
+		sent := smsSender.Sent()
+		assert.Len(t, sent, 1)
+		assert.Equal(t, "254700000000", sent[0].Number)
+	})
+
+	t.Run("sends transactional messages to opted out numbers", func(t *testing.T) {
+
+		smsSender := mocks.NewMockSMSSender()
+		suppressions := &fakeSMSSuppressionRepository{numbers: map[string]bool{"254728389583": true}}
+
+		filter := NewSuppressionFilter(nil, suppressions, smsSender, logging.Discard())
+
+		err := filter.Send(ctx, &model.SMSMessage{Number: "+254728389583", Message: "code 123456", Transactional: true})
+		assert.NoError(t, err)
+
+		assert.Len(t, smsSender.Sent(), 1)
+	})
+}
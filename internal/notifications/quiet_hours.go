@@ -0,0 +1,67 @@
+package notifications
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ernestngugi/sil-backend/internal/model"
+)
+
+// QuietUntil reports whether now falls within quietHours and, if it does,
+// when they end. Quiet hours starting and ending at the same time are off.
+func QuietUntil(quietHours *model.QuietHours, now time.Time) (time.Time, bool) {
+
+	if quietHours == nil {
+		return time.Time{}, false
+	}
+
+	location, err := time.LoadLocation(quietHours.TimeZone)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	start, err := clockMinutes(quietHours.Start)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	end, err := clockMinutes(quietHours.End)
+	if err != nil || start == end {
+		return time.Time{}, false
+	}
+
+	local := now.In(location)
+	minute := local.Hour()*60 + local.Minute()
+	today := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, location)
+
+	switch {
+	case start < end:
+		// e.g. 13:00 to 15:00
+		if minute >= start && minute < end {
+			return atMinute(today, end), true
+		}
+	case minute >= start:
+		// e.g. 22:00 to 07:00, before midnight
+		return atMinute(today.AddDate(0, 0, 1), end), true
+	case minute < end:
+		// e.g. 22:00 to 07:00, after midnight
+		return atMinute(today, end), true
+	}
+
+	return time.Time{}, false
+}
+
+// clockMinutes parses "HH:MM" into minutes after midnight.
+func clockMinutes(clock string) (int, error) {
+
+	parsed, err := time.Parse("15:04", clock)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time of day %q: %w", clock, err)
+	}
+
+	return parsed.Hour()*60 + parsed.Minute(), nil
+}
+
+func atMinute(day time.Time, minutes int) time.Time {
+	return time.Date(day.Year(), day.Month(), day.Day(), minutes/60, minutes%60, 0, 0, day.Location())
+}
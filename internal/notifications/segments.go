@@ -0,0 +1,59 @@
+package notifications
+
+import (
+	"strings"
+	"unicode/utf16"
+
+	"github.com/ernestngugi/sil-backend/internal/model"
+)
+
+const (
+	gsm7SingleSegment = 160
+	gsm7MultiSegment  = 153
+	ucs2SingleSegment = 70
+	ucs2MultiSegment  = 67
+)
+
+const (
+	// gsm7Basic is the GSM 03.38 default alphabet, one septet per character.
+	gsm7Basic = "@£$¥èéùìòÇ\nØø\rÅåΔ_ΦΓΛΩΠΨΣΘΞÆæßÉ !\"#¤%&'()*+,-./0123456789:;<=>?" +
+		"¡ABCDEFGHIJKLMNOPQRSTUVWXYZÄÖÑÜ§¿abcdefghijklmnopqrstuvwxyzäöñüà"
+
+	// gsm7Extension characters are sent as an escape followed by the
+	// character, two septets each.
+	gsm7Extension = "\f^{}\\[~]|€"
+)
+
+// CountSegments works out how text would be encoded and how many SMS segments
+// it takes. Characters is the length in the encoding's units: septets for
+// GSM-7, UTF-16 code units for UCS-2. A single character outside GSM-7, such
+// as an emoji or a curly quote, switches the whole message to UCS-2.
+func CountSegments(text string) (model.SMSEncoding, int, int) {
+
+	septets := 0
+
+	for _, r := range text {
+		switch {
+		case strings.ContainsRune(gsm7Basic, r):
+			septets++
+		case strings.ContainsRune(gsm7Extension, r):
+			septets += 2
+		default:
+			units := len(utf16.Encode([]rune(text)))
+			return model.SMSEncodingUCS2, units, segments(units, ucs2SingleSegment, ucs2MultiSegment)
+		}
+	}
+
+	return model.SMSEncodingGSM7, septets, segments(septets, gsm7SingleSegment, gsm7MultiSegment)
+}
+
+// segments is how many parts a message of length units takes. Multi-part
+// messages carry a header, so each part holds fewer units than a single one.
+func segments(length, single, multi int) int {
+
+	if length <= single {
+		return 1
+	}
+
+	return (length + multi - 1) / multi
+}
@@ -0,0 +1,229 @@
+package notifications
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/ernestngugi/sil-backend/internal/db"
+	"github.com/ernestngugi/sil-backend/internal/model"
+	"github.com/ernestngugi/sil-backend/internal/repos"
+	"github.com/ernestngugi/sil-backend/providers"
+)
+
+const (
+	defaultQueueSize = 256
+	defaultWorkers   = 4
+
+	// outboxPollInterval is how often deferred messages are checked for ones
+	// that have fallen due.
+	outboxPollInterval = time.Minute
+
+	// outboxClaimTimeout is how long a replica has to send the messages it
+	// claimed from the outbox before another may claim them.
+	outboxClaimTimeout = 5 * time.Minute
+)
+
+var ErrDispatcherClosed = errors.New("sms dispatcher is shut down")
+
+type (
+	// SMSDispatcher sends messages in the background so request handlers do
+	// not wait on the SMS provider.
+	SMSDispatcher interface {
+		Dispatch(ctx context.Context, request *model.SMSMessage) error
+		Defer(ctx context.Context, request *model.SMSMessage, sendAfter time.Time) error
+		ResumePending(ctx context.Context) error
+		Start()
+		Shutdown(ctx context.Context) error
+	}
+
+	smsDispatcher struct {
+		dB                  db.DB
+		smsSender           providers.SMSSender
+		smsOutboxRepository repos.SMSOutboxRepository
+		logger              *slog.Logger
+		pool                *workerPool[*queuedSMS]
+
+		startOnce   sync.Once
+		stopPolling sync.Once
+		pollStop    chan struct{}
+		pollStopped chan struct{}
+	}
+
+	queuedSMS struct {
+		// ctx carries request-scoped values such as the request ID but is
+		// detached from the request's cancellation.
+		ctx     context.Context
+		request *model.SMSMessage
+		// outboxID is the message's outbox row, deleted once it is sent,
+		// or zero for messages that were never persisted.
+		outboxID int64
+	}
+)
+
+func NewSMSDispatcher(
+	dB db.DB,
+	smsSender providers.SMSSender,
+	smsOutboxRepository repos.SMSOutboxRepository,
+	logger *slog.Logger,
+) SMSDispatcher {
+
+	dispatcher := &smsDispatcher{
+		dB:                  dB,
+		smsSender:           smsSender,
+		smsOutboxRepository: smsOutboxRepository,
+		logger:              logger,
+		pollStop:            make(chan struct{}),
+		pollStopped:         make(chan struct{}),
+	}
+
+	dispatcher.pool = newWorkerPool(defaultQueueSize, defaultWorkers, dispatcher.send)
+
+	return dispatcher
+}
+
+// Dispatch queues a message for delivery. It blocks when the queue is full.
+func (d *smsDispatcher) Dispatch(ctx context.Context, request *model.SMSMessage) error {
+
+	return d.enqueue(&queuedSMS{ctx: context.WithoutCancel(ctx), request: request})
+}
+
+func (d *smsDispatcher) enqueue(queued *queuedSMS) error {
+
+	if !d.pool.submit(queued) {
+		return ErrDispatcherClosed
+	}
+
+	return nil
+}
+
+// Defer persists a message to the outbox to be sent once sendAfter has
+// passed, on whichever replica polls for it first.
+func (d *smsDispatcher) Defer(ctx context.Context, request *model.SMSMessage, sendAfter time.Time) error {
+	return d.smsOutboxRepository.Save(ctx, d.dB, request, sendAfter)
+}
+
+// ResumePending queues persisted messages that are due: those left by a
+// previous shutdown, deferred ones whose time has come and ones another
+// replica claimed but did not send. They stay in the outbox until sent.
+func (d *smsDispatcher) ResumePending(ctx context.Context) error {
+
+	now := time.Now()
+
+	messages, err := d.smsOutboxRepository.Claim(ctx, d.dB, now, now.Add(outboxClaimTimeout))
+	if err != nil {
+		return err
+	}
+
+	for _, message := range messages {
+		err := d.enqueue(&queuedSMS{ctx: context.WithoutCancel(ctx), request: message.Request, outboxID: message.ID})
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(messages) > 0 {
+		d.logger.InfoContext(ctx, "resumed pending sms", slog.Int("count", len(messages)))
+	}
+
+	return nil
+}
+
+// Start resumes pending messages straight away and then polls the outbox for
+// deferred ones.
+func (d *smsDispatcher) Start() {
+	d.startOnce.Do(func() {
+		go d.poll()
+	})
+}
+
+// Shutdown stops accepting messages and waits for the queue to drain. Messages
+// still queued when ctx is done are persisted to the outbox for the next start.
+func (d *smsDispatcher) Shutdown(ctx context.Context) error {
+
+	// Polling stops first so nothing is claimed from the outbox that could
+	// no longer be queued. A dispatcher that was never started has nothing
+	// to wait for.
+	d.stopPolling.Do(func() { close(d.pollStop) })
+	d.startOnce.Do(func() { close(d.pollStopped) })
+
+	select {
+	case <-d.pollStopped:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	unsent := d.pool.shutdown(ctx)
+	if len(unsent) == 0 {
+		return nil
+	}
+
+	// ctx is already done, persisting must not be cut short by it.
+	persistCtx := context.WithoutCancel(ctx)
+
+	var persisted int
+
+	for _, queued := range unsent {
+
+		// Messages from the outbox are still in it, and are sent once
+		// their claim runs out.
+		if queued.outboxID != 0 {
+			continue
+		}
+
+		err := d.smsOutboxRepository.Save(persistCtx, d.dB, queued.request, time.Now())
+		if err != nil {
+			return err
+		}
+		persisted++
+	}
+
+	d.logger.InfoContext(ctx, "persisted unsent sms to the outbox", slog.Int("count", persisted))
+
+	return nil
+}
+
+func (d *smsDispatcher) poll() {
+	defer close(d.pollStopped)
+
+	ticker := time.NewTicker(outboxPollInterval)
+	defer ticker.Stop()
+
+	for {
+		err := d.ResumePending(context.Background())
+		if err != nil {
+			d.logger.Error("failed to resume pending sms", slog.String("error", err.Error()))
+		}
+
+		select {
+		case <-d.pollStop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// send sends a queued message and removes it from the outbox if it came from
+// there. Outbox messages that fail are tried again once their claim runs out.
+func (d *smsDispatcher) send(queued *queuedSMS) {
+
+	err := d.smsSender.Send(queued.ctx, queued.request)
+	if err != nil {
+		d.logger.ErrorContext(queued.ctx, "failed to send sms", slog.String("error", err.Error()))
+		return
+	}
+
+	if queued.outboxID == 0 {
+		return
+	}
+
+	err = d.smsOutboxRepository.Delete(queued.ctx, d.dB, queued.outboxID)
+	if err != nil {
+		d.logger.ErrorContext(queued.ctx, "failed to remove sent sms from the outbox",
+			slog.Int64("outbox_id", queued.outboxID),
+			slog.String("error", err.Error()),
+		)
+	}
+}
@@ -0,0 +1,127 @@
+package notifications
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ernestngugi/sil-backend/internal/db"
+	"github.com/ernestngugi/sil-backend/internal/logging"
+	"github.com/ernestngugi/sil-backend/internal/model"
+	"github.com/ernestngugi/sil-backend/internal/repos"
+	"github.com/ernestngugi/sil-backend/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeSMSOutboxRepository struct {
+	mu       sync.Mutex
+	messages []*model.OutboxSMS
+	deleted  []int64
+}
+
+func (r *fakeSMSOutboxRepository) Claim(ctx context.Context, operations db.SQLOperations, now, claimedUntil time.Time) ([]*model.OutboxSMS, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	messages := r.messages
+	r.messages = nil
+
+	return messages, nil
+}
+
+func (r *fakeSMSOutboxRepository) Delete(ctx context.Context, operations db.SQLOperations, id int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.deleted = append(r.deleted, id)
+
+	return nil
+}
+
+func (r *fakeSMSOutboxRepository) Save(ctx context.Context, operations db.SQLOperations, request *model.SMSMessage, sendAfter time.Time) error {
+	return nil
+}
+
+func (r *fakeSMSOutboxRepository) Deleted() []int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]int64{}, r.deleted...)
+}
+
+type failingSMSSender struct{}
+
+func (s failingSMSSender) Send(ctx context.Context, request *model.SMSMessage) error {
+	return errors.New("provider unavailable")
+}
+
+func TestSMSDispatcher(t *testing.T) {
+
+	ctx := context.Background()
+
+	t.Run("sends queued messages before shutdown returns", func(t *testing.T) {
+
+		smsSender := mocks.NewMockSMSSender()
+
+		dispatcher := NewSMSDispatcher(nil, smsSender, repos.NewSMSOutboxRepository(), logging.Discard())
+
+		for i := 0; i < 10; i++ {
+			err := dispatcher.Dispatch(ctx, &model.SMSMessage{Number: "254728389583", Message: "test"})
+			assert.NoError(t, err)
+		}
+
+		err := dispatcher.Shutdown(ctx)
+		assert.NoError(t, err)
+
+		assert.Len(t, smsSender.Sent(), 10)
+	})
+
+	t.Run("rejects messages after shutdown", func(t *testing.T) {
+
+		dispatcher := NewSMSDispatcher(nil, mocks.NewMockSMSSender(), repos.NewSMSOutboxRepository(), logging.Discard())
+
+		err := dispatcher.Shutdown(ctx)
+		assert.NoError(t, err)
+
+		err = dispatcher.Dispatch(ctx, &model.SMSMessage{Number: "254728389583", Message: "test"})
+		assert.Equal(t, ErrDispatcherClosed, err)
+	})
+
+	t.Run("removes outbox messages once they are sent", func(t *testing.T) {
+
+		smsSender := mocks.NewMockSMSSender()
+		outbox := &fakeSMSOutboxRepository{messages: []*model.OutboxSMS{
+			{ID: 1, Request: &model.SMSMessage{Number: "254728389583", Message: "test"}},
+		}}
+
+		dispatcher := NewSMSDispatcher(nil, smsSender, outbox, logging.Discard())
+
+		err := dispatcher.ResumePending(ctx)
+		assert.NoError(t, err)
+
+		err = dispatcher.Shutdown(ctx)
+		assert.NoError(t, err)
+
+		assert.Len(t, smsSender.Sent(), 1)
+		assert.Equal(t, []int64{1}, outbox.Deleted())
+	})
+
+	t.Run("leaves outbox messages that fail to be claimed again", func(t *testing.T) {
+
+		outbox := &fakeSMSOutboxRepository{messages: []*model.OutboxSMS{
+			{ID: 1, Request: &model.SMSMessage{Number: "254728389583", Message: "test"}},
+		}}
+
+		dispatcher := NewSMSDispatcher(nil, failingSMSSender{}, outbox, logging.Discard())
+
+		err := dispatcher.ResumePending(ctx)
+		assert.NoError(t, err)
+
+		err = dispatcher.Shutdown(ctx)
+		assert.NoError(t, err)
+
+		assert.Empty(t, outbox.Deleted())
+	})
+}
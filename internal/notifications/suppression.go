@@ -0,0 +1,61 @@
+package notifications
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+
+	"github.com/ernestngugi/sil-backend/internal/db"
+	"github.com/ernestngugi/sil-backend/internal/model"
+	"github.com/ernestngugi/sil-backend/internal/repos"
+	"github.com/ernestngugi/sil-backend/providers"
+)
+
+type suppressionFilter struct {
+	dB                       db.DB
+	smsSuppressionRepository repos.SMSSuppressionRepository
+	next                     providers.SMSSender
+	logger                   *slog.Logger
+}
+
+// NewSuppressionFilter drops messages to numbers on the suppression list
+// before they reach next, other than transactional ones. Dropped messages are
+// not an error, the customer asked not to be sent them.
+func NewSuppressionFilter(
+	dB db.DB,
+	smsSuppressionRepository repos.SMSSuppressionRepository,
+	next providers.SMSSender,
+	logger *slog.Logger,
+) providers.SMSSender {
+	return &suppressionFilter{
+		dB:                       dB,
+		smsSuppressionRepository: smsSuppressionRepository,
+		next:                     next,
+		logger:                   logger,
+	}
+}
+
+func (f *suppressionFilter) Send(ctx context.Context, request *model.SMSMessage) error {
+
+	if request.Transactional {
+		return f.next.Send(ctx, request)
+	}
+
+	suppressed, err := f.smsSuppressionRepository.Suppressed(ctx, f.dB, NormalizePhoneNumber(request.Number))
+	if err != nil {
+		return err
+	}
+
+	if suppressed {
+		f.logger.InfoContext(ctx, "sms not sent to opted out number")
+		return nil
+	}
+
+	return f.next.Send(ctx, request)
+}
+
+// NormalizePhoneNumber returns number in international format without the
+// leading plus, the form numbers are suppressed in.
+func NormalizePhoneNumber(number string) string {
+	return strings.TrimPrefix(strings.TrimSpace(number), "+")
+}
@@ -0,0 +1,217 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"text/template"
+
+	"github.com/ernestngugi/sil-backend/internal/apperrors"
+	"github.com/ernestngugi/sil-backend/internal/db"
+	"github.com/ernestngugi/sil-backend/internal/model"
+	"github.com/ernestngugi/sil-backend/internal/repos"
+)
+
+// defaultSMSTemplates are sent for events and locales admins have not
+// customised.
+var defaultSMSTemplates = map[model.NotificationEvent]map[string]string{
+	model.NotificationOrderCreated: {
+		model.LocaleEnglish: "Your order {{.OrderID}} for {{.Item}} (KES {{.Amount}}) has been received. We will text you when it is on its way.",
+		model.LocaleSwahili: "Oda yako {{.OrderID}} ya {{.Item}} (KES {{.Amount}}) imepokelewa. Tutakutumia ujumbe ikiwa njiani.",
+	},
+	model.NotificationOrderDispatched: {
+		model.LocaleEnglish: "Your order {{.OrderID}} for {{.Item}} has been dispatched.",
+		model.LocaleSwahili: "Oda yako {{.OrderID}} ya {{.Item}} imetumwa.",
+	},
+	model.NotificationOrderCancelled: {
+		model.LocaleEnglish: "Your order {{.OrderID}} for {{.Item}} has been cancelled.",
+		model.LocaleSwahili: "Oda yako {{.OrderID}} ya {{.Item}} imeghairiwa.",
+	},
+	model.NotificationOrderRefunded: {
+		model.LocaleEnglish: "KES {{.Amount}} for your order {{.OrderID}} has been refunded.",
+		model.LocaleSwahili: "KES {{.Amount}} za oda yako {{.OrderID}} zimerejeshwa.",
+	},
+	model.NotificationOrderPaid: {
+		model.LocaleEnglish: "We have received KES {{.Amount}} for your order {{.OrderID}}.{{if .Receipt}} M-Pesa receipt {{.Receipt}}.{{end}}",
+		model.LocaleSwahili: "Tumepokea KES {{.Amount}} za oda yako {{.OrderID}}.{{if .Receipt}} Risiti ya M-Pesa {{.Receipt}}.{{end}}",
+	},
+	model.NotificationRiderAssigned: {
+		model.LocaleEnglish: "{{.RiderName}} ({{.RiderPhone}}) is delivering your order {{.OrderID}}. Give the rider code {{.DeliveryCode}} on delivery.",
+		model.LocaleSwahili: "{{.RiderName}} ({{.RiderPhone}}) analeta oda yako {{.OrderID}}. Mpe msafirishaji nambari {{.DeliveryCode}} ukipokea.",
+	},
+	model.NotificationOrderDelivered: {
+		model.LocaleEnglish: "Your order {{.OrderID}} for {{.Item}} has been delivered. Thank you for shopping with us.",
+		model.LocaleSwahili: "Oda yako {{.OrderID}} ya {{.Item}} imefikishwa. Asante kwa kununua kwetu.",
+	},
+	model.NotificationCartAbandoned: {
+		model.LocaleEnglish: "You left {{.Item}} (KES {{.Amount}}) in your cart. Check out before it sells out.",
+		model.LocaleSwahili: "Umeacha {{.Item}} (KES {{.Amount}}) kwenye kikapu chako. Kamilisha oda kabla hazijaisha.",
+	},
+}
+
+type (
+	// TemplateData are the variables templates can use, e.g. {{.OrderID}}.
+	TemplateData struct {
+		// OrderID is zero for messages about a cart.
+		OrderID int64
+		Item    string
+		// Amount is formatted with thousands separators and two
+		// decimals.
+		Amount string
+		// Receipt is the M-Pesa receipt number of a payment, when known.
+		Receipt string
+		// RiderName, RiderPhone and DeliveryCode are set for messages about
+		// a shipment's rider.
+		RiderName    string
+		RiderPhone   string
+		DeliveryCode string
+	}
+
+	// SMSTemplates renders the message for an event in a customer's locale.
+	SMSTemplates interface {
+		Render(ctx context.Context, operations db.SQLOperations, event model.NotificationEvent, locale string, data *TemplateData) (*model.SMSPreview, error)
+	}
+
+	smsTemplates struct {
+		smsTemplateRepository repos.SMSTemplateRepository
+		logger                *slog.Logger
+	}
+)
+
+func NewSMSTemplates(smsTemplateRepository repos.SMSTemplateRepository, logger *slog.Logger) SMSTemplates {
+	return &smsTemplates{
+		smsTemplateRepository: smsTemplateRepository,
+		logger:                logger,
+	}
+}
+
+// OrderTemplateData returns the variables describing order.
+func OrderTemplateData(order *model.Order) *TemplateData {
+	return &TemplateData{
+		OrderID: order.ID,
+		Item:    order.Item,
+		Amount:  model.FormatAmount(order.Amount),
+	}
+}
+
+// SampleTemplateData is used to preview templates.
+func SampleTemplateData() *TemplateData {
+	return &TemplateData{
+		OrderID:      1024,
+		Item:         "Maize flour 2kg",
+		Amount:       model.FormatAmount(1250),
+		Receipt:      "SJK3H2L9QX",
+		RiderName:    "Otieno",
+		RiderPhone:   "254712345678",
+		DeliveryCode: "482913",
+	}
+}
+
+// Render uses the customised template for the locale if there is one, then
+// the built in one, falling back to the default locale when the event has
+// no template in locale at all.
+func (t *smsTemplates) Render(
+	ctx context.Context,
+	operations db.SQLOperations,
+	event model.NotificationEvent,
+	locale string,
+	data *TemplateData,
+) (*model.SMSPreview, error) {
+
+	body, err := t.body(ctx, operations, event, locale)
+	if err != nil {
+		return &model.SMSPreview{}, err
+	}
+
+	preview, err := RenderSMS(body, data)
+	if err != nil {
+		return &model.SMSPreview{}, err
+	}
+
+	if preview.Segments > 1 {
+		t.logger.WarnContext(ctx, "sms spans multiple segments",
+			slog.String("event", string(event)),
+			slog.String("locale", locale),
+			slog.Int("segments", preview.Segments),
+		)
+	}
+
+	return preview, nil
+}
+
+func (t *smsTemplates) body(
+	ctx context.Context,
+	operations db.SQLOperations,
+	event model.NotificationEvent,
+	locale string,
+) (string, error) {
+
+	for _, candidate := range []string{locale, model.DefaultLocale} {
+
+		template, err := t.smsTemplateRepository.Template(ctx, operations, event, candidate)
+		if err == nil {
+			return template.Body, nil
+		}
+
+		if !apperrors.Is(err, apperrors.KindNotFound) {
+			return "", err
+		}
+
+		if body, ok := DefaultSMSTemplate(event, candidate); ok {
+			return body, nil
+		}
+	}
+
+	return "", fmt.Errorf("no sms template for %v", event)
+}
+
+// DefaultSMSTemplate returns the built in template for event in locale.
+func DefaultSMSTemplate(event model.NotificationEvent, locale string) (string, bool) {
+	body, ok := defaultSMSTemplates[event][locale]
+	return body, ok
+}
+
+// RenderSMS executes the template body with data and reports the encoding
+// and segments of the result, warning about anything that makes it costlier
+// to send.
+func RenderSMS(body string, data *TemplateData) (*model.SMSPreview, error) {
+
+	parsed, err := template.New("sms").Option("missingkey=error").Parse(body)
+	if err != nil {
+		return &model.SMSPreview{}, invalidTemplateError(err)
+	}
+
+	var text bytes.Buffer
+
+	err = parsed.Execute(&text, data)
+	if err != nil {
+		return &model.SMSPreview{}, invalidTemplateError(err)
+	}
+
+	preview := &model.SMSPreview{
+		Text:     strings.TrimSpace(text.String()),
+		Warnings: make([]string, 0),
+	}
+
+	preview.Encoding, preview.Characters, preview.Segments = CountSegments(preview.Text)
+
+	if preview.Encoding == model.SMSEncodingUCS2 {
+		preview.Warnings = append(preview.Warnings, "message contains characters outside the GSM-7 alphabet and is sent as UCS-2, which fits 70 characters per segment instead of 160")
+	}
+
+	if preview.Segments > 1 {
+		preview.Warnings = append(preview.Warnings, fmt.Sprintf("message is sent in %d segments and billed as %d messages", preview.Segments, preview.Segments))
+	}
+
+	return preview, nil
+}
+
+func invalidTemplateError(err error) *apperrors.Error {
+	return apperrors.Validation("invalid_sms_template", "sms template is invalid", apperrors.FieldError{
+		Field:   "body",
+		Code:    "template",
+		Message: err.Error(),
+	})
+}
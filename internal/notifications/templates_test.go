@@ -0,0 +1,154 @@
+package notifications
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ernestngugi/sil-backend/internal/apperrors"
+	"github.com/ernestngugi/sil-backend/internal/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCountSegments(t *testing.T) {
+
+	t.Run("counts gsm-7 characters as one septet", func(t *testing.T) {
+
+		encoding, characters, segments := CountSegments("Your order 1024 has been dispatched.")
+		assert.Equal(t, model.SMSEncodingGSM7, encoding)
+		assert.Equal(t, 36, characters)
+		assert.Equal(t, 1, segments)
+	})
+
+	t.Run("counts extension characters as two septets", func(t *testing.T) {
+
+		encoding, characters, segments := CountSegments("Total €5 [paid]")
+		assert.Equal(t, model.SMSEncodingGSM7, encoding)
+		assert.Equal(t, 18, characters)
+		assert.Equal(t, 1, segments)
+	})
+
+	t.Run("splits long gsm-7 messages into 153 septet segments", func(t *testing.T) {
+
+		_, _, segments := CountSegments(strings.Repeat("a", 160))
+		assert.Equal(t, 1, segments)
+
+		_, _, segments = CountSegments(strings.Repeat("a", 161))
+		assert.Equal(t, 2, segments)
+
+		_, _, segments = CountSegments(strings.Repeat("a", 307))
+		assert.Equal(t, 3, segments)
+	})
+
+	t.Run("switches to ucs-2 for characters outside gsm-7", func(t *testing.T) {
+
+		encoding, characters, segments := CountSegments("Asante 🙏")
+		assert.Equal(t, model.SMSEncodingUCS2, encoding)
+		assert.Equal(t, 9, characters)
+		assert.Equal(t, 1, segments)
+
+		_, _, segments = CountSegments(strings.Repeat("’", 71))
+		assert.Equal(t, 2, segments)
+	})
+}
+
+func TestRenderSMS(t *testing.T) {
+
+	t.Run("renders the default templates", func(t *testing.T) {
+
+		for _, event := range model.NotificationEvents {
+			for _, locale := range model.Locales {
+
+				body, ok := DefaultSMSTemplate(event, locale)
+				assert.True(t, ok, "%v %v", event, locale)
+
+				preview, err := RenderSMS(body, SampleTemplateData())
+				assert.NoError(t, err)
+				assert.Equal(t, model.SMSEncodingGSM7, preview.Encoding, "%v %v", event, locale)
+				assert.Equal(t, 1, preview.Segments, "%v %v", event, locale)
+				assert.Empty(t, preview.Warnings)
+			}
+		}
+	})
+
+	t.Run("substitutes order details", func(t *testing.T) {
+
+		preview, err := RenderSMS("Order {{.OrderID}}: {{.Item}} KES {{.Amount}}", &TemplateData{
+			OrderID: 7,
+			Item:    "Sugar",
+			Amount:  model.FormatAmount(99.5),
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, "Order 7: Sugar KES 99.50", preview.Text)
+	})
+
+	t.Run("warns about ucs-2 and multi-part messages", func(t *testing.T) {
+
+		preview, err := RenderSMS(strings.Repeat("Karibu tena 😊 ", 10), SampleTemplateData())
+		assert.NoError(t, err)
+		assert.Equal(t, model.SMSEncodingUCS2, preview.Encoding)
+		assert.Greater(t, preview.Segments, 1)
+		assert.Len(t, preview.Warnings, 2)
+	})
+
+	t.Run("rejects invalid templates", func(t *testing.T) {
+
+		for _, body := range []string{"Order {{.OrderID", "Order {{.Customer}}"} {
+
+			_, err := RenderSMS(body, SampleTemplateData())
+			assert.Error(t, err)
+			assert.True(t, apperrors.Is(err, apperrors.KindValidation), body)
+		}
+	})
+}
+
+func TestRenderEmail(t *testing.T) {
+
+	t.Run("renders every event in every locale", func(t *testing.T) {
+
+		for _, event := range model.NotificationEvents {
+			for _, locale := range model.Locales {
+
+				message, err := RenderEmail(event, locale, SampleTemplateData())
+				assert.NoError(t, err)
+				if event != model.NotificationCartAbandoned {
+					assert.Contains(t, message.Subject, "1024", "%v %v", event, locale)
+				}
+				assert.Contains(t, message.Text, "Maize flour 2kg")
+				assert.Contains(t, message.HTML, `<html lang="`+locale+`">`)
+			}
+		}
+	})
+
+	t.Run("leaves out the order of cart messages", func(t *testing.T) {
+
+		data := SampleTemplateData()
+		data.OrderID = 0
+
+		message, err := RenderEmail(model.NotificationCartAbandoned, model.LocaleEnglish, data)
+		assert.NoError(t, err)
+		assert.Equal(t, "You left Maize flour 2kg in your cart", message.Subject)
+		assert.NotContains(t, message.Text, "#0")
+		assert.NotContains(t, message.HTML, "#0")
+	})
+
+	t.Run("escapes order details in html only", func(t *testing.T) {
+
+		message, err := RenderEmail(model.NotificationOrderDispatched, model.LocaleEnglish, &TemplateData{
+			OrderID: 7,
+			Item:    "Salt & <b>pepper</b>",
+			Amount:  model.FormatAmount(10),
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, "Your order 7 is on its way", message.Subject)
+		assert.Contains(t, message.Text, "Item: Salt & <b>pepper</b>")
+		assert.Contains(t, message.HTML, "Salt &amp; &lt;b&gt;pepper&lt;/b&gt;")
+		assert.NotContains(t, message.HTML, "<b>pepper</b>")
+	})
+
+	t.Run("falls back to the default locale", func(t *testing.T) {
+
+		message, err := RenderEmail(model.NotificationOrderCreated, "fr", SampleTemplateData())
+		assert.NoError(t, err)
+		assert.Equal(t, "We have received your order 1024", message.Subject)
+	})
+}
@@ -0,0 +1,112 @@
+package notifications
+
+import (
+	"context"
+	"sync"
+)
+
+// workerPool handles jobs on a fixed number of goroutines, so callers
+// queueing them do not wait for them to be handled.
+type workerPool[T any] struct {
+	handle func(T)
+
+	mu      sync.RWMutex
+	closed  bool
+	queue   chan T
+	stop    chan struct{}
+	workers sync.WaitGroup
+}
+
+func newWorkerPool[T any](queueSize, workers int, handle func(T)) *workerPool[T] {
+
+	pool := &workerPool[T]{
+		handle: handle,
+		queue:  make(chan T, queueSize),
+		stop:   make(chan struct{}),
+	}
+
+	for i := 0; i < workers; i++ {
+		pool.workers.Add(1)
+		go pool.work()
+	}
+
+	return pool
+}
+
+// submit queues job, blocking when the queue is full. It reports false once
+// the pool is shut down.
+func (p *workerPool[T]) submit(job T) bool {
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.closed {
+		return false
+	}
+
+	p.queue <- job
+
+	return true
+}
+
+// shutdown stops accepting jobs and waits for the queue to drain. If ctx is
+// done first the workers stop after the job they are on and the jobs still
+// queued are returned instead.
+func (p *workerPool[T]) shutdown(ctx context.Context) []T {
+
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return nil
+	}
+	p.closed = true
+	close(p.queue)
+	p.mu.Unlock()
+
+	drained := make(chan struct{})
+	go func() {
+		p.workers.Wait()
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+		return nil
+	case <-ctx.Done():
+	}
+
+	close(p.stop)
+	<-drained
+
+	unhandled := make([]T, 0, len(p.queue))
+	for job := range p.queue {
+		unhandled = append(unhandled, job)
+	}
+
+	return unhandled
+}
+
+func (p *workerPool[T]) work() {
+	defer p.workers.Done()
+
+	for {
+		// A stopped pool leaves the rest of the queue to shutdown, even
+		// when a job is ready as well.
+		select {
+		case <-p.stop:
+			return
+		default:
+		}
+
+		select {
+		case <-p.stop:
+			return
+		case job, ok := <-p.queue:
+			if !ok {
+				return
+			}
+
+			p.handle(job)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package notifications
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWorkerPool(t *testing.T) {
+
+	t.Run("returns jobs still queued when shutdown is cut short", func(t *testing.T) {
+
+		started := make(chan struct{})
+
+		var pool *workerPool[int]
+
+		// The first job runs until the pool is stopped.
+		pool = newWorkerPool(4, 1, func(job int) {
+			if job == 1 {
+				close(started)
+				<-pool.stop
+			}
+		})
+
+		for job := 1; job <= 3; job++ {
+			assert.True(t, pool.submit(job))
+		}
+
+		<-started
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		unhandled := pool.shutdown(ctx)
+		assert.Equal(t, []int{2, 3}, unhandled)
+
+		assert.False(t, pool.submit(4))
+	})
+}
@@ -0,0 +1,155 @@
+package openapi
+
+import "strings"
+
+// Version is the OpenAPI specification version documents are written against.
+const Version = "3.1.0"
+
+type (
+	// Document is the subset of an OpenAPI 3.1 document this API needs.
+	Document struct {
+		OpenAPI    string                `json:"openapi"`
+		Info       Info                  `json:"info"`
+		Servers    []Server              `json:"servers,omitempty"`
+		Tags       []Tag                 `json:"tags,omitempty"`
+		Paths      map[string]PathItem   `json:"paths"`
+		Components *Components           `json:"components,omitempty"`
+		Security   []SecurityRequirement `json:"security,omitempty"`
+	}
+
+	Info struct {
+		Title       string `json:"title"`
+		Description string `json:"description,omitempty"`
+		Version     string `json:"version"`
+	}
+
+	Server struct {
+		URL         string `json:"url"`
+		Description string `json:"description,omitempty"`
+	}
+
+	Tag struct {
+		Name        string `json:"name"`
+		Description string `json:"description,omitempty"`
+	}
+
+	// PathItem maps lower case HTTP methods to operations.
+	PathItem map[string]*Operation
+
+	Operation struct {
+		OperationID string                `json:"operationId"`
+		Summary     string                `json:"summary,omitempty"`
+		Description string                `json:"description,omitempty"`
+		Tags        []string              `json:"tags,omitempty"`
+		Parameters  []Parameter           `json:"parameters,omitempty"`
+		RequestBody *RequestBody          `json:"requestBody,omitempty"`
+		Responses   map[string]*Response  `json:"responses"`
+		Security    []SecurityRequirement `json:"security,omitempty"`
+	}
+
+	Parameter struct {
+		Name        string  `json:"name"`
+		In          string  `json:"in"`
+		Description string  `json:"description,omitempty"`
+		Required    bool    `json:"required,omitempty"`
+		Schema      *Schema `json:"schema"`
+	}
+
+	RequestBody struct {
+		Description string                `json:"description,omitempty"`
+		Required    bool                  `json:"required,omitempty"`
+		Content     map[string]*MediaType `json:"content"`
+	}
+
+	Response struct {
+		Description string                `json:"description"`
+		Headers     map[string]*Header    `json:"headers,omitempty"`
+		Content     map[string]*MediaType `json:"content,omitempty"`
+	}
+
+	Header struct {
+		Description string  `json:"description,omitempty"`
+		Schema      *Schema `json:"schema"`
+	}
+
+	MediaType struct {
+		Schema *Schema `json:"schema"`
+	}
+
+	Components struct {
+		Schemas         map[string]*Schema         `json:"schemas,omitempty"`
+		SecuritySchemes map[string]*SecurityScheme `json:"securitySchemes,omitempty"`
+	}
+
+	SecurityScheme struct {
+		Type        string `json:"type"`
+		Description string `json:"description,omitempty"`
+		Name        string `json:"name,omitempty"`
+		In          string `json:"in,omitempty"`
+	}
+
+	// SecurityRequirement maps a security scheme name to its required scopes.
+	SecurityRequirement map[string][]string
+
+	// Schema is a JSON Schema 2020-12 object as used by OpenAPI 3.1.
+	Schema struct {
+		Ref                  string             `json:"$ref,omitempty"`
+		Type                 string             `json:"type,omitempty"`
+		Format               string             `json:"format,omitempty"`
+		Description          string             `json:"description,omitempty"`
+		Properties           map[string]*Schema `json:"properties,omitempty"`
+		Required             []string           `json:"required,omitempty"`
+		Items                *Schema            `json:"items,omitempty"`
+		AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+		Enum                 []string           `json:"enum,omitempty"`
+		MinLength            *int               `json:"minLength,omitempty"`
+		MaxLength            *int               `json:"maxLength,omitempty"`
+		MinItems             *int               `json:"minItems,omitempty"`
+		MaxItems             *int               `json:"maxItems,omitempty"`
+		Minimum              *float64           `json:"minimum,omitempty"`
+		Maximum              *float64           `json:"maximum,omitempty"`
+		ExclusiveMinimum     *float64           `json:"exclusiveMinimum,omitempty"`
+		ExclusiveMaximum     *float64           `json:"exclusiveMaximum,omitempty"`
+		Pattern              string             `json:"pattern,omitempty"`
+	}
+)
+
+// New returns an empty document with the given info.
+func New(info Info) *Document {
+	return &Document{
+		OpenAPI: Version,
+		Info:    info,
+		Paths:   make(map[string]PathItem),
+		Components: &Components{
+			Schemas:         make(map[string]*Schema),
+			SecuritySchemes: make(map[string]*SecurityScheme),
+		},
+	}
+}
+
+// AddOperation registers operation under method and an OpenAPI path such as
+// /v1/orders/{id}.
+func (d *Document) AddOperation(method, path string, operation *Operation) {
+
+	item, ok := d.Paths[path]
+	if !ok {
+		item = make(PathItem)
+		d.Paths[path] = item
+	}
+
+	item[strings.ToLower(method)] = operation
+}
+
+// HasOperation reports whether the document describes method on path.
+func (d *Document) HasOperation(method, path string) bool {
+
+	_, ok := d.Paths[path][strings.ToLower(method)]
+	return ok
+}
+
+// JSONContent is a content map with a single application/json media type.
+func JSONContent(schema *Schema) map[string]*MediaType {
+	return map[string]*MediaType{
+		"application/json": {Schema: schema},
+	}
+}
@@ -0,0 +1,231 @@
+package openapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ernestngugi/sil-backend/internal/forms"
+)
+
+const schemaRefPrefix = "#/components/schemas/"
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+)
+
+// Schema registers the JSON schema of v's type as a component called name and
+// returns a reference to it. Properties follow the `json` tags of the type and
+// constraints are taken from its `validate` tags, so the document cannot drift
+// from the rules forms.Validate enforces. Nested named structs are registered
+// under their Go type name.
+func (d *Document) Schema(name string, v any) *Schema {
+	return d.namedSchema(name, reflect.TypeOf(v))
+}
+
+// Ref returns a reference to the component schema called name.
+func Ref(name string) *Schema {
+	return &Schema{Ref: schemaRefPrefix + name}
+}
+
+func (d *Document) namedSchema(name string, t reflect.Type) *Schema {
+
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	if _, ok := d.Components.Schemas[name]; !ok {
+		// Reserve the name first so self-referencing types terminate.
+		d.Components.Schemas[name] = &Schema{}
+		*d.Components.Schemas[name] = *d.structSchema(t)
+	}
+
+	return Ref(name)
+}
+
+func (d *Document) schemaFor(t reflect.Type) *Schema {
+
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch t {
+	case timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case rawMessageType:
+		return &Schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		if t.Name() == "" {
+			return d.structSchema(t)
+		}
+		return d.namedSchema(t.Name(), t)
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: d.schemaFor(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: d.schemaFor(t.Elem())}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	default:
+		return &Schema{}
+	}
+}
+
+func (d *Document) structSchema(t reflect.Type) *Schema {
+
+	schema := &Schema{
+		Type:       "object",
+		Properties: make(map[string]*Schema),
+	}
+
+	d.addFields(schema, t)
+
+	return schema
+}
+
+func (d *Document) addFields(schema *Schema, t reflect.Type) {
+
+	for i := 0; i < t.NumField(); i++ {
+
+		field := t.Field(i)
+
+		name, options, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			d.addFields(schema, field.Type)
+			continue
+		}
+
+		if !field.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		property := d.schemaFor(field.Type)
+
+		rules, hasRules := field.Tag.Lookup("validate")
+		if hasRules {
+			applyRules(property, rules)
+		}
+
+		schema.Properties[name] = property
+
+		// Input forms are required when validated as such; output types
+		// always carry a field unless it is omitted when empty.
+		if hasRules && listContains(rules, "required") || !hasRules && !listContains(options, "omitempty") {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+}
+
+// applyRules maps the validate rules that have a JSON schema equivalent onto
+// schema. Rules after "dive" apply to the items of a list. Rules without an
+// equivalent are left to the API's error responses.
+func applyRules(schema *Schema, rules string) {
+
+	isString := schema.Type == "string"
+	isArray := schema.Type == "array"
+
+	for _, rule := range strings.Split(rules, ",") {
+
+		tag, param, _ := strings.Cut(rule, "=")
+
+		switch tag {
+		case "dive":
+			if schema.Items != nil {
+				_, itemRules, _ := strings.Cut(rules, "dive,")
+				applyRules(schema.Items, itemRules)
+			}
+			return
+		case "url":
+			schema.Format = "uri"
+		case "notblank":
+			schema.MinLength = intPtr(1)
+		case "email":
+			schema.Format = "email"
+		case "oneof":
+			schema.Enum = strings.Fields(param)
+		case "pattern":
+			if pattern, ok := forms.Pattern(param); ok {
+				schema.Pattern = pattern
+			}
+		case "len":
+			if isString {
+				schema.MinLength, schema.MaxLength = parseInt(param), parseInt(param)
+			}
+		case "min":
+			if isString {
+				schema.MinLength = parseInt(param)
+			} else if isArray {
+				schema.MinItems = parseInt(param)
+			} else {
+				schema.Minimum = parseFloat(param)
+			}
+		case "max":
+			if isString {
+				schema.MaxLength = parseInt(param)
+			} else if isArray {
+				schema.MaxItems = parseInt(param)
+			} else {
+				schema.Maximum = parseFloat(param)
+			}
+		case "gt":
+			schema.ExclusiveMinimum = parseFloat(param)
+		case "gte":
+			schema.Minimum = parseFloat(param)
+		case "lt":
+			schema.ExclusiveMaximum = parseFloat(param)
+		case "lte":
+			schema.Maximum = parseFloat(param)
+		}
+	}
+}
+
+// listContains reports whether the comma separated list contains item.
+func listContains(list, item string) bool {
+	for _, element := range strings.Split(list, ",") {
+		if element == item {
+			return true
+		}
+	}
+	return false
+}
+
+func intPtr(i int) *int {
+	return &i
+}
+
+func parseInt(s string) *int {
+	i, err := strconv.Atoi(s)
+	if err != nil {
+		return nil
+	}
+	return &i
+}
+
+func parseFloat(s string) *float64 {
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return nil
+	}
+	return &f
+}
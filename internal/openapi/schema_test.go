@@ -0,0 +1,66 @@
+package openapi
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testLine struct {
+	Quantity int `json:"quantity" validate:"gte=1,lte=100"`
+}
+
+type testForm struct {
+	Name    string     `json:"name" validate:"required,notblank,max=50"`
+	Phone   string     `json:"phone" validate:"pattern=phone"`
+	Status  string     `json:"status" validate:"oneof=pending paid"`
+	Amount  float64    `json:"amount" validate:"gt=0"`
+	Lines   []testLine `json:"lines" validate:"dive"`
+	Ignored string     `json:"-"`
+}
+
+type testModel struct {
+	ID      int64     `json:"id"`
+	Created time.Time `json:"date_created"`
+	Note    string    `json:"note,omitempty"`
+}
+
+func TestSchema(t *testing.T) {
+
+	t.Run("derives constraints from validate tags", func(t *testing.T) {
+
+		doc := New(Info{Title: "test", Version: "1"})
+
+		ref := doc.Schema("Form", testForm{})
+		assert.Equal(t, "#/components/schemas/Form", ref.Ref)
+
+		schema := doc.Components.Schemas["Form"]
+		assert.Equal(t, []string{"name"}, schema.Required)
+		assert.NotContains(t, schema.Properties, "Ignored")
+
+		assert.Equal(t, 50, *schema.Properties["name"].MaxLength)
+		assert.Equal(t, 1, *schema.Properties["name"].MinLength)
+		assert.Equal(t, `^254[17]\d{8}$`, schema.Properties["phone"].Pattern)
+		assert.Equal(t, []string{"pending", "paid"}, schema.Properties["status"].Enum)
+		assert.Equal(t, 0.0, *schema.Properties["amount"].ExclusiveMinimum)
+
+		assert.Equal(t, "array", schema.Properties["lines"].Type)
+		assert.Equal(t, "#/components/schemas/testLine", schema.Properties["lines"].Items.Ref)
+
+		line := doc.Components.Schemas["testLine"]
+		assert.Equal(t, 1.0, *line.Properties["quantity"].Minimum)
+		assert.Equal(t, 100.0, *line.Properties["quantity"].Maximum)
+	})
+
+	t.Run("marks output fields required unless omitted when empty", func(t *testing.T) {
+
+		doc := New(Info{Title: "test", Version: "1"})
+		doc.Schema("Model", &testModel{})
+
+		schema := doc.Components.Schemas["Model"]
+		assert.Equal(t, []string{"id", "date_created"}, schema.Required)
+		assert.Equal(t, "int64", schema.Properties["id"].Format)
+		assert.Equal(t, "date-time", schema.Properties["date_created"].Format)
+	})
+}
@@ -0,0 +1,224 @@
+package orderevents
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Channel is the Postgres notification channel the order_events trigger
+// notifies on.
+const Channel = "order_events"
+
+const (
+	minReconnectInterval = time.Second
+	maxReconnectInterval = time.Minute
+
+	// pingInterval is how often an idle listener checks its connection, so a
+	// silently dropped one is noticed and re-established.
+	pingInterval = 90 * time.Second
+)
+
+type (
+	// Broker listens for order event notifications and wakes the
+	// subscriptions of the customer each event belongs to. One broker per
+	// process holds a single database connection however many clients are
+	// streaming, and every replica sees events written by the others.
+	Broker interface {
+		Subscribe(customerID int64) *Subscription
+		Start()
+		Shutdown(ctx context.Context) error
+	}
+
+	// Subscription signals on C when new events may be available for a
+	// customer. Signals are coalesced, so readers should load every event
+	// after the last one they saw rather than count signals.
+	Subscription struct {
+		C <-chan struct{}
+		// Done is closed when the broker shuts down.
+		Done <-chan struct{}
+
+		customerID int64
+		wake       chan struct{}
+		broker     *broker
+	}
+
+	// notification is the payload sent by the order_events trigger.
+	notification struct {
+		ID         int64 `json:"id"`
+		OrderID    int64 `json:"order_id"`
+		CustomerID int64 `json:"customer_id"`
+	}
+
+	broker struct {
+		databaseURL string
+		logger      *slog.Logger
+
+		mu            sync.Mutex
+		subscriptions map[int64]map[*Subscription]struct{}
+
+		once    sync.Once
+		stop    chan struct{}
+		stopped chan struct{}
+	}
+)
+
+func NewBroker(databaseURL string, logger *slog.Logger) Broker {
+	return &broker{
+		databaseURL:   databaseURL,
+		logger:        logger,
+		subscriptions: make(map[int64]map[*Subscription]struct{}),
+		stop:          make(chan struct{}),
+		stopped:       make(chan struct{}),
+	}
+}
+
+func (b *broker) Subscribe(customerID int64) *Subscription {
+
+	wake := make(chan struct{}, 1)
+
+	subscription := &Subscription{
+		C:          wake,
+		Done:       b.stop,
+		customerID: customerID,
+		wake:       wake,
+		broker:     b,
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.subscriptions[customerID] == nil {
+		b.subscriptions[customerID] = make(map[*Subscription]struct{})
+	}
+
+	b.subscriptions[customerID][subscription] = struct{}{}
+
+	return subscription
+}
+
+// Close stops signalling the subscription.
+func (s *Subscription) Close() {
+
+	b := s.broker
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	delete(b.subscriptions[s.customerID], s)
+
+	if len(b.subscriptions[s.customerID]) == 0 {
+		delete(b.subscriptions, s.customerID)
+	}
+}
+
+// Start listens for notifications until Shutdown is called.
+func (b *broker) Start() {
+	go b.run()
+}
+
+// Shutdown stops listening and closes Done on every subscription so that open
+// streams end and the HTTP server can drain.
+func (b *broker) Shutdown(ctx context.Context) error {
+
+	b.once.Do(func() { close(b.stop) })
+
+	select {
+	case <-b.stopped:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (b *broker) run() {
+	defer close(b.stopped)
+
+	listener := pq.NewListener(b.databaseURL, minReconnectInterval, maxReconnectInterval, b.logListenerEvent)
+	defer listener.Close()
+
+	// Listen blocks until the database is reachable, so it must not hold up
+	// shutdown.
+	go func() {
+		err := listener.Listen(Channel)
+		if err != nil {
+			b.logger.Error("failed to listen for order events", slog.String("error", err.Error()))
+		}
+	}()
+
+	ticker := time.NewTicker(pingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-b.stop:
+			return
+
+		case n := <-listener.Notify:
+			if n == nil {
+				// Notifications sent while reconnecting are lost, so
+				// every stream catches up from the table.
+				b.wakeAll()
+				continue
+			}
+			b.notify(n.Extra)
+
+		case <-ticker.C:
+			go listener.Ping()
+		}
+	}
+}
+
+func (b *broker) notify(payload string) {
+
+	var n notification
+
+	err := json.Unmarshal([]byte(payload), &n)
+	if err != nil {
+		b.logger.Warn("invalid order event notification", slog.String("payload", payload), slog.String("error", err.Error()))
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for subscription := range b.subscriptions[n.CustomerID] {
+		subscription.signal()
+	}
+}
+
+func (b *broker) wakeAll() {
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, subscriptions := range b.subscriptions {
+		for subscription := range subscriptions {
+			subscription.signal()
+		}
+	}
+}
+
+func (s *Subscription) signal() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (b *broker) logListenerEvent(event pq.ListenerEventType, err error) {
+	switch event {
+	case pq.ListenerEventConnected:
+		b.logger.Info("listening for order events")
+	case pq.ListenerEventDisconnected:
+		b.logger.Warn("order event listener disconnected", slog.String("error", err.Error()))
+	case pq.ListenerEventReconnected:
+		b.logger.Info("order event listener reconnected")
+	case pq.ListenerEventConnectionAttemptFailed:
+		b.logger.Warn("order event listener failed to connect", slog.String("error", err.Error()))
+	}
+}
@@ -0,0 +1,114 @@
+package orderevents
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ernestngugi/sil-backend/internal/logging"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBroker(t *testing.T) {
+
+	t.Run("wakes only the subscriptions of the event's customer", func(t *testing.T) {
+
+		b := NewBroker("", logging.Discard()).(*broker)
+
+		first := b.Subscribe(1)
+		defer first.Close()
+
+		other := b.Subscribe(2)
+		defer other.Close()
+
+		b.notify(`{"id":10,"order_id":5,"customer_id":1}`)
+
+		assert.True(t, signalled(first))
+		assert.False(t, signalled(other))
+	})
+
+	t.Run("coalesces signals until they are read", func(t *testing.T) {
+
+		b := NewBroker("", logging.Discard()).(*broker)
+
+		subscription := b.Subscribe(1)
+		defer subscription.Close()
+
+		b.notify(`{"id":10,"order_id":5,"customer_id":1}`)
+		b.notify(`{"id":11,"order_id":5,"customer_id":1}`)
+
+		assert.True(t, signalled(subscription))
+		assert.False(t, signalled(subscription))
+	})
+
+	t.Run("stops signalling closed subscriptions", func(t *testing.T) {
+
+		b := NewBroker("", logging.Discard()).(*broker)
+
+		subscription := b.Subscribe(1)
+		subscription.Close()
+
+		b.notify(`{"id":10,"order_id":5,"customer_id":1}`)
+
+		assert.False(t, signalled(subscription))
+		assert.Empty(t, b.subscriptions)
+	})
+
+	t.Run("ignores malformed notifications", func(t *testing.T) {
+
+		b := NewBroker("", logging.Discard()).(*broker)
+
+		subscription := b.Subscribe(1)
+		defer subscription.Close()
+
+		b.notify("not json")
+
+		assert.False(t, signalled(subscription))
+	})
+
+	t.Run("wakes every subscription after reconnecting", func(t *testing.T) {
+
+		b := NewBroker("", logging.Discard()).(*broker)
+
+		first := b.Subscribe(1)
+		defer first.Close()
+
+		other := b.Subscribe(2)
+		defer other.Close()
+
+		b.wakeAll()
+
+		assert.True(t, signalled(first))
+		assert.True(t, signalled(other))
+	})
+
+	t.Run("ends subscriptions on shutdown", func(t *testing.T) {
+
+		b := NewBroker("postgres://localhost:1/unreachable?sslmode=disable&connect_timeout=1", logging.Discard())
+		b.Start()
+
+		subscription := b.Subscribe(1)
+		defer subscription.Close()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		err := b.Shutdown(ctx)
+		assert.NoError(t, err)
+
+		select {
+		case <-subscription.Done:
+		default:
+			t.Fatal("subscription not done after shutdown")
+		}
+	})
+}
+
+func signalled(subscription *Subscription) bool {
+	select {
+	case <-subscription.C:
+		return true
+	default:
+		return false
+	}
+}
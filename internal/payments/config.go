@@ -0,0 +1,44 @@
+package payments
+
+import (
+	"time"
+
+	"github.com/ernestngugi/sil-backend/internal/env"
+)
+
+type Config struct {
+	PollInterval time.Duration
+	// QueryAfter is how long a payment waits for its callback before its
+	// status is queried.
+	QueryAfter time.Duration
+	// ExpireAfter is how long a payment may go unanswered before it is
+	// marked failed.
+	ExpireAfter time.Duration
+	BatchSize   int
+}
+
+// ConfigFromEnv reads the PAYMENT_* settings.
+func ConfigFromEnv() (*Config, error) {
+
+	config := &Config{}
+
+	var err error
+
+	if config.PollInterval, err = env.Duration("PAYMENT_POLL_INTERVAL", 30*time.Second); err != nil {
+		return &Config{}, err
+	}
+
+	if config.QueryAfter, err = env.Duration("PAYMENT_QUERY_AFTER", time.Minute); err != nil {
+		return &Config{}, err
+	}
+
+	if config.ExpireAfter, err = env.Duration("PAYMENT_EXPIRE_AFTER", 5*time.Minute); err != nil {
+		return &Config{}, err
+	}
+
+	if config.BatchSize, err = env.Int("PAYMENT_BATCH_SIZE", 20); err != nil {
+		return &Config{}, err
+	}
+
+	return config, nil
+}
@@ -0,0 +1,78 @@
+package payments
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/ernestngugi/sil-backend/internal/db"
+)
+
+type (
+	// Poller reconciles payments whose callbacks are late in the background.
+	Poller interface {
+		Start()
+		Shutdown(ctx context.Context) error
+	}
+
+	poller struct {
+		dB        db.DB
+		processor Processor
+		config    *Config
+		logger    *slog.Logger
+
+		once    sync.Once
+		stop    chan struct{}
+		stopped chan struct{}
+	}
+)
+
+func NewPoller(dB db.DB, processor Processor, config *Config, logger *slog.Logger) Poller {
+	return &poller{
+		dB:        dB,
+		processor: processor,
+		config:    config,
+		logger:    logger,
+		stop:      make(chan struct{}),
+		stopped:   make(chan struct{}),
+	}
+}
+
+// Start reconciles payments every PollInterval until Shutdown is called.
+func (p *poller) Start() {
+	go p.run()
+}
+
+// Shutdown stops polling and waits for the queries in flight.
+func (p *poller) Shutdown(ctx context.Context) error {
+
+	p.once.Do(func() { close(p.stop) })
+
+	select {
+	case <-p.stopped:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (p *poller) run() {
+	defer close(p.stopped)
+
+	ticker := time.NewTicker(p.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-ticker.C:
+		}
+
+		_, err := p.processor.Reconcile(context.Background(), p.dB)
+		if err != nil {
+			p.logger.Error("failed to reconcile payments", slog.String("error", err.Error()))
+		}
+	}
+}
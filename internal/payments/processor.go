@@ -0,0 +1,465 @@
+package payments
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"time"
+
+	"github.com/ernestngugi/sil-backend/internal/apperrors"
+	"github.com/ernestngugi/sil-backend/internal/db"
+	"github.com/ernestngugi/sil-backend/internal/invoices"
+	"github.com/ernestngugi/sil-backend/internal/loyalty"
+	"github.com/ernestngugi/sil-backend/internal/model"
+	"github.com/ernestngugi/sil-backend/internal/notifications"
+	"github.com/ernestngugi/sil-backend/internal/repos"
+	"github.com/ernestngugi/sil-backend/providers"
+)
+
+// expiredDescription is recorded on payments nobody answered.
+const expiredDescription = "no result from M-Pesa before the payment expired"
+
+var (
+	errOrderAlreadyPaid  = apperrors.Conflict("order_already_paid", "order has already been paid")
+	errPaymentInProgress = apperrors.Conflict("payment_in_progress", "a payment for the order is waiting for the customer to approve it")
+	errOrderCancelled    = apperrors.Conflict("order_cancelled", "cancelled orders cannot be paid")
+	errOrderRefunded     = apperrors.Conflict("order_refunded", "refunded orders cannot be paid again")
+	errMPesaUnavailable  = apperrors.Unavailable("mpesa_unavailable", "M-Pesa could not be reached, try again later")
+	errPushUnconfirmed   = apperrors.Unavailable("mpesa_unconfirmed", "M-Pesa did not confirm the payment prompt, the customer may still receive it")
+)
+
+type (
+	// Processor collects order payments with M-Pesa STK Push and records
+	// their results, whether they arrive by callback or status query.
+	Processor interface {
+		Initiate(ctx context.Context, dB db.DB, order *model.Order, phoneNumber string) (*model.Payment, error)
+		Complete(ctx context.Context, dB db.DB, result *model.PaymentResult) (*model.Payment, error)
+		Reconcile(ctx context.Context, dB db.DB) (int, error)
+	}
+
+	processor struct {
+		mpesa              providers.MPesa
+		customerRepository repos.CustomerRepository
+		orderRepository    repos.OrderRepository
+		paymentRepository  repos.PaymentRepository
+		notifier           notifications.Notifier
+		invoicer           invoices.Invoicer
+		ledger             loyalty.Ledger
+		config             *Config
+		logger             *slog.Logger
+	}
+
+	// outcome is what a payment ends with. ResultCode is nil for payments
+	// M-Pesa never reported on, and amount is zero when M-Pesa did not say
+	// how much was paid.
+	outcome struct {
+		status        model.PaymentStatus
+		resultCode    *int
+		description   string
+		receiptNumber string
+		amount        float64
+	}
+)
+
+func NewProcessor(
+	mpesa providers.MPesa,
+	customerRepository repos.CustomerRepository,
+	orderRepository repos.OrderRepository,
+	paymentRepository repos.PaymentRepository,
+	notifier notifications.Notifier,
+	invoicer invoices.Invoicer,
+	ledger loyalty.Ledger,
+	config *Config,
+	logger *slog.Logger,
+) Processor {
+	return &processor{
+		mpesa:              mpesa,
+		customerRepository: customerRepository,
+		orderRepository:    orderRepository,
+		paymentRepository:  paymentRepository,
+		notifier:           notifier,
+		invoicer:           invoicer,
+		ledger:             ledger,
+		config:             config,
+		logger:             logger,
+	}
+}
+
+// Initiate prompts phoneNumber to pay for order. M-Pesa only takes whole
+// shillings, so amounts with cents are rounded up. A push Daraja may have
+// taken without saying so, when it times out say, leaves the payment pending
+// until its callback arrives or it expires.
+func (p *processor) Initiate(
+	ctx context.Context,
+	dB db.DB,
+	order *model.Order,
+	phoneNumber string,
+) (*model.Payment, error) {
+
+	switch {
+	case order.PaymentStatus == model.PaymentStatusPaid:
+		return &model.Payment{}, errOrderAlreadyPaid
+	case order.PaymentStatus == model.PaymentStatusRefunded:
+		return &model.Payment{}, errOrderRefunded
+	case order.PaymentStatus == model.PaymentStatusPending:
+		return &model.Payment{}, errPaymentInProgress
+	case order.Status == model.OrderStatusCancelled:
+		return &model.Payment{}, errOrderCancelled
+	}
+
+	payment := &model.Payment{
+		OrderID:     order.ID,
+		PhoneNumber: phoneNumber,
+		Amount:      order.Amount,
+	}
+
+	// Moving the order to pending first means two requests cannot both
+	// prompt the customer.
+	err := db.WithTransaction(ctx, dB, func(operations db.SQLOperations) error {
+
+		err := p.orderRepository.UpdatePaymentStatus(ctx, operations, order, model.PaymentStatusPending)
+		if err != nil {
+			return err
+		}
+
+		return p.paymentRepository.Save(ctx, operations, payment)
+	})
+	if err != nil {
+		return &model.Payment{}, err
+	}
+
+	logger := p.logger.With(slog.Int64("order_id", order.ID), slog.Int64("payment_id", payment.ID))
+
+	response, err := p.mpesa.STKPush(ctx, &model.STKPushRequest{
+		PhoneNumber:      phoneNumber,
+		Amount:           int64(math.Ceil(order.Amount)),
+		AccountReference: fmt.Sprintf("Order %d", order.ID),
+		Description:      fmt.Sprintf("Order %d", order.ID),
+	})
+	if err != nil {
+
+		if !errors.Is(err, providers.ErrSTKPushNotSent) {
+			logger.WarnContext(ctx, "stk push not confirmed", slog.String("error", err.Error()))
+			return &model.Payment{}, errPushUnconfirmed.Wrap(err)
+		}
+
+		logger.ErrorContext(ctx, "failed to send stk push", slog.String("error", err.Error()))
+
+		_, completeErr := p.complete(ctx, dB, p.byID(ctx, payment.ID), &outcome{
+			status:      model.PaymentStatusFailed,
+			description: err.Error(),
+		})
+		if completeErr != nil {
+			logger.ErrorContext(ctx, "failed to record stk push failure", slog.String("error", completeErr.Error()))
+		}
+
+		return &model.Payment{}, errMPesaUnavailable.Wrap(err)
+	}
+
+	payment.MerchantRequestID = response.MerchantRequestID
+	payment.CheckoutRequestID = response.CheckoutRequestID
+
+	err = p.paymentRepository.Update(ctx, dB, payment)
+	if err != nil {
+		return &model.Payment{}, err
+	}
+
+	logger.InfoContext(ctx, "stk push sent", slog.String("checkout_request_id", payment.CheckoutRequestID))
+
+	return payment, nil
+}
+
+// Complete records the result M-Pesa reported for a payment. Results for
+// payments that already have one are ignored, so callbacks may be repeated.
+// Successful payments whose push Daraja never confirmed are matched by the
+// number that paid and the amount, and take the request IDs in the result.
+func (p *processor) Complete(
+	ctx context.Context,
+	dB db.DB,
+	result *model.PaymentResult,
+) (*model.Payment, error) {
+
+	find := func(operations db.SQLOperations) (*model.Payment, error) {
+
+		payment, err := p.paymentRepository.PaymentByCheckoutRequestID(ctx, operations, result.CheckoutRequestID)
+		if !apperrors.Is(err, apperrors.KindNotFound) || result.PhoneNumber == "" {
+			return payment, err
+		}
+
+		payment, err = p.paymentRepository.UnconfirmedPayment(ctx, operations, notifications.NormalizePhoneNumber(result.PhoneNumber), int64(result.Amount))
+		if err != nil {
+			return &model.Payment{}, err
+		}
+
+		payment.MerchantRequestID = result.MerchantRequestID
+		payment.CheckoutRequestID = result.CheckoutRequestID
+
+		return payment, nil
+	}
+
+	return p.complete(ctx, dB, find, resultOutcome(result))
+}
+
+// Reconcile queries M-Pesa for payments whose callback has not arrived within
+// QueryAfter, and fails those still unanswered after ExpireAfter. It returns
+// how many payments it looked at.
+func (p *processor) Reconcile(ctx context.Context, dB db.DB) (int, error) {
+
+	now := time.Now()
+
+	payments, err := p.paymentRepository.PendingPayments(ctx, dB, now.Add(-p.config.QueryAfter), p.config.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, payment := range payments {
+		p.reconcile(ctx, dB, payment, now)
+	}
+
+	return len(payments), nil
+}
+
+func (p *processor) reconcile(ctx context.Context, dB db.DB, payment *model.Payment, now time.Time) {
+
+	logger := p.logger.With(slog.Int64("order_id", payment.OrderID), slog.Int64("payment_id", payment.ID))
+
+	var err error
+
+	// Payments without a checkout request ID were never accepted by
+	// M-Pesa, so there is nothing to query.
+	if payment.CheckoutRequestID != "" {
+
+		var result *model.PaymentResult
+
+		result, err = p.mpesa.STKQuery(ctx, payment.CheckoutRequestID)
+		if err == nil {
+
+			_, err = p.complete(ctx, dB, p.byID(ctx, payment.ID), resultOutcome(result))
+			if err != nil {
+				logger.ErrorContext(ctx, "failed to record queried payment result", slog.String("error", err.Error()))
+			}
+
+			return
+		}
+
+		if !errors.Is(err, providers.ErrSTKPushProcessing) {
+			logger.WarnContext(ctx, "failed to query payment status", slog.String("error", err.Error()))
+		}
+	}
+
+	if now.Sub(payment.DateCreated) < p.config.ExpireAfter {
+		return
+	}
+
+	_, err = p.complete(ctx, dB, p.byID(ctx, payment.ID), &outcome{
+		status:      model.PaymentStatusFailed,
+		description: expiredDescription,
+	})
+	if err != nil {
+		logger.ErrorContext(ctx, "failed to expire payment", slog.String("error", err.Error()))
+		return
+	}
+
+	logger.InfoContext(ctx, "payment expired")
+}
+
+// complete records the outcome on the payment find locks, and on its order,
+// credits the loyalty points a paid order earns, then issues a receipt and
+// thanks the customer for successful payments. A success reported after the
+// payment expired is still recorded, since the money was taken. So is one for
+// a different amount than was asked for, but as a failure that leaves the
+// order unpaid and has to be looked into. A success for an order cancelled
+// while the customer was paying is recorded on the payment alone.
+func (p *processor) complete(
+	ctx context.Context,
+	dB db.DB,
+	find func(operations db.SQLOperations) (*model.Payment, error),
+	result *outcome,
+) (*model.Payment, error) {
+
+	var payment *model.Payment
+	var order *model.Order
+	var completed bool
+
+	err := db.WithTransaction(ctx, dB, func(operations db.SQLOperations) error {
+
+		var err error
+
+		payment, err = find(operations)
+		if err != nil {
+			return err
+		}
+
+		expired := payment.Status == model.PaymentStatusFailed && payment.ResultCode == nil && payment.CheckoutRequestID != ""
+
+		if payment.Status != model.PaymentStatusPending && !(expired && result.status == model.PaymentStatusPaid) {
+			return nil
+		}
+
+		if result.status == model.PaymentStatusPaid && result.amount != 0 && result.amount != math.Ceil(payment.Amount) {
+
+			p.logger.ErrorContext(ctx, "payment amount does not match the order",
+				slog.Int64("order_id", payment.OrderID),
+				slog.Int64("payment_id", payment.ID),
+				slog.Float64("amount", result.amount),
+				slog.String("receipt_number", result.receiptNumber),
+			)
+
+			result = &outcome{
+				status:        model.PaymentStatusFailed,
+				resultCode:    result.resultCode,
+				description:   fmt.Sprintf("M-Pesa reported KES %v paid instead of KES %v", result.amount, math.Ceil(payment.Amount)),
+				receiptNumber: result.receiptNumber,
+			}
+		}
+
+		timeNow := time.Now()
+
+		payment.Status = result.status
+		payment.ResultCode = result.resultCode
+		payment.ResultDescription = result.description
+		payment.ReceiptNumber = result.receiptNumber
+		payment.DateCompleted = &timeNow
+
+		err = p.paymentRepository.Update(ctx, operations, payment)
+		if err != nil {
+			return err
+		}
+
+		order, err = p.orderRepository.OrderByID(ctx, operations, payment.OrderID)
+		if err != nil {
+			return err
+		}
+
+		completed = true
+
+		// Cancelling an order took back its points and returned its stock,
+		// so money taken for it afterwards has to be refunded instead.
+		if order.Status == model.OrderStatusCancelled {
+
+			if result.status == model.PaymentStatusPaid {
+				p.logger.WarnContext(ctx, "cancelled order was paid and needs a refund",
+					slog.Int64("order_id", order.ID),
+					slog.Int64("payment_id", payment.ID),
+					slog.String("receipt_number", payment.ReceiptNumber),
+				)
+			}
+
+			return nil
+		}
+
+		// A paid order stays paid. Paying twice needs a refund, which
+		// someone has to look into, as does paying for a refunded order.
+		if order.PaymentStatus == model.PaymentStatusPaid || order.PaymentStatus == model.PaymentStatusRefunded {
+
+			if result.status == model.PaymentStatusPaid {
+				p.logger.WarnContext(ctx, "order was paid more than once",
+					slog.Int64("order_id", order.ID),
+					slog.Int64("payment_id", payment.ID),
+				)
+			}
+
+			return nil
+		}
+
+		// A mismatched payment that had already expired leaves the order
+		// as it is, the customer may be paying for it again.
+		if expired && result.status != model.PaymentStatusPaid {
+			return nil
+		}
+
+		err = p.orderRepository.UpdatePaymentStatus(ctx, operations, order, result.status)
+		if err != nil {
+			return err
+		}
+
+		if result.status != model.PaymentStatusPaid {
+			return nil
+		}
+
+		return p.ledger.Earn(ctx, operations, order)
+	})
+	if err != nil {
+		return &model.Payment{}, err
+	}
+
+	if completed {
+
+		p.logger.InfoContext(ctx, "payment completed",
+			slog.Int64("order_id", payment.OrderID),
+			slog.Int64("payment_id", payment.ID),
+			slog.String("status", string(payment.Status)),
+		)
+
+		if payment.Status == model.PaymentStatusPaid && order.Status != model.OrderStatusCancelled {
+			p.issueReceipt(ctx, dB, order)
+			p.notifyPaid(ctx, dB, order, payment)
+		}
+	}
+
+	return payment, nil
+}
+
+// issueReceipt numbers the receipt for a paid order as soon as it is paid.
+// Receipts are also issued when first downloaded, so a failure here only
+// means the customer waits for theirs.
+func (p *processor) issueReceipt(ctx context.Context, dB db.DB, order *model.Order) {
+
+	_, err := p.invoicer.Receipt(ctx, dB, order)
+	if err != nil {
+		p.logger.ErrorContext(ctx, "failed to issue receipt", slog.Int64("order_id", order.ID), slog.String("error", err.Error()))
+	}
+}
+
+// notifyPaid confirms the payment to the customer, by SMS to the number that
+// paid unless they chose otherwise.
+func (p *processor) notifyPaid(ctx context.Context, dB db.DB, order *model.Order, payment *model.Payment) {
+
+	logger := p.logger.With(slog.Int64("order_id", order.ID), slog.Int64("payment_id", payment.ID))
+
+	customer, err := p.customerRepository.CustomerByID(ctx, dB, order.CustomerID)
+	if err != nil {
+		logger.ErrorContext(ctx, "failed to load customer to notify", slog.String("error", err.Error()))
+		return
+	}
+
+	data := notifications.OrderTemplateData(order)
+	data.Amount = model.FormatAmount(payment.Amount)
+	data.Receipt = payment.ReceiptNumber
+
+	err = p.notifier.Notify(ctx, dB, &notifications.Notification{
+		Customer:    customer,
+		Event:       model.NotificationOrderPaid,
+		Data:        data,
+		PhoneNumber: payment.PhoneNumber,
+	})
+	if err != nil {
+		logger.ErrorContext(ctx, "failed to notify customer", slog.String("error", err.Error()))
+	}
+}
+
+func (p *processor) byID(ctx context.Context, paymentID int64) func(operations db.SQLOperations) (*model.Payment, error) {
+	return func(operations db.SQLOperations) (*model.Payment, error) {
+		return p.paymentRepository.PaymentByID(ctx, operations, paymentID)
+	}
+}
+
+func resultOutcome(result *model.PaymentResult) *outcome {
+
+	status := model.PaymentStatusFailed
+	if result.ResultCode == model.STKResultSuccess {
+		status = model.PaymentStatusPaid
+	}
+
+	resultCode := result.ResultCode
+
+	return &outcome{
+		status:        status,
+		resultCode:    &resultCode,
+		description:   result.ResultDescription,
+		receiptNumber: result.ReceiptNumber,
+		amount:        result.Amount,
+	}
+}
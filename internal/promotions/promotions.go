@@ -0,0 +1,135 @@
+package promotions
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/ernestngugi/sil-backend/internal/apperrors"
+	"github.com/ernestngugi/sil-backend/internal/model"
+)
+
+var (
+	// ErrInvalidCode covers unknown and deactivated codes alike, so codes
+	// cannot be probed.
+	ErrInvalidCode = promoCodeError("promo_code_invalid", "promo code is not valid")
+
+	errNotStarted    = promoCodeError("promo_code_not_started", "promo code is not valid yet")
+	errExpired       = promoCodeError("promo_code_expired", "promo code has expired")
+	errNotApplicable = promoCodeError("promo_code_not_applicable", "promo code does not apply to this item")
+	errUsedUp        = apperrors.Conflict("promo_code_used_up", "promo code has been redeemed the maximum number of times")
+	errAlreadyUsed   = apperrors.Conflict("promo_code_already_used", "you have already redeemed this promo code the maximum number of times")
+)
+
+// Code is how promo codes are stored and looked up. Codes are matched
+// without regard to case, since customers type them in from an SMS.
+func Code(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// Discount checks that promotion can be applied to order at now, given how
+// many times the customer placing it has already redeemed it, and returns
+// the discount on the order's gross amount. On an order with lines only the
+// lines the promotion applies to are discounted. The discount never exceeds
+// the amount it is taken off, and always leaves model.MinPaymentAmount to pay.
+func Discount(
+	promotion *model.Promotion,
+	order *model.Order,
+	customerRedemptions int,
+	now time.Time,
+) (float64, error) {
+
+	if !promotion.Active {
+		return 0, ErrInvalidCode
+	}
+
+	if promotion.StartsAt != nil && now.Before(*promotion.StartsAt) {
+		return 0, errNotStarted
+	}
+
+	if promotion.EndsAt != nil && !now.Before(*promotion.EndsAt) {
+		return 0, errExpired
+	}
+
+	eligible := eligibleAmount(promotion, order)
+	if eligible == 0 {
+		return 0, errNotApplicable
+	}
+
+	if order.Amount < promotion.MinOrderAmount {
+		return 0, promoCodeError(
+			"promo_code_minimum_not_met",
+			fmt.Sprintf("promo code needs an order of at least KES %s", model.FormatAmount(promotion.MinOrderAmount)),
+		)
+	}
+
+	if promotion.UsageLimit != nil && promotion.TimesRedeemed >= *promotion.UsageLimit {
+		return 0, errUsedUp
+	}
+
+	if promotion.PerCustomerLimit != nil && customerRedemptions >= *promotion.PerCustomerLimit {
+		return 0, errAlreadyUsed
+	}
+
+	discount := promotion.DiscountValue
+	if promotion.DiscountType == model.DiscountPercentage {
+		discount = math.Round(eligible*promotion.DiscountValue) / 100
+	}
+
+	return math.Min(discount, math.Min(eligible, math.Max(order.Amount-model.MinPaymentAmount, 0))), nil
+}
+
+// Applies reports whether promotion targets item or tax category. Items are
+// matched without regard to case.
+func Applies(promotion *model.Promotion, item, category string) bool {
+
+	if len(promotion.Items) == 0 && len(promotion.TaxCategories) == 0 {
+		return true
+	}
+
+	for _, promoted := range promotion.Items {
+		if strings.EqualFold(strings.TrimSpace(promoted), strings.TrimSpace(item)) {
+			return true
+		}
+	}
+
+	for _, promoted := range promotion.TaxCategories {
+		if promoted == category {
+			return true
+		}
+	}
+
+	return false
+}
+
+// eligibleAmount is the part of order the promotion applies to.
+func eligibleAmount(promotion *model.Promotion, order *model.Order) float64 {
+
+	if len(order.Lines) == 0 {
+
+		if Applies(promotion, order.Item, order.Tax.Category) {
+			return order.Amount
+		}
+
+		return 0
+	}
+
+	var eligible float64
+
+	for _, line := range order.Lines {
+		if Applies(promotion, line.Item, line.Tax.Category) {
+			eligible += line.Amount
+		}
+	}
+
+	return math.Round(eligible*100) / 100
+}
+
+func promoCodeError(code, message string) *apperrors.Error {
+	return apperrors.Validation(code, message, apperrors.FieldError{
+		Field:   "promo_code",
+		Code:    code,
+		Message: message,
+	})
+}
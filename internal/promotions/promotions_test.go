@@ -0,0 +1,122 @@
+package promotions
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ernestngugi/sil-backend/internal/apperrors"
+	"github.com/ernestngugi/sil-backend/internal/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCode(t *testing.T) {
+	assert.Equal(t, "KARIBU-10", Code(" karibu-10 "))
+}
+
+func TestDiscount(t *testing.T) {
+
+	now := time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)
+	order := &model.Order{Item: "Maize flour 2kg", Amount: 1250, Tax: model.OrderTax{Category: "zero_rated"}}
+
+	limit := func(n int) *int { return &n }
+	at := func(hours int) *time.Time {
+		t := now.Add(time.Duration(hours) * time.Hour)
+		return &t
+	}
+
+	t.Run("takes a percentage off", func(t *testing.T) {
+
+		discount, err := Discount(&model.Promotion{Active: true, DiscountType: model.DiscountPercentage, DiscountValue: 10}, order, 0, now)
+		assert.NoError(t, err)
+		assert.Equal(t, 125.0, discount)
+
+		discount, err = Discount(&model.Promotion{Active: true, DiscountType: model.DiscountPercentage, DiscountValue: 12.5}, &model.Order{Amount: 99.99}, 0, now)
+		assert.NoError(t, err)
+		assert.Equal(t, 12.5, discount)
+	})
+
+	t.Run("takes a fixed amount off, leaving a shilling to pay", func(t *testing.T) {
+
+		discount, err := Discount(&model.Promotion{Active: true, DiscountType: model.DiscountFixed, DiscountValue: 200}, order, 0, now)
+		assert.NoError(t, err)
+		assert.Equal(t, 200.0, discount)
+
+		discount, err = Discount(&model.Promotion{Active: true, DiscountType: model.DiscountFixed, DiscountValue: 5000}, order, 0, now)
+		assert.NoError(t, err)
+		assert.Equal(t, 1249.0, discount)
+
+		discount, err = Discount(&model.Promotion{Active: true, DiscountType: model.DiscountPercentage, DiscountValue: 100}, order, 0, now)
+		assert.NoError(t, err)
+		assert.Equal(t, 1249.0, discount)
+	})
+
+	t.Run("applies to targeted items and categories", func(t *testing.T) {
+
+		for _, promotion := range []*model.Promotion{
+			{Items: []string{"Sugar 1kg", "maize flour 2kg"}},
+			{TaxCategories: []string{"zero_rated"}},
+			{Items: []string{"Sugar 1kg"}, TaxCategories: []string{"zero_rated"}},
+		} {
+			promotion.Active = true
+			promotion.DiscountType = model.DiscountFixed
+			promotion.DiscountValue = 50
+
+			discount, err := Discount(promotion, order, 0, now)
+			assert.NoError(t, err)
+			assert.Equal(t, 50.0, discount)
+		}
+	})
+
+	t.Run("discounts only the lines it applies to", func(t *testing.T) {
+
+		order := &model.Order{Amount: 1450, Lines: []*model.OrderLine{
+			{Item: "Maize flour 2kg", Amount: 250, Tax: model.OrderTax{Category: "zero_rated"}},
+			{Item: "Sugar 1kg", Amount: 200, Tax: model.OrderTax{Category: "standard"}},
+			{Item: "Cooking oil 1l", Amount: 1000, Tax: model.OrderTax{Category: "standard"}},
+		}}
+
+		discount, err := Discount(&model.Promotion{Active: true, DiscountType: model.DiscountPercentage, DiscountValue: 10, TaxCategories: []string{"standard"}}, order, 0, now)
+		assert.NoError(t, err)
+		assert.Equal(t, 120.0, discount)
+
+		discount, err = Discount(&model.Promotion{Active: true, DiscountType: model.DiscountFixed, DiscountValue: 500, Items: []string{"MAIZE FLOUR 2KG"}}, order, 0, now)
+		assert.NoError(t, err)
+		assert.Equal(t, 250.0, discount)
+
+		_, err = Discount(&model.Promotion{Active: true, DiscountType: model.DiscountFixed, DiscountValue: 50, Items: []string{"Rice 2kg"}}, order, 0, now)
+		assert.Equal(t, "promo_code_not_applicable", apperrors.From(err).Code)
+	})
+
+	t.Run("rejects promotions that do not apply", func(t *testing.T) {
+
+		for code, promotion := range map[string]*model.Promotion{
+			"promo_code_invalid":         {},
+			"promo_code_not_started":     {Active: true, StartsAt: at(1)},
+			"promo_code_expired":         {Active: true, StartsAt: at(-2), EndsAt: at(0)},
+			"promo_code_not_applicable":  {Active: true, Items: []string{"Sugar 1kg"}, TaxCategories: []string{"standard"}},
+			"promo_code_minimum_not_met": {Active: true, MinOrderAmount: 1500},
+		} {
+			_, err := Discount(promotion, order, 0, now)
+			assert.Equal(t, code, apperrors.From(err).Code)
+			assert.True(t, apperrors.Is(err, apperrors.KindValidation), code)
+			assert.Equal(t, "promo_code", apperrors.From(err).Fields[0].Field)
+		}
+	})
+
+	t.Run("enforces usage limits", func(t *testing.T) {
+
+		promotion := &model.Promotion{Active: true, DiscountType: model.DiscountFixed, DiscountValue: 50, UsageLimit: limit(100), PerCustomerLimit: limit(1)}
+
+		_, err := Discount(promotion, order, 0, now)
+		assert.NoError(t, err)
+
+		_, err = Discount(promotion, order, 1, now)
+		assert.Equal(t, "promo_code_already_used", apperrors.From(err).Code)
+
+		promotion.TimesRedeemed = 100
+
+		_, err = Discount(promotion, order, 0, now)
+		assert.Equal(t, "promo_code_used_up", apperrors.From(err).Code)
+		assert.True(t, apperrors.Is(err, apperrors.KindConflict))
+	})
+}
@@ -0,0 +1,84 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sweepInterval is how often idle buckets are dropped from a memory store.
+const sweepInterval = time.Minute
+
+type (
+	bucket struct {
+		tokens  float64
+		updated time.Time
+		period  time.Duration
+	}
+
+	memoryStore struct {
+		mu        sync.Mutex
+		buckets   map[string]*bucket
+		now       func() time.Time
+		lastSweep time.Time
+	}
+)
+
+// NewMemoryStore keeps buckets in process memory. Limits are per replica, so
+// use the Postgres store when running more than one.
+func NewMemoryStore() Store {
+	return newMemoryStore(time.Now)
+}
+
+func newMemoryStore(now func() time.Time) *memoryStore {
+	return &memoryStore{
+		buckets:   make(map[string]*bucket),
+		now:       now,
+		lastSweep: now(),
+	}
+}
+
+func (s *memoryStore) Take(ctx context.Context, key string, policy Policy) (*Result, error) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+
+	s.sweep(now)
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(policy.Limit), updated: now}
+		s.buckets[key] = b
+	}
+
+	b.tokens = policy.refill(b.tokens, now.Sub(b.updated))
+	b.updated = now
+	b.period = policy.Period
+
+	if b.tokens < 1 {
+		return policy.result(false, b.tokens), nil
+	}
+
+	b.tokens--
+
+	return policy.result(true, b.tokens), nil
+}
+
+// sweep drops buckets that have been idle long enough to be full again, which
+// is indistinguishable from not having a bucket at all.
+func (s *memoryStore) sweep(now time.Time) {
+
+	if now.Sub(s.lastSweep) < sweepInterval {
+		return
+	}
+
+	for key, b := range s.buckets {
+		if now.Sub(b.updated) >= b.period {
+			delete(s.buckets, key)
+		}
+	}
+
+	s.lastSweep = now
+}
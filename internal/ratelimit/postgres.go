@@ -0,0 +1,87 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/ernestngugi/sil-backend/internal/db"
+)
+
+// refillSQL is the number of tokens in the bucket being updated once refilled
+// up to the current time. $2 is the bucket size and $3 the refill rate per
+// second. Elapsed time is clamped at zero because a request queued on the row
+// lock may have started before the one holding it.
+const refillSQL = "LEAST($2::float8, b.tokens + GREATEST(0, EXTRACT(EPOCH FROM now() - b.date_modified)) * $3::float8)"
+
+const (
+	takeRateLimitSQL = "INSERT INTO rate_limit_buckets AS b (key, tokens, allowed, date_modified, date_expires) " +
+		"VALUES ($1, $2::float8 - 1, true, now(), now() + make_interval(secs => $4::float8)) " +
+		"ON CONFLICT (key) DO UPDATE SET " +
+		"tokens = " + refillSQL + " - CASE WHEN " + refillSQL + " >= 1 THEN 1 ELSE 0 END, " +
+		"allowed = " + refillSQL + " >= 1, " +
+		"date_modified = GREATEST(b.date_modified, now()), " +
+		"date_expires = GREATEST(b.date_modified, now()) + make_interval(secs => $4::float8) " +
+		"RETURNING tokens, allowed"
+	pruneRateLimitSQL = "DELETE FROM rate_limit_buckets WHERE date_expires < now()"
+)
+
+type postgresStore struct {
+	dB db.DB
+
+	mu        sync.Mutex
+	lastPrune time.Time
+}
+
+// NewPostgresStore shares buckets between replicas. Each request is a single
+// upsert, so the row lock on the bucket is all that serialises concurrent
+// requests from the same client.
+func NewPostgresStore(dB db.DB) Store {
+	return &postgresStore{
+		dB: dB,
+	}
+}
+
+func (s *postgresStore) Take(ctx context.Context, key string, policy Policy) (*Result, error) {
+
+	err := s.prune(ctx)
+	if err != nil {
+		return &Result{}, err
+	}
+
+	var tokens float64
+	var allowed bool
+
+	err = s.dB.QueryRowContext(
+		ctx,
+		takeRateLimitSQL,
+		key,
+		float64(policy.Limit),
+		policy.rate(),
+		policy.Period.Seconds(),
+	).Scan(&tokens, &allowed)
+	if err != nil {
+		return &Result{}, err
+	}
+
+	return policy.result(allowed, tokens), nil
+}
+
+// prune deletes buckets that have refilled completely, at most once per
+// sweepInterval per replica.
+func (s *postgresStore) prune(ctx context.Context) error {
+
+	s.mu.Lock()
+
+	if time.Since(s.lastPrune) < sweepInterval {
+		s.mu.Unlock()
+		return nil
+	}
+
+	s.lastPrune = time.Now()
+	s.mu.Unlock()
+
+	_, err := s.dB.ExecContext(ctx, pruneRateLimitSQL)
+
+	return err
+}
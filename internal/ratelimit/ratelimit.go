@@ -0,0 +1,193 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ernestngugi/sil-backend/internal/env"
+)
+
+// Policy names used by the router. Each route group is limited by exactly one
+// policy, so a client's budget for one does not drain another.
+const (
+	PolicyDefault       = "default"
+	PolicyCreateOrder   = "orders.create"
+	PolicyCreatePayment = "payments.create"
+	PolicyLogin         = "login"
+)
+
+const (
+	StoreMemory   = "memory"
+	StorePostgres = "postgres"
+)
+
+type (
+	// Policy is a token bucket allowing bursts of Limit requests, refilled at
+	// Limit tokens per Period.
+	Policy struct {
+		Name   string
+		Limit  int
+		Period time.Duration
+	}
+
+	// Result is the state of a bucket after a request took, or failed to
+	// take, a token from it.
+	Result struct {
+		Allowed   bool
+		Limit     int
+		Remaining int
+		// Reset is how long until the bucket is full again.
+		Reset time.Duration
+		// RetryAfter is how long until a denied request would be allowed.
+		RetryAfter time.Duration
+	}
+
+	// Store keeps token buckets. Take must be atomic per key so that limits
+	// hold under concurrent requests.
+	Store interface {
+		Take(ctx context.Context, key string, policy Policy) (*Result, error)
+	}
+
+	Config struct {
+		Enabled  bool
+		Store    string
+		Policies map[string]Policy
+	}
+
+	// Limiter applies named policies to client keys.
+	Limiter struct {
+		store    Store
+		policies map[string]Policy
+	}
+)
+
+func defaultPolicies() map[string]Policy {
+	return map[string]Policy{
+		PolicyDefault:       {Name: PolicyDefault, Limit: 120, Period: time.Minute},
+		PolicyCreateOrder:   {Name: PolicyCreateOrder, Limit: 10, Period: time.Minute},
+		PolicyCreatePayment: {Name: PolicyCreatePayment, Limit: 5, Period: time.Minute},
+		PolicyLogin:         {Name: PolicyLogin, Limit: 20, Period: time.Minute},
+	}
+}
+
+// ConfigFromEnv reads RATE_LIMIT_ENABLED, RATE_LIMIT_STORE (memory or
+// postgres) and per-policy overrides such as RATE_LIMIT_ORDERS_CREATE=10/1m.
+func ConfigFromEnv() (*Config, error) {
+
+	config := &Config{
+		Store:    strings.ToLower(env.String("RATE_LIMIT_STORE", StoreMemory)),
+		Policies: defaultPolicies(),
+	}
+
+	var err error
+
+	if config.Enabled, err = env.Bool("RATE_LIMIT_ENABLED", true); err != nil {
+		return &Config{}, err
+	}
+
+	if config.Store != StoreMemory && config.Store != StorePostgres {
+		return &Config{}, fmt.Errorf("invalid RATE_LIMIT_STORE %q", config.Store)
+	}
+
+	for name, policy := range config.Policies {
+
+		key := "RATE_LIMIT_" + strings.ToUpper(strings.ReplaceAll(name, ".", "_"))
+
+		value := env.String(key, "")
+		if value == "" {
+			continue
+		}
+
+		policy, err = ParsePolicy(name, value)
+		if err != nil {
+			return &Config{}, fmt.Errorf("invalid %v: %w", key, err)
+		}
+
+		config.Policies[name] = policy
+	}
+
+	return config, nil
+}
+
+// ParsePolicy parses a limit written as requests per period, e.g. "10/1m".
+func ParsePolicy(name, value string) (Policy, error) {
+
+	limitValue, periodValue, found := strings.Cut(value, "/")
+	if !found {
+		return Policy{}, fmt.Errorf("expected <limit>/<period>, got %q", value)
+	}
+
+	limit, err := strconv.Atoi(strings.TrimSpace(limitValue))
+	if err != nil || limit < 1 {
+		return Policy{}, fmt.Errorf("limit must be a positive integer, got %q", limitValue)
+	}
+
+	period, err := time.ParseDuration(strings.TrimSpace(periodValue))
+	if err != nil || period <= 0 {
+		return Policy{}, fmt.Errorf("period must be a positive duration, got %q", periodValue)
+	}
+
+	return Policy{Name: name, Limit: limit, Period: period}, nil
+}
+
+func NewLimiter(store Store, policies map[string]Policy) *Limiter {
+	return &Limiter{
+		store:    store,
+		policies: policies,
+	}
+}
+
+// Policy returns the policy called name, falling back to the default policy.
+func (l *Limiter) Policy(name string) Policy {
+
+	policy, ok := l.policies[name]
+	if !ok {
+		return l.policies[PolicyDefault]
+	}
+
+	return policy
+}
+
+// Allow takes a token for client from the bucket of the named policy.
+func (l *Limiter) Allow(ctx context.Context, policyName, client string) (*Result, error) {
+
+	policy := l.Policy(policyName)
+
+	return l.store.Take(ctx, policy.Name+":"+client, policy)
+}
+
+// rate is the number of tokens added to a bucket per second.
+func (p Policy) rate() float64 {
+	return float64(p.Limit) / p.Period.Seconds()
+}
+
+// result describes a bucket holding tokens after a request was allowed or
+// denied.
+func (p Policy) result(allowed bool, tokens float64) *Result {
+
+	result := &Result{
+		Allowed:   allowed,
+		Limit:     p.Limit,
+		Remaining: int(math.Floor(tokens)),
+		Reset:     secondsToDuration((float64(p.Limit) - tokens) / p.rate()),
+	}
+
+	if !allowed {
+		result.RetryAfter = secondsToDuration((1 - tokens) / p.rate())
+	}
+
+	return result
+}
+
+func secondsToDuration(seconds float64) time.Duration {
+	return time.Duration(math.Ceil(seconds * float64(time.Second)))
+}
+
+// refill returns the tokens in a bucket that held tokens elapsed ago.
+func (p Policy) refill(tokens float64, elapsed time.Duration) float64 {
+	return math.Min(float64(p.Limit), tokens+elapsed.Seconds()*p.rate())
+}
@@ -0,0 +1,133 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryStore(t *testing.T) {
+
+	ctx := context.Background()
+	now := time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)
+	store := newMemoryStore(func() time.Time { return now })
+	policy := Policy{Name: "test", Limit: 3, Period: 3 * time.Second}
+
+	t.Run("allows a burst up to the limit", func(t *testing.T) {
+
+		for remaining := 2; remaining >= 0; remaining-- {
+
+			result, err := store.Take(ctx, "burst", policy)
+			assert.NoError(t, err)
+			assert.True(t, result.Allowed)
+			assert.Equal(t, remaining, result.Remaining)
+		}
+
+		result, err := store.Take(ctx, "burst", policy)
+		assert.NoError(t, err)
+		assert.False(t, result.Allowed)
+		assert.Equal(t, 0, result.Remaining)
+		assert.Equal(t, time.Second, result.RetryAfter)
+		assert.Equal(t, 3*time.Second, result.Reset)
+	})
+
+	t.Run("keeps buckets per key", func(t *testing.T) {
+
+		result, err := store.Take(ctx, "other", policy)
+		assert.NoError(t, err)
+		assert.True(t, result.Allowed)
+	})
+
+	t.Run("refills over time", func(t *testing.T) {
+
+		now = now.Add(time.Second)
+
+		result, err := store.Take(ctx, "burst", policy)
+		assert.NoError(t, err)
+		assert.True(t, result.Allowed)
+
+		result, err = store.Take(ctx, "burst", policy)
+		assert.NoError(t, err)
+		assert.False(t, result.Allowed)
+	})
+
+	t.Run("sweeps idle buckets", func(t *testing.T) {
+
+		now = now.Add(time.Hour)
+
+		_, err := store.Take(ctx, "new", policy)
+		assert.NoError(t, err)
+		assert.Len(t, store.buckets, 1)
+	})
+}
+
+func TestLimiter(t *testing.T) {
+
+	ctx := context.Background()
+
+	limiter := NewLimiter(NewMemoryStore(), map[string]Policy{
+		PolicyDefault:     {Name: PolicyDefault, Limit: 5, Period: time.Minute},
+		PolicyCreateOrder: {Name: PolicyCreateOrder, Limit: 1, Period: time.Minute},
+	})
+
+	t.Run("applies named policies", func(t *testing.T) {
+
+		result, err := limiter.Allow(ctx, PolicyCreateOrder, "customer:a")
+		assert.NoError(t, err)
+		assert.True(t, result.Allowed)
+		assert.Equal(t, 1, result.Limit)
+
+		result, err = limiter.Allow(ctx, PolicyCreateOrder, "customer:a")
+		assert.NoError(t, err)
+		assert.False(t, result.Allowed)
+	})
+
+	t.Run("keeps policies independent", func(t *testing.T) {
+
+		result, err := limiter.Allow(ctx, PolicyDefault, "customer:a")
+		assert.NoError(t, err)
+		assert.True(t, result.Allowed)
+	})
+
+	t.Run("falls back to the default policy", func(t *testing.T) {
+		assert.Equal(t, PolicyDefault, limiter.Policy("unknown").Name)
+	})
+}
+
+func TestConfig(t *testing.T) {
+
+	t.Run("parses policies", func(t *testing.T) {
+
+		policy, err := ParsePolicy("test", "10/1m")
+		assert.NoError(t, err)
+		assert.Equal(t, Policy{Name: "test", Limit: 10, Period: time.Minute}, policy)
+
+		for _, value := range []string{"10", "0/1m", "x/1m", "10/0s", "10/minute"} {
+			_, err := ParsePolicy("test", value)
+			assert.Error(t, err, value)
+		}
+	})
+
+	t.Run("reads the environment", func(t *testing.T) {
+
+		t.Setenv("RATE_LIMIT_STORE", "postgres")
+		t.Setenv("RATE_LIMIT_ORDERS_CREATE", "5/30s")
+
+		config, err := ConfigFromEnv()
+		assert.NoError(t, err)
+		assert.True(t, config.Enabled)
+		assert.Equal(t, StorePostgres, config.Store)
+		assert.Equal(t, Policy{Name: PolicyCreateOrder, Limit: 5, Period: 30 * time.Second}, config.Policies[PolicyCreateOrder])
+		assert.Equal(t, 20, config.Policies[PolicyLogin].Limit)
+	})
+
+	t.Run("rejects invalid settings", func(t *testing.T) {
+
+		t.Setenv("RATE_LIMIT_STORE", "redis")
+
+		_, err := ConfigFromEnv()
+		assert.Error(t, err)
+	})
+}
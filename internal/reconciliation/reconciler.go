@@ -0,0 +1,355 @@
+package reconciliation
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"math"
+	"regexp"
+	"strconv"
+
+	"github.com/ernestngugi/sil-backend/internal/apperrors"
+	"github.com/ernestngugi/sil-backend/internal/db"
+	"github.com/ernestngugi/sil-backend/internal/model"
+	"github.com/ernestngugi/sil-backend/internal/repos"
+)
+
+// accountReferencePattern matches the account reference STK Push payments
+// are made with, and what customers tend to type when paying by paybill.
+var accountReferencePattern = regexp.MustCompile(`(?i)^order\s*#?\s*(\d+)$`)
+
+type (
+	// Reconciler matches the transactions on provider statements with the
+	// orders they paid for.
+	Reconciler interface {
+		// Import reconciles a statement and reports whether it was new.
+		// Importing a statement again returns the run it was first
+		// reconciled in.
+		Import(ctx context.Context, dB db.DB, fileName string, statement []byte) (*model.ReconciliationRun, bool, error)
+	}
+
+	reconciler struct {
+		orderRepository          repos.OrderRepository
+		paymentRepository        repos.PaymentRepository
+		reconciliationRepository repos.ReconciliationRepository
+		logger                   *slog.Logger
+	}
+
+	// matching is what has been matched so far in a run.
+	matching struct {
+		receipts map[string]int
+		orders   map[int64]string
+		payments map[int64]bool
+	}
+)
+
+func NewReconciler(
+	orderRepository repos.OrderRepository,
+	paymentRepository repos.PaymentRepository,
+	reconciliationRepository repos.ReconciliationRepository,
+	logger *slog.Logger,
+) Reconciler {
+	return &reconciler{
+		orderRepository:          orderRepository,
+		paymentRepository:        paymentRepository,
+		reconciliationRepository: reconciliationRepository,
+		logger:                   logger,
+	}
+}
+
+func (r *reconciler) Import(
+	ctx context.Context,
+	dB db.DB,
+	fileName string,
+	statement []byte,
+) (*model.ReconciliationRun, bool, error) {
+
+	transactions, err := ParseMPesaStatement(bytes.NewReader(statement))
+	if err != nil {
+		return &model.ReconciliationRun{}, false, err
+	}
+
+	checksum := sha256.Sum256(statement)
+
+	run := &model.ReconciliationRun{
+		Provider:        model.StatementProviderMPesa,
+		FileName:        fileName,
+		StatementSHA256: hex.EncodeToString(checksum[:]),
+	}
+
+	created := false
+
+	err = db.WithTransaction(ctx, dB, func(operations db.SQLOperations) error {
+
+		created, err = r.reconciliationRepository.SaveRun(ctx, operations, run)
+		if err != nil {
+			return err
+		}
+
+		if !created {
+			run, err = r.reconciliationRepository.RunBySHA256(ctx, operations, run.StatementSHA256)
+			return err
+		}
+
+		state := &matching{
+			receipts: make(map[string]int),
+			orders:   make(map[int64]string),
+			payments: make(map[int64]bool),
+		}
+
+		for _, transaction := range transactions {
+
+			entry, err := r.match(ctx, operations, state, transaction)
+			if err != nil {
+				return fmt.Errorf("line %d: %w", transaction.Line, err)
+			}
+
+			entry.RunID = run.ID
+
+			err = r.reconciliationRepository.SaveEntry(ctx, operations, entry)
+			if err != nil {
+				return err
+			}
+
+			run.Tally(entry)
+		}
+
+		return r.reconciliationRepository.UpdateRunTotals(ctx, operations, run)
+	})
+	if err != nil {
+		return &model.ReconciliationRun{}, false, err
+	}
+
+	if created {
+		r.logger.InfoContext(
+			ctx,
+			"statement reconciled",
+			slog.Int64("reconciliation_id", run.ID),
+			slog.String("file_name", fileName),
+			slog.Int("transactions", run.Transactions),
+			slog.Int("matched", run.Matched),
+		)
+	}
+
+	return run, created, nil
+}
+
+// match finds the order a transaction paid for and how it compares with it.
+func (r *reconciler) match(
+	ctx context.Context,
+	operations db.SQLOperations,
+	state *matching,
+	transaction *model.StatementTransaction,
+) (*model.ReconciliationEntry, error) {
+
+	entry := &model.ReconciliationEntry{
+		Line:             transaction.Line,
+		ReceiptNumber:    transaction.ReceiptNumber,
+		CompletedAt:      transaction.CompletedAt,
+		Amount:           transaction.Amount,
+		PhoneNumber:      transaction.PhoneNumber,
+		AccountReference: transaction.AccountReference,
+		Status:           model.ReconciliationUnmatched,
+	}
+
+	if line, ok := state.receipts[transaction.ReceiptNumber]; ok {
+		entry.Status = model.ReconciliationDuplicate
+		entry.Note = fmt.Sprintf("receipt already appears on line %d", line)
+		return entry, nil
+	}
+
+	state.receipts[transaction.ReceiptNumber] = transaction.Line
+
+	order, payment, note, err := r.find(ctx, operations, state, transaction)
+	if err != nil {
+		return entry, err
+	}
+
+	if order == nil {
+		entry.Note = note
+		return entry, nil
+	}
+
+	// M-Pesa only takes whole shillings, so orders are charged rounded up.
+	expected := math.Ceil(order.Amount)
+
+	entry.OrderID = &order.ID
+	entry.ExpectedAmount = &expected
+
+	if payment != nil {
+		entry.PaymentID = &payment.ID
+	}
+
+	if cents(transaction.Amount) != cents(expected) {
+		entry.Status = model.ReconciliationAmountMismatch
+		entry.Note = fmt.Sprintf("order %d is KES %.2f", order.ID, expected)
+		return entry, nil
+	}
+
+	receipt, err := r.paidBy(ctx, operations, state, order.ID, transaction.ReceiptNumber)
+	if err != nil {
+		return entry, err
+	}
+
+	if receipt != "" {
+		entry.Status = model.ReconciliationDuplicate
+		entry.Note = fmt.Sprintf("order %d was already paid by receipt %v", order.ID, receipt)
+		return entry, nil
+	}
+
+	state.orders[order.ID] = transaction.ReceiptNumber
+
+	entry.Status = model.ReconciliationMatched
+
+	switch {
+	case payment == nil:
+		entry.Note = "no payment on record for the order"
+	case payment.Status != model.PaymentStatusPaid:
+		entry.Note = fmt.Sprintf("payment %d is recorded as %v", payment.ID, payment.Status)
+	}
+
+	return entry, nil
+}
+
+// find looks for the order a transaction paid for by receipt number, then
+// account reference, then phone number and amount. note explains why no
+// order was found.
+func (r *reconciler) find(
+	ctx context.Context,
+	operations db.SQLOperations,
+	state *matching,
+	transaction *model.StatementTransaction,
+) (order *model.Order, payment *model.Payment, note string, err error) {
+
+	payment, err = r.paymentRepository.PaymentByReceiptNumber(ctx, operations, transaction.ReceiptNumber)
+	if err == nil {
+		state.payments[payment.ID] = true
+		order, err = r.orderRepository.OrderByID(ctx, operations, payment.OrderID)
+		return order, payment, "", err
+	}
+
+	if !apperrors.Is(err, apperrors.KindNotFound) {
+		return nil, nil, "", err
+	}
+
+	note = "no payment or order matches the receipt, account reference or phone number"
+
+	if matches := accountReferencePattern.FindStringSubmatch(transaction.AccountReference); matches != nil {
+
+		orderID, _ := strconv.ParseInt(matches[1], 10, 64)
+
+		order, err = r.orderRepository.OrderByID(ctx, operations, orderID)
+		if err == nil {
+			return order, r.orderPayment(ctx, operations, state, order.ID, transaction), "", nil
+		}
+
+		if !apperrors.Is(err, apperrors.KindNotFound) {
+			return nil, nil, "", err
+		}
+
+		note = fmt.Sprintf("account reference names order %d, which does not exist", orderID)
+	}
+
+	if transaction.Amount != math.Trunc(transaction.Amount) {
+		return nil, nil, note, nil
+	}
+
+	payments, err := r.paymentRepository.PaymentsForAmount(ctx, operations, int64(transaction.Amount))
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	candidates := make([]*model.Payment, 0)
+
+	for _, candidate := range payments {
+		if r.unclaimed(state, candidate, transaction) {
+			candidates = append(candidates, candidate)
+		}
+	}
+
+	switch len(candidates) {
+	case 0:
+		return nil, nil, note, nil
+	case 1:
+		payment = candidates[0]
+		state.payments[payment.ID] = true
+		order, err = r.orderRepository.OrderByID(ctx, operations, payment.OrderID)
+		return order, payment, "", err
+	default:
+		return nil, nil, fmt.Sprintf("%d payments from the phone number are for the same amount", len(candidates)), nil
+	}
+}
+
+// orderPayment picks the payment made for an order found by account
+// reference, if there was one.
+func (r *reconciler) orderPayment(
+	ctx context.Context,
+	operations db.SQLOperations,
+	state *matching,
+	orderID int64,
+	transaction *model.StatementTransaction,
+) *model.Payment {
+
+	payments, err := r.paymentRepository.Payments(ctx, operations, orderID)
+	if err != nil {
+		r.logger.WarnContext(ctx, "failed to list order payments", slog.Int64("order_id", orderID), slog.String("error", err.Error()))
+		return nil
+	}
+
+	for _, payment := range payments {
+		if r.unclaimed(state, payment, transaction) {
+			state.payments[payment.ID] = true
+			return payment
+		}
+	}
+
+	return nil
+}
+
+// unclaimed reports whether payment could have been made by transaction:
+// it came from the same phone and no other transaction settled it.
+func (r *reconciler) unclaimed(state *matching, payment *model.Payment, transaction *model.StatementTransaction) bool {
+
+	if state.payments[payment.ID] || !phoneNumberMatches(transaction.PhoneNumber, payment.PhoneNumber) {
+		return false
+	}
+
+	return payment.ReceiptNumber == "" || payment.ReceiptNumber == transaction.ReceiptNumber
+}
+
+// paidBy returns a different receipt that already settled an order, in this
+// run or an earlier one.
+func (r *reconciler) paidBy(
+	ctx context.Context,
+	operations db.SQLOperations,
+	state *matching,
+	orderID int64,
+	receiptNumber string,
+) (string, error) {
+
+	if receipt, ok := state.orders[orderID]; ok {
+		return receipt, nil
+	}
+
+	// Overlapping statements list the same transaction twice, which is not
+	// a second payment.
+	receipts, err := r.reconciliationRepository.MatchedReceipts(ctx, operations, orderID)
+	if err != nil {
+		return "", err
+	}
+
+	for _, receipt := range receipts {
+		if receipt != receiptNumber {
+			return receipt, nil
+		}
+	}
+
+	return "", nil
+}
+
+func cents(amount float64) int64 {
+	return int64(math.Round(amount * 100))
+}
@@ -0,0 +1,111 @@
+package reconciliation
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ernestngugi/sil-backend/internal/apperrors"
+	"github.com/ernestngugi/sil-backend/internal/model"
+	"github.com/stretchr/testify/assert"
+)
+
+const statement = `Account Holder:,SAVANNAH SHOP
+Time Period:,From 19-10-2026 00:00:00 To 19-10-2026 23:59:59
+
+Receipt No.,Completion Time,Initiation Time,Details,Transaction Status,Paid In,Withdrawn,Balance,Balance Confirmed,Reason Type,Other Party Info,Linked Transaction ID,A/C No.
+SJK3H2L9QX,19-10-2026 09:15:02,19-10-2026 09:14:58,Pay Bill Online from 2547*****149 - JANE DOE Acc. Order 12,Completed,"1,250.00",,"10,250.00",true,Pay Bill Online,2547*****149 - JANE DOE,,Order 12
+SJK3H2M1AB,19-10-2026 09:15:02,19-10-2026 09:15:02,Business Pay Bill Charge,Completed,,-25.00,"10,225.00",true,Pay Bill Charge,,,
+SJK3H2N4CD,19-10-2026 10:01:40,19-10-2026 10:01:33,Pay Bill from 0712***678 - JOHN DOE Acc. =HYPERLINK(),Completed,99.50,,"10,324.50",true,Pay Bill,0712***678 - JOHN DOE,,=HYPERLINK()
+SJK3H2P7EF,19-10-2026 11:30:00,19-10-2026 11:29:51,Pay Bill Online,Failed,500.00,,,true,Pay Bill Online,254700000001 - AMOS K,,Order 13
+`
+
+func TestParseMPesaStatement(t *testing.T) {
+
+	t.Run("reads completed payments below the column headings", func(t *testing.T) {
+
+		transactions, err := ParseMPesaStatement(strings.NewReader(statement))
+		assert.NoError(t, err)
+		assert.Len(t, transactions, 2)
+
+		assert.Equal(t, &model.StatementTransaction{
+			Line:             5,
+			ReceiptNumber:    "SJK3H2L9QX",
+			CompletedAt:      time.Date(2026, 10, 19, 9, 15, 2, 0, eastAfricaTime),
+			Amount:           1250,
+			PhoneNumber:      "2547*****149",
+			AccountReference: "Order 12",
+		}, transactions[0])
+
+		assert.Equal(t, 7, transactions[1].Line)
+		assert.Equal(t, 99.5, transactions[1].Amount)
+		assert.Equal(t, "0712***678", transactions[1].PhoneNumber)
+	})
+
+	t.Run("rejects files that are not statements", func(t *testing.T) {
+
+		for _, file := range []string{
+			"",
+			"order,amount\n1,100\n",
+			"Receipt No.,Completion Time,Paid In\nSJK3H2L9QX,19-10-2026 09:15:02,100\n",
+			"Receipt No.,Completion Time,Transaction Status,Paid In,Other Party Info,A/C No.\nSJK3H2L9QX,yesterday,Completed,100,,\n",
+			"Receipt No.,Completion Time,Transaction Status,Paid In,Other Party Info,A/C No.\nSJK3H2L9QX,19-10-2026 09:15:02,Completed,lots,,\n",
+		} {
+			_, err := ParseMPesaStatement(strings.NewReader(file))
+			assert.Error(t, err, file)
+			assert.True(t, apperrors.Is(err, apperrors.KindValidation), file)
+		}
+	})
+}
+
+func TestPhoneNumberMatches(t *testing.T) {
+
+	for statement, expected := range map[string]bool{
+		"254708374149":  true,
+		"2547*****149":  true,
+		"0708***149":    true,
+		"2547******148": false,
+		"254708374":     false,
+		"":              false,
+	} {
+		assert.Equal(t, expected, phoneNumberMatches(statement, "254708374149"), statement)
+	}
+}
+
+func TestWriteReport(t *testing.T) {
+
+	orderID, expected := int64(12), 1250.0
+
+	var report bytes.Buffer
+
+	err := WriteReport(&report, []*model.ReconciliationEntry{
+		{
+			Line:             5,
+			ReceiptNumber:    "SJK3H2L9QX",
+			CompletedAt:      time.Date(2026, 10, 19, 6, 15, 2, 0, time.UTC),
+			Amount:           1250,
+			PhoneNumber:      "2547*****149",
+			AccountReference: "Order 12",
+			Status:           model.ReconciliationMatched,
+			OrderID:          &orderID,
+			ExpectedAmount:   &expected,
+		},
+		{
+			Line:             7,
+			ReceiptNumber:    "SJK3H2N4CD",
+			CompletedAt:      time.Date(2026, 10, 19, 7, 1, 40, 0, time.UTC),
+			Amount:           99.5,
+			PhoneNumber:      "0712***678",
+			AccountReference: "=HYPERLINK()",
+			Status:           model.ReconciliationUnmatched,
+			Note:             "no payment, order or phone number matches",
+		},
+	})
+	assert.NoError(t, err)
+
+	assert.Equal(t, "line,receipt_number,completed_at,amount,phone_number,account_reference,status,order_id,payment_id,expected_amount,note\n"+
+		"5,SJK3H2L9QX,2026-10-19 09:15:02,1250.00,2547*****149,Order 12,matched,12,,1250.00,\n"+
+		"7,SJK3H2N4CD,2026-10-19 10:01:40,99.50,0712***678,'=HYPERLINK(),unmatched,,,,\"no payment, order or phone number matches\"\n",
+		report.String())
+}
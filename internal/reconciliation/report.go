@@ -0,0 +1,86 @@
+package reconciliation
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ernestngugi/sil-backend/internal/model"
+)
+
+var reportHeader = []string{
+	"line",
+	"receipt_number",
+	"completed_at",
+	"amount",
+	"phone_number",
+	"account_reference",
+	"status",
+	"order_id",
+	"payment_id",
+	"expected_amount",
+	"note",
+}
+
+// WriteReport writes a run's entries as CSV for finance to review.
+func WriteReport(writer io.Writer, entries []*model.ReconciliationEntry) error {
+
+	csvWriter := csv.NewWriter(writer)
+
+	err := csvWriter.Write(reportHeader)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+
+		orderID, paymentID, expectedAmount := "", "", ""
+
+		if entry.OrderID != nil {
+			orderID = strconv.FormatInt(*entry.OrderID, 10)
+		}
+
+		if entry.PaymentID != nil {
+			paymentID = strconv.FormatInt(*entry.PaymentID, 10)
+		}
+
+		if entry.ExpectedAmount != nil {
+			expectedAmount = strconv.FormatFloat(*entry.ExpectedAmount, 'f', 2, 64)
+		}
+
+		err := csvWriter.Write([]string{
+			strconv.Itoa(entry.Line),
+			spreadsheetText(entry.ReceiptNumber),
+			entry.CompletedAt.In(eastAfricaTime).Format(time.DateTime),
+			strconv.FormatFloat(entry.Amount, 'f', 2, 64),
+			spreadsheetText(entry.PhoneNumber),
+			spreadsheetText(entry.AccountReference),
+			string(entry.Status),
+			orderID,
+			paymentID,
+			expectedAmount,
+			spreadsheetText(entry.Note),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	csvWriter.Flush()
+
+	return csvWriter.Error()
+}
+
+// spreadsheetText stops text paying customers typed, such as the account
+// reference, from being run as a formula when the report is opened in a
+// spreadsheet.
+func spreadsheetText(value string) string {
+
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+
+	return value
+}
@@ -0,0 +1,209 @@
+package reconciliation
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ernestngugi/sil-backend/internal/apperrors"
+	"github.com/ernestngugi/sil-backend/internal/model"
+)
+
+// Column headings of the M-Pesa organisation portal statement export.
+const (
+	columnReceiptNumber     = "receipt no."
+	columnCompletionTime    = "completion time"
+	columnTransactionStatus = "transaction status"
+	columnPaidIn            = "paid in"
+	columnOtherPartyInfo    = "other party info"
+	columnAccountNumber     = "a/c no."
+)
+
+var (
+	statementColumns = []string{
+		columnReceiptNumber,
+		columnCompletionTime,
+		columnTransactionStatus,
+		columnPaidIn,
+		columnOtherPartyInfo,
+		columnAccountNumber,
+	}
+
+	// Statements are exported in Nairobi time, in whichever layout the
+	// portal or a spreadsheet resaving it chose.
+	eastAfricaTime        = time.FixedZone("EAT", 3*60*60)
+	completionTimeFormats = []string{
+		"02-01-2006 15:04:05",
+		"2006-01-02 15:04:05",
+		"02/01/2006 15:04:05",
+		"02/01/2006 15:04",
+		time.RFC3339,
+	}
+)
+
+// ParseMPesaStatement reads the money paid in according to an M-Pesa
+// statement CSV. Rows above the column headings, withdrawals and
+// transactions that did not complete are skipped.
+func ParseMPesaStatement(reader io.Reader) ([]*model.StatementTransaction, error) {
+
+	csvReader := csv.NewReader(reader)
+	csvReader.FieldsPerRecord = -1
+	csvReader.LazyQuotes = true
+	csvReader.TrimLeadingSpace = true
+
+	var columns map[string]int
+
+	transactions := make([]*model.StatementTransaction, 0)
+
+	for {
+
+		record, err := csvReader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return []*model.StatementTransaction{}, invalidStatement(err.Error())
+		}
+
+		line, _ := csvReader.FieldPos(0)
+
+		if columns == nil {
+			columns, err = statementHeader(record)
+			if err != nil {
+				return []*model.StatementTransaction{}, err
+			}
+			continue
+		}
+
+		transaction, err := statementTransaction(columns, record, line)
+		if err != nil {
+			return []*model.StatementTransaction{}, err
+		}
+
+		if transaction != nil {
+			transactions = append(transactions, transaction)
+		}
+	}
+
+	if columns == nil {
+		return []*model.StatementTransaction{}, invalidStatement("no M-Pesa statement column headings found")
+	}
+
+	return transactions, nil
+}
+
+// statementHeader returns the position of each statement column when record
+// holds the column headings, and nil for the rows above them.
+func statementHeader(record []string) (map[string]int, error) {
+
+	columns := make(map[string]int, len(record))
+
+	for i, heading := range record {
+		columns[strings.ToLower(strings.TrimSpace(heading))] = i
+	}
+
+	if _, ok := columns[columnReceiptNumber]; !ok {
+		return nil, nil
+	}
+
+	for _, column := range statementColumns {
+		if _, ok := columns[column]; !ok {
+			return nil, invalidStatement(fmt.Sprintf("statement has no %q column", column))
+		}
+	}
+
+	return columns, nil
+}
+
+func statementTransaction(columns map[string]int, record []string, line int) (*model.StatementTransaction, error) {
+
+	value := func(column string) string {
+		i := columns[column]
+		if i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	if value(columnReceiptNumber) == "" || !strings.EqualFold(value(columnTransactionStatus), "completed") {
+		return nil, nil
+	}
+
+	paidIn := strings.ReplaceAll(value(columnPaidIn), ",", "")
+	if paidIn == "" {
+		return nil, nil
+	}
+
+	amount, err := strconv.ParseFloat(paidIn, 64)
+	if err != nil {
+		return nil, invalidStatement(fmt.Sprintf("line %d: invalid paid in amount %q", line, value(columnPaidIn)))
+	}
+
+	if amount <= 0 {
+		return nil, nil
+	}
+
+	completedAt, err := parseCompletionTime(value(columnCompletionTime))
+	if err != nil {
+		return nil, invalidStatement(fmt.Sprintf("line %d: invalid completion time %q", line, value(columnCompletionTime)))
+	}
+
+	// Other party info reads "254708374149 - JANE DOE", usually with the
+	// middle digits of the phone number masked.
+	phoneNumber, _, _ := strings.Cut(value(columnOtherPartyInfo), " - ")
+
+	return &model.StatementTransaction{
+		Line:             line,
+		ReceiptNumber:    strings.ToUpper(value(columnReceiptNumber)),
+		CompletedAt:      completedAt,
+		Amount:           amount,
+		PhoneNumber:      strings.TrimSpace(phoneNumber),
+		AccountReference: value(columnAccountNumber),
+	}, nil
+}
+
+func parseCompletionTime(value string) (time.Time, error) {
+
+	var err error
+
+	for _, format := range completionTimeFormats {
+
+		var completedAt time.Time
+
+		completedAt, err = time.ParseInLocation(format, value, eastAfricaTime)
+		if err == nil {
+			return completedAt, nil
+		}
+	}
+
+	return time.Time{}, err
+}
+
+// phoneNumberMatches reports whether a statement phone number, which may be
+// masked with asterisks or in local format, could be recorded.
+func phoneNumberMatches(statement, recorded string) bool {
+
+	if strings.HasPrefix(statement, "0") {
+		statement = "254" + statement[1:]
+	}
+
+	if statement == "" || len(statement) != len(recorded) {
+		return false
+	}
+
+	for i := 0; i < len(statement); i++ {
+		if statement[i] != '*' && statement[i] != recorded[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func invalidStatement(message string) error {
+	return apperrors.Validation("invalid_statement", message)
+}
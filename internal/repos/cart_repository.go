@@ -0,0 +1,227 @@
+package repos
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/ernestngugi/sil-backend/internal/apperrors"
+	"github.com/ernestngugi/sil-backend/internal/db"
+	"github.com/ernestngugi/sil-backend/internal/model"
+)
+
+const (
+	// touchCartSQL creates the customer's cart or marks it as changed,
+	// holding it until the transaction ends.
+	touchCartSQL = "INSERT INTO carts (customer_id, date_created, date_modified) VALUES ($1, $2, $2) " +
+		"ON CONFLICT (customer_id) DO UPDATE SET date_modified = EXCLUDED.date_modified"
+	getCartSQL  = "SELECT COALESCE(promo_code, ''), date_modified FROM carts WHERE customer_id = $1"
+	lockCartSQL = getCartSQL + " FOR UPDATE"
+	// listCartLinesSQL prices lines at the current product price. A line is
+	// available while its product is on sale and in stock.
+	listCartLinesSQL = "SELECT l.product_id, p.sku, p.name, l.quantity, p.price, p.tax_category, p.active AND p.stock >= l.quantity " +
+		"FROM cart_lines l JOIN products p ON p.id = l.product_id WHERE l.customer_id = $1 ORDER BY l.date_added, l.product_id"
+	setCartLineSQL = "INSERT INTO cart_lines (customer_id, product_id, quantity, date_added) VALUES ($1, $2, $3, $4) " +
+		"ON CONFLICT (customer_id, product_id) DO UPDATE SET quantity = EXCLUDED.quantity"
+	deleteCartLineSQL   = "DELETE FROM cart_lines WHERE customer_id = $1 AND product_id = $2"
+	setCartPromoCodeSQL = "UPDATE carts SET promo_code = NULLIF($1, ''), date_modified = $2 WHERE customer_id = $3"
+	deleteCartSQL       = "DELETE FROM carts WHERE customer_id = $1"
+	// claimAbandonedCartsSQL marks carts left unchanged since before $2 as
+	// reminded, at most once per change. SKIP LOCKED lets replicas claim
+	// disjoint sets and leaves carts being changed for the next run.
+	claimAbandonedCartsSQL = "UPDATE carts SET reminded_at = $3 WHERE customer_id IN (" +
+		"SELECT c.customer_id FROM carts c WHERE c.date_modified < $2 AND (c.reminded_at IS NULL OR c.reminded_at < c.date_modified) " +
+		"AND EXISTS (SELECT 1 FROM cart_lines l WHERE l.customer_id = c.customer_id) " +
+		"ORDER BY c.date_modified LIMIT $1 FOR UPDATE SKIP LOCKED) RETURNING customer_id"
+)
+
+var errCartLineNotFound = apperrors.NotFound("cart_line_not_found", "product is not in the cart")
+
+type (
+	CartRepository interface {
+		// Cart returns the customer's cart, which is empty if they never
+		// added to it.
+		Cart(ctx context.Context, operations db.SQLOperations, customerID int64) (*model.Cart, error)
+		// LockCart is Cart, holding the cart until the transaction ends.
+		LockCart(ctx context.Context, operations db.SQLOperations, customerID int64) (*model.Cart, error)
+		// Touch creates the customer's cart if they have none and marks it
+		// as changed, holding it until the transaction ends.
+		Touch(ctx context.Context, operations db.SQLOperations, customerID int64) error
+		// SetLine puts quantity of the product in the cart, replacing any
+		// quantity already there. The cart must have been touched.
+		SetLine(ctx context.Context, operations db.SQLOperations, customerID, productID int64, quantity int) error
+		DeleteLine(ctx context.Context, operations db.SQLOperations, customerID, productID int64) error
+		SetPromoCode(ctx context.Context, operations db.SQLOperations, customerID int64, code string) error
+		// Clear empties the customer's cart.
+		Clear(ctx context.Context, operations db.SQLOperations, customerID int64) error
+		// ClaimAbandoned marks up to limit carts left unchanged since before
+		// as reminded and returns their customers. A cart is claimed once
+		// for each time it is abandoned.
+		ClaimAbandoned(ctx context.Context, operations db.SQLOperations, before time.Time, limit int) ([]int64, error)
+	}
+
+	cartRepository struct{}
+)
+
+func NewCartRepository() CartRepository {
+	return &cartRepository{}
+}
+
+func (r *cartRepository) Cart(
+	ctx context.Context,
+	operations db.SQLOperations,
+	customerID int64,
+) (*model.Cart, error) {
+	return r.cart(ctx, operations, getCartSQL, customerID)
+}
+
+func (r *cartRepository) LockCart(
+	ctx context.Context,
+	operations db.SQLOperations,
+	customerID int64,
+) (*model.Cart, error) {
+	return r.cart(ctx, operations, lockCartSQL, customerID)
+}
+
+func (r *cartRepository) Touch(
+	ctx context.Context,
+	operations db.SQLOperations,
+	customerID int64,
+) error {
+
+	_, err := operations.ExecContext(ctx, touchCartSQL, customerID, time.Now())
+
+	return err
+}
+
+func (r *cartRepository) SetLine(
+	ctx context.Context,
+	operations db.SQLOperations,
+	customerID, productID int64,
+	quantity int,
+) error {
+
+	_, err := operations.ExecContext(ctx, setCartLineSQL, customerID, productID, quantity, time.Now())
+
+	return err
+}
+
+func (r *cartRepository) DeleteLine(
+	ctx context.Context,
+	operations db.SQLOperations,
+	customerID, productID int64,
+) error {
+
+	result, err := operations.ExecContext(ctx, deleteCartLineSQL, customerID, productID)
+	if err != nil {
+		return err
+	}
+
+	return expectRow(result, errCartLineNotFound)
+}
+
+func (r *cartRepository) SetPromoCode(
+	ctx context.Context,
+	operations db.SQLOperations,
+	customerID int64,
+	code string,
+) error {
+
+	_, err := operations.ExecContext(ctx, setCartPromoCodeSQL, code, time.Now(), customerID)
+
+	return err
+}
+
+func (r *cartRepository) Clear(
+	ctx context.Context,
+	operations db.SQLOperations,
+	customerID int64,
+) error {
+
+	_, err := operations.ExecContext(ctx, deleteCartSQL, customerID)
+
+	return err
+}
+
+func (r *cartRepository) ClaimAbandoned(
+	ctx context.Context,
+	operations db.SQLOperations,
+	before time.Time,
+	limit int,
+) ([]int64, error) {
+
+	rows, err := operations.QueryContext(ctx, claimAbandonedCartsSQL, limit, before, time.Now())
+	if err != nil {
+		return []int64{}, err
+	}
+
+	defer rows.Close()
+
+	customerIDs := make([]int64, 0)
+
+	for rows.Next() {
+
+		var customerID int64
+
+		err := rows.Scan(&customerID)
+		if err != nil {
+			return []int64{}, err
+		}
+
+		customerIDs = append(customerIDs, customerID)
+	}
+
+	return customerIDs, rows.Err()
+}
+
+func (r *cartRepository) cart(
+	ctx context.Context,
+	operations db.SQLOperations,
+	query string,
+	customerID int64,
+) (*model.Cart, error) {
+
+	cart := &model.Cart{CustomerID: customerID, Lines: make([]*model.CartLine, 0)}
+
+	var dateModified time.Time
+
+	err := operations.QueryRowContext(ctx, query, customerID).Scan(&cart.PromoCode, &dateModified)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return cart, nil
+		}
+		return &model.Cart{}, err
+	}
+
+	cart.DateModified = &dateModified
+
+	rows, err := operations.QueryContext(ctx, listCartLinesSQL, customerID)
+	if err != nil {
+		return &model.Cart{}, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+
+		var line model.CartLine
+
+		err := rows.Scan(
+			&line.ProductID,
+			&line.SKU,
+			&line.Item,
+			&line.Quantity,
+			&line.UnitPrice,
+			&line.TaxCategory,
+			&line.Available,
+		)
+		if err != nil {
+			return &model.Cart{}, err
+		}
+
+		cart.Lines = append(cart.Lines, &line)
+	}
+
+	return cart, rows.Err()
+}
@@ -2,18 +2,23 @@ package repos
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"time"
 
+	"github.com/ernestngugi/sil-backend/internal/apperrors"
 	"github.com/ernestngugi/sil-backend/internal/db"
 	"github.com/ernestngugi/sil-backend/internal/model"
 )
 
 const (
-	insertCustomerSQL    = "INSERT INTO customers (name, date_created, date_modified) VALUES ($1, $2, $3) RETURNING id"
-	selectCustomerSQL    = "SELECT id, name, date_created, date_modified FROM customers"
+	insertCustomerSQL = "INSERT INTO customers (name, locale, phone_number, date_created, date_modified) " +
+		"VALUES ($1, $2, NULLIF($3, ''), $4, $5) RETURNING id"
+	selectCustomerSQL    = "SELECT id, name, locale, COALESCE(phone_number, ''), date_created, date_modified FROM customers"
 	getCustomerByIDSQL   = selectCustomerSQL + " WHERE id = $1"
 	getCustomerByNameSQL = selectCustomerSQL + " WHERE LOWER(name) = $1"
+	updatePhoneNumberSQL = "UPDATE customers SET phone_number = $1, date_modified = $2 WHERE id = $3"
 )
 
 type (
@@ -21,6 +26,7 @@ type (
 		CustomerByID(ctx context.Context, operations db.SQLOperations, customerID int64) (*model.Customer, error)
 		CustomerByName(ctx context.Context, operations db.SQLOperations, name string) (*model.Customer, error)
 		Save(ctx context.Context, operations db.SQLOperations, customer *model.Customer) error
+		UpdatePhoneNumber(ctx context.Context, operations db.SQLOperations, customer *model.Customer, phoneNumber string) error
 	}
 
 	customerRepository struct{}
@@ -40,8 +46,11 @@ func (r *customerRepository) CustomerByName(
 
 	var customer model.Customer
 
-	err := row.Scan(&customer.ID, &customer.Name, &customer.DateCreated, &customer.DateModified)
+	err := row.Scan(&customer.ID, &customer.Name, &customer.Locale, &customer.PhoneNumber, &customer.DateCreated, &customer.DateModified)
 	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return &model.Customer{}, apperrors.NotFound("customer_not_found", "customer not found").Wrap(err)
+		}
 		return &model.Customer{}, err
 	}
 
@@ -58,8 +67,11 @@ func (r *customerRepository) CustomerByID(
 
 	var customer model.Customer
 
-	err := row.Scan(&customer.ID, &customer.Name, &customer.DateCreated, &customer.DateModified)
+	err := row.Scan(&customer.ID, &customer.Name, &customer.Locale, &customer.PhoneNumber, &customer.DateCreated, &customer.DateModified)
 	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return &model.Customer{}, apperrors.NotFound("customer_not_found", "customer not found").Wrap(err)
+		}
 		return &model.Customer{}, err
 	}
 
@@ -76,12 +88,18 @@ func (r *customerRepository) Save(
 	customer.DateCreated = timeNow
 	customer.DateModified = timeNow
 
+	if customer.Locale == "" {
+		customer.Locale = model.DefaultLocale
+	}
+
 	if customer.ID == 0 {
 
 		err := operations.QueryRowContext(
 			ctx,
 			insertCustomerSQL,
 			customer.Name,
+			customer.Locale,
+			customer.PhoneNumber,
 			customer.DateCreated,
 			customer.DateModified,
 		).Scan(&customer.ID)
@@ -94,3 +112,23 @@ func (r *customerRepository) Save(
 
 	return fmt.Errorf("update customer forbidden")
 }
+
+func (r *customerRepository) UpdatePhoneNumber(
+	ctx context.Context,
+	operations db.SQLOperations,
+	customer *model.Customer,
+	phoneNumber string,
+) error {
+
+	timeNow := time.Now()
+
+	_, err := operations.ExecContext(ctx, updatePhoneNumberSQL, phoneNumber, timeNow, customer.ID)
+	if err != nil {
+		return err
+	}
+
+	customer.PhoneNumber = phoneNumber
+	customer.DateModified = timeNow
+
+	return nil
+}
@@ -0,0 +1,141 @@
+package repos
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+
+	"github.com/ernestngugi/sil-backend/internal/apperrors"
+	"github.com/ernestngugi/sil-backend/internal/db"
+	"github.com/ernestngugi/sil-backend/internal/model"
+)
+
+const (
+	lockInvoiceSequenceSQL = "SELECT last_number FROM invoice_sequences WHERE kind = $1 FOR UPDATE"
+	nextInvoiceNumberSQL   = "UPDATE invoice_sequences SET last_number = last_number + 1 WHERE kind = $1 RETURNING last_number"
+	insertInvoiceSQL       = "INSERT INTO invoices (kind, sequence_number, number, order_id, payment_id, customer_name, lines, net_amount, tax_amount, " +
+		"gross_amount, payment_reference, payment_phone, date_paid, date_issued) " +
+		"VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, NULLIF($11, ''), NULLIF($12, ''), $13, $14) RETURNING id"
+	getInvoiceByOrderIDSQL = "SELECT id, kind, number, order_id, payment_id, customer_name, lines, net_amount, tax_amount, gross_amount, " +
+		"COALESCE(payment_reference, ''), COALESCE(payment_phone, ''), date_paid, date_issued FROM invoices WHERE order_id = $1 AND kind = $2"
+)
+
+var errInvoiceNotFound = apperrors.NotFound("invoice_not_found", "invoice not found")
+
+type (
+	InvoiceRepository interface {
+		// LockSequence holds the number series for kind until the
+		// transaction ends.
+		LockSequence(ctx context.Context, operations db.SQLOperations, kind model.InvoiceKind) error
+		NextNumber(ctx context.Context, operations db.SQLOperations, kind model.InvoiceKind) (int64, error)
+		InvoiceByOrderID(ctx context.Context, operations db.SQLOperations, orderID int64, kind model.InvoiceKind) (*model.Invoice, error)
+		Save(ctx context.Context, operations db.SQLOperations, invoice *model.Invoice, sequenceNumber int64) error
+	}
+
+	invoiceRepository struct{}
+)
+
+func NewInvoiceRepository() InvoiceRepository {
+	return &invoiceRepository{}
+}
+
+func (r *invoiceRepository) LockSequence(
+	ctx context.Context,
+	operations db.SQLOperations,
+	kind model.InvoiceKind,
+) error {
+
+	var lastNumber int64
+
+	return operations.QueryRowContext(ctx, lockInvoiceSequenceSQL, kind).Scan(&lastNumber)
+}
+
+func (r *invoiceRepository) NextNumber(
+	ctx context.Context,
+	operations db.SQLOperations,
+	kind model.InvoiceKind,
+) (int64, error) {
+
+	var number int64
+
+	err := operations.QueryRowContext(ctx, nextInvoiceNumberSQL, kind).Scan(&number)
+	if err != nil {
+		return 0, err
+	}
+
+	return number, nil
+}
+
+func (r *invoiceRepository) InvoiceByOrderID(
+	ctx context.Context,
+	operations db.SQLOperations,
+	orderID int64,
+	kind model.InvoiceKind,
+) (*model.Invoice, error) {
+
+	var invoice model.Invoice
+	var lines []byte
+
+	err := operations.QueryRowContext(ctx, getInvoiceByOrderIDSQL, orderID, kind).Scan(
+		&invoice.ID,
+		&invoice.Kind,
+		&invoice.Number,
+		&invoice.OrderID,
+		&invoice.PaymentID,
+		&invoice.CustomerName,
+		&lines,
+		&invoice.NetAmount,
+		&invoice.TaxAmount,
+		&invoice.GrossAmount,
+		&invoice.PaymentReference,
+		&invoice.PaymentPhone,
+		&invoice.DatePaid,
+		&invoice.DateIssued,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return &model.Invoice{}, errInvoiceNotFound.Wrap(err)
+		}
+		return &model.Invoice{}, err
+	}
+
+	err = json.Unmarshal(lines, &invoice.Lines)
+	if err != nil {
+		return &model.Invoice{}, err
+	}
+
+	return &invoice, nil
+}
+
+func (r *invoiceRepository) Save(
+	ctx context.Context,
+	operations db.SQLOperations,
+	invoice *model.Invoice,
+	sequenceNumber int64,
+) error {
+
+	lines, err := json.Marshal(invoice.Lines)
+	if err != nil {
+		return err
+	}
+
+	return operations.QueryRowContext(
+		ctx,
+		insertInvoiceSQL,
+		invoice.Kind,
+		sequenceNumber,
+		invoice.Number,
+		invoice.OrderID,
+		invoice.PaymentID,
+		invoice.CustomerName,
+		lines,
+		invoice.NetAmount,
+		invoice.TaxAmount,
+		invoice.GrossAmount,
+		invoice.PaymentReference,
+		invoice.PaymentPhone,
+		invoice.DatePaid,
+		invoice.DateIssued,
+	).Scan(&invoice.ID)
+}
@@ -0,0 +1,143 @@
+package repos
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/ernestngugi/sil-backend/internal/apperrors"
+	"github.com/ernestngugi/sil-backend/internal/db"
+	"github.com/ernestngugi/sil-backend/internal/model"
+)
+
+const (
+	// lockLoyaltyAccountSQL holds the customer until the transaction ends,
+	// so their ledger is written one entry at a time. It does not block
+	// orders being placed for them.
+	lockLoyaltyAccountSQL = "SELECT id FROM customers WHERE id = $1 FOR NO KEY UPDATE"
+	insertLoyaltyEntrySQL = "INSERT INTO loyalty_entries (customer_id, order_id, kind, points, amount, expires_at, date_created) " +
+		"VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id"
+	selectLoyaltyEntrySQL      = "SELECT id, customer_id, order_id, kind, points, amount, expires_at, date_created FROM loyalty_entries"
+	listLoyaltyEntriesSQL      = selectLoyaltyEntrySQL + " WHERE customer_id = $1 ORDER BY id"
+	listOrderLoyaltyEntriesSQL = selectLoyaltyEntrySQL + " WHERE order_id = $1 ORDER BY id"
+)
+
+var errLoyaltyAccountNotFound = apperrors.NotFound("customer_not_found", "customer not found")
+
+type (
+	// LoyaltyRepository reads and appends to the loyalty ledger. Entries
+	// cannot be changed or removed.
+	LoyaltyRepository interface {
+		// LockAccount holds the customer's ledger until the transaction
+		// ends.
+		LockAccount(ctx context.Context, operations db.SQLOperations, customerID int64) error
+		SaveEntry(ctx context.Context, operations db.SQLOperations, entry *model.LoyaltyEntry) error
+		Entries(ctx context.Context, operations db.SQLOperations, customerID int64) ([]*model.LoyaltyEntry, error)
+		OrderEntries(ctx context.Context, operations db.SQLOperations, orderID int64) ([]*model.LoyaltyEntry, error)
+	}
+
+	loyaltyRepository struct{}
+)
+
+func NewLoyaltyRepository() LoyaltyRepository {
+	return &loyaltyRepository{}
+}
+
+func (r *loyaltyRepository) LockAccount(
+	ctx context.Context,
+	operations db.SQLOperations,
+	customerID int64,
+) error {
+
+	var id int64
+
+	err := operations.QueryRowContext(ctx, lockLoyaltyAccountSQL, customerID).Scan(&id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return errLoyaltyAccountNotFound.Wrap(err)
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (r *loyaltyRepository) SaveEntry(
+	ctx context.Context,
+	operations db.SQLOperations,
+	entry *model.LoyaltyEntry,
+) error {
+
+	entry.DateCreated = time.Now()
+
+	return operations.QueryRowContext(
+		ctx,
+		insertLoyaltyEntrySQL,
+		entry.CustomerID,
+		entry.OrderID,
+		entry.Kind,
+		entry.Points,
+		entry.Amount,
+		entry.ExpiresAt,
+		entry.DateCreated,
+	).Scan(&entry.ID)
+}
+
+// Entries returns the customer's whole ledger, oldest first.
+func (r *loyaltyRepository) Entries(
+	ctx context.Context,
+	operations db.SQLOperations,
+	customerID int64,
+) ([]*model.LoyaltyEntry, error) {
+	return r.entries(ctx, operations, listLoyaltyEntriesSQL, customerID)
+}
+
+// OrderEntries returns the entries written for an order, oldest first.
+func (r *loyaltyRepository) OrderEntries(
+	ctx context.Context,
+	operations db.SQLOperations,
+	orderID int64,
+) ([]*model.LoyaltyEntry, error) {
+	return r.entries(ctx, operations, listOrderLoyaltyEntriesSQL, orderID)
+}
+
+func (r *loyaltyRepository) entries(
+	ctx context.Context,
+	operations db.SQLOperations,
+	query string,
+	args ...any,
+) ([]*model.LoyaltyEntry, error) {
+
+	rows, err := operations.QueryContext(ctx, query, args...)
+	if err != nil {
+		return []*model.LoyaltyEntry{}, err
+	}
+
+	defer rows.Close()
+
+	entries := make([]*model.LoyaltyEntry, 0)
+
+	for rows.Next() {
+
+		var entry model.LoyaltyEntry
+
+		err := rows.Scan(
+			&entry.ID,
+			&entry.CustomerID,
+			&entry.OrderID,
+			&entry.Kind,
+			&entry.Points,
+			&entry.Amount,
+			&entry.ExpiresAt,
+			&entry.DateCreated,
+		)
+		if err != nil {
+			return []*model.LoyaltyEntry{}, err
+		}
+
+		entries = append(entries, &entry)
+	}
+
+	return entries, rows.Err()
+}
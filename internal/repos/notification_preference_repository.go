@@ -0,0 +1,124 @@
+package repos
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/ernestngugi/sil-backend/internal/db"
+	"github.com/ernestngugi/sil-backend/internal/model"
+)
+
+const (
+	listNotificationChannelsSQL  = "SELECT event, channel FROM notification_preferences WHERE customer_id = $1"
+	upsertNotificationChannelSQL = "INSERT INTO notification_preferences (customer_id, event, channel, date_modified) VALUES ($1, $2, $3, $4) " +
+		"ON CONFLICT (customer_id, event) DO UPDATE SET channel = EXCLUDED.channel, date_modified = EXCLUDED.date_modified"
+	getQuietHoursSQL    = "SELECT start_time, end_time, time_zone FROM quiet_hours WHERE customer_id = $1"
+	upsertQuietHoursSQL = "INSERT INTO quiet_hours (customer_id, start_time, end_time, time_zone, date_modified) VALUES ($1, $2, $3, $4, $5) " +
+		"ON CONFLICT (customer_id) DO UPDATE SET start_time = EXCLUDED.start_time, end_time = EXCLUDED.end_time, time_zone = EXCLUDED.time_zone, date_modified = EXCLUDED.date_modified"
+	deleteQuietHoursSQL = "DELETE FROM quiet_hours WHERE customer_id = $1"
+)
+
+type (
+	NotificationPreferenceRepository interface {
+		Preferences(ctx context.Context, operations db.SQLOperations, customerID int64) (*model.NotificationPreferences, error)
+		SaveChannel(ctx context.Context, operations db.SQLOperations, customerID int64, event model.NotificationEvent, channel model.NotificationChannel) error
+		SaveQuietHours(ctx context.Context, operations db.SQLOperations, customerID int64, quietHours *model.QuietHours) error
+	}
+
+	notificationPreferenceRepository struct{}
+)
+
+func NewNotificationPreferenceRepository() NotificationPreferenceRepository {
+	return &notificationPreferenceRepository{}
+}
+
+// Preferences returns the channels the customer chose and their quiet hours,
+// if they set any. Events missing from Channels use the default channel.
+func (r *notificationPreferenceRepository) Preferences(
+	ctx context.Context,
+	operations db.SQLOperations,
+	customerID int64,
+) (*model.NotificationPreferences, error) {
+
+	rows, err := operations.QueryContext(ctx, listNotificationChannelsSQL, customerID)
+	if err != nil {
+		return &model.NotificationPreferences{}, err
+	}
+
+	defer rows.Close()
+
+	preferences := &model.NotificationPreferences{
+		Channels: make(map[model.NotificationEvent]model.NotificationChannel),
+	}
+
+	for rows.Next() {
+
+		var event model.NotificationEvent
+		var channel model.NotificationChannel
+
+		err := rows.Scan(&event, &channel)
+		if err != nil {
+			return &model.NotificationPreferences{}, err
+		}
+
+		preferences.Channels[event] = channel
+	}
+
+	if err := rows.Err(); err != nil {
+		return &model.NotificationPreferences{}, err
+	}
+
+	var quietHours model.QuietHours
+
+	err = operations.QueryRowContext(ctx, getQuietHoursSQL, customerID).Scan(&quietHours.Start, &quietHours.End, &quietHours.TimeZone)
+	switch {
+	case err == nil:
+		preferences.QuietHours = &quietHours
+	case !errors.Is(err, sql.ErrNoRows):
+		return &model.NotificationPreferences{}, err
+	}
+
+	return preferences, nil
+}
+
+func (r *notificationPreferenceRepository) SaveChannel(
+	ctx context.Context,
+	operations db.SQLOperations,
+	customerID int64,
+	event model.NotificationEvent,
+	channel model.NotificationChannel,
+) error {
+
+	_, err := operations.ExecContext(ctx, upsertNotificationChannelSQL, customerID, event, channel, time.Now())
+
+	return err
+}
+
+// SaveQuietHours sets the customer's quiet hours, or removes them when
+// quietHours is nil.
+func (r *notificationPreferenceRepository) SaveQuietHours(
+	ctx context.Context,
+	operations db.SQLOperations,
+	customerID int64,
+	quietHours *model.QuietHours,
+) error {
+
+	if quietHours == nil {
+		_, err := operations.ExecContext(ctx, deleteQuietHoursSQL, customerID)
+		return err
+	}
+
+	_, err := operations.ExecContext(
+		ctx,
+		upsertQuietHoursSQL,
+		customerID,
+		quietHours.Start,
+		quietHours.End,
+		quietHours.TimeZone,
+		time.Now(),
+	)
+
+	return err
+}
@@ -0,0 +1,111 @@
+package repos
+
+import (
+	"context"
+
+	"github.com/ernestngugi/sil-backend/internal/db"
+	"github.com/ernestngugi/sil-backend/internal/model"
+)
+
+const (
+	// lockOrderEventsSQL serialises writers until they commit, so events
+	// commit in ID order and a stream that has read an ID never later finds
+	// a lower one. The key is arbitrary but must stay the same.
+	lockOrderEventsSQL  = "SELECT pg_advisory_xact_lock(7301202610190001)"
+	insertOrderEventSQL = "INSERT INTO order_events (order_id, customer_id, status, previous_status) VALUES ($1, $2, $3, NULLIF($4, '')) RETURNING id, date_created"
+	selectOrderEventSQL = "SELECT id, order_id, customer_id, status, COALESCE(previous_status, ''), date_created FROM order_events"
+	// A zero order ID matches every order of the customer.
+	listOrderEventsSQL = selectOrderEventSQL + " WHERE customer_id = $1 AND ($2::bigint = 0 OR order_id = $2) AND id > $3 ORDER BY id LIMIT $4"
+)
+
+type (
+	OrderEventRepository interface {
+		Save(ctx context.Context, operations db.SQLOperations, event *model.OrderEvent) error
+		EventsAfter(ctx context.Context, operations db.SQLOperations, filter *model.OrderEventFilter, afterID int64, limit int) ([]*model.OrderEvent, error)
+	}
+
+	orderEventRepository struct{}
+)
+
+func NewOrderEventRepository() OrderEventRepository {
+	return &orderEventRepository{}
+}
+
+// Save records event. Listeners are notified once the surrounding
+// transaction commits. It must be called in a transaction, and as late in it
+// as possible: other writers wait from here until the transaction ends.
+func (r *orderEventRepository) Save(
+	ctx context.Context,
+	operations db.SQLOperations,
+	event *model.OrderEvent,
+) error {
+
+	_, err := operations.ExecContext(ctx, lockOrderEventsSQL)
+	if err != nil {
+		return err
+	}
+
+	return operations.QueryRowContext(
+		ctx,
+		insertOrderEventSQL,
+		event.OrderID,
+		event.CustomerID,
+		event.Status,
+		event.PreviousStatus,
+	).Scan(&event.ID, &event.DateCreated)
+}
+
+// EventsAfter returns up to limit events matching filter with IDs above
+// afterID, oldest first.
+func (r *orderEventRepository) EventsAfter(
+	ctx context.Context,
+	operations db.SQLOperations,
+	filter *model.OrderEventFilter,
+	afterID int64,
+	limit int,
+) ([]*model.OrderEvent, error) {
+
+	rows, err := operations.QueryContext(ctx, listOrderEventsSQL, filter.CustomerID, filter.OrderID, afterID, limit)
+	if err != nil {
+		return []*model.OrderEvent{}, err
+	}
+
+	defer rows.Close()
+
+	events := make([]*model.OrderEvent, 0)
+
+	for rows.Next() {
+
+		event, err := scanOrderEvent(rows)
+		if err != nil {
+			return []*model.OrderEvent{}, err
+		}
+
+		events = append(events, event)
+	}
+
+	if err := rows.Err(); err != nil {
+		return []*model.OrderEvent{}, err
+	}
+
+	return events, nil
+}
+
+func scanOrderEvent(row scanner) (*model.OrderEvent, error) {
+
+	var event model.OrderEvent
+
+	err := row.Scan(
+		&event.ID,
+		&event.OrderID,
+		&event.CustomerID,
+		&event.Status,
+		&event.PreviousStatus,
+		&event.DateCreated,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &event, nil
+}
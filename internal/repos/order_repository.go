@@ -2,22 +2,42 @@ package repos
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"time"
 
+	"github.com/ernestngugi/sil-backend/internal/apperrors"
 	"github.com/ernestngugi/sil-backend/internal/db"
 	"github.com/ernestngugi/sil-backend/internal/model"
 )
 
 const (
-	insertOrderSQL  = "INSERT INTO orders(item, amount, customer_id, date_created) VALUES ($1, $2, $3, $4) RETURNING id"
-	selectOrderSQL  = "SELECT id, item, amount, customer_id, date_created FROM orders"
-	getOrderByIDSQL = selectOrderSQL + " WHERE id = $1"
+	insertOrderSQL = "INSERT INTO orders(item, amount, tax_category, tax_treatment, tax_rate, net_amount, tax_amount, promo_code, discount_amount, " +
+		"loyalty_points, loyalty_amount, customer_id, status, payment_status, date_created) " +
+		"VALUES ($1, $2, $3, $4, $5, $6, $7, NULLIF($8, ''), $9, $10, $11, $12, $13, $14, $15) RETURNING id"
+	selectOrderSQL = "SELECT id, item, amount, tax_category, tax_treatment, tax_rate, net_amount, tax_amount, COALESCE(promo_code, ''), discount_amount, " +
+		"loyalty_points, loyalty_amount, customer_id, status, payment_status, date_created FROM orders"
+	getOrderByIDSQL             = selectOrderSQL + " WHERE id = $1"
+	updateOrderStatusSQL        = "UPDATE orders SET status = $1, date_modified = $2 WHERE id = $3 AND status = $4"
+	updateOrderPaymentStatusSQL = "UPDATE orders SET payment_status = $1, date_modified = $2 WHERE id = $3 AND payment_status = $4"
+
+	insertOrderLineSQL = "INSERT INTO order_lines (order_id, product_id, item, quantity, unit_price, discount_amount, amount, " +
+		"tax_category, tax_treatment, tax_rate, net_amount, tax_amount) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)"
+	listOrderLinesSQL = "SELECT product_id, item, quantity, unit_price, discount_amount, amount, tax_category, tax_treatment, tax_rate, " +
+		"net_amount, tax_amount FROM order_lines WHERE order_id = $1 ORDER BY id"
+)
+
+var (
+	errOrderStatusChanged        = apperrors.Conflict("order_status_changed", "order status was changed by another request")
+	errOrderPaymentStatusChanged = apperrors.Conflict("order_payment_status_changed", "order payment status was changed by another request")
 )
 
 type (
 	OrderRepository interface {
 		OrderByID(ctx context.Context, operations db.SQLOperations, orderID int64) (*model.Order, error)
 		Save(ctx context.Context, operations db.SQLOperations, order *model.Order) error
+		UpdateStatus(ctx context.Context, operations db.SQLOperations, order *model.Order, status model.OrderStatus) error
+		UpdatePaymentStatus(ctx context.Context, operations db.SQLOperations, order *model.Order, status model.PaymentStatus) error
 	}
 
 	orderRepository struct{}
@@ -37,7 +57,34 @@ func (r *orderRepository) OrderByID(
 
 	var order model.Order
 
-	err := row.Scan(&order.ID, &order.Item, &order.Amount, &order.CustomerID, &order.DateCreated)
+	err := row.Scan(
+		&order.ID,
+		&order.Item,
+		&order.Amount,
+		&order.Tax.Category,
+		&order.Tax.Treatment,
+		&order.Tax.Rate,
+		&order.Tax.NetAmount,
+		&order.Tax.TaxAmount,
+		&order.PromoCode,
+		&order.DiscountAmount,
+		&order.LoyaltyPoints,
+		&order.LoyaltyAmount,
+		&order.CustomerID,
+		&order.Status,
+		&order.PaymentStatus,
+		&order.DateCreated,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return &model.Order{}, apperrors.NotFound("order_not_found", "order not found").Wrap(err)
+		}
+		return &model.Order{}, err
+	}
+
+	order.Tax.GrossAmount = order.Amount
+
+	order.Lines, err = r.lines(ctx, operations, order.ID)
 	if err != nil {
 		return &model.Order{}, err
 	}
@@ -54,17 +101,173 @@ func (r *orderRepository) Save(
 	timeNow := time.Now()
 	order.DateCreated = timeNow
 
+	if order.Status == "" {
+		order.Status = model.OrderStatusPending
+	}
+
+	if order.PaymentStatus == "" {
+		order.PaymentStatus = model.PaymentStatusUnpaid
+	}
+
 	err := operations.QueryRowContext(
 		ctx,
 		insertOrderSQL,
 		order.Item,
 		order.Amount,
+		order.Tax.Category,
+		order.Tax.Treatment,
+		order.Tax.Rate,
+		order.Tax.NetAmount,
+		order.Tax.TaxAmount,
+		order.PromoCode,
+		order.DiscountAmount,
+		order.LoyaltyPoints,
+		order.LoyaltyAmount,
 		order.CustomerID,
+		order.Status,
+		order.PaymentStatus,
 		order.DateCreated,
 	).Scan(&order.ID)
 	if err != nil {
 		return err
 	}
 
+	for _, line := range order.Lines {
+
+		_, err = operations.ExecContext(
+			ctx,
+			insertOrderLineSQL,
+			order.ID,
+			line.ProductID,
+			line.Item,
+			line.Quantity,
+			line.UnitPrice,
+			line.DiscountAmount,
+			line.Amount,
+			line.Tax.Category,
+			line.Tax.Treatment,
+			line.Tax.Rate,
+			line.Tax.NetAmount,
+			line.Tax.TaxAmount,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// UpdateStatus moves order to status, failing with a conflict if another
+// request changed the status since order was read.
+func (r *orderRepository) UpdateStatus(
+	ctx context.Context,
+	operations db.SQLOperations,
+	order *model.Order,
+	status model.OrderStatus,
+) error {
+
+	result, err := operations.ExecContext(
+		ctx,
+		updateOrderStatusSQL,
+		status,
+		time.Now(),
+		order.ID,
+		order.Status,
+	)
+	if err != nil {
+		return err
+	}
+
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if updated == 0 {
+		return errOrderStatusChanged
+	}
+
+	order.Status = status
+
+	return nil
+}
+
+// UpdatePaymentStatus moves order to the payment status, failing with a
+// conflict if another request changed it since order was read.
+func (r *orderRepository) UpdatePaymentStatus(
+	ctx context.Context,
+	operations db.SQLOperations,
+	order *model.Order,
+	status model.PaymentStatus,
+) error {
+
+	result, err := operations.ExecContext(
+		ctx,
+		updateOrderPaymentStatusSQL,
+		status,
+		time.Now(),
+		order.ID,
+		order.PaymentStatus,
+	)
+	if err != nil {
+		return err
+	}
+
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if updated == 0 {
+		return errOrderPaymentStatusChanged
+	}
+
+	order.PaymentStatus = status
+
 	return nil
 }
+
+func (r *orderRepository) lines(
+	ctx context.Context,
+	operations db.SQLOperations,
+	orderID int64,
+) ([]*model.OrderLine, error) {
+
+	rows, err := operations.QueryContext(ctx, listOrderLinesSQL, orderID)
+	if err != nil {
+		return []*model.OrderLine{}, err
+	}
+
+	defer rows.Close()
+
+	var lines []*model.OrderLine
+
+	for rows.Next() {
+
+		var line model.OrderLine
+
+		err := rows.Scan(
+			&line.ProductID,
+			&line.Item,
+			&line.Quantity,
+			&line.UnitPrice,
+			&line.DiscountAmount,
+			&line.Amount,
+			&line.Tax.Category,
+			&line.Tax.Treatment,
+			&line.Tax.Rate,
+			&line.Tax.NetAmount,
+			&line.Tax.TaxAmount,
+		)
+		if err != nil {
+			return []*model.OrderLine{}, err
+		}
+
+		line.Tax.GrossAmount = line.Amount
+
+		lines = append(lines, &line)
+	}
+
+	return lines, rows.Err()
+}
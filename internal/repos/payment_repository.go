@@ -0,0 +1,263 @@
+package repos
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/ernestngugi/sil-backend/internal/apperrors"
+	"github.com/ernestngugi/sil-backend/internal/db"
+	"github.com/ernestngugi/sil-backend/internal/model"
+)
+
+const (
+	insertPaymentSQL = "INSERT INTO payments (order_id, phone_number, amount, status, date_created) VALUES ($1, $2, $3, $4, $5) RETURNING id"
+	selectPaymentSQL = "SELECT id, order_id, phone_number, amount, status, COALESCE(merchant_request_id, ''), " +
+		"COALESCE(checkout_request_id, ''), result_code, COALESCE(result_description, ''), COALESCE(receipt_number, ''), " +
+		"date_created, date_completed FROM payments"
+	// The lookups lock the payment so a callback and a status query
+	// arriving together complete it once.
+	getPaymentByIDSQL                = selectPaymentSQL + " WHERE id = $1 FOR UPDATE"
+	getPaymentByCheckoutRequestIDSQL = selectPaymentSQL + " WHERE checkout_request_id = $1 FOR UPDATE"
+	getPaymentByReceiptNumberSQL     = selectPaymentSQL + " WHERE receipt_number = $1"
+	listOrderPaymentsSQL             = selectPaymentSQL + " WHERE order_id = $1 ORDER BY id DESC"
+	listPaymentsForAmountSQL         = selectPaymentSQL + " WHERE CEIL(amount) = $1 ORDER BY id"
+	listPendingPaymentsSQL           = selectPaymentSQL + " WHERE status = 'pending' AND date_created <= $1 ORDER BY date_created LIMIT $2"
+	updatePaymentSQL                 = "UPDATE payments SET status = $1, merchant_request_id = NULLIF($2, ''), checkout_request_id = NULLIF($3, ''), " +
+		"result_code = $4, result_description = NULLIF($5, ''), receipt_number = NULLIF($6, ''), date_completed = $7 WHERE id = $8"
+	getCustomerPhoneNumberSQL = "SELECT p.phone_number FROM payments p JOIN orders o ON o.id = p.order_id " +
+		"WHERE o.customer_id = $1 ORDER BY p.id DESC LIMIT 1"
+	getUnconfirmedPaymentSQL = selectPaymentSQL + " WHERE status = 'pending' AND checkout_request_id IS NULL " +
+		"AND LTRIM(phone_number, '+') = $1 AND CEIL(amount) = $2 ORDER BY id LIMIT 1 FOR UPDATE"
+)
+
+var errPaymentNotFound = apperrors.NotFound("payment_not_found", "payment not found")
+
+type (
+	PaymentRepository interface {
+		Save(ctx context.Context, operations db.SQLOperations, payment *model.Payment) error
+		PaymentByID(ctx context.Context, operations db.SQLOperations, paymentID int64) (*model.Payment, error)
+		PaymentByCheckoutRequestID(ctx context.Context, operations db.SQLOperations, checkoutRequestID string) (*model.Payment, error)
+		PaymentByReceiptNumber(ctx context.Context, operations db.SQLOperations, receiptNumber string) (*model.Payment, error)
+		UnconfirmedPayment(ctx context.Context, operations db.SQLOperations, phoneNumber string, amount int64) (*model.Payment, error)
+		Payments(ctx context.Context, operations db.SQLOperations, orderID int64) ([]*model.Payment, error)
+		PaymentsForAmount(ctx context.Context, operations db.SQLOperations, amount int64) ([]*model.Payment, error)
+		PendingPayments(ctx context.Context, operations db.SQLOperations, createdBefore time.Time, limit int) ([]*model.Payment, error)
+		Update(ctx context.Context, operations db.SQLOperations, payment *model.Payment) error
+		// CustomerPhoneNumber returns the number the customer last paid
+		// from, or an empty string if they never tried to pay.
+		CustomerPhoneNumber(ctx context.Context, operations db.SQLOperations, customerID int64) (string, error)
+	}
+
+	paymentRepository struct{}
+)
+
+func NewPaymentRepository() PaymentRepository {
+	return &paymentRepository{}
+}
+
+func (r *paymentRepository) Save(
+	ctx context.Context,
+	operations db.SQLOperations,
+	payment *model.Payment,
+) error {
+
+	payment.DateCreated = time.Now()
+
+	if payment.Status == "" {
+		payment.Status = model.PaymentStatusPending
+	}
+
+	return operations.QueryRowContext(
+		ctx,
+		insertPaymentSQL,
+		payment.OrderID,
+		payment.PhoneNumber,
+		payment.Amount,
+		payment.Status,
+		payment.DateCreated,
+	).Scan(&payment.ID)
+}
+
+func (r *paymentRepository) PaymentByID(
+	ctx context.Context,
+	operations db.SQLOperations,
+	paymentID int64,
+) (*model.Payment, error) {
+	return r.payment(ctx, operations, getPaymentByIDSQL, paymentID)
+}
+
+func (r *paymentRepository) PaymentByCheckoutRequestID(
+	ctx context.Context,
+	operations db.SQLOperations,
+	checkoutRequestID string,
+) (*model.Payment, error) {
+	return r.payment(ctx, operations, getPaymentByCheckoutRequestIDSQL, checkoutRequestID)
+}
+
+func (r *paymentRepository) PaymentByReceiptNumber(
+	ctx context.Context,
+	operations db.SQLOperations,
+	receiptNumber string,
+) (*model.Payment, error) {
+	return r.payment(ctx, operations, getPaymentByReceiptNumberSQL, receiptNumber)
+}
+
+// UnconfirmedPayment locks the oldest pending payment of amount whole
+// shillings from phoneNumber, given without the leading plus, that Daraja
+// never confirmed it had pushed.
+func (r *paymentRepository) UnconfirmedPayment(
+	ctx context.Context,
+	operations db.SQLOperations,
+	phoneNumber string,
+	amount int64,
+) (*model.Payment, error) {
+	return r.payment(ctx, operations, getUnconfirmedPaymentSQL, phoneNumber, amount)
+}
+
+// Payments returns the payments for an order, newest first.
+func (r *paymentRepository) Payments(
+	ctx context.Context,
+	operations db.SQLOperations,
+	orderID int64,
+) ([]*model.Payment, error) {
+	return r.payments(ctx, operations, listOrderPaymentsSQL, orderID)
+}
+
+// PaymentsForAmount returns the payments that were charged amount whole
+// shillings, whatever their status.
+func (r *paymentRepository) PaymentsForAmount(
+	ctx context.Context,
+	operations db.SQLOperations,
+	amount int64,
+) ([]*model.Payment, error) {
+	return r.payments(ctx, operations, listPaymentsForAmountSQL, amount)
+}
+
+// PendingPayments returns up to limit payments still waiting for a result
+// that were started before createdBefore, oldest first.
+func (r *paymentRepository) PendingPayments(
+	ctx context.Context,
+	operations db.SQLOperations,
+	createdBefore time.Time,
+	limit int,
+) ([]*model.Payment, error) {
+	return r.payments(ctx, operations, listPendingPaymentsSQL, createdBefore, limit)
+}
+
+func (r *paymentRepository) Update(
+	ctx context.Context,
+	operations db.SQLOperations,
+	payment *model.Payment,
+) error {
+
+	result, err := operations.ExecContext(
+		ctx,
+		updatePaymentSQL,
+		payment.Status,
+		payment.MerchantRequestID,
+		payment.CheckoutRequestID,
+		payment.ResultCode,
+		payment.ResultDescription,
+		payment.ReceiptNumber,
+		payment.DateCompleted,
+		payment.ID,
+	)
+	if err != nil {
+		return err
+	}
+
+	return expectRow(result, errPaymentNotFound)
+}
+
+func (r *paymentRepository) CustomerPhoneNumber(
+	ctx context.Context,
+	operations db.SQLOperations,
+	customerID int64,
+) (string, error) {
+
+	var phoneNumber string
+
+	err := operations.QueryRowContext(ctx, getCustomerPhoneNumberSQL, customerID).Scan(&phoneNumber)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", nil
+		}
+		return "", err
+	}
+
+	return phoneNumber, nil
+}
+
+func (r *paymentRepository) payment(
+	ctx context.Context,
+	operations db.SQLOperations,
+	query string,
+	args ...any,
+) (*model.Payment, error) {
+
+	payment, err := scanPayment(operations.QueryRowContext(ctx, query, args...))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return &model.Payment{}, errPaymentNotFound.Wrap(err)
+		}
+		return &model.Payment{}, err
+	}
+
+	return payment, nil
+}
+
+func (r *paymentRepository) payments(
+	ctx context.Context,
+	operations db.SQLOperations,
+	query string,
+	args ...any,
+) ([]*model.Payment, error) {
+
+	rows, err := operations.QueryContext(ctx, query, args...)
+	if err != nil {
+		return []*model.Payment{}, err
+	}
+
+	defer rows.Close()
+
+	payments := make([]*model.Payment, 0)
+
+	for rows.Next() {
+
+		payment, err := scanPayment(rows)
+		if err != nil {
+			return []*model.Payment{}, err
+		}
+
+		payments = append(payments, payment)
+	}
+
+	return payments, rows.Err()
+}
+
+func scanPayment(row scanner) (*model.Payment, error) {
+
+	var payment model.Payment
+
+	err := row.Scan(
+		&payment.ID,
+		&payment.OrderID,
+		&payment.PhoneNumber,
+		&payment.Amount,
+		&payment.Status,
+		&payment.MerchantRequestID,
+		&payment.CheckoutRequestID,
+		&payment.ResultCode,
+		&payment.ResultDescription,
+		&payment.ReceiptNumber,
+		&payment.DateCreated,
+		&payment.DateCompleted,
+	)
+	if err != nil {
+		return &model.Payment{}, err
+	}
+
+	return &payment, nil
+}
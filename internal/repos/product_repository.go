@@ -0,0 +1,237 @@
+package repos
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/ernestngugi/sil-backend/internal/apperrors"
+	"github.com/ernestngugi/sil-backend/internal/db"
+	"github.com/ernestngugi/sil-backend/internal/model"
+	"github.com/lib/pq"
+)
+
+const (
+	insertProductSQL = "INSERT INTO products (sku, name, price, tax_category, stock, active, date_created, date_modified) " +
+		"VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id"
+	selectProductSQL      = "SELECT id, sku, name, price, tax_category, stock, active, date_created, date_modified FROM products"
+	getProductByIDSQL     = selectProductSQL + " WHERE id = $1"
+	listProductsSQL       = selectProductSQL + " ORDER BY name, id"
+	listActiveProductsSQL = selectProductSQL + " WHERE active ORDER BY name, id"
+	// lockProductsSQL locks in ID order, so checkouts sharing products
+	// cannot deadlock.
+	lockProductsSQL  = selectProductSQL + " WHERE id = ANY($1) ORDER BY id FOR UPDATE"
+	updateProductSQL = "UPDATE products SET name = $1, price = $2, tax_category = $3, stock = $4, active = $5, date_modified = $6 WHERE id = $7"
+	takeStockSQL     = "UPDATE products SET stock = stock - $1, date_modified = $2 WHERE id = $3 AND stock >= $1"
+	returnStockSQL   = "UPDATE products SET stock = stock + $1, date_modified = $2 WHERE id = $3"
+)
+
+var (
+	errProductNotFound = apperrors.NotFound("product_not_found", "product not found")
+	errProductSKUTaken = apperrors.Conflict("product_sku_taken", "another product already uses this SKU")
+	errStockTaken      = apperrors.Conflict("out_of_stock", "there is not enough stock of the product")
+)
+
+type (
+	ProductRepository interface {
+		SaveProduct(ctx context.Context, operations db.SQLOperations, product *model.Product) error
+		ProductByID(ctx context.Context, operations db.SQLOperations, productID int64) (*model.Product, error)
+		// Products returns the catalogue by name, leaving out withdrawn
+		// products unless all is set.
+		Products(ctx context.Context, operations db.SQLOperations, all bool) ([]*model.Product, error)
+		// LockProducts returns the products with IDs, holding them until
+		// the transaction ends. Unknown IDs are left out.
+		LockProducts(ctx context.Context, operations db.SQLOperations, productIDs []int64) ([]*model.Product, error)
+		UpdateProduct(ctx context.Context, operations db.SQLOperations, product *model.Product) error
+		TakeStock(ctx context.Context, operations db.SQLOperations, productID int64, quantity int) error
+		ReturnStock(ctx context.Context, operations db.SQLOperations, productID int64, quantity int) error
+	}
+
+	productRepository struct{}
+)
+
+func NewProductRepository() ProductRepository {
+	return &productRepository{}
+}
+
+func (r *productRepository) SaveProduct(
+	ctx context.Context,
+	operations db.SQLOperations,
+	product *model.Product,
+) error {
+
+	timeNow := time.Now()
+	product.DateCreated = timeNow
+	product.DateModified = timeNow
+
+	err := operations.QueryRowContext(
+		ctx,
+		insertProductSQL,
+		product.SKU,
+		product.Name,
+		product.Price,
+		product.TaxCategory,
+		product.Stock,
+		product.Active,
+		product.DateCreated,
+		product.DateModified,
+	).Scan(&product.ID)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code.Name() == "unique_violation" {
+			return errProductSKUTaken.Wrap(err)
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (r *productRepository) ProductByID(
+	ctx context.Context,
+	operations db.SQLOperations,
+	productID int64,
+) (*model.Product, error) {
+
+	product, err := scanProduct(operations.QueryRowContext(ctx, getProductByIDSQL, productID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return &model.Product{}, errProductNotFound.Wrap(err)
+		}
+		return &model.Product{}, err
+	}
+
+	return product, nil
+}
+
+func (r *productRepository) Products(
+	ctx context.Context,
+	operations db.SQLOperations,
+	all bool,
+) ([]*model.Product, error) {
+
+	query := listActiveProductsSQL
+	if all {
+		query = listProductsSQL
+	}
+
+	return r.products(ctx, operations, query)
+}
+
+func (r *productRepository) LockProducts(
+	ctx context.Context,
+	operations db.SQLOperations,
+	productIDs []int64,
+) ([]*model.Product, error) {
+	return r.products(ctx, operations, lockProductsSQL, pq.Array(productIDs))
+}
+
+func (r *productRepository) UpdateProduct(
+	ctx context.Context,
+	operations db.SQLOperations,
+	product *model.Product,
+) error {
+
+	product.DateModified = time.Now()
+
+	result, err := operations.ExecContext(
+		ctx,
+		updateProductSQL,
+		product.Name,
+		product.Price,
+		product.TaxCategory,
+		product.Stock,
+		product.Active,
+		product.DateModified,
+		product.ID,
+	)
+	if err != nil {
+		return err
+	}
+
+	return expectRow(result, errProductNotFound)
+}
+
+// TakeStock removes quantity from the product's stock, failing rather than
+// letting it go below zero.
+func (r *productRepository) TakeStock(
+	ctx context.Context,
+	operations db.SQLOperations,
+	productID int64,
+	quantity int,
+) error {
+
+	result, err := operations.ExecContext(ctx, takeStockSQL, quantity, time.Now(), productID)
+	if err != nil {
+		return err
+	}
+
+	return expectRow(result, errStockTaken)
+}
+
+func (r *productRepository) ReturnStock(
+	ctx context.Context,
+	operations db.SQLOperations,
+	productID int64,
+	quantity int,
+) error {
+
+	result, err := operations.ExecContext(ctx, returnStockSQL, quantity, time.Now(), productID)
+	if err != nil {
+		return err
+	}
+
+	return expectRow(result, errProductNotFound)
+}
+
+func (r *productRepository) products(
+	ctx context.Context,
+	operations db.SQLOperations,
+	query string,
+	args ...any,
+) ([]*model.Product, error) {
+
+	rows, err := operations.QueryContext(ctx, query, args...)
+	if err != nil {
+		return []*model.Product{}, err
+	}
+
+	defer rows.Close()
+
+	products := make([]*model.Product, 0)
+
+	for rows.Next() {
+
+		product, err := scanProduct(rows)
+		if err != nil {
+			return []*model.Product{}, err
+		}
+
+		products = append(products, product)
+	}
+
+	return products, rows.Err()
+}
+
+func scanProduct(row scanner) (*model.Product, error) {
+
+	var product model.Product
+
+	err := row.Scan(
+		&product.ID,
+		&product.SKU,
+		&product.Name,
+		&product.Price,
+		&product.TaxCategory,
+		&product.Stock,
+		&product.Active,
+		&product.DateCreated,
+		&product.DateModified,
+	)
+	if err != nil {
+		return &model.Product{}, err
+	}
+
+	return &product, nil
+}
@@ -0,0 +1,383 @@
+package repos
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/ernestngugi/sil-backend/internal/apperrors"
+	"github.com/ernestngugi/sil-backend/internal/db"
+	"github.com/ernestngugi/sil-backend/internal/model"
+	"github.com/lib/pq"
+)
+
+const (
+	insertPromotionSQL = "INSERT INTO promotions (code, description, discount_type, discount_value, min_order_amount, items, tax_categories, " +
+		"starts_at, ends_at, usage_limit, per_customer_limit, active, date_created, date_modified) " +
+		"VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14) RETURNING id"
+	selectPromotionSQL = "SELECT id, code, description, discount_type, discount_value, min_order_amount, items, tax_categories, " +
+		"starts_at, ends_at, usage_limit, per_customer_limit, times_redeemed, active, date_created, date_modified FROM promotions"
+	getPromotionByIDSQL   = selectPromotionSQL + " WHERE id = $1"
+	listPromotionsSQL     = selectPromotionSQL + " ORDER BY id DESC"
+	getPromotionByCodeSQL = selectPromotionSQL + " WHERE code = $1"
+	// lockPromotionByCodeSQL holds the promotion until the transaction ends,
+	// so redemptions of one code are counted one at a time.
+	lockPromotionByCodeSQL = selectPromotionSQL + " WHERE code = $1 FOR UPDATE"
+	updatePromotionSQL     = "UPDATE promotions SET description = $1, discount_value = $2, min_order_amount = $3, items = $4, tax_categories = $5, " +
+		"starts_at = $6, ends_at = $7, usage_limit = $8, per_customer_limit = $9, active = $10, date_modified = $11 WHERE id = $12"
+	deletePromotionSQL = "DELETE FROM promotions WHERE id = $1"
+
+	insertPromotionRedemptionSQL = "INSERT INTO promotion_redemptions (promotion_id, order_id, customer_id, discount_amount, order_amount, date_created) " +
+		"VALUES ($1, $2, $3, $4, $5, $6) RETURNING id"
+	countPromotionRedeemedSQL   = "UPDATE promotions SET times_redeemed = times_redeemed + 1 WHERE id = $1"
+	countCustomerRedemptionsSQL = "SELECT COUNT(*) FROM promotion_redemptions WHERE promotion_id = $1 AND customer_id = $2"
+	listPromotionRedemptionsSQL = "SELECT id, promotion_id, order_id, customer_id, discount_amount, order_amount, date_created " +
+		"FROM promotion_redemptions WHERE promotion_id = $1 ORDER BY id DESC LIMIT $2"
+	promotionReportSQL = "SELECT p.id, p.code, p.active, COUNT(r.id), COUNT(DISTINCT r.customer_id), " +
+		"COUNT(o.id) FILTER (WHERE o.payment_status = 'paid'), COALESCE(SUM(r.discount_amount), 0), COALESCE(SUM(r.order_amount), 0), " +
+		"MIN(r.date_created), MAX(r.date_created) FROM promotions p " +
+		"LEFT JOIN promotion_redemptions r ON r.promotion_id = p.id LEFT JOIN orders o ON o.id = r.order_id " +
+		"GROUP BY p.id ORDER BY p.id DESC"
+)
+
+var (
+	errPromotionNotFound  = apperrors.NotFound("promotion_not_found", "promotion not found")
+	errPromotionCodeTaken = apperrors.Conflict("promotion_code_taken", "another promotion already uses this code")
+)
+
+type (
+	PromotionRepository interface {
+		SavePromotion(ctx context.Context, operations db.SQLOperations, promotion *model.Promotion) error
+		PromotionByID(ctx context.Context, operations db.SQLOperations, promotionID int64) (*model.Promotion, error)
+		Promotions(ctx context.Context, operations db.SQLOperations) ([]*model.Promotion, error)
+		PromotionByCode(ctx context.Context, operations db.SQLOperations, code string) (*model.Promotion, error)
+		// LockPromotionByCode returns the promotion with code, holding it
+		// until the transaction ends.
+		LockPromotionByCode(ctx context.Context, operations db.SQLOperations, code string) (*model.Promotion, error)
+		UpdatePromotion(ctx context.Context, operations db.SQLOperations, promotion *model.Promotion) error
+		DeletePromotion(ctx context.Context, operations db.SQLOperations, promotionID int64) error
+
+		// SaveRedemption records the redemption and counts it against the
+		// promotion's usage limit.
+		SaveRedemption(ctx context.Context, operations db.SQLOperations, redemption *model.PromotionRedemption) error
+		CustomerRedemptions(ctx context.Context, operations db.SQLOperations, promotionID, customerID int64) (int, error)
+		Redemptions(ctx context.Context, operations db.SQLOperations, promotionID int64, limit int) ([]*model.PromotionRedemption, error)
+		Report(ctx context.Context, operations db.SQLOperations) ([]*model.PromotionReport, error)
+	}
+
+	promotionRepository struct{}
+)
+
+func NewPromotionRepository() PromotionRepository {
+	return &promotionRepository{}
+}
+
+func (r *promotionRepository) SavePromotion(
+	ctx context.Context,
+	operations db.SQLOperations,
+	promotion *model.Promotion,
+) error {
+
+	timeNow := time.Now()
+	promotion.DateCreated = timeNow
+	promotion.DateModified = timeNow
+
+	err := operations.QueryRowContext(
+		ctx,
+		insertPromotionSQL,
+		promotion.Code,
+		promotion.Description,
+		promotion.DiscountType,
+		promotion.DiscountValue,
+		promotion.MinOrderAmount,
+		pq.Array(promotion.Items),
+		pq.Array(promotion.TaxCategories),
+		promotion.StartsAt,
+		promotion.EndsAt,
+		promotion.UsageLimit,
+		promotion.PerCustomerLimit,
+		promotion.Active,
+		promotion.DateCreated,
+		promotion.DateModified,
+	).Scan(&promotion.ID)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code.Name() == "unique_violation" {
+			return errPromotionCodeTaken.Wrap(err)
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (r *promotionRepository) PromotionByID(
+	ctx context.Context,
+	operations db.SQLOperations,
+	promotionID int64,
+) (*model.Promotion, error) {
+	return r.promotion(ctx, operations, getPromotionByIDSQL, promotionID)
+}
+
+func (r *promotionRepository) Promotions(
+	ctx context.Context,
+	operations db.SQLOperations,
+) ([]*model.Promotion, error) {
+
+	rows, err := operations.QueryContext(ctx, listPromotionsSQL)
+	if err != nil {
+		return []*model.Promotion{}, err
+	}
+
+	defer rows.Close()
+
+	promotions := make([]*model.Promotion, 0)
+
+	for rows.Next() {
+
+		promotion, err := scanPromotion(rows)
+		if err != nil {
+			return []*model.Promotion{}, err
+		}
+
+		promotions = append(promotions, promotion)
+	}
+
+	return promotions, rows.Err()
+}
+
+func (r *promotionRepository) PromotionByCode(
+	ctx context.Context,
+	operations db.SQLOperations,
+	code string,
+) (*model.Promotion, error) {
+	return r.promotion(ctx, operations, getPromotionByCodeSQL, code)
+}
+
+func (r *promotionRepository) LockPromotionByCode(
+	ctx context.Context,
+	operations db.SQLOperations,
+	code string,
+) (*model.Promotion, error) {
+	return r.promotion(ctx, operations, lockPromotionByCodeSQL, code)
+}
+
+func (r *promotionRepository) UpdatePromotion(
+	ctx context.Context,
+	operations db.SQLOperations,
+	promotion *model.Promotion,
+) error {
+
+	promotion.DateModified = time.Now()
+
+	result, err := operations.ExecContext(
+		ctx,
+		updatePromotionSQL,
+		promotion.Description,
+		promotion.DiscountValue,
+		promotion.MinOrderAmount,
+		pq.Array(promotion.Items),
+		pq.Array(promotion.TaxCategories),
+		promotion.StartsAt,
+		promotion.EndsAt,
+		promotion.UsageLimit,
+		promotion.PerCustomerLimit,
+		promotion.Active,
+		promotion.DateModified,
+		promotion.ID,
+	)
+	if err != nil {
+		return err
+	}
+
+	return expectRow(result, errPromotionNotFound)
+}
+
+func (r *promotionRepository) DeletePromotion(
+	ctx context.Context,
+	operations db.SQLOperations,
+	promotionID int64,
+) error {
+
+	result, err := operations.ExecContext(ctx, deletePromotionSQL, promotionID)
+	if err != nil {
+		return err
+	}
+
+	return expectRow(result, errPromotionNotFound)
+}
+
+func (r *promotionRepository) SaveRedemption(
+	ctx context.Context,
+	operations db.SQLOperations,
+	redemption *model.PromotionRedemption,
+) error {
+
+	redemption.DateCreated = time.Now()
+
+	err := operations.QueryRowContext(
+		ctx,
+		insertPromotionRedemptionSQL,
+		redemption.PromotionID,
+		redemption.OrderID,
+		redemption.CustomerID,
+		redemption.DiscountAmount,
+		redemption.OrderAmount,
+		redemption.DateCreated,
+	).Scan(&redemption.ID)
+	if err != nil {
+		return err
+	}
+
+	result, err := operations.ExecContext(ctx, countPromotionRedeemedSQL, redemption.PromotionID)
+	if err != nil {
+		return err
+	}
+
+	return expectRow(result, errPromotionNotFound)
+}
+
+func (r *promotionRepository) CustomerRedemptions(
+	ctx context.Context,
+	operations db.SQLOperations,
+	promotionID, customerID int64,
+) (int, error) {
+
+	var count int
+
+	err := operations.QueryRowContext(ctx, countCustomerRedemptionsSQL, promotionID, customerID).Scan(&count)
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// Redemptions returns the latest redemptions of a promotion, newest first.
+func (r *promotionRepository) Redemptions(
+	ctx context.Context,
+	operations db.SQLOperations,
+	promotionID int64,
+	limit int,
+) ([]*model.PromotionRedemption, error) {
+
+	rows, err := operations.QueryContext(ctx, listPromotionRedemptionsSQL, promotionID, limit)
+	if err != nil {
+		return []*model.PromotionRedemption{}, err
+	}
+
+	defer rows.Close()
+
+	redemptions := make([]*model.PromotionRedemption, 0)
+
+	for rows.Next() {
+
+		var redemption model.PromotionRedemption
+
+		err := rows.Scan(
+			&redemption.ID,
+			&redemption.PromotionID,
+			&redemption.OrderID,
+			&redemption.CustomerID,
+			&redemption.DiscountAmount,
+			&redemption.OrderAmount,
+			&redemption.DateCreated,
+		)
+		if err != nil {
+			return []*model.PromotionRedemption{}, err
+		}
+
+		redemptions = append(redemptions, &redemption)
+	}
+
+	return redemptions, rows.Err()
+}
+
+// Report totals the redemptions of every promotion, newest promotion first.
+func (r *promotionRepository) Report(
+	ctx context.Context,
+	operations db.SQLOperations,
+) ([]*model.PromotionReport, error) {
+
+	rows, err := operations.QueryContext(ctx, promotionReportSQL)
+	if err != nil {
+		return []*model.PromotionReport{}, err
+	}
+
+	defer rows.Close()
+
+	reports := make([]*model.PromotionReport, 0)
+
+	for rows.Next() {
+
+		var report model.PromotionReport
+
+		err := rows.Scan(
+			&report.PromotionID,
+			&report.Code,
+			&report.Active,
+			&report.Redemptions,
+			&report.Customers,
+			&report.PaidOrders,
+			&report.DiscountAmount,
+			&report.OrderAmount,
+			&report.FirstRedeemed,
+			&report.LastRedeemed,
+		)
+		if err != nil {
+			return []*model.PromotionReport{}, err
+		}
+
+		reports = append(reports, &report)
+	}
+
+	return reports, rows.Err()
+}
+
+func (r *promotionRepository) promotion(
+	ctx context.Context,
+	operations db.SQLOperations,
+	query string,
+	args ...any,
+) (*model.Promotion, error) {
+
+	promotion, err := scanPromotion(operations.QueryRowContext(ctx, query, args...))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return &model.Promotion{}, errPromotionNotFound.Wrap(err)
+		}
+		return &model.Promotion{}, err
+	}
+
+	return promotion, nil
+}
+
+func scanPromotion(row scanner) (*model.Promotion, error) {
+
+	var promotion model.Promotion
+
+	err := row.Scan(
+		&promotion.ID,
+		&promotion.Code,
+		&promotion.Description,
+		&promotion.DiscountType,
+		&promotion.DiscountValue,
+		&promotion.MinOrderAmount,
+		pq.Array(&promotion.Items),
+		pq.Array(&promotion.TaxCategories),
+		&promotion.StartsAt,
+		&promotion.EndsAt,
+		&promotion.UsageLimit,
+		&promotion.PerCustomerLimit,
+		&promotion.TimesRedeemed,
+		&promotion.Active,
+		&promotion.DateCreated,
+		&promotion.DateModified,
+	)
+	if err != nil {
+		return &model.Promotion{}, err
+	}
+
+	return &promotion, nil
+}
@@ -0,0 +1,287 @@
+package repos
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/ernestngugi/sil-backend/internal/apperrors"
+	"github.com/ernestngugi/sil-backend/internal/db"
+	"github.com/ernestngugi/sil-backend/internal/model"
+)
+
+const (
+	// Importing a statement that is already on record inserts nothing, which
+	// is how re-imports are detected.
+	insertReconciliationRunSQL = "INSERT INTO reconciliation_runs (provider, file_name, statement_sha256, date_created) VALUES ($1, $2, $3, $4) " +
+		"ON CONFLICT (statement_sha256) DO NOTHING RETURNING id"
+	selectReconciliationRunSQL = "SELECT id, provider, file_name, statement_sha256, transactions, matched, unmatched, duplicates, " +
+		"amount_mismatches, date_created FROM reconciliation_runs"
+	getReconciliationRunByIDSQL      = selectReconciliationRunSQL + " WHERE id = $1"
+	getReconciliationRunBySHA256SQL  = selectReconciliationRunSQL + " WHERE statement_sha256 = $1"
+	listReconciliationRunsSQL        = selectReconciliationRunSQL + " ORDER BY id DESC LIMIT $1"
+	updateReconciliationRunTotalsSQL = "UPDATE reconciliation_runs SET transactions = $1, matched = $2, unmatched = $3, duplicates = $4, amount_mismatches = $5 WHERE id = $6"
+	insertReconciliationEntrySQL     = "INSERT INTO reconciliation_entries (run_id, line, receipt_number, completed_at, amount, phone_number, account_reference, status, order_id, payment_id, expected_amount, note) " +
+		"VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12) RETURNING id"
+	listReconciliationEntriesSQL = "SELECT id, run_id, line, receipt_number, completed_at, amount, phone_number, account_reference, status, order_id, payment_id, " +
+		"expected_amount, note FROM reconciliation_entries WHERE run_id = $1 ORDER BY line"
+	listMatchedReceiptsSQL = "SELECT DISTINCT receipt_number FROM reconciliation_entries WHERE order_id = $1 AND status = 'matched'"
+)
+
+var errReconciliationRunNotFound = apperrors.NotFound("reconciliation_not_found", "reconciliation not found")
+
+type (
+	ReconciliationRepository interface {
+		// SaveRun records a new run and reports false, saving nothing, when
+		// the statement was imported before.
+		SaveRun(ctx context.Context, operations db.SQLOperations, run *model.ReconciliationRun) (bool, error)
+		RunByID(ctx context.Context, operations db.SQLOperations, runID int64) (*model.ReconciliationRun, error)
+		RunBySHA256(ctx context.Context, operations db.SQLOperations, statementSHA256 string) (*model.ReconciliationRun, error)
+		Runs(ctx context.Context, operations db.SQLOperations, limit int) ([]*model.ReconciliationRun, error)
+		UpdateRunTotals(ctx context.Context, operations db.SQLOperations, run *model.ReconciliationRun) error
+		SaveEntry(ctx context.Context, operations db.SQLOperations, entry *model.ReconciliationEntry) error
+		Entries(ctx context.Context, operations db.SQLOperations, runID int64) ([]*model.ReconciliationEntry, error)
+		MatchedReceipts(ctx context.Context, operations db.SQLOperations, orderID int64) ([]string, error)
+	}
+
+	reconciliationRepository struct{}
+)
+
+func NewReconciliationRepository() ReconciliationRepository {
+	return &reconciliationRepository{}
+}
+
+func (r *reconciliationRepository) SaveRun(
+	ctx context.Context,
+	operations db.SQLOperations,
+	run *model.ReconciliationRun,
+) (bool, error) {
+
+	run.DateCreated = time.Now()
+
+	err := operations.QueryRowContext(
+		ctx,
+		insertReconciliationRunSQL,
+		run.Provider,
+		run.FileName,
+		run.StatementSHA256,
+		run.DateCreated,
+	).Scan(&run.ID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (r *reconciliationRepository) RunByID(
+	ctx context.Context,
+	operations db.SQLOperations,
+	runID int64,
+) (*model.ReconciliationRun, error) {
+	return r.run(ctx, operations, getReconciliationRunByIDSQL, runID)
+}
+
+func (r *reconciliationRepository) RunBySHA256(
+	ctx context.Context,
+	operations db.SQLOperations,
+	statementSHA256 string,
+) (*model.ReconciliationRun, error) {
+	return r.run(ctx, operations, getReconciliationRunBySHA256SQL, statementSHA256)
+}
+
+// Runs returns the latest runs, newest first.
+func (r *reconciliationRepository) Runs(
+	ctx context.Context,
+	operations db.SQLOperations,
+	limit int,
+) ([]*model.ReconciliationRun, error) {
+
+	rows, err := operations.QueryContext(ctx, listReconciliationRunsSQL, limit)
+	if err != nil {
+		return []*model.ReconciliationRun{}, err
+	}
+
+	defer rows.Close()
+
+	runs := make([]*model.ReconciliationRun, 0)
+
+	for rows.Next() {
+
+		run, err := scanReconciliationRun(rows)
+		if err != nil {
+			return []*model.ReconciliationRun{}, err
+		}
+
+		runs = append(runs, run)
+	}
+
+	return runs, rows.Err()
+}
+
+func (r *reconciliationRepository) UpdateRunTotals(
+	ctx context.Context,
+	operations db.SQLOperations,
+	run *model.ReconciliationRun,
+) error {
+
+	result, err := operations.ExecContext(
+		ctx,
+		updateReconciliationRunTotalsSQL,
+		run.Transactions,
+		run.Matched,
+		run.Unmatched,
+		run.Duplicates,
+		run.AmountMismatches,
+		run.ID,
+	)
+	if err != nil {
+		return err
+	}
+
+	return expectRow(result, errReconciliationRunNotFound)
+}
+
+func (r *reconciliationRepository) SaveEntry(
+	ctx context.Context,
+	operations db.SQLOperations,
+	entry *model.ReconciliationEntry,
+) error {
+	return operations.QueryRowContext(
+		ctx,
+		insertReconciliationEntrySQL,
+		entry.RunID,
+		entry.Line,
+		entry.ReceiptNumber,
+		entry.CompletedAt,
+		entry.Amount,
+		entry.PhoneNumber,
+		entry.AccountReference,
+		entry.Status,
+		entry.OrderID,
+		entry.PaymentID,
+		entry.ExpectedAmount,
+		entry.Note,
+	).Scan(&entry.ID)
+}
+
+// Entries returns a run's entries in statement order.
+func (r *reconciliationRepository) Entries(
+	ctx context.Context,
+	operations db.SQLOperations,
+	runID int64,
+) ([]*model.ReconciliationEntry, error) {
+
+	rows, err := operations.QueryContext(ctx, listReconciliationEntriesSQL, runID)
+	if err != nil {
+		return []*model.ReconciliationEntry{}, err
+	}
+
+	defer rows.Close()
+
+	entries := make([]*model.ReconciliationEntry, 0)
+
+	for rows.Next() {
+
+		var entry model.ReconciliationEntry
+
+		err := rows.Scan(
+			&entry.ID,
+			&entry.RunID,
+			&entry.Line,
+			&entry.ReceiptNumber,
+			&entry.CompletedAt,
+			&entry.Amount,
+			&entry.PhoneNumber,
+			&entry.AccountReference,
+			&entry.Status,
+			&entry.OrderID,
+			&entry.PaymentID,
+			&entry.ExpectedAmount,
+			&entry.Note,
+		)
+		if err != nil {
+			return []*model.ReconciliationEntry{}, err
+		}
+
+		entries = append(entries, &entry)
+	}
+
+	return entries, rows.Err()
+}
+
+// MatchedReceipts returns the receipts earlier runs matched to an order.
+func (r *reconciliationRepository) MatchedReceipts(
+	ctx context.Context,
+	operations db.SQLOperations,
+	orderID int64,
+) ([]string, error) {
+
+	rows, err := operations.QueryContext(ctx, listMatchedReceiptsSQL, orderID)
+	if err != nil {
+		return []string{}, err
+	}
+
+	defer rows.Close()
+
+	receipts := make([]string, 0)
+
+	for rows.Next() {
+
+		var receipt string
+
+		err := rows.Scan(&receipt)
+		if err != nil {
+			return []string{}, err
+		}
+
+		receipts = append(receipts, receipt)
+	}
+
+	return receipts, rows.Err()
+}
+
+func (r *reconciliationRepository) run(
+	ctx context.Context,
+	operations db.SQLOperations,
+	query string,
+	args ...any,
+) (*model.ReconciliationRun, error) {
+
+	run, err := scanReconciliationRun(operations.QueryRowContext(ctx, query, args...))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return &model.ReconciliationRun{}, errReconciliationRunNotFound.Wrap(err)
+		}
+		return &model.ReconciliationRun{}, err
+	}
+
+	return run, nil
+}
+
+func scanReconciliationRun(row scanner) (*model.ReconciliationRun, error) {
+
+	var run model.ReconciliationRun
+
+	err := row.Scan(
+		&run.ID,
+		&run.Provider,
+		&run.FileName,
+		&run.StatementSHA256,
+		&run.Transactions,
+		&run.Matched,
+		&run.Unmatched,
+		&run.Duplicates,
+		&run.AmountMismatches,
+		&run.DateCreated,
+	)
+	if err != nil {
+		return &model.ReconciliationRun{}, err
+	}
+
+	return &run, nil
+}
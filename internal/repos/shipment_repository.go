@@ -0,0 +1,258 @@
+package repos
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/ernestngugi/sil-backend/internal/apperrors"
+	"github.com/ernestngugi/sil-backend/internal/db"
+	"github.com/ernestngugi/sil-backend/internal/model"
+	"github.com/lib/pq"
+)
+
+const (
+	insertShipmentSQL = "INSERT INTO shipments (order_id, carrier, tracking_number, rider_name, rider_phone, estimated_delivery, status, " +
+		"code_hash, date_created, date_modified) VALUES ($1, $2, NULLIF($3, ''), $4, $5, $6, $7, $8, $9, $10) RETURNING id"
+	selectShipmentSQL = "SELECT id, order_id, carrier, COALESCE(tracking_number, ''), rider_name, rider_phone, estimated_delivery, status, " +
+		"code_hash, code_attempts, COALESCE(recipient_name, ''), date_delivered, date_created, date_modified FROM shipments"
+	getShipmentByIDSQL      = selectShipmentSQL + " WHERE id = $1"
+	getShipmentByOrderIDSQL = selectShipmentSQL + " WHERE order_id = $1"
+	// lockShipmentSQL holds the shipment until the transaction ends, so
+	// delivery code attempts are counted one at a time.
+	lockShipmentSQL   = getShipmentByIDSQL + " FOR UPDATE"
+	updateShipmentSQL = "UPDATE shipments SET carrier = $1, tracking_number = NULLIF($2, ''), rider_name = $3, rider_phone = $4, " +
+		"estimated_delivery = $5, status = $6, code_hash = $7, code_attempts = $8, recipient_name = NULLIF($9, ''), date_delivered = $10, " +
+		"date_modified = $11 WHERE id = $12"
+
+	insertShipmentEventSQL = "INSERT INTO shipment_events (shipment_id, status, description, location, date_created) " +
+		"VALUES ($1, $2, NULLIF($3, ''), NULLIF($4, ''), $5) RETURNING id"
+	listShipmentEventsSQL = "SELECT id, status, COALESCE(description, ''), COALESCE(location, ''), date_created " +
+		"FROM shipment_events WHERE shipment_id = $1 ORDER BY id"
+)
+
+var (
+	errShipmentNotFound = apperrors.NotFound("shipment_not_found", "shipment not found")
+	errShipmentExists   = apperrors.Conflict("shipment_exists", "the order already has a shipment")
+)
+
+type (
+	ShipmentRepository interface {
+		SaveShipment(ctx context.Context, operations db.SQLOperations, shipment *model.Shipment) error
+		// ShipmentByID and the other lookups return the shipment with its
+		// events.
+		ShipmentByID(ctx context.Context, operations db.SQLOperations, shipmentID int64) (*model.Shipment, error)
+		ShipmentByOrderID(ctx context.Context, operations db.SQLOperations, orderID int64) (*model.Shipment, error)
+		// LockShipment returns the shipment, holding it until the
+		// transaction ends.
+		LockShipment(ctx context.Context, operations db.SQLOperations, shipmentID int64) (*model.Shipment, error)
+		UpdateShipment(ctx context.Context, operations db.SQLOperations, shipment *model.Shipment) error
+		// SaveEvent records event against the shipment and appends it to
+		// the shipment's events.
+		SaveEvent(ctx context.Context, operations db.SQLOperations, shipment *model.Shipment, event *model.ShipmentEvent) error
+	}
+
+	shipmentRepository struct{}
+)
+
+func NewShipmentRepository() ShipmentRepository {
+	return &shipmentRepository{}
+}
+
+func (r *shipmentRepository) SaveShipment(
+	ctx context.Context,
+	operations db.SQLOperations,
+	shipment *model.Shipment,
+) error {
+
+	timeNow := time.Now()
+	shipment.DateCreated = timeNow
+	shipment.DateModified = timeNow
+
+	err := operations.QueryRowContext(
+		ctx,
+		insertShipmentSQL,
+		shipment.OrderID,
+		shipment.Carrier,
+		shipment.TrackingNumber,
+		shipment.RiderName,
+		shipment.RiderPhone,
+		shipment.EstimatedDelivery,
+		shipment.Status,
+		shipment.CodeHash,
+		shipment.DateCreated,
+		shipment.DateModified,
+	).Scan(&shipment.ID)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code.Name() == "unique_violation" {
+			return errShipmentExists.Wrap(err)
+		}
+		return err
+	}
+
+	if shipment.Events == nil {
+		shipment.Events = make([]*model.ShipmentEvent, 0)
+	}
+
+	return nil
+}
+
+func (r *shipmentRepository) ShipmentByID(
+	ctx context.Context,
+	operations db.SQLOperations,
+	shipmentID int64,
+) (*model.Shipment, error) {
+	return r.shipment(ctx, operations, getShipmentByIDSQL, shipmentID)
+}
+
+func (r *shipmentRepository) ShipmentByOrderID(
+	ctx context.Context,
+	operations db.SQLOperations,
+	orderID int64,
+) (*model.Shipment, error) {
+	return r.shipment(ctx, operations, getShipmentByOrderIDSQL, orderID)
+}
+
+func (r *shipmentRepository) LockShipment(
+	ctx context.Context,
+	operations db.SQLOperations,
+	shipmentID int64,
+) (*model.Shipment, error) {
+	return r.shipment(ctx, operations, lockShipmentSQL, shipmentID)
+}
+
+func (r *shipmentRepository) UpdateShipment(
+	ctx context.Context,
+	operations db.SQLOperations,
+	shipment *model.Shipment,
+) error {
+
+	shipment.DateModified = time.Now()
+
+	result, err := operations.ExecContext(
+		ctx,
+		updateShipmentSQL,
+		shipment.Carrier,
+		shipment.TrackingNumber,
+		shipment.RiderName,
+		shipment.RiderPhone,
+		shipment.EstimatedDelivery,
+		shipment.Status,
+		shipment.CodeHash,
+		shipment.CodeAttempts,
+		shipment.RecipientName,
+		shipment.DateDelivered,
+		shipment.DateModified,
+		shipment.ID,
+	)
+	if err != nil {
+		return err
+	}
+
+	return expectRow(result, errShipmentNotFound)
+}
+
+func (r *shipmentRepository) SaveEvent(
+	ctx context.Context,
+	operations db.SQLOperations,
+	shipment *model.Shipment,
+	event *model.ShipmentEvent,
+) error {
+
+	event.DateCreated = time.Now()
+
+	err := operations.QueryRowContext(
+		ctx,
+		insertShipmentEventSQL,
+		shipment.ID,
+		event.Status,
+		event.Description,
+		event.Location,
+		event.DateCreated,
+	).Scan(&event.ID)
+	if err != nil {
+		return err
+	}
+
+	shipment.Events = append(shipment.Events, event)
+
+	return nil
+}
+
+func (r *shipmentRepository) shipment(
+	ctx context.Context,
+	operations db.SQLOperations,
+	query string,
+	args ...any,
+) (*model.Shipment, error) {
+
+	var shipment model.Shipment
+
+	err := operations.QueryRowContext(ctx, query, args...).Scan(
+		&shipment.ID,
+		&shipment.OrderID,
+		&shipment.Carrier,
+		&shipment.TrackingNumber,
+		&shipment.RiderName,
+		&shipment.RiderPhone,
+		&shipment.EstimatedDelivery,
+		&shipment.Status,
+		&shipment.CodeHash,
+		&shipment.CodeAttempts,
+		&shipment.RecipientName,
+		&shipment.DateDelivered,
+		&shipment.DateCreated,
+		&shipment.DateModified,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return &model.Shipment{}, errShipmentNotFound.Wrap(err)
+		}
+		return &model.Shipment{}, err
+	}
+
+	shipment.Events, err = r.events(ctx, operations, shipment.ID)
+	if err != nil {
+		return &model.Shipment{}, err
+	}
+
+	return &shipment, nil
+}
+
+func (r *shipmentRepository) events(
+	ctx context.Context,
+	operations db.SQLOperations,
+	shipmentID int64,
+) ([]*model.ShipmentEvent, error) {
+
+	rows, err := operations.QueryContext(ctx, listShipmentEventsSQL, shipmentID)
+	if err != nil {
+		return []*model.ShipmentEvent{}, err
+	}
+
+	defer rows.Close()
+
+	events := make([]*model.ShipmentEvent, 0)
+
+	for rows.Next() {
+
+		var event model.ShipmentEvent
+
+		err := rows.Scan(
+			&event.ID,
+			&event.Status,
+			&event.Description,
+			&event.Location,
+			&event.DateCreated,
+		)
+		if err != nil {
+			return []*model.ShipmentEvent{}, err
+		}
+
+		events = append(events, &event)
+	}
+
+	return events, rows.Err()
+}
@@ -0,0 +1,98 @@
+package repos
+
+import (
+	"context"
+	"time"
+
+	"github.com/ernestngugi/sil-backend/internal/db"
+	"github.com/ernestngugi/sil-backend/internal/model"
+)
+
+const (
+	insertSMSOutboxSQL = "INSERT INTO sms_outbox (phone_number, message, transactional, send_after, date_created) VALUES ($1, $2, $3, $4, $5)"
+	claimSMSOutboxSQL  = "UPDATE sms_outbox SET claimed_until = $2 WHERE id IN (" +
+		"SELECT id FROM sms_outbox WHERE send_after <= $1 AND (claimed_until IS NULL OR claimed_until <= $1) ORDER BY id FOR UPDATE SKIP LOCKED" +
+		") RETURNING id, phone_number, message, transactional"
+	deleteSMSOutboxSQL = "DELETE FROM sms_outbox WHERE id = $1"
+)
+
+type (
+	SMSOutboxRepository interface {
+		Claim(ctx context.Context, operations db.SQLOperations, now, claimedUntil time.Time) ([]*model.OutboxSMS, error)
+		Delete(ctx context.Context, operations db.SQLOperations, id int64) error
+		Save(ctx context.Context, operations db.SQLOperations, request *model.SMSMessage, sendAfter time.Time) error
+	}
+
+	smsOutboxRepository struct{}
+)
+
+func NewSMSOutboxRepository() SMSOutboxRepository {
+	return &smsOutboxRepository{}
+}
+
+// Claim returns every persisted message due by now that no replica holds a
+// claim on, and claims them until claimedUntil. Claimed messages stay in the
+// outbox until they are deleted, so a replica that stops before sending them
+// only delays them.
+func (r *smsOutboxRepository) Claim(
+	ctx context.Context,
+	operations db.SQLOperations,
+	now, claimedUntil time.Time,
+) ([]*model.OutboxSMS, error) {
+
+	rows, err := operations.QueryContext(ctx, claimSMSOutboxSQL, now, claimedUntil)
+	if err != nil {
+		return []*model.OutboxSMS{}, err
+	}
+
+	defer rows.Close()
+
+	messages := make([]*model.OutboxSMS, 0)
+
+	for rows.Next() {
+
+		message := &model.OutboxSMS{Request: &model.SMSMessage{}}
+
+		err := rows.Scan(&message.ID, &message.Request.Number, &message.Request.Message, &message.Request.Transactional)
+		if err != nil {
+			return []*model.OutboxSMS{}, err
+		}
+
+		messages = append(messages, message)
+	}
+
+	return messages, rows.Err()
+}
+
+// Delete removes a message that has been sent from the outbox.
+func (r *smsOutboxRepository) Delete(
+	ctx context.Context,
+	operations db.SQLOperations,
+	id int64,
+) error {
+
+	_, err := operations.ExecContext(ctx, deleteSMSOutboxSQL, id)
+
+	return err
+}
+
+// Save persists a message to be sent once sendAfter has passed.
+func (r *smsOutboxRepository) Save(
+	ctx context.Context,
+	operations db.SQLOperations,
+	request *model.SMSMessage,
+	sendAfter time.Time,
+) error {
+
+	_, err := operations.ExecContext(
+		ctx,
+		insertSMSOutboxSQL,
+		request.Number,
+		request.Message,
+		request.Transactional,
+		sendAfter,
+		time.Now(),
+	)
+
+	return err
+}
@@ -0,0 +1,63 @@
+package repos
+
+import (
+	"context"
+	"time"
+
+	"github.com/ernestngugi/sil-backend/internal/db"
+	"github.com/ernestngugi/sil-backend/internal/model"
+)
+
+const (
+	existsSMSSuppressionSQL = "SELECT EXISTS (SELECT 1 FROM sms_suppressions WHERE phone_number = $1)"
+	insertSMSSuppressionSQL = "INSERT INTO sms_suppressions (phone_number, reason, date_created) VALUES ($1, $2, $3) ON CONFLICT (phone_number) DO NOTHING"
+)
+
+type (
+	// SMSSuppressionRepository stores numbers that must not be sent SMS.
+	// Numbers are kept in international format without the leading plus.
+	SMSSuppressionRepository interface {
+		Suppressed(ctx context.Context, operations db.SQLOperations, phoneNumber string) (bool, error)
+		Save(ctx context.Context, operations db.SQLOperations, suppression *model.SMSSuppression) error
+	}
+
+	smsSuppressionRepository struct{}
+)
+
+func NewSMSSuppressionRepository() SMSSuppressionRepository {
+	return &smsSuppressionRepository{}
+}
+
+func (r *smsSuppressionRepository) Suppressed(
+	ctx context.Context,
+	operations db.SQLOperations,
+	phoneNumber string,
+) (bool, error) {
+
+	var suppressed bool
+
+	err := operations.QueryRowContext(ctx, existsSMSSuppressionSQL, phoneNumber).Scan(&suppressed)
+
+	return suppressed, err
+}
+
+// Save adds a number to the suppression list. Numbers already on it keep
+// their original reason and date.
+func (r *smsSuppressionRepository) Save(
+	ctx context.Context,
+	operations db.SQLOperations,
+	suppression *model.SMSSuppression,
+) error {
+
+	suppression.DateCreated = time.Now()
+
+	_, err := operations.ExecContext(
+		ctx,
+		insertSMSSuppressionSQL,
+		suppression.PhoneNumber,
+		suppression.Reason,
+		suppression.DateCreated,
+	)
+
+	return err
+}
@@ -0,0 +1,136 @@
+package repos
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/ernestngugi/sil-backend/internal/apperrors"
+	"github.com/ernestngugi/sil-backend/internal/db"
+	"github.com/ernestngugi/sil-backend/internal/model"
+)
+
+const (
+	selectSMSTemplateSQL = "SELECT event, locale, body, date_modified FROM sms_templates"
+	getSMSTemplateSQL    = selectSMSTemplateSQL + " WHERE event = $1 AND locale = $2"
+	listSMSTemplatesSQL  = selectSMSTemplateSQL + " ORDER BY event, locale"
+	upsertSMSTemplateSQL = "INSERT INTO sms_templates (event, locale, body, date_created, date_modified) VALUES ($1, $2, $3, $4, $4) ON CONFLICT (event, locale) DO UPDATE SET body = EXCLUDED.body, date_modified = EXCLUDED.date_modified"
+	deleteSMSTemplateSQL = "DELETE FROM sms_templates WHERE event = $1 AND locale = $2"
+)
+
+type (
+	// SMSTemplateRepository stores the templates admins have customised.
+	SMSTemplateRepository interface {
+		Template(ctx context.Context, operations db.SQLOperations, event model.NotificationEvent, locale string) (*model.SMSTemplate, error)
+		Templates(ctx context.Context, operations db.SQLOperations) ([]*model.SMSTemplate, error)
+		Save(ctx context.Context, operations db.SQLOperations, template *model.SMSTemplate) error
+		Delete(ctx context.Context, operations db.SQLOperations, event model.NotificationEvent, locale string) error
+	}
+
+	smsTemplateRepository struct{}
+)
+
+func NewSMSTemplateRepository() SMSTemplateRepository {
+	return &smsTemplateRepository{}
+}
+
+func (r *smsTemplateRepository) Template(
+	ctx context.Context,
+	operations db.SQLOperations,
+	event model.NotificationEvent,
+	locale string,
+) (*model.SMSTemplate, error) {
+
+	template, err := scanSMSTemplate(operations.QueryRowContext(ctx, getSMSTemplateSQL, event, locale))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return &model.SMSTemplate{}, apperrors.NotFound("sms_template_not_found", "sms template not found").Wrap(err)
+		}
+		return &model.SMSTemplate{}, err
+	}
+
+	return template, nil
+}
+
+func (r *smsTemplateRepository) Templates(
+	ctx context.Context,
+	operations db.SQLOperations,
+) ([]*model.SMSTemplate, error) {
+
+	rows, err := operations.QueryContext(ctx, listSMSTemplatesSQL)
+	if err != nil {
+		return []*model.SMSTemplate{}, err
+	}
+
+	defer rows.Close()
+
+	templates := make([]*model.SMSTemplate, 0)
+
+	for rows.Next() {
+
+		template, err := scanSMSTemplate(rows)
+		if err != nil {
+			return []*model.SMSTemplate{}, err
+		}
+
+		templates = append(templates, template)
+	}
+
+	if err := rows.Err(); err != nil {
+		return []*model.SMSTemplate{}, err
+	}
+
+	return templates, nil
+}
+
+// Save creates or replaces the custom template for its event and locale.
+func (r *smsTemplateRepository) Save(
+	ctx context.Context,
+	operations db.SQLOperations,
+	template *model.SMSTemplate,
+) error {
+
+	timeNow := time.Now()
+
+	_, err := operations.ExecContext(ctx, upsertSMSTemplateSQL, template.Event, template.Locale, template.Body, timeNow)
+	if err != nil {
+		return err
+	}
+
+	template.Custom = true
+	template.DateModified = &timeNow
+
+	return nil
+}
+
+func (r *smsTemplateRepository) Delete(
+	ctx context.Context,
+	operations db.SQLOperations,
+	event model.NotificationEvent,
+	locale string,
+) error {
+
+	result, err := operations.ExecContext(ctx, deleteSMSTemplateSQL, event, locale)
+	if err != nil {
+		return err
+	}
+
+	return expectRow(result, apperrors.NotFound("sms_template_not_found", "sms template not found"))
+}
+
+func scanSMSTemplate(row scanner) (*model.SMSTemplate, error) {
+
+	template := model.SMSTemplate{Custom: true}
+
+	var dateModified time.Time
+
+	err := row.Scan(&template.Event, &template.Locale, &template.Body, &dateModified)
+	if err != nil {
+		return nil, err
+	}
+
+	template.DateModified = &dateModified
+
+	return &template, nil
+}
@@ -0,0 +1,438 @@
+package repos
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/ernestngugi/sil-backend/internal/apperrors"
+	"github.com/ernestngugi/sil-backend/internal/db"
+	"github.com/ernestngugi/sil-backend/internal/model"
+	"github.com/lib/pq"
+)
+
+const (
+	insertWebhookSubscriptionSQL = "INSERT INTO webhook_subscriptions (url, secret, event_types, active, date_created, date_modified) " +
+		"VALUES ($1, $2, $3, $4, $5, $6) RETURNING id"
+	selectWebhookSubscriptionSQL = "SELECT id, url, secret, event_types, active, consecutive_failures, date_disabled, date_created, date_modified " +
+		"FROM webhook_subscriptions"
+	getWebhookSubscriptionByIDSQL     = selectWebhookSubscriptionSQL + " WHERE id = $1"
+	listWebhookSubscriptionsSQL       = selectWebhookSubscriptionSQL + " ORDER BY id"
+	listActiveWebhookSubscriptionsSQL = selectWebhookSubscriptionSQL + " WHERE active AND $1 = ANY(event_types) ORDER BY id"
+	updateWebhookSubscriptionSQL      = "UPDATE webhook_subscriptions SET url = $1, event_types = $2, active = $3, consecutive_failures = $4, " +
+		"date_disabled = $5, date_modified = $6 WHERE id = $7"
+	deleteWebhookSubscriptionSQL = "DELETE FROM webhook_subscriptions WHERE id = $1"
+	// recordWebhookFailureSQL counts a failed attempt and disables the
+	// subscription once $2 attempts in a row have failed.
+	recordWebhookFailureSQL = "UPDATE webhook_subscriptions SET consecutive_failures = consecutive_failures + 1, " +
+		"active = active AND consecutive_failures + 1 < $2, " +
+		"date_disabled = CASE WHEN active AND consecutive_failures + 1 >= $2 THEN $3 ELSE date_disabled END, " +
+		"date_modified = $3 WHERE id = $1 RETURNING active"
+	recordWebhookSuccessSQL = "UPDATE webhook_subscriptions SET consecutive_failures = 0, date_modified = $2 " +
+		"WHERE id = $1 AND consecutive_failures <> 0"
+
+	insertWebhookDeliverySQL = "INSERT INTO webhook_deliveries (subscription_id, event_id, event_type, payload, status, next_attempt_at, date_created) " +
+		"VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id"
+	webhookDeliveryColumns = "id, subscription_id, event_id, event_type, payload, status, attempts, next_attempt_at, " +
+		"last_status_code, last_error, date_created, date_delivered"
+	selectWebhookDeliverySQL         = "SELECT " + webhookDeliveryColumns + " FROM webhook_deliveries"
+	getWebhookDeliveryByIDSQL        = selectWebhookDeliverySQL + " WHERE subscription_id = $1 AND id = $2"
+	listWebhookDeliveriesSQL         = selectWebhookDeliverySQL + " WHERE subscription_id = $1 ORDER BY id DESC LIMIT $2"
+	listWebhookDeliveriesByStatusSQL = selectWebhookDeliverySQL + " WHERE subscription_id = $1 AND status = $2 ORDER BY id DESC LIMIT $3"
+	// claimWebhookDeliveriesSQL leases due deliveries by pushing their next
+	// attempt into the future. SKIP LOCKED lets replicas claim disjoint sets;
+	// a replica that dies mid-delivery leaves them to be retried after the
+	// lease.
+	claimWebhookDeliveriesSQL = "UPDATE webhook_deliveries SET next_attempt_at = $3 WHERE id IN (" +
+		"SELECT id FROM webhook_deliveries WHERE status = 'pending' AND next_attempt_at <= $2 " +
+		"ORDER BY next_attempt_at LIMIT $1 FOR UPDATE SKIP LOCKED) RETURNING " + webhookDeliveryColumns
+	updateWebhookDeliverySQL = "UPDATE webhook_deliveries SET status = $1, attempts = $2, next_attempt_at = $3, last_status_code = $4, " +
+		"last_error = $5, date_delivered = $6 WHERE id = $7"
+)
+
+var (
+	errWebhookSubscriptionNotFound = apperrors.NotFound("webhook_subscription_not_found", "webhook subscription not found")
+	errWebhookDeliveryNotFound     = apperrors.NotFound("webhook_delivery_not_found", "webhook delivery not found")
+)
+
+type (
+	WebhookRepository interface {
+		SaveSubscription(ctx context.Context, operations db.SQLOperations, subscription *model.WebhookSubscription) error
+		SubscriptionByID(ctx context.Context, operations db.SQLOperations, subscriptionID int64) (*model.WebhookSubscription, error)
+		Subscriptions(ctx context.Context, operations db.SQLOperations) ([]*model.WebhookSubscription, error)
+		ActiveSubscriptions(ctx context.Context, operations db.SQLOperations, eventType string) ([]*model.WebhookSubscription, error)
+		UpdateSubscription(ctx context.Context, operations db.SQLOperations, subscription *model.WebhookSubscription) error
+		DeleteSubscription(ctx context.Context, operations db.SQLOperations, subscriptionID int64) error
+		RecordFailure(ctx context.Context, operations db.SQLOperations, subscriptionID int64, disableAfter int) (bool, error)
+		RecordSuccess(ctx context.Context, operations db.SQLOperations, subscriptionID int64) error
+
+		SaveDelivery(ctx context.Context, operations db.SQLOperations, delivery *model.WebhookDelivery) error
+		DeliveryByID(ctx context.Context, operations db.SQLOperations, subscriptionID, deliveryID int64) (*model.WebhookDelivery, error)
+		Deliveries(ctx context.Context, operations db.SQLOperations, subscriptionID int64, status model.WebhookDeliveryStatus, limit int) ([]*model.WebhookDelivery, error)
+		ClaimDueDeliveries(ctx context.Context, operations db.SQLOperations, limit int, lease time.Duration) ([]*model.WebhookDelivery, error)
+		UpdateDelivery(ctx context.Context, operations db.SQLOperations, delivery *model.WebhookDelivery) error
+	}
+
+	webhookRepository struct{}
+
+	scanner interface {
+		Scan(dest ...any) error
+	}
+)
+
+func NewWebhookRepository() WebhookRepository {
+	return &webhookRepository{}
+}
+
+func (r *webhookRepository) SaveSubscription(
+	ctx context.Context,
+	operations db.SQLOperations,
+	subscription *model.WebhookSubscription,
+) error {
+
+	timeNow := time.Now()
+	subscription.DateCreated = timeNow
+	subscription.DateModified = timeNow
+
+	return operations.QueryRowContext(
+		ctx,
+		insertWebhookSubscriptionSQL,
+		subscription.URL,
+		subscription.Secret,
+		pq.Array(subscription.EventTypes),
+		subscription.Active,
+		subscription.DateCreated,
+		subscription.DateModified,
+	).Scan(&subscription.ID)
+}
+
+func (r *webhookRepository) SubscriptionByID(
+	ctx context.Context,
+	operations db.SQLOperations,
+	subscriptionID int64,
+) (*model.WebhookSubscription, error) {
+
+	subscription, err := scanWebhookSubscription(operations.QueryRowContext(ctx, getWebhookSubscriptionByIDSQL, subscriptionID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return &model.WebhookSubscription{}, errWebhookSubscriptionNotFound.Wrap(err)
+		}
+		return &model.WebhookSubscription{}, err
+	}
+
+	return subscription, nil
+}
+
+func (r *webhookRepository) Subscriptions(
+	ctx context.Context,
+	operations db.SQLOperations,
+) ([]*model.WebhookSubscription, error) {
+	return r.querySubscriptions(ctx, operations, listWebhookSubscriptionsSQL)
+}
+
+func (r *webhookRepository) ActiveSubscriptions(
+	ctx context.Context,
+	operations db.SQLOperations,
+	eventType string,
+) ([]*model.WebhookSubscription, error) {
+	return r.querySubscriptions(ctx, operations, listActiveWebhookSubscriptionsSQL, eventType)
+}
+
+func (r *webhookRepository) querySubscriptions(
+	ctx context.Context,
+	operations db.SQLOperations,
+	query string,
+	args ...any,
+) ([]*model.WebhookSubscription, error) {
+
+	rows, err := operations.QueryContext(ctx, query, args...)
+	if err != nil {
+		return []*model.WebhookSubscription{}, err
+	}
+
+	defer rows.Close()
+
+	subscriptions := make([]*model.WebhookSubscription, 0)
+
+	for rows.Next() {
+
+		subscription, err := scanWebhookSubscription(rows)
+		if err != nil {
+			return []*model.WebhookSubscription{}, err
+		}
+
+		subscriptions = append(subscriptions, subscription)
+	}
+
+	return subscriptions, rows.Err()
+}
+
+func (r *webhookRepository) UpdateSubscription(
+	ctx context.Context,
+	operations db.SQLOperations,
+	subscription *model.WebhookSubscription,
+) error {
+
+	subscription.DateModified = time.Now()
+
+	result, err := operations.ExecContext(
+		ctx,
+		updateWebhookSubscriptionSQL,
+		subscription.URL,
+		pq.Array(subscription.EventTypes),
+		subscription.Active,
+		subscription.ConsecutiveFailures,
+		subscription.DateDisabled,
+		subscription.DateModified,
+		subscription.ID,
+	)
+	if err != nil {
+		return err
+	}
+
+	return expectRow(result, errWebhookSubscriptionNotFound)
+}
+
+func (r *webhookRepository) DeleteSubscription(
+	ctx context.Context,
+	operations db.SQLOperations,
+	subscriptionID int64,
+) error {
+
+	result, err := operations.ExecContext(ctx, deleteWebhookSubscriptionSQL, subscriptionID)
+	if err != nil {
+		return err
+	}
+
+	return expectRow(result, errWebhookSubscriptionNotFound)
+}
+
+// RecordFailure counts a failed delivery attempt and reports whether the
+// subscription is still active afterwards.
+func (r *webhookRepository) RecordFailure(
+	ctx context.Context,
+	operations db.SQLOperations,
+	subscriptionID int64,
+	disableAfter int,
+) (bool, error) {
+
+	var active bool
+
+	err := operations.QueryRowContext(ctx, recordWebhookFailureSQL, subscriptionID, disableAfter, time.Now()).Scan(&active)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, errWebhookSubscriptionNotFound.Wrap(err)
+		}
+		return false, err
+	}
+
+	return active, nil
+}
+
+func (r *webhookRepository) RecordSuccess(
+	ctx context.Context,
+	operations db.SQLOperations,
+	subscriptionID int64,
+) error {
+
+	_, err := operations.ExecContext(ctx, recordWebhookSuccessSQL, subscriptionID, time.Now())
+
+	return err
+}
+
+func (r *webhookRepository) SaveDelivery(
+	ctx context.Context,
+	operations db.SQLOperations,
+	delivery *model.WebhookDelivery,
+) error {
+
+	timeNow := time.Now()
+	delivery.DateCreated = timeNow
+
+	if delivery.Status == "" {
+		delivery.Status = model.WebhookDeliveryPending
+	}
+
+	if delivery.NextAttemptAt.IsZero() {
+		delivery.NextAttemptAt = timeNow
+	}
+
+	return operations.QueryRowContext(
+		ctx,
+		insertWebhookDeliverySQL,
+		delivery.SubscriptionID,
+		delivery.EventID,
+		delivery.EventType,
+		[]byte(delivery.Payload),
+		delivery.Status,
+		delivery.NextAttemptAt,
+		delivery.DateCreated,
+	).Scan(&delivery.ID)
+}
+
+func (r *webhookRepository) DeliveryByID(
+	ctx context.Context,
+	operations db.SQLOperations,
+	subscriptionID, deliveryID int64,
+) (*model.WebhookDelivery, error) {
+
+	delivery, err := scanWebhookDelivery(operations.QueryRowContext(ctx, getWebhookDeliveryByIDSQL, subscriptionID, deliveryID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return &model.WebhookDelivery{}, errWebhookDeliveryNotFound.Wrap(err)
+		}
+		return &model.WebhookDelivery{}, err
+	}
+
+	return delivery, nil
+}
+
+// Deliveries returns the latest deliveries to a subscription, optionally only
+// those with status.
+func (r *webhookRepository) Deliveries(
+	ctx context.Context,
+	operations db.SQLOperations,
+	subscriptionID int64,
+	status model.WebhookDeliveryStatus,
+	limit int,
+) ([]*model.WebhookDelivery, error) {
+
+	if status == "" {
+		return r.queryDeliveries(ctx, operations, listWebhookDeliveriesSQL, subscriptionID, limit)
+	}
+
+	return r.queryDeliveries(ctx, operations, listWebhookDeliveriesByStatusSQL, subscriptionID, status, limit)
+}
+
+// ClaimDueDeliveries leases up to limit pending deliveries whose next attempt
+// is due.
+func (r *webhookRepository) ClaimDueDeliveries(
+	ctx context.Context,
+	operations db.SQLOperations,
+	limit int,
+	lease time.Duration,
+) ([]*model.WebhookDelivery, error) {
+
+	timeNow := time.Now()
+
+	return r.queryDeliveries(ctx, operations, claimWebhookDeliveriesSQL, limit, timeNow, timeNow.Add(lease))
+}
+
+func (r *webhookRepository) queryDeliveries(
+	ctx context.Context,
+	operations db.SQLOperations,
+	query string,
+	args ...any,
+) ([]*model.WebhookDelivery, error) {
+
+	rows, err := operations.QueryContext(ctx, query, args...)
+	if err != nil {
+		return []*model.WebhookDelivery{}, err
+	}
+
+	defer rows.Close()
+
+	deliveries := make([]*model.WebhookDelivery, 0)
+
+	for rows.Next() {
+
+		delivery, err := scanWebhookDelivery(rows)
+		if err != nil {
+			return []*model.WebhookDelivery{}, err
+		}
+
+		deliveries = append(deliveries, delivery)
+	}
+
+	return deliveries, rows.Err()
+}
+
+func (r *webhookRepository) UpdateDelivery(
+	ctx context.Context,
+	operations db.SQLOperations,
+	delivery *model.WebhookDelivery,
+) error {
+
+	result, err := operations.ExecContext(
+		ctx,
+		updateWebhookDeliverySQL,
+		delivery.Status,
+		delivery.Attempts,
+		delivery.NextAttemptAt,
+		delivery.LastStatusCode,
+		delivery.LastError,
+		delivery.DateDelivered,
+		delivery.ID,
+	)
+	if err != nil {
+		return err
+	}
+
+	return expectRow(result, errWebhookDeliveryNotFound)
+}
+
+func scanWebhookSubscription(row scanner) (*model.WebhookSubscription, error) {
+
+	var subscription model.WebhookSubscription
+
+	err := row.Scan(
+		&subscription.ID,
+		&subscription.URL,
+		&subscription.Secret,
+		pq.Array(&subscription.EventTypes),
+		&subscription.Active,
+		&subscription.ConsecutiveFailures,
+		&subscription.DateDisabled,
+		&subscription.DateCreated,
+		&subscription.DateModified,
+	)
+	if err != nil {
+		return &model.WebhookSubscription{}, err
+	}
+
+	return &subscription, nil
+}
+
+func scanWebhookDelivery(row scanner) (*model.WebhookDelivery, error) {
+
+	var delivery model.WebhookDelivery
+	var payload []byte
+
+	err := row.Scan(
+		&delivery.ID,
+		&delivery.SubscriptionID,
+		&delivery.EventID,
+		&delivery.EventType,
+		&payload,
+		&delivery.Status,
+		&delivery.Attempts,
+		&delivery.NextAttemptAt,
+		&delivery.LastStatusCode,
+		&delivery.LastError,
+		&delivery.DateCreated,
+		&delivery.DateDelivered,
+	)
+	if err != nil {
+		return &model.WebhookDelivery{}, err
+	}
+
+	delivery.Payload = payload
+
+	return &delivery, nil
+}
+
+// expectRow returns notFound when result affected no rows.
+func expectRow(result sql.Result, notFound *apperrors.Error) error {
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return notFound
+	}
+
+	return nil
+}
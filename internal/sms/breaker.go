@@ -0,0 +1,103 @@
+package sms
+
+import (
+	"sync"
+	"time"
+)
+
+type breakerState int
+
+const (
+	breakerClosed breakerState = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+// breaker stops sending to a provider after threshold failures in a row. Once
+// cooldown has passed a single trial message is let through: success closes
+// the circuit again, failure keeps it open for another cooldown.
+type breaker struct {
+	threshold int
+	cooldown  time.Duration
+	// onChange is called with the lock held whenever the circuit opens or
+	// closes.
+	onChange func(open bool)
+
+	mu       sync.Mutex
+	state    breakerState
+	failures int
+	openedAt time.Time
+}
+
+func newBreaker(threshold int, cooldown time.Duration, onChange func(open bool)) *breaker {
+	return &breaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+		onChange:  onChange,
+	}
+}
+
+// allow reports whether a message may be sent now.
+func (b *breaker) allow(now time.Time) bool {
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case breakerClosed:
+		return true
+	case breakerOpen:
+		if now.Sub(b.openedAt) < b.cooldown {
+			return false
+		}
+		b.state = breakerHalfOpen
+		return true
+	default:
+		// A trial message is already in flight.
+		return false
+	}
+}
+
+func (b *breaker) success() {
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures = 0
+
+	if b.state != breakerClosed {
+		b.state = breakerClosed
+		b.onChange(false)
+	}
+}
+
+// abort ends a send that was cancelled before the provider answered. A trial
+// interrupted this way is allowed again straight away, as the cooldown has
+// already passed.
+func (b *breaker) abort() {
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == breakerHalfOpen {
+		b.state = breakerOpen
+	}
+}
+
+func (b *breaker) failure(now time.Time) {
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+
+	switch {
+	case b.state == breakerHalfOpen:
+		b.state = breakerOpen
+		b.openedAt = now
+	case b.state == breakerClosed && b.failures >= b.threshold:
+		b.state = breakerOpen
+		b.openedAt = now
+		b.onChange(true)
+	}
+}
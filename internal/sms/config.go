@@ -0,0 +1,100 @@
+package sms
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ernestngugi/sil-backend/internal/env"
+)
+
+const (
+	defaultBreakerThreshold = 5
+	defaultBreakerCooldown  = 30 * time.Second
+)
+
+type (
+	// Route sends messages to numbers starting with Prefix through Providers,
+	// in order of priority.
+	Route struct {
+		Prefix    string
+		Providers []string
+	}
+
+	Config struct {
+		// Providers are the providers to set up, in order of priority for
+		// numbers no route matches.
+		Providers []string
+		Routes    []Route
+		// BreakerThreshold is how many failures in a row open a provider's
+		// circuit, BreakerCooldown how long it stays open before a trial
+		// message is let through.
+		BreakerThreshold int
+		BreakerCooldown  time.Duration
+	}
+)
+
+// ConfigFromEnv reads SMS_PROVIDERS, e.g. "at,twilio", SMS_ROUTES and the
+// SMS_BREAKER_* settings.
+func ConfigFromEnv() (*Config, error) {
+
+	config := &Config{
+		Providers: splitList(env.String("SMS_PROVIDERS", "at"), ","),
+	}
+
+	if len(config.Providers) == 0 {
+		return &Config{}, fmt.Errorf("SMS_PROVIDERS must name at least one provider")
+	}
+
+	var err error
+
+	config.Routes, err = ParseRoutes(env.String("SMS_ROUTES", ""))
+	if err != nil {
+		return &Config{}, fmt.Errorf("invalid SMS_ROUTES: %w", err)
+	}
+
+	if config.BreakerThreshold, err = env.Int("SMS_BREAKER_THRESHOLD", defaultBreakerThreshold); err != nil {
+		return &Config{}, err
+	}
+
+	if config.BreakerCooldown, err = env.Duration("SMS_BREAKER_COOLDOWN", defaultBreakerCooldown); err != nil {
+		return &Config{}, err
+	}
+
+	return config, nil
+}
+
+// ParseRoutes parses semicolon separated routes from a number prefix to comma
+// separated providers, e.g. "254=at,twilio;1=twilio".
+func ParseRoutes(value string) ([]Route, error) {
+
+	routes := make([]Route, 0)
+
+	for _, route := range splitList(value, ";") {
+
+		prefix, providerList, found := strings.Cut(route, "=")
+		prefix = strings.TrimPrefix(strings.TrimSpace(prefix), "+")
+		providers := splitList(providerList, ",")
+
+		if !found || prefix == "" || len(providers) == 0 {
+			return []Route{}, fmt.Errorf("expected <prefix>=<provider>[,<provider>...], got %q", route)
+		}
+
+		routes = append(routes, Route{Prefix: prefix, Providers: providers})
+	}
+
+	return routes, nil
+}
+
+func splitList(value, separator string) []string {
+
+	items := make([]string, 0)
+
+	for _, item := range strings.Split(value, separator) {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, strings.ToLower(item))
+		}
+	}
+
+	return items
+}
@@ -0,0 +1,125 @@
+package sms
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/ernestngugi/sil-backend/internal/metrics"
+	"github.com/ernestngugi/sil-backend/internal/model"
+	"github.com/ernestngugi/sil-backend/providers"
+)
+
+// ErrNoProviderAvailable is returned when every provider for a number has an
+// open circuit.
+var ErrNoProviderAvailable = errors.New("no sms provider available")
+
+type router struct {
+	senders  map[string]providers.SMSSender
+	breakers map[string]*breaker
+	routes   []Route
+	// fallback lists the providers for numbers no route matches.
+	fallback []string
+	logger   *slog.Logger
+	now      func() time.Time
+}
+
+// NewRouter sends each message through the providers of the route with the
+// longest prefix matching its number, falling back to config.Providers. A
+// provider that fails, or whose circuit is open, is skipped for the next one.
+// Failing over after a timeout may deliver a message twice, which is better
+// than not delivering it.
+func NewRouter(
+	senders map[string]providers.SMSSender,
+	config *Config,
+	appMetrics *metrics.Metrics,
+	logger *slog.Logger,
+) (providers.SMSSender, error) {
+
+	r := &router{
+		senders:  senders,
+		breakers: make(map[string]*breaker),
+		routes:   config.Routes,
+		fallback: config.Providers,
+		logger:   logger,
+		now:      time.Now,
+	}
+
+	for _, route := range append(config.Routes, Route{Providers: config.Providers}) {
+		for _, name := range route.Providers {
+			if _, ok := senders[name]; !ok {
+				return nil, fmt.Errorf("sms provider %q is not configured", name)
+			}
+		}
+	}
+
+	for name := range senders {
+
+		name := name
+
+		r.breakers[name] = newBreaker(config.BreakerThreshold, config.BreakerCooldown, func(open bool) {
+			appMetrics.ObserveSMSCircuit(name, open)
+			if open {
+				logger.Warn("sms provider circuit opened", slog.String("provider", name))
+			} else {
+				logger.Info("sms provider circuit closed", slog.String("provider", name))
+			}
+		})
+	}
+
+	return r, nil
+}
+
+func (r *router) Send(ctx context.Context, message *model.SMSMessage) error {
+
+	var errs []error
+
+	for _, name := range r.providersFor(message.Number) {
+
+		breaker := r.breakers[name]
+
+		if !breaker.allow(r.now()) {
+			errs = append(errs, fmt.Errorf("%v: %w", name, ErrNoProviderAvailable))
+			continue
+		}
+
+		err := r.senders[name].Send(ctx, message)
+		if err == nil {
+			breaker.success()
+			return nil
+		}
+
+		// A cancelled send says nothing about the provider.
+		if ctx.Err() != nil {
+			breaker.abort()
+			return err
+		}
+
+		breaker.failure(r.now())
+		errs = append(errs, fmt.Errorf("%v: %w", name, err))
+
+		r.logger.WarnContext(ctx, "sms provider failed", slog.String("provider", name), slog.String("error", err.Error()))
+	}
+
+	return errors.Join(errs...)
+}
+
+// providersFor returns the providers of the route with the longest prefix
+// matching number.
+func (r *router) providersFor(number string) []string {
+
+	number = strings.TrimPrefix(number, "+")
+
+	selected := Route{Providers: r.fallback}
+
+	for _, route := range r.routes {
+		if strings.HasPrefix(number, route.Prefix) && len(route.Prefix) > len(selected.Prefix) {
+			selected = route
+		}
+	}
+
+	return selected.Providers
+}
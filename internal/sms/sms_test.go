@@ -0,0 +1,204 @@
+package sms
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ernestngugi/sil-backend/internal/logging"
+	"github.com/ernestngugi/sil-backend/internal/metrics"
+	"github.com/ernestngugi/sil-backend/internal/model"
+	"github.com/ernestngugi/sil-backend/providers"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRoutes(t *testing.T) {
+
+	t.Run("parses prefixes and providers", func(t *testing.T) {
+
+		routes, err := ParseRoutes(" +254=AT, twilio ; 1=twilio ")
+		assert.NoError(t, err)
+		assert.Equal(t, []Route{
+			{Prefix: "254", Providers: []string{"at", "twilio"}},
+			{Prefix: "1", Providers: []string{"twilio"}},
+		}, routes)
+	})
+
+	t.Run("rejects malformed routes", func(t *testing.T) {
+
+		for _, value := range []string{"254", "=at", "254="} {
+			_, err := ParseRoutes(value)
+			assert.Error(t, err, value)
+		}
+	})
+}
+
+func TestRouter(t *testing.T) {
+
+	ctx := context.Background()
+
+	config := &Config{
+		Providers:        []string{"at", "twilio"},
+		Routes:           []Route{{Prefix: "1", Providers: []string{"twilio"}}, {Prefix: "1800", Providers: []string{"at"}}},
+		BreakerThreshold: 2,
+		BreakerCooldown:  time.Minute,
+	}
+
+	newRouter := func(at, twilio *fakeSender) *router {
+
+		sender, err := NewRouter(map[string]providers.SMSSender{"at": at, "twilio": twilio}, config, metrics.New(), logging.Discard())
+		assert.NoError(t, err)
+
+		return sender.(*router)
+	}
+
+	t.Run("routes by the longest matching prefix", func(t *testing.T) {
+
+		at, twilio := &fakeSender{}, &fakeSender{}
+		r := newRouter(at, twilio)
+
+		assert.NoError(t, r.Send(ctx, &model.SMSMessage{Number: "+15005550006"}))
+		assert.NoError(t, r.Send(ctx, &model.SMSMessage{Number: "18005550006"}))
+		assert.NoError(t, r.Send(ctx, &model.SMSMessage{Number: "254728389583"}))
+
+		assert.Equal(t, 2, at.calls())
+		assert.Equal(t, 1, twilio.calls())
+	})
+
+	t.Run("fails over to the next provider", func(t *testing.T) {
+
+		at, twilio := &fakeSender{err: errors.New("at is down")}, &fakeSender{}
+		r := newRouter(at, twilio)
+
+		assert.NoError(t, r.Send(ctx, &model.SMSMessage{Number: "254728389583"}))
+
+		assert.Equal(t, 1, at.calls())
+		assert.Equal(t, 1, twilio.calls())
+	})
+
+	t.Run("skips providers with an open circuit until the cooldown passes", func(t *testing.T) {
+
+		at, twilio := &fakeSender{err: errors.New("at is down")}, &fakeSender{}
+		r := newRouter(at, twilio)
+
+		timeNow := time.Now()
+		r.now = func() time.Time { return timeNow }
+
+		for i := 0; i < 3; i++ {
+			assert.NoError(t, r.Send(ctx, &model.SMSMessage{Number: "254728389583"}))
+		}
+
+		assert.Equal(t, 2, at.calls(), "circuit opens after two failures")
+		assert.Equal(t, 3, twilio.calls())
+
+		at.setErr(nil)
+		timeNow = timeNow.Add(time.Minute)
+
+		assert.NoError(t, r.Send(ctx, &model.SMSMessage{Number: "254728389583"}))
+		assert.NoError(t, r.Send(ctx, &model.SMSMessage{Number: "254728389583"}))
+
+		assert.Equal(t, 4, at.calls(), "a successful trial closes the circuit")
+		assert.Equal(t, 3, twilio.calls())
+	})
+
+	t.Run("lets a new trial through after a cancelled one", func(t *testing.T) {
+
+		at, twilio := &fakeSender{err: errors.New("at is down")}, &fakeSender{}
+		r := newRouter(at, twilio)
+
+		timeNow := time.Now()
+		r.now = func() time.Time { return timeNow }
+
+		for i := 0; i < 2; i++ {
+			assert.NoError(t, r.Send(ctx, &model.SMSMessage{Number: "254728389583"}))
+		}
+
+		timeNow = timeNow.Add(time.Minute)
+
+		cancelled, cancel := context.WithCancel(ctx)
+		cancel()
+
+		assert.Error(t, r.Send(cancelled, &model.SMSMessage{Number: "254728389583"}))
+		assert.Equal(t, 3, at.calls())
+
+		at.setErr(nil)
+
+		assert.NoError(t, r.Send(ctx, &model.SMSMessage{Number: "254728389583"}))
+		assert.Equal(t, 4, at.calls(), "the circuit is not stuck half open")
+	})
+
+	t.Run("reports every provider's failure", func(t *testing.T) {
+
+		at, twilio := &fakeSender{err: errors.New("at is down")}, &fakeSender{err: errors.New("twilio is down")}
+		r := newRouter(at, twilio)
+
+		err := r.Send(ctx, &model.SMSMessage{Number: "254728389583"})
+		assert.ErrorContains(t, err, "at is down")
+		assert.ErrorContains(t, err, "twilio is down")
+
+		r.Send(ctx, &model.SMSMessage{Number: "254728389583"})
+
+		err = r.Send(ctx, &model.SMSMessage{Number: "254728389583"})
+		assert.ErrorIs(t, err, ErrNoProviderAvailable)
+	})
+
+	t.Run("rejects routes to providers that are not set up", func(t *testing.T) {
+
+		_, err := NewRouter(map[string]providers.SMSSender{"at": &fakeSender{}}, config, metrics.New(), logging.Discard())
+		assert.Error(t, err)
+	})
+}
+
+func TestBreaker(t *testing.T) {
+
+	var changes []bool
+
+	b := newBreaker(1, time.Minute, func(open bool) { changes = append(changes, open) })
+	timeNow := time.Now()
+
+	b.failure(timeNow)
+	assert.False(t, b.allow(timeNow))
+
+	timeNow = timeNow.Add(time.Minute)
+	assert.True(t, b.allow(timeNow), "lets a trial through after the cooldown")
+	assert.False(t, b.allow(timeNow), "lets only one trial through")
+
+	b.failure(timeNow)
+	assert.False(t, b.allow(timeNow.Add(time.Second)), "a failed trial reopens the circuit")
+
+	timeNow = timeNow.Add(time.Minute)
+	assert.True(t, b.allow(timeNow))
+
+	b.success()
+	assert.True(t, b.allow(timeNow))
+	assert.True(t, b.allow(timeNow))
+
+	assert.Equal(t, []bool{true, false}, changes)
+}
+
+type fakeSender struct {
+	mu    sync.Mutex
+	err   error
+	count int
+}
+
+func (s *fakeSender) Send(ctx context.Context, message *model.SMSMessage) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.count++
+	return s.err
+}
+
+func (s *fakeSender) setErr(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.err = err
+}
+
+func (s *fakeSender) calls() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.count
+}
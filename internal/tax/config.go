@@ -0,0 +1,139 @@
+package tax
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ernestngugi/sil-backend/internal/apperrors"
+	"github.com/ernestngugi/sil-backend/internal/env"
+	"github.com/ernestngugi/sil-backend/internal/model"
+)
+
+const defaultCategories = "standard:standard,zero_rated:zero_rated,exempt:exempt"
+
+type Config struct {
+	// StandardRate is the VAT percentage charged on standard rated goods.
+	StandardRate float64
+	// Categories are the product categories orders may be placed under.
+	Categories map[string]*model.TaxCategory
+	// DefaultCategory applies to orders that do not name one.
+	DefaultCategory string
+}
+
+// ConfigFromEnv reads VAT_RATE, the TAX_CATEGORIES an order may be placed
+// under and the TAX_DEFAULT_CATEGORY used when it names none.
+//
+// Categories are written as "code:treatment" pairs separated by commas, for
+// example "general:standard,maize_flour:zero_rated,medicine:exempt". A
+// standard rated category may carry its own rate, as in "fuel:standard:8".
+func ConfigFromEnv() (*Config, error) {
+
+	config := &Config{
+		Categories:      map[string]*model.TaxCategory{},
+		DefaultCategory: env.String("TAX_DEFAULT_CATEGORY", "standard"),
+	}
+
+	var err error
+
+	if config.StandardRate, err = env.Float("VAT_RATE", 16); err != nil {
+		return &Config{}, err
+	}
+
+	if !validRate(config.StandardRate) {
+		return &Config{}, fmt.Errorf("invalid VAT_RATE: %v is not a percentage", config.StandardRate)
+	}
+
+	for _, entry := range strings.Split(env.String("TAX_CATEGORIES", defaultCategories), ",") {
+
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		category, err := parseCategory(entry, config.StandardRate)
+		if err != nil {
+			return &Config{}, fmt.Errorf("invalid TAX_CATEGORIES: %w", err)
+		}
+
+		if _, ok := config.Categories[category.Code]; ok {
+			return &Config{}, fmt.Errorf("invalid TAX_CATEGORIES: %q is listed twice", category.Code)
+		}
+
+		config.Categories[category.Code] = category
+	}
+
+	if _, ok := config.Categories[config.DefaultCategory]; !ok {
+		return &Config{}, fmt.Errorf("invalid TAX_DEFAULT_CATEGORY: %q is not in TAX_CATEGORIES", config.DefaultCategory)
+	}
+
+	return config, nil
+}
+
+// Category returns the tax category with code, or the default category
+// when code is empty.
+func (c *Config) Category(code string) (*model.TaxCategory, error) {
+
+	if code == "" {
+		code = c.DefaultCategory
+	}
+
+	category, ok := c.Categories[code]
+	if !ok {
+		return &model.TaxCategory{}, apperrors.Validation(
+			"invalid_tax_category",
+			"tax category is not recognised",
+			apperrors.FieldError{
+				Field:   "tax_category",
+				Code:    "oneof",
+				Message: fmt.Sprintf("tax_category %q is not recognised", code),
+			},
+		)
+	}
+
+	return category, nil
+}
+
+func parseCategory(entry string, standardRate float64) (*model.TaxCategory, error) {
+
+	parts := strings.Split(entry, ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return nil, fmt.Errorf("%q is not code:treatment", entry)
+	}
+
+	category := &model.TaxCategory{
+		Code:      strings.TrimSpace(parts[0]),
+		Treatment: model.TaxTreatment(strings.TrimSpace(parts[1])),
+	}
+
+	if category.Code == "" {
+		return nil, fmt.Errorf("%q has no code", entry)
+	}
+
+	switch category.Treatment {
+	case model.TaxTreatmentStandard:
+		category.Rate = standardRate
+	case model.TaxTreatmentZeroRated, model.TaxTreatmentExempt:
+		if len(parts) == 3 {
+			return nil, fmt.Errorf("%q: only standard rated categories take a rate", entry)
+		}
+	default:
+		return nil, fmt.Errorf("%q: unknown treatment %q", entry, category.Treatment)
+	}
+
+	if len(parts) == 3 {
+
+		rate, err := strconv.ParseFloat(strings.TrimSpace(parts[2]), 64)
+		if err != nil || !validRate(rate) {
+			return nil, fmt.Errorf("%q: %q is not a percentage", entry, parts[2])
+		}
+
+		category.Rate = rate
+	}
+
+	return category, nil
+}
+
+func validRate(rate float64) bool {
+	return rate >= 0 && rate < 100
+}
@@ -0,0 +1,198 @@
+package tax
+
+import (
+	"math"
+
+	"github.com/ernestngugi/sil-backend/internal/model"
+)
+
+// Inclusive splits gross, an amount that already includes VAT, into its net
+// amount and tax under category. The tax is rounded to the cent and the net
+// amount is what is left, so the two always add up to gross.
+func Inclusive(category *model.TaxCategory, gross float64) model.OrderTax {
+
+	grossCents := cents(gross)
+	taxCents := int64(math.Round(float64(grossCents) * category.Rate / (100 + category.Rate)))
+
+	return orderTax(category, grossCents-taxCents, taxCents)
+}
+
+// Exclusive adds VAT under category to net, an amount before tax. The tax is
+// rounded to the cent.
+func Exclusive(category *model.TaxCategory, net float64) model.OrderTax {
+
+	netCents := cents(net)
+	taxCents := int64(math.Round(float64(netCents) * category.Rate / 100))
+
+	return orderTax(category, netCents, taxCents)
+}
+
+// Summarise totals invoice lines by treatment and rate, in the order each
+// first appears. Lines are already rounded, so totals are exact.
+func Summarise(lines []*model.InvoiceLine) []*model.TaxSummary {
+
+	type group struct {
+		treatment       model.TaxTreatment
+		rate            float64
+		net, tax, gross int64
+	}
+
+	var groups []*group
+
+	for _, line := range lines {
+
+		treatment := line.TaxTreatment
+		if treatment == "" {
+			// Invoices issued before tax categories were all standard rated.
+			treatment = model.TaxTreatmentStandard
+		}
+
+		var g *group
+
+		for _, existing := range groups {
+			if existing.treatment == treatment && existing.rate == line.TaxRate {
+				g = existing
+				break
+			}
+		}
+
+		if g == nil {
+			g = &group{treatment: treatment, rate: line.TaxRate}
+			groups = append(groups, g)
+		}
+
+		g.net += cents(line.NetAmount)
+		g.tax += cents(line.TaxAmount)
+		g.gross += cents(line.GrossAmount)
+	}
+
+	summaries := make([]*model.TaxSummary, 0, len(groups))
+
+	for _, g := range groups {
+		summaries = append(summaries, &model.TaxSummary{
+			Treatment:   g.treatment,
+			Rate:        g.rate,
+			NetAmount:   amount(g.net),
+			TaxAmount:   amount(g.tax),
+			GrossAmount: amount(g.gross),
+		})
+	}
+
+	return summaries
+}
+
+func orderTax(category *model.TaxCategory, netCents, taxCents int64) model.OrderTax {
+	return model.OrderTax{
+		Category:    category.Code,
+		Treatment:   category.Treatment,
+		Rate:        category.Rate,
+		NetAmount:   amount(netCents),
+		TaxAmount:   amount(taxCents),
+		GrossAmount: amount(netCents + taxCents),
+	}
+}
+
+// cents converts a shilling amount to whole cents. Working in cents keeps
+// float error out of the rounding.
+func cents(amount float64) int64 {
+	return int64(math.Round(amount * 100))
+}
+
+func amount(cents int64) float64 {
+	return float64(cents) / 100
+}
+
+// Sum totals the tax on lines. The category, treatment and rate are kept
+// when every line shares them.
+func Sum(lines []*model.OrderLine) model.OrderTax {
+
+	var netCents, taxCents int64
+
+	for _, line := range lines {
+		netCents += cents(line.Tax.NetAmount)
+		taxCents += cents(line.Tax.TaxAmount)
+	}
+
+	sum := model.OrderTax{
+		NetAmount:   amount(netCents),
+		TaxAmount:   amount(taxCents),
+		GrossAmount: amount(netCents + taxCents),
+	}
+
+	for i, line := range lines {
+
+		if i > 0 && line.Tax.Category != sum.Category {
+			sum.Category, sum.Treatment, sum.Rate = "", "", 0
+			break
+		}
+
+		sum.Category, sum.Treatment, sum.Rate = line.Tax.Category, line.Tax.Treatment, line.Tax.Rate
+	}
+
+	return sum
+}
+
+// Discount takes discount off order and works out its VAT again on what is
+// left. On an order with lines the discount is shared between the lines
+// applies selects, in proportion to their amounts, and each line is taxed
+// under its own category. The discount must not exceed the amount of those
+// lines.
+func Discount(order *model.Order, discount float64, applies func(line *model.OrderLine) bool) {
+
+	if len(order.Lines) == 0 {
+		order.Tax = Inclusive(category(order.Tax), order.Amount-discount)
+		order.Amount = order.Tax.GrossAmount
+		return
+	}
+
+	var eligible []*model.OrderLine
+	var eligibleCents int64
+
+	for _, line := range order.Lines {
+		if applies(line) {
+			eligible = append(eligible, line)
+			eligibleCents += cents(line.Amount)
+		}
+	}
+
+	discountCents := min(cents(discount), eligibleCents)
+	if discountCents <= 0 {
+		return
+	}
+
+	shares := make([]int64, len(eligible))
+
+	var shared int64
+
+	for i, line := range eligible {
+		shares[i] = discountCents * cents(line.Amount) / eligibleCents
+		shared += shares[i]
+	}
+
+	// Whole cents left over from rounding down go to the first lines that
+	// can take them.
+	for i := 0; shared < discountCents; i = (i + 1) % len(eligible) {
+		if shares[i] < cents(eligible[i].Amount) {
+			shares[i]++
+			shared++
+		}
+	}
+
+	for i, line := range eligible {
+		line.Tax = Inclusive(category(line.Tax), amount(cents(line.Amount)-shares[i]))
+		line.Amount = line.Tax.GrossAmount
+		line.DiscountAmount = amount(cents(line.DiscountAmount) + shares[i])
+	}
+
+	order.Tax = Sum(order.Lines)
+	order.Amount = order.Tax.GrossAmount
+}
+
+// category is the tax category orderTax was worked out under.
+func category(orderTax model.OrderTax) *model.TaxCategory {
+	return &model.TaxCategory{
+		Code:      orderTax.Category,
+		Treatment: orderTax.Treatment,
+		Rate:      orderTax.Rate,
+	}
+}
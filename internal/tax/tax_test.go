@@ -0,0 +1,242 @@
+package tax
+
+import (
+	"testing"
+
+	"github.com/ernestngugi/sil-backend/internal/apperrors"
+	"github.com/ernestngugi/sil-backend/internal/model"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	standardRated = &model.TaxCategory{Code: "standard", Treatment: model.TaxTreatmentStandard, Rate: 16}
+	zeroRated     = &model.TaxCategory{Code: "zero_rated", Treatment: model.TaxTreatmentZeroRated}
+	exempt        = &model.TaxCategory{Code: "exempt", Treatment: model.TaxTreatmentExempt}
+)
+
+func TestInclusive(t *testing.T) {
+
+	for gross, expected := range map[float64][2]float64{
+		1160:     {1000, 160},
+		1250:     {1077.59, 172.41},
+		99.5:     {85.78, 13.72},
+		0.01:     {0.01, 0},
+		0.07:     {0.06, 0.01},
+		10000:    {8620.69, 1379.31},
+		99999.99: {86206.89, 13793.10},
+	} {
+		orderTax := Inclusive(standardRated, gross)
+		assert.Equal(t, expected[0], orderTax.NetAmount, gross)
+		assert.Equal(t, expected[1], orderTax.TaxAmount, gross)
+		assert.Equal(t, gross, orderTax.GrossAmount, gross)
+	}
+
+	for _, category := range []*model.TaxCategory{zeroRated, exempt} {
+
+		orderTax := Inclusive(category, 500)
+		assert.Equal(t, model.OrderTax{
+			Category:    category.Code,
+			Treatment:   category.Treatment,
+			NetAmount:   500,
+			GrossAmount: 500,
+		}, orderTax)
+	}
+}
+
+func TestExclusive(t *testing.T) {
+
+	for net, expected := range map[float64][2]float64{
+		1000:    {160, 1160},
+		1077.59: {172.41, 1250},
+		85.78:   {13.72, 99.5},
+		0.03:    {0, 0.03},
+		0.04:    {0.01, 0.05},
+	} {
+		orderTax := Exclusive(standardRated, net)
+		assert.Equal(t, net, orderTax.NetAmount, net)
+		assert.Equal(t, expected[0], orderTax.TaxAmount, net)
+		assert.Equal(t, expected[1], orderTax.GrossAmount, net)
+	}
+
+	orderTax := Exclusive(zeroRated, 200)
+	assert.Equal(t, 0.0, orderTax.TaxAmount)
+	assert.Equal(t, 200.0, orderTax.GrossAmount)
+}
+
+func TestSummarise(t *testing.T) {
+
+	line := func(category *model.TaxCategory, gross float64) *model.InvoiceLine {
+		orderTax := Inclusive(category, gross)
+		return &model.InvoiceLine{
+			TaxTreatment: orderTax.Treatment,
+			TaxRate:      orderTax.Rate,
+			NetAmount:    orderTax.NetAmount,
+			TaxAmount:    orderTax.TaxAmount,
+			GrossAmount:  orderTax.GrossAmount,
+		}
+	}
+
+	summaries := Summarise([]*model.InvoiceLine{
+		line(standardRated, 0.1),
+		line(exempt, 80),
+		line(standardRated, 0.2),
+		line(zeroRated, 200),
+		line(standardRated, 0.3),
+		{TaxRate: 16, NetAmount: 100, TaxAmount: 16, GrossAmount: 116},
+	})
+
+	assert.Equal(t, []*model.TaxSummary{
+		{Treatment: model.TaxTreatmentStandard, Rate: 16, NetAmount: 100.52, TaxAmount: 16.08, GrossAmount: 116.6},
+		{Treatment: model.TaxTreatmentExempt, NetAmount: 80, GrossAmount: 80},
+		{Treatment: model.TaxTreatmentZeroRated, NetAmount: 200, GrossAmount: 200},
+	}, summaries)
+
+	assert.Empty(t, Summarise(nil))
+}
+
+func TestConfigFromEnv(t *testing.T) {
+
+	t.Run("defaults to the standard rate", func(t *testing.T) {
+
+		config, err := ConfigFromEnv()
+		assert.NoError(t, err)
+		assert.Equal(t, 16.0, config.StandardRate)
+
+		category, err := config.Category("")
+		assert.NoError(t, err)
+		assert.Equal(t, standardRated, category)
+
+		category, err = config.Category("exempt")
+		assert.NoError(t, err)
+		assert.Equal(t, exempt, category)
+	})
+
+	t.Run("reads categories", func(t *testing.T) {
+
+		t.Setenv("VAT_RATE", "14")
+		t.Setenv("TAX_CATEGORIES", "general:standard, maize_flour:zero_rated,medicine:exempt,fuel:standard:8")
+		t.Setenv("TAX_DEFAULT_CATEGORY", "general")
+
+		config, err := ConfigFromEnv()
+		assert.NoError(t, err)
+
+		assert.Equal(t, map[string]*model.TaxCategory{
+			"general":     {Code: "general", Treatment: model.TaxTreatmentStandard, Rate: 14},
+			"maize_flour": {Code: "maize_flour", Treatment: model.TaxTreatmentZeroRated},
+			"medicine":    {Code: "medicine", Treatment: model.TaxTreatmentExempt},
+			"fuel":        {Code: "fuel", Treatment: model.TaxTreatmentStandard, Rate: 8},
+		}, config.Categories)
+
+		category, err := config.Category("")
+		assert.Equal(t, "general", category.Code)
+		assert.NoError(t, err)
+
+		_, err = config.Category("standard")
+		assert.True(t, apperrors.Is(err, apperrors.KindValidation))
+		assert.Equal(t, "invalid_tax_category", apperrors.From(err).Code)
+	})
+
+	t.Run("rejects invalid configuration", func(t *testing.T) {
+
+		for _, env := range []map[string]string{
+			{"VAT_RATE": "100"},
+			{"VAT_RATE": "-1"},
+			{"TAX_CATEGORIES": "general"},
+			{"TAX_CATEGORIES": "general:reduced"},
+			{"TAX_CATEGORIES": "standard:standard,standard:exempt"},
+			{"TAX_CATEGORIES": "standard:standard,bread:zero_rated:5"},
+			{"TAX_CATEGORIES": "standard:standard:high"},
+			{"TAX_CATEGORIES": ":standard"},
+			{"TAX_DEFAULT_CATEGORY": "general"},
+		} {
+			for key, value := range env {
+				t.Setenv(key, value)
+			}
+
+			_, err := ConfigFromEnv()
+			assert.Error(t, err, env)
+
+			for key := range env {
+				t.Setenv(key, "")
+			}
+		}
+	})
+}
+
+func TestSum(t *testing.T) {
+
+	lines := []*model.OrderLine{
+		{Tax: Inclusive(standardRated, 1160)},
+		{Tax: Inclusive(standardRated, 99.5)},
+	}
+
+	sum := Sum(lines)
+	assert.Equal(t, "standard", sum.Category)
+	assert.Equal(t, 16.0, sum.Rate)
+	assert.Equal(t, 1085.78, sum.NetAmount)
+	assert.Equal(t, 173.72, sum.TaxAmount)
+	assert.Equal(t, 1259.5, sum.GrossAmount)
+
+	lines = append(lines, &model.OrderLine{Tax: Inclusive(zeroRated, 500)})
+
+	sum = Sum(lines)
+	assert.Empty(t, sum.Category)
+	assert.Empty(t, sum.Treatment)
+	assert.Zero(t, sum.Rate)
+	assert.Equal(t, 1759.5, sum.GrossAmount)
+}
+
+func TestDiscount(t *testing.T) {
+
+	all := func(line *model.OrderLine) bool { return true }
+
+	t.Run("taxes a single item order again", func(t *testing.T) {
+
+		order := &model.Order{Amount: 1250, Tax: Inclusive(standardRated, 1250)}
+
+		Discount(order, 200, all)
+		assert.Equal(t, 1050.0, order.Amount)
+		assert.Equal(t, 144.83, order.Tax.TaxAmount)
+		assert.Equal(t, "standard", order.Tax.Category)
+	})
+
+	t.Run("shares the discount between lines", func(t *testing.T) {
+
+		line := func(category *model.TaxCategory, gross float64) *model.OrderLine {
+			return &model.OrderLine{Amount: gross, Tax: Inclusive(category, gross)}
+		}
+
+		order := &model.Order{Lines: []*model.OrderLine{
+			line(standardRated, 600),
+			line(zeroRated, 300),
+			line(standardRated, 100),
+		}}
+		order.Tax = Sum(order.Lines)
+		order.Amount = order.Tax.GrossAmount
+
+		Discount(order, 100.01, all)
+		assert.Equal(t, 899.99, order.Amount)
+		assert.Equal(t, 539.99, order.Lines[0].Amount)
+		assert.Equal(t, 60.01, order.Lines[0].DiscountAmount)
+		assert.Equal(t, 270.0, order.Lines[1].Amount)
+		assert.Zero(t, order.Lines[1].Tax.TaxAmount)
+		assert.Equal(t, 90.0, order.Lines[2].Amount)
+		assert.Equal(t, 74.48, order.Lines[0].Tax.TaxAmount)
+		assert.Equal(t, 12.41, order.Lines[2].Tax.TaxAmount)
+		assert.Equal(t, 86.89, order.Tax.TaxAmount)
+	})
+
+	t.Run("only discounts the lines it applies to", func(t *testing.T) {
+
+		order := &model.Order{Lines: []*model.OrderLine{
+			{Item: "Sugar 1kg", Amount: 200, Tax: Inclusive(standardRated, 200)},
+			{Item: "Maize flour 2kg", Amount: 300, Tax: Inclusive(zeroRated, 300)},
+		}}
+
+		Discount(order, 1000, func(line *model.OrderLine) bool { return line.Item == "Maize flour 2kg" })
+		assert.Equal(t, 200.0, order.Amount)
+		assert.Equal(t, 200.0, order.Lines[0].Amount)
+		assert.Zero(t, order.Lines[1].Amount)
+		assert.Equal(t, 300.0, order.Lines[1].DiscountAmount)
+	})
+}
@@ -0,0 +1,60 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/coreos/go-oidc"
+	"github.com/ernestngugi/sil-backend/providers"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/oauth2"
+)
+
+type openID struct {
+	next   providers.OpenID
+	client *http.Client
+}
+
+// NewOpenID decorates any providers.OpenID with spans around the token
+// exchange and user info calls. The outbound HTTP requests made by oauth2 and
+// go-oidc are traced as child spans and carry the trace context.
+func NewOpenID(next providers.OpenID) providers.OpenID {
+	return &openID{
+		next:   next,
+		client: &http.Client{Transport: otelhttp.NewTransport(http.DefaultTransport)},
+	}
+}
+
+func (o *openID) AuthCodeURL(code string, opts ...oauth2.AuthCodeOption) string {
+	return o.next.AuthCodeURL(code, opts...)
+}
+
+func (o *openID) Exchange(ctx context.Context, token string, opts ...oauth2.AuthCodeOption) (*oauth2.Token, error) {
+
+	ctx, span := Tracer().Start(ctx, "oidc.exchange", trace.WithSpanKind(trace.SpanKindClient))
+	defer span.End()
+
+	authToken, err := o.next.Exchange(oidc.ClientContext(ctx, o.client), token, opts...)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	return authToken, err
+}
+
+func (o *openID) UserInfo(ctx context.Context, tokenSource oauth2.TokenSource) (*oidc.UserInfo, error) {
+
+	ctx, span := Tracer().Start(ctx, "oidc.userinfo", trace.WithSpanKind(trace.SpanKindClient))
+	defer span.End()
+
+	userInfo, err := o.next.UserInfo(oidc.ClientContext(ctx, o.client), tokenSource)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	return userInfo, err
+}
@@ -0,0 +1,59 @@
+package tracing
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var (
+	stringLiteral  = regexp.MustCompile(`'(?:[^']|'')*'`)
+	numericLiteral = regexp.MustCompile(`([^\w$.])-?\d+(?:\.\d+)?\b`)
+	whitespace     = regexp.MustCompile(`\s+`)
+)
+
+// SanitizeSQL replaces string and numeric literals with placeholders so
+// statements can be attached to spans without leaking customer data.
+// Positional parameters such as $1 are kept.
+func SanitizeSQL(query string) string {
+	query = stringLiteral.ReplaceAllString(query, "?")
+	query = numericLiteral.ReplaceAllString(query, "${1}?")
+	return strings.TrimSpace(whitespace.ReplaceAllString(query, " "))
+}
+
+// StartSQLSpan starts a client span for a database statement. The caller must
+// pass the statement's error to the returned function once it completes.
+func StartSQLSpan(ctx context.Context, query string) (context.Context, func(error)) {
+
+	statement := SanitizeSQL(query)
+
+	operation := statement
+	if i := strings.IndexByte(statement, ' '); i > 0 {
+		operation = statement[:i]
+	}
+	operation = strings.ToUpper(operation)
+
+	ctx, span := Tracer().Start(
+		ctx,
+		"db."+strings.ToLower(operation),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemPostgreSQL,
+			semconv.DBOperation(operation),
+			attribute.String("db.statement", statement),
+		),
+	)
+
+	return ctx, func(err error) {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}
+}
@@ -0,0 +1,76 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/coreos/go-oidc"
+	"github.com/ernestngugi/sil-backend/mocks"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"golang.org/x/oauth2"
+)
+
+func TestTracing(t *testing.T) {
+
+	ctx := context.Background()
+
+	exporter := tracetest.NewInMemoryExporter()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+
+	t.Run("sanitizes sql literals", func(t *testing.T) {
+
+		assert.Equal(
+			t,
+			"SELECT id FROM customers WHERE name = ? AND id > ? AND customer_id = $1",
+			SanitizeSQL("SELECT id FROM customers\n\tWHERE name = 'o''brien' AND id > 10 AND customer_id = $1"),
+		)
+	})
+
+	t.Run("records sql spans", func(t *testing.T) {
+
+		exporter.Reset()
+
+		_, end := StartSQLSpan(ctx, "SELECT id FROM orders WHERE amount = 100")
+		end(errors.New("boom"))
+
+		spans := exporter.GetSpans()
+		assert.Len(t, spans, 1)
+		assert.Equal(t, "db.select", spans[0].Name)
+		assert.Equal(t, codes.Error, spans[0].Status.Code)
+		assert.Contains(t, spans[0].Attributes, attribute.String("db.statement", "SELECT id FROM orders WHERE amount = ?"))
+	})
+
+	t.Run("records oidc spans", func(t *testing.T) {
+
+		exporter.Reset()
+
+		mockOpenID := mocks.NewMockOpenID()
+		mockOpenID.AuthToken = &oauth2.Token{AccessToken: "token"}
+		mockOpenID.User = &oidc.UserInfo{Email: "customer@example.com"}
+
+		openID := NewOpenID(mockOpenID)
+
+		ctx, parent := Tracer().Start(ctx, "request")
+
+		token, err := openID.Exchange(ctx, "code")
+		assert.NoError(t, err)
+
+		userInfo, err := openID.UserInfo(ctx, oauth2.StaticTokenSource(token))
+		assert.NoError(t, err)
+		assert.Equal(t, "customer@example.com", userInfo.Email)
+
+		parent.End()
+
+		spans := exporter.GetSpans()
+		assert.Len(t, spans, 3)
+		assert.Equal(t, "oidc.exchange", spans[0].Name)
+		assert.Equal(t, "oidc.userinfo", spans[1].Name)
+		assert.Equal(t, spans[2].SpanContext.SpanID(), spans[0].Parent.SpanID())
+	})
+}
@@ -0,0 +1,78 @@
+package auth
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ernestngugi/sil-backend/internal/env"
+)
+
+// APIKeyHeader carries the key of a partner or internal client.
+const APIKeyHeader = "X-API-Key"
+
+type (
+	apiKey struct {
+		client string
+		key    []byte
+	}
+
+	// APIKeys identifies clients by the keys configured for them.
+	APIKeys struct {
+		keys []apiKey
+	}
+)
+
+// APIKeysFromEnv reads the environment variable key, a comma separated list
+// of client:key pairs.
+func APIKeysFromEnv(key string) (*APIKeys, error) {
+
+	apiKeys, err := ParseAPIKeys(env.String(key, ""))
+	if err != nil {
+		return &APIKeys{}, fmt.Errorf("invalid %v: %w", key, err)
+	}
+
+	return apiKeys, nil
+}
+
+func ParseAPIKeys(value string) (*APIKeys, error) {
+
+	apiKeys := &APIKeys{}
+
+	for _, pair := range strings.Split(value, ",") {
+
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		client, key, found := strings.Cut(pair, ":")
+		if !found || client == "" || key == "" {
+			return &APIKeys{}, errors.New("expected comma separated client:key pairs")
+		}
+
+		apiKeys.keys = append(apiKeys.keys, apiKey{client: client, key: []byte(key)})
+	}
+
+	return apiKeys, nil
+}
+
+// Client returns the name of the client key belongs to. Every configured key
+// is compared in constant time so response times do not leak a prefix.
+func (k *APIKeys) Client(key string) (string, bool) {
+
+	if k == nil || key == "" {
+		return "", false
+	}
+
+	var client string
+
+	for _, configured := range k.keys {
+		if subtle.ConstantTimeCompare(configured.key, []byte(key)) == 1 {
+			client = configured.client
+		}
+	}
+
+	return client, client != ""
+}
@@ -2,15 +2,16 @@ package auth
 
 import (
 	"context"
-	"errors"
 	"net/http"
 
 	"github.com/coreos/go-oidc"
+	"github.com/ernestngugi/sil-backend/internal/apperrors"
 	"github.com/ernestngugi/sil-backend/providers"
 	"golang.org/x/oauth2"
 )
 
-const tokenHeader = "X-SIL-TOKEN"
+// TokenHeader carries the access token issued by the login callback.
+const TokenHeader = "X-SIL-TOKEN"
 
 type Authenticator interface {
 	TokenFromRequest(ctx context.Context, request *http.Request) (*oidc.UserInfo, error)
@@ -28,20 +29,20 @@ func NewAuthenticator(oidcProvider providers.OpenID) Authenticator {
 
 func (a *authenticator) TokenFromRequest(ctx context.Context, request *http.Request) (*oidc.UserInfo, error) {
 
-	token := request.Header.Get(tokenHeader)
+	token := request.Header.Get(TokenHeader)
 
 	if token == "" {
-		return &oidc.UserInfo{}, errors.New("token not provided")
+		return &oidc.UserInfo{}, apperrors.Unauthorized("token_missing", "token not provided")
 	}
 
 	authToken, err := a.oidcProvider.Exchange(ctx, token)
 	if err != nil {
-		return &oidc.UserInfo{}, err
+		return &oidc.UserInfo{}, apperrors.Unauthorized("invalid_token", "token is invalid or expired").Wrap(err)
 	}
 
 	userInfo, err := a.oidcProvider.UserInfo(ctx, oauth2.StaticTokenSource(authToken))
 	if err != nil {
-		return &oidc.UserInfo{}, errors.New("failed to get user info")
+		return &oidc.UserInfo{}, apperrors.Unauthorized("invalid_token", "failed to get user info").Wrap(err)
 	}
 
 	return userInfo, nil
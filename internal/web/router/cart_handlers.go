@@ -0,0 +1,145 @@
+package router
+
+import (
+	"net/http"
+
+	"github.com/ernestngugi/sil-backend/internal/controller"
+	"github.com/ernestngugi/sil-backend/internal/db"
+	"github.com/ernestngugi/sil-backend/internal/forms"
+	"github.com/gin-gonic/gin"
+)
+
+func cart(dB db.DB, cartController controller.CartController) func(c *gin.Context) {
+	return func(c *gin.Context) {
+
+		cart, err := cartController.Cart(c.Request.Context(), dB)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, cart)
+	}
+}
+
+func addCartLine(dB db.DB, cartController controller.CartController) func(c *gin.Context) {
+	return func(c *gin.Context) {
+
+		var form forms.AddCartLineForm
+
+		err := forms.DecodeJSON(c.Request.Body, &form, requestLocale(c))
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		cart, err := cartController.AddLine(c.Request.Context(), dB, &form)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, cart)
+	}
+}
+
+func updateCartLine(dB db.DB, cartController controller.CartController) func(c *gin.Context) {
+	return func(c *gin.Context) {
+
+		productID, err := idParam(c, "product_id")
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		var form forms.UpdateCartLineForm
+
+		err = forms.DecodeJSON(c.Request.Body, &form, requestLocale(c))
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		cart, err := cartController.UpdateLine(c.Request.Context(), dB, productID, &form)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, cart)
+	}
+}
+
+func removeCartLine(dB db.DB, cartController controller.CartController) func(c *gin.Context) {
+	return func(c *gin.Context) {
+
+		productID, err := idParam(c, "product_id")
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		cart, err := cartController.RemoveLine(c.Request.Context(), dB, productID)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, cart)
+	}
+}
+
+func applyCartPromoCode(dB db.DB, cartController controller.CartController) func(c *gin.Context) {
+	return func(c *gin.Context) {
+
+		var form forms.ApplyPromoCodeForm
+
+		err := forms.DecodeJSON(c.Request.Body, &form, requestLocale(c))
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		cart, err := cartController.ApplyPromoCode(c.Request.Context(), dB, &form)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, cart)
+	}
+}
+
+func removeCartPromoCode(dB db.DB, cartController controller.CartController) func(c *gin.Context) {
+	return func(c *gin.Context) {
+
+		cart, err := cartController.RemovePromoCode(c.Request.Context(), dB)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, cart)
+	}
+}
+
+func checkoutCart(dB db.DB, orderController controller.OrderController) func(c *gin.Context) {
+	return func(c *gin.Context) {
+
+		var form forms.CheckoutCartForm
+
+		err := forms.DecodeJSON(c.Request.Body, &form, requestLocale(c))
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		order, err := orderController.CheckoutCart(c.Request.Context(), dB, &form)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusCreated, order)
+	}
+}
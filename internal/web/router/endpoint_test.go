@@ -16,9 +16,15 @@ import (
 	"github.com/ernestngugi/sil-backend/internal/controller"
 	"github.com/ernestngugi/sil-backend/internal/db"
 	"github.com/ernestngugi/sil-backend/internal/forms"
+	"github.com/ernestngugi/sil-backend/internal/logging"
+	"github.com/ernestngugi/sil-backend/internal/loyalty"
+	"github.com/ernestngugi/sil-backend/internal/metrics"
 	"github.com/ernestngugi/sil-backend/internal/model"
+	"github.com/ernestngugi/sil-backend/internal/notifications"
 	"github.com/ernestngugi/sil-backend/internal/repos"
+	"github.com/ernestngugi/sil-backend/internal/tax"
 	"github.com/ernestngugi/sil-backend/internal/web/auth"
+	"github.com/ernestngugi/sil-backend/internal/webhooks"
 	"github.com/ernestngugi/sil-backend/mocks"
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
@@ -33,22 +39,49 @@ func TestApplicationEndpoints(t *testing.T) {
 
 	ctx := context.Background()
 
-	dB := db.InitDB()
+	dB, err := db.InitDB(ctx, logging.Discard())
+	if err != nil {
+		t.Fatalf("failed to initialise database: %v", err)
+	}
 	defer dB.Close()
 
-	atProvider := mocks.NewMockATProvider()
+	err = db.RunMigrations(ctx, dB)
+	if err != nil {
+		t.Fatalf("failed to migrate database: %v", err)
+	}
+
+	smsSender := mocks.NewMockSMSSender()
 	oidcProvider := mocks.NewMockOpenID()
 
 	customerRepository := repos.NewCustomerRepository()
 	orderRepository := repos.NewOrderRepository()
 
 	customerController := controller.NewCustomerController(customerRepository)
-	orderController := controller.NewOrderController(atProvider, customerRepository, orderRepository)
+	logger := logging.Discard()
+
+	smsDispatcher := notifications.NewSMSDispatcher(dB, smsSender, repos.NewSMSOutboxRepository(), logger)
+	defer smsDispatcher.Shutdown(ctx)
+
+	emailDispatcher := notifications.NewEmailDispatcher(mocks.NewMockEmailSender(), logger)
+	defer emailDispatcher.Shutdown(ctx)
+
+	notifier := notifications.NewNotifier(repos.NewNotificationPreferenceRepository(), repos.NewPaymentRepository(), notifications.NewSMSTemplates(repos.NewSMSTemplateRepository(), logger), smsDispatcher, emailDispatcher, true)
+
+	taxConfig, err := tax.ConfigFromEnv()
+	assert.NoError(t, err)
+
+	loyaltyConfig, err := loyalty.ConfigFromEnv()
+	assert.NoError(t, err)
+
+	ledger := loyalty.NewLedger(repos.NewLoyaltyRepository(), loyaltyConfig)
+
+	orderController := controller.NewOrderController(customerRepository, orderRepository, repos.NewOrderEventRepository(), repos.NewPromotionRepository(), repos.NewCartRepository(), repos.NewProductRepository(), ledger, notifier, webhooks.NewPublisher(repos.NewWebhookRepository()), taxConfig, metrics.New(), logger)
 
 	testRouter := gin.Default()
+	testRouter.Use(errorMiddleware(logger))
 	appRouter := testRouter.Group("/v1")
 	unAuthenticatedUser := testRouter.Group("")
-	appRouter.Use(authMiddleware(auth.NewAuthenticator(oidcProvider)))
+	appRouter.Use(authMiddleware(auth.NewAuthenticator(oidcProvider), logger))
 
 	appRouter.POST("/orders", createOrder(dB, orderController))
 	appRouter.GET("/orders/:id", orderByID(dB, orderController))
@@ -91,7 +124,7 @@ func TestApplicationEndpoints(t *testing.T) {
 		tokenHeader := w.Header().Get("X-SIL-TOKEN")
 		assert.NotEmpty(t, tokenHeader)
 
-		clearCustomerTable(ctx, dB)
+		clearCustomerTable(t, ctx, dB)
 
 	})
 	t.Run("can create an order", func(t *testing.T) {
@@ -140,17 +173,66 @@ func TestApplicationEndpoints(t *testing.T) {
 
 		assert.Equal(t, w.Code, http.StatusOK)
 
-		clearCustomerTable(ctx, dB)
-		clearOrderTable(ctx, dB)
+		clearCustomerTable(t, ctx, dB)
+		clearOrderTable(t, ctx, dB)
+	})
+
+	t.Run("returns a problem when the order does not exist", func(t *testing.T) {
+
+		customer := model.BuildCustomer()
+
+		err := customerRepository.Save(ctx, dB, customer)
+		assert.NoError(t, err)
+
+		oidcProvider.User = &oidc.UserInfo{Email: customer.Name}
+
+		w := httptest.NewRecorder()
+
+		req, err := http.NewRequest(http.MethodGet, "/v1/orders/999999", nil)
+		assert.NoError(t, err)
+
+		req.Header.Set("X-SIL-TOKEN", customer.Name)
+
+		testRouter.ServeHTTP(w, req)
+
+		var body problem
+
+		err = json.Unmarshal(w.Body.Bytes(), &body)
+		assert.NoError(t, err)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Equal(t, "order_not_found", body.Code)
+
+		clearCustomerTable(t, ctx, dB)
+	})
+
+	t.Run("rejects requests without a token", func(t *testing.T) {
+
+		w := httptest.NewRecorder()
+
+		req, err := http.NewRequest(http.MethodGet, "/v1/orders/1", nil)
+		assert.NoError(t, err)
+
+		testRouter.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
 	})
 }
 
-func clearCustomerTable(ctx context.Context, dB db.DB) {
-	dB.ExecContext(ctx, "DELETE FROM customers")
-	dB.ExecContext(ctx, "ALTER SEQUENCE customers_id_seq RESTART WITH 1")
+func clearCustomerTable(t *testing.T, ctx context.Context, dB db.DB) {
+	t.Helper()
+
+	// TRUNCATE gets past the loyalty ledger's append-only trigger, and CASCADE
+	// clears everything recorded against the customers.
+	_, err := dB.ExecContext(ctx, "TRUNCATE customers RESTART IDENTITY CASCADE")
+	assert.NoError(t, err)
 }
 
-func clearOrderTable(ctx context.Context, dB db.DB) {
-	dB.ExecContext(ctx, "DELETE FROM orders")
-	dB.ExecContext(ctx, "ALTER SEQUENCE orders_id_seq RESTART WITH 1")
+func clearOrderTable(t *testing.T, ctx context.Context, dB db.DB) {
+	t.Helper()
+
+	// TRUNCATE gets past the loyalty ledger's append-only trigger, and CASCADE
+	// clears everything recorded against the orders.
+	_, err := dB.ExecContext(ctx, "TRUNCATE orders RESTART IDENTITY CASCADE")
+	assert.NoError(t, err)
 }
@@ -2,15 +2,16 @@ package router
 
 import (
 	"context"
-	"fmt"
+	"log/slog"
 	"net/http"
 	"os"
-	"strconv"
 	"strings"
 
+	"github.com/ernestngugi/sil-backend/internal/apperrors"
 	"github.com/ernestngugi/sil-backend/internal/controller"
 	"github.com/ernestngugi/sil-backend/internal/db"
 	"github.com/ernestngugi/sil-backend/internal/forms"
+	"github.com/ernestngugi/sil-backend/internal/health"
 	"github.com/ernestngugi/sil-backend/internal/model"
 	"github.com/ernestngugi/sil-backend/internal/web/auth"
 	"github.com/ernestngugi/sil-backend/providers"
@@ -23,14 +24,17 @@ func customerByName(dB db.DB, customerController controller.CustomerController)
 
 		name := strings.TrimSpace(c.Param("name"))
 		if name == "" {
-			c.JSON(http.StatusBadRequest, gin.H{"success": false})
+			c.Error(apperrors.Validation("invalid_customer_name", "customer name is required", apperrors.FieldError{
+				Field:   "name",
+				Code:    "required",
+				Message: "name is required",
+			}))
 			return
 		}
 
 		customer, err := customerController.CustomerByName(c.Request.Context(), dB, name)
 		if err != nil {
-
-			c.JSON(http.StatusBadRequest, gin.H{"success": false})
+			c.Error(err)
 			return
 		}
 
@@ -43,15 +47,15 @@ func createOrder(dB db.DB, orderController controller.OrderController) func(c *g
 
 		var form forms.CreateOrderForm
 
-		err := c.BindJSON(&form)
+		err := forms.DecodeJSON(c.Request.Body, &form, requestLocale(c))
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"success": false})
+			c.Error(err)
 			return
 		}
 
 		order, err := orderController.CreateOrder(c.Request.Context(), dB, &form)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"success": false})
+			c.Error(err)
 			return
 		}
 
@@ -62,15 +66,15 @@ func createOrder(dB db.DB, orderController controller.OrderController) func(c *g
 func orderByID(dB db.DB, orderController controller.OrderController) func(c *gin.Context) {
 	return func(c *gin.Context) {
 
-		orderID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		orderID, err := idParam(c, "id")
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"success": false})
+			c.Error(err)
 			return
 		}
 
 		order, err := orderController.OrderByID(c.Request.Context(), dB, orderID)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"success": false})
+			c.Error(err)
 			return
 		}
 
@@ -78,13 +82,48 @@ func orderByID(dB db.DB, orderController controller.OrderController) func(c *gin
 	}
 }
 
-func authMiddleware(authAuthenticator auth.Authenticator) gin.HandlerFunc {
+// requestLocale is the language used for validation messages.
+func requestLocale(c *gin.Context) string {
+	return forms.Locale(c.GetHeader("Accept-Language"))
+}
+
+func invalidIDError(field string) *apperrors.Error {
+	return apperrors.Validation("invalid_id", field+" must be a number", apperrors.FieldError{
+		Field:   field,
+		Code:    "number",
+		Message: field + " must be a number",
+	})
+}
+
+func liveness() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"status": health.StatusOK})
+	}
+}
+
+func readiness(appReadiness *health.Readiness) func(c *gin.Context) {
+	return func(c *gin.Context) {
+
+		report := appReadiness.Check(c.Request.Context())
+		if report.Status != health.StatusOK {
+			c.JSON(http.StatusServiceUnavailable, report)
+			return
+		}
+
+		c.JSON(http.StatusOK, report)
+	}
+}
+
+func authMiddleware(authAuthenticator auth.Authenticator, logger *slog.Logger) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx := c.Request.Context()
 
 		userInfo, err := authAuthenticator.TokenFromRequest(ctx, c.Request)
 		if err != nil {
-			fmt.Println("authentication error")
+			logger.WarnContext(ctx, "authentication error", slog.String("error", err.Error()))
+			c.Error(err)
+			c.Abort()
+			return
 		}
 
 		ctx = context.WithValue(ctx, model.CustomerKeyName, userInfo.Email)
@@ -109,7 +148,7 @@ func handleLogin(
 	return func(c *gin.Context) {
 
 		if c.Query("state") != os.Getenv("OAUTH_STATE_STRING") {
-			c.JSON(http.StatusBadRequest, gin.H{"success": false})
+			c.Error(apperrors.Validation("invalid_oauth_state", "oauth state does not match"))
 			return
 		}
 
@@ -117,23 +156,32 @@ func handleLogin(
 
 		token, err := oidcProvider.Exchange(ctx, c.Query("code"))
 		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"success": false})
+			c.Error(apperrors.Unauthorized("invalid_oauth_code", "oauth code exchange failed").Wrap(err))
 			return
 		}
 
 		userInfo, err := oidcProvider.UserInfo(ctx, oauth2.StaticTokenSource(token))
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"success": false})
+			c.Error(apperrors.Unavailable("identity_provider_unavailable", "failed to get user info").Wrap(err))
+			return
+		}
+
+		// Messages are sent in the language the customer signed up with.
+		form := &forms.CustomerCreateForm{Name: userInfo.Email, Locale: requestLocale(c)}
+
+		err = forms.Validate(form, requestLocale(c))
+		if err != nil {
+			c.Error(err)
 			return
 		}
 
-		customer, err := customerController.CreateCustomer(ctx, dB, &forms.CustomerCreateForm{Name: userInfo.Email})
+		customer, err := customerController.CreateCustomer(ctx, dB, form)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"success": false})
+			c.Error(err)
 			return
 		}
 
-		c.Writer.Header().Set("X-SIL-TOKEN", token.AccessToken)
+		c.Writer.Header().Set(auth.TokenHeader, token.AccessToken)
 		c.JSON(http.StatusOK, customer)
 	}
 }
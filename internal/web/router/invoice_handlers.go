@@ -0,0 +1,43 @@
+package router
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/ernestngugi/sil-backend/internal/controller"
+	"github.com/ernestngugi/sil-backend/internal/db"
+	"github.com/ernestngugi/sil-backend/internal/model"
+	"github.com/gin-gonic/gin"
+)
+
+func orderInvoice(dB db.DB, invoiceController controller.InvoiceController) func(c *gin.Context) {
+	return invoicePDF(dB, invoiceController.Invoice)
+}
+
+func orderReceipt(dB db.DB, invoiceController controller.InvoiceController) func(c *gin.Context) {
+	return invoicePDF(dB, invoiceController.Receipt)
+}
+
+func invoicePDF(
+	dB db.DB,
+	document func(ctx context.Context, dB db.DB, orderID int64) (*model.Invoice, []byte, error),
+) func(c *gin.Context) {
+	return func(c *gin.Context) {
+
+		orderID, err := idParam(c, "id")
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		invoice, pdf, err := document(c.Request.Context(), dB, orderID)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.Header("Content-Disposition", fmt.Sprintf(`inline; filename="%v.pdf"`, invoice.Number))
+		c.Data(http.StatusOK, "application/pdf", pdf)
+	}
+}
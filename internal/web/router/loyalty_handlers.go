@@ -0,0 +1,22 @@
+package router
+
+import (
+	"net/http"
+
+	"github.com/ernestngugi/sil-backend/internal/controller"
+	"github.com/ernestngugi/sil-backend/internal/db"
+	"github.com/gin-gonic/gin"
+)
+
+func loyaltyAccount(dB db.DB, loyaltyController controller.LoyaltyController) func(c *gin.Context) {
+	return func(c *gin.Context) {
+
+		account, err := loyaltyController.Account(c.Request.Context(), dB)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, account)
+	}
+}
@@ -0,0 +1,143 @@
+package router
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/ernestngugi/sil-backend/internal/apperrors"
+	"github.com/ernestngugi/sil-backend/internal/logging"
+	"github.com/ernestngugi/sil-backend/internal/metrics"
+	"github.com/ernestngugi/sil-backend/internal/web/auth"
+	"github.com/gin-gonic/gin"
+)
+
+// callbackKeyParameter carries the API key on provider callbacks, which
+// cannot set headers.
+const callbackKeyParameter = "api_key"
+
+// requestIDMiddleware accepts a well-formed X-Request-ID from the client or
+// generates one, echoes it on the response and stores it in the request
+// context so it reaches the database and outbound provider calls.
+func requestIDMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		requestID := c.GetHeader(logging.RequestIDHeader)
+		if !logging.ValidRequestID(requestID) {
+			requestID = logging.NewRequestID()
+		}
+
+		c.Writer.Header().Set(logging.RequestIDHeader, requestID)
+		c.Request = c.Request.WithContext(logging.WithRequestID(c.Request.Context(), requestID))
+		c.Next()
+	}
+}
+
+func requestLogger(logger *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		start := time.Now()
+
+		c.Next()
+
+		status := c.Writer.Status()
+
+		level := slog.LevelInfo
+		switch {
+		case status >= http.StatusInternalServerError:
+			level = slog.LevelError
+		case status >= http.StatusBadRequest:
+			level = slog.LevelWarn
+		}
+
+		logger.Log(
+			c.Request.Context(),
+			level,
+			"http request",
+			slog.String("method", c.Request.Method),
+			slog.String("route", c.FullPath()),
+			slog.String("path", c.Request.URL.Path),
+			slog.Int("status", status),
+			slog.Duration("latency", time.Since(start)),
+			slog.String("client_ip", c.ClientIP()),
+			slog.Int("response_size", c.Writer.Size()),
+		)
+	}
+}
+
+// metricsMiddleware labels requests by route template rather than raw path to
+// keep label cardinality bounded.
+func metricsMiddleware(appMetrics *metrics.Metrics) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		done := appMetrics.HTTPRequestStarted()
+
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+
+		done(c.Request.Method, route, c.Writer.Status())
+	}
+}
+
+func recoveryMiddleware(logger *slog.Logger) gin.HandlerFunc {
+	return gin.CustomRecoveryWithWriter(io.Discard, func(c *gin.Context, recovered any) {
+		logger.ErrorContext(c.Request.Context(), "panic recovered", slog.Any("panic", recovered))
+		c.AbortWithStatus(http.StatusInternalServerError)
+	})
+}
+
+// adminMiddleware admits requests carrying one of the admin API keys.
+func adminMiddleware(adminKeys *auth.APIKeys, logger *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+
+		key := c.GetHeader(auth.APIKeyHeader)
+		if key == "" {
+			c.Error(apperrors.Unauthorized("api_key_missing", "api key not provided"))
+			c.Abort()
+			return
+		}
+
+		client, ok := adminKeys.Client(key)
+		if !ok {
+			logger.WarnContext(ctx, "invalid admin api key")
+			c.Error(apperrors.Unauthorized("invalid_api_key", "api key is invalid"))
+			c.Abort()
+			return
+		}
+
+		logger.InfoContext(ctx, "admin request", slog.String("client", client), slog.String("method", c.Request.Method), slog.String("route", c.FullPath()))
+		c.Next()
+	}
+}
+
+// callbackMiddleware admits provider callbacks carrying one of the API keys in
+// the query string.
+func callbackMiddleware(apiKeys *auth.APIKeys, logger *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+
+		key := c.Query(callbackKeyParameter)
+		if key == "" {
+			c.Error(apperrors.Unauthorized("api_key_missing", "api key not provided"))
+			c.Abort()
+			return
+		}
+
+		client, ok := apiKeys.Client(key)
+		if !ok {
+			logger.WarnContext(ctx, "invalid callback api key")
+			c.Error(apperrors.Unauthorized("invalid_api_key", "api key is invalid"))
+			c.Abort()
+			return
+		}
+
+		logger.InfoContext(ctx, "provider callback", slog.String("client", client), slog.String("route", c.FullPath()))
+		c.Next()
+	}
+}
@@ -0,0 +1,52 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ernestngugi/sil-backend/internal/logging"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestIDMiddleware(t *testing.T) {
+
+	var seen string
+
+	testRouter := gin.New()
+	testRouter.Use(requestIDMiddleware())
+	testRouter.GET("/ping", func(c *gin.Context) {
+		seen = logging.RequestIDFromContext(c.Request.Context())
+		c.Status(http.StatusOK)
+	})
+
+	t.Run("propagates the client request id", func(t *testing.T) {
+
+		w := httptest.NewRecorder()
+
+		req, err := http.NewRequest(http.MethodGet, "/ping", nil)
+		assert.NoError(t, err)
+
+		req.Header.Set(logging.RequestIDHeader, "client-id-1")
+
+		testRouter.ServeHTTP(w, req)
+
+		assert.Equal(t, "client-id-1", w.Header().Get(logging.RequestIDHeader))
+		assert.Equal(t, "client-id-1", seen)
+	})
+
+	t.Run("generates a request id when none is sent", func(t *testing.T) {
+
+		w := httptest.NewRecorder()
+
+		req, err := http.NewRequest(http.MethodGet, "/ping", nil)
+		assert.NoError(t, err)
+
+		testRouter.ServeHTTP(w, req)
+
+		requestID := w.Header().Get(logging.RequestIDHeader)
+		assert.NotEmpty(t, requestID)
+		assert.Equal(t, requestID, seen)
+	})
+}
@@ -0,0 +1,70 @@
+package router
+
+import (
+	"net/http"
+
+	"github.com/ernestngugi/sil-backend/internal/controller"
+	"github.com/ernestngugi/sil-backend/internal/db"
+	"github.com/ernestngugi/sil-backend/internal/forms"
+	"github.com/gin-gonic/gin"
+)
+
+func notificationPreferences(dB db.DB, notificationController controller.NotificationController) func(c *gin.Context) {
+	return func(c *gin.Context) {
+
+		preferences, err := notificationController.Preferences(c.Request.Context(), dB)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, preferences)
+	}
+}
+
+func updateNotificationPreferences(dB db.DB, notificationController controller.NotificationController) func(c *gin.Context) {
+	return func(c *gin.Context) {
+
+		var form forms.UpdateNotificationPreferencesForm
+
+		err := forms.DecodeJSON(c.Request.Body, &form, requestLocale(c))
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		preferences, err := notificationController.UpdatePreferences(c.Request.Context(), dB, &form)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, preferences)
+	}
+}
+
+// smsOptOut handles the form Africa's Talking posts when a number opts out
+// of messages from our sender ID.
+func smsOptOut(dB db.DB, notificationController controller.NotificationController) func(c *gin.Context) {
+	return func(c *gin.Context) {
+
+		form := forms.SMSOptOutForm{
+			SenderID:    c.PostForm("senderId"),
+			PhoneNumber: c.PostForm("phoneNumber"),
+		}
+
+		err := forms.Validate(&form, requestLocale(c))
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		err = notificationController.OptOut(c.Request.Context(), dB, &form)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.Status(http.StatusOK)
+	}
+}
@@ -0,0 +1,1072 @@
+package router
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/ernestngugi/sil-backend/internal/apperrors"
+	"github.com/ernestngugi/sil-backend/internal/forms"
+	"github.com/ernestngugi/sil-backend/internal/health"
+	"github.com/ernestngugi/sil-backend/internal/model"
+	"github.com/ernestngugi/sil-backend/internal/openapi"
+	"github.com/ernestngugi/sil-backend/internal/web/auth"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	apiVersion = "1.0.0"
+
+	tokenSecurityScheme    = "silToken"
+	adminSecurityScheme    = "adminApiKey"
+	callbackSecurityScheme = "callbackApiKey"
+)
+
+// apiSpec describes every route registered by BuildRouter. TestOpenAPISpec
+// fails when a route is added without being documented here.
+func apiSpec() *openapi.Document {
+
+	doc := openapi.New(openapi.Info{
+		Title:       "SIL API",
+		Description: "Customers and orders for the SIL shop. Errors are returned as RFC 7807 problem details with a stable `code`.",
+		Version:     apiVersion,
+	})
+
+	doc.Tags = []openapi.Tag{
+		{Name: "orders", Description: "Placing and looking up orders."},
+		{Name: "customers", Description: "Customer accounts, created on first login."},
+		{Name: "auth", Description: "OpenID Connect login."},
+		{Name: "admin", Description: "Back office operations, authenticated with an admin API key."},
+		{Name: "webhooks", Description: "Webhook subscriptions notified of order events, signed with HMAC-SHA256."},
+		{Name: "notifications", Description: "Messages sent to customers about their orders."},
+		{Name: "payments", Description: "Collecting order payments with M-Pesa STK Push."},
+		{Name: "promotions", Description: "Promo codes customers apply when ordering."},
+		{Name: "loyalty", Description: "Points customers earn on paid orders and spend on new ones."},
+		{Name: "products", Description: "The catalogue customers fill their carts from."},
+		{Name: "cart", Description: "Each customer's cart, kept between sessions until they check out."},
+		{Name: "shipments", Description: "Delivering orders by rider, with tracking and proof of delivery."},
+		{Name: "operations", Description: "Health, metrics and API documentation."},
+	}
+
+	doc.Components.SecuritySchemes[tokenSecurityScheme] = &openapi.SecurityScheme{
+		Type:        "apiKey",
+		In:          "header",
+		Name:        auth.TokenHeader,
+		Description: "Access token returned by the login callback in the " + auth.TokenHeader + " header.",
+	}
+
+	doc.Components.SecuritySchemes[adminSecurityScheme] = &openapi.SecurityScheme{
+		Type:        "apiKey",
+		In:          "header",
+		Name:        auth.APIKeyHeader,
+		Description: "Admin API key configured in ADMIN_API_KEYS.",
+	}
+
+	doc.Components.SecuritySchemes[callbackSecurityScheme] = &openapi.SecurityScheme{
+		Type:        "apiKey",
+		In:          "query",
+		Name:        callbackKeyParameter,
+		Description: "API key configured in API_KEYS, added to callback URLs registered with providers.",
+	}
+
+	authenticated := []openapi.SecurityRequirement{{tokenSecurityScheme: {}}}
+	admin := []openapi.SecurityRequirement{{adminSecurityScheme: {}}}
+	callback := []openapi.SecurityRequirement{{callbackSecurityScheme: {}}}
+
+	order := doc.Schema("Order", model.Order{})
+	orderEvent := doc.Schema("OrderEvent", model.OrderEvent{})
+	customer := doc.Schema("Customer", model.Customer{})
+	createOrderForm := doc.Schema("CreateOrderForm", forms.CreateOrderForm{})
+	healthReport := doc.Schema("HealthReport", health.Report{})
+	doc.Schema("Problem", problem{})
+	subscription := doc.Schema("WebhookSubscription", model.WebhookSubscription{})
+	delivery := doc.Schema("WebhookDelivery", model.WebhookDelivery{})
+
+	doc.AddOperation(http.MethodPost, "/v1/orders", &openapi.Operation{
+		OperationID: "createOrder",
+		Summary:     "Place an order for the authenticated customer",
+		Description: "The customer is notified by SMS or email, as they prefer, once the order is saved. " +
+			"VAT is worked out for the order's tax category and a `promo_code` discount is taken off the amount including VAT, " +
+			"followed by the value of any `loyalty_points` spent. Codes that have reached their usage limit are rejected with 409.",
+		Tags:     []string{"orders"},
+		Security: authenticated,
+		RequestBody: &openapi.RequestBody{
+			Required: true,
+			Content:  openapi.JSONContent(createOrderForm),
+		},
+		Responses: withErrors(map[string]*openapi.Response{
+			"200": jsonResponse("The created order.", order),
+		}, http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound, http.StatusConflict, http.StatusTooManyRequests),
+	})
+
+	doc.AddOperation(http.MethodGet, "/v1/orders/{id}", &openapi.Operation{
+		OperationID: "orderByID",
+		Summary:     "Get an order by ID",
+		Tags:        []string{"orders"},
+		Security:    authenticated,
+		Parameters:  []openapi.Parameter{idPathParameter("id")},
+		Responses: withErrors(map[string]*openapi.Response{
+			"200": jsonResponse("The order.", order),
+		}, http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound, http.StatusTooManyRequests),
+	})
+
+	lastEventID := []openapi.Parameter{
+		{
+			Name:        lastEventIDHeader,
+			In:          "header",
+			Description: "Resume after this event, as sent by reconnecting EventSource clients.",
+			Schema:      &openapi.Schema{Type: "integer", Format: "int64"},
+		},
+		{
+			Name:        "last_event_id",
+			In:          "query",
+			Description: "Resume after this event when the header cannot be set.",
+			Schema:      &openapi.Schema{Type: "integer", Format: "int64"},
+		},
+	}
+
+	eventStream := &openapi.Response{
+		Description: "A stream of `order.created` and `order.status_changed` events whose data is an OrderEvent. " +
+			"Event IDs increase, and comments are sent while the stream is idle.",
+		Content: map[string]*openapi.MediaType{"text/event-stream": {Schema: orderEvent}},
+	}
+
+	doc.AddOperation(http.MethodGet, "/v1/orders/events", &openapi.Operation{
+		OperationID: "orderEvents",
+		Summary:     "Stream status changes of the customer's orders",
+		Tags:        []string{"orders"},
+		Security:    authenticated,
+		Parameters:  lastEventID,
+		Responses: withErrors(map[string]*openapi.Response{
+			"200": eventStream,
+		}, http.StatusBadRequest, http.StatusUnauthorized, http.StatusTooManyRequests),
+	})
+
+	doc.AddOperation(http.MethodGet, "/v1/orders/{id}/events", &openapi.Operation{
+		OperationID: "orderEventsByID",
+		Summary:     "Stream status changes of an order",
+		Tags:        []string{"orders"},
+		Security:    authenticated,
+		Parameters:  append([]openapi.Parameter{idPathParameter("id")}, lastEventID...),
+		Responses: withErrors(map[string]*openapi.Response{
+			"200": eventStream,
+		}, http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound, http.StatusTooManyRequests),
+	})
+
+	doc.AddOperation(http.MethodGet, "/v1/customers/{name}", &openapi.Operation{
+		OperationID: "customerByName",
+		Summary:     "Get a customer by name",
+		Description: "Customer names are the email addresses they signed in with.",
+		Tags:        []string{"customers"},
+		Security:    authenticated,
+		Parameters: []openapi.Parameter{{
+			Name:     "name",
+			In:       "path",
+			Required: true,
+			Schema:   &openapi.Schema{Type: "string"},
+		}},
+		Responses: withErrors(map[string]*openapi.Response{
+			"200": jsonResponse("The customer.", customer),
+		}, http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound, http.StatusTooManyRequests),
+	})
+
+	preferences := doc.Schema("NotificationPreferences", model.NotificationPreferences{})
+
+	doc.AddOperation(http.MethodGet, "/v1/customers/me/notification-preferences", &openapi.Operation{
+		OperationID: "notificationPreferences",
+		Summary:     "Get how the customer is notified about their orders",
+		Tags:        []string{"customers", "notifications"},
+		Security:    authenticated,
+		Responses: withErrors(map[string]*openapi.Response{
+			"200": jsonResponse("The channel for every event and the customer's quiet hours.", preferences),
+		}, http.StatusUnauthorized, http.StatusNotFound, http.StatusTooManyRequests),
+	})
+
+	doc.AddOperation(http.MethodPatch, "/v1/customers/me/notification-preferences", &openapi.Operation{
+		OperationID: "updateNotificationPreferences",
+		Summary:     "Change how the customer is notified about their orders",
+		Description: "Events left out of `channels` keep their channel. Messages that are not urgent, such as dispatch notices, " +
+			"are held until the end of the customer's quiet hours. Send `quiet_hours` without a start and end to turn them off. " +
+			"SMS go to `phone_number`, or to the number the customer last paid from until one is set.",
+		Tags:     []string{"customers", "notifications"},
+		Security: authenticated,
+		RequestBody: &openapi.RequestBody{
+			Required: true,
+			Content:  openapi.JSONContent(doc.Schema("UpdateNotificationPreferencesForm", forms.UpdateNotificationPreferencesForm{})),
+		},
+		Responses: withErrors(map[string]*openapi.Response{
+			"200": jsonResponse("The updated preferences.", preferences),
+		}, http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound, http.StatusTooManyRequests),
+	})
+
+	doc.AddOperation(http.MethodGet, "/v1/customers/me/loyalty", &openapi.Operation{
+		OperationID: "loyaltyAccount",
+		Summary:     "Get the customer's loyalty points",
+		Description: "Points are earned when an order is paid, taken back when it is refunded or cancelled, " +
+			"and expire if they are not spent in time. The history lists the latest ledger entries, newest first.",
+		Tags:     []string{"customers", "loyalty"},
+		Security: authenticated,
+		Responses: withErrors(map[string]*openapi.Response{
+			"200": jsonResponse("The points balance, what it is worth and its history.", doc.Schema("LoyaltyAccount", model.LoyaltyAccount{})),
+		}, http.StatusUnauthorized, http.StatusNotFound, http.StatusTooManyRequests),
+	})
+
+	loginResponse := jsonResponse("The customer that signed in.", customer)
+	loginResponse.Headers = map[string]*openapi.Header{
+		auth.TokenHeader: {
+			Description: "Token to send in the " + auth.TokenHeader + " header of authenticated requests.",
+			Schema:      &openapi.Schema{Type: "string"},
+		},
+	}
+
+	doc.AddOperation(http.MethodPost, "/v1/callback", &openapi.Operation{
+		OperationID: "handleLogin",
+		Summary:     "Complete an OpenID Connect login",
+		Description: "Exchanges the authorization code for a token and creates the customer on first login.",
+		Tags:        []string{"auth"},
+		Parameters: []openapi.Parameter{
+			{Name: "code", In: "query", Required: true, Schema: &openapi.Schema{Type: "string"}},
+			{Name: "state", In: "query", Required: true, Schema: &openapi.Schema{Type: "string"}},
+		},
+		Responses: withErrors(map[string]*openapi.Response{
+			"200": loginResponse,
+		}, http.StatusBadRequest, http.StatusUnauthorized, http.StatusConflict, http.StatusTooManyRequests, http.StatusServiceUnavailable),
+	})
+
+	doc.AddOperation(http.MethodPost, "/v1/callbacks/at/opt-out", &openapi.Operation{
+		OperationID: "smsOptOut",
+		Summary:     "Record an Africa's Talking opt-out",
+		Description: "Africa's Talking posts here when a number replies STOP. No further SMS are sent to the number.",
+		Tags:        []string{"notifications"},
+		Security:    callback,
+		RequestBody: &openapi.RequestBody{
+			Required: true,
+			Content: map[string]*openapi.MediaType{
+				"application/x-www-form-urlencoded": {Schema: doc.Schema("SMSOptOutForm", forms.SMSOptOutForm{})},
+			},
+		},
+		Responses: withErrors(map[string]*openapi.Response{
+			"200": {Description: "The number was added to the suppression list."},
+		}, http.StatusBadRequest, http.StatusUnauthorized, http.StatusTooManyRequests),
+	})
+
+	payment := doc.Schema("Payment", model.Payment{})
+
+	doc.AddOperation(http.MethodPost, "/v1/orders/{id}/payments", &openapi.Operation{
+		OperationID: "createPayment",
+		Summary:     "Ask the customer to pay for an order with M-Pesa",
+		Description: "Sends an STK Push prompting the phone number to pay the order amount, rounded up to whole shillings. " +
+			"The payment stays pending until the customer answers, and fails if they have not answered within a few minutes. " +
+			"Orders that are paid or waiting for a payment cannot be paid again. " +
+			"A 503 `mpesa_unconfirmed` means M-Pesa did not answer in time: the payment stays pending, as the customer may still be prompted.",
+		Tags:       []string{"orders", "payments"},
+		Security:   authenticated,
+		Parameters: []openapi.Parameter{idPathParameter("id")},
+		RequestBody: &openapi.RequestBody{
+			Required: true,
+			Content:  openapi.JSONContent(doc.Schema("CreatePaymentForm", forms.CreatePaymentForm{})),
+		},
+		Responses: withErrors(map[string]*openapi.Response{
+			"202": jsonResponse("The pending payment.", payment),
+		}, http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound, http.StatusConflict, http.StatusTooManyRequests, http.StatusServiceUnavailable),
+	})
+
+	doc.AddOperation(http.MethodGet, "/v1/orders/{id}/payments", &openapi.Operation{
+		OperationID: "orderPayments",
+		Summary:     "List the payments for an order",
+		Tags:        []string{"orders", "payments"},
+		Security:    authenticated,
+		Parameters:  []openapi.Parameter{idPathParameter("id")},
+		Responses: withErrors(map[string]*openapi.Response{
+			"200": jsonResponse("The order's payments, newest first.", &openapi.Schema{Type: "array", Items: payment}),
+		}, http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound, http.StatusTooManyRequests),
+	})
+
+	pdfResponse := func(description string) *openapi.Response {
+		return &openapi.Response{
+			Description: description,
+			Content:     map[string]*openapi.MediaType{"application/pdf": {Schema: &openapi.Schema{Type: "string", Format: "binary"}}},
+		}
+	}
+
+	doc.AddOperation(http.MethodGet, "/v1/orders/{id}/invoice.pdf", &openapi.Operation{
+		OperationID: "orderInvoice",
+		Summary:     "Download the tax invoice for an order",
+		Description: "The invoice is numbered the first time it is downloaded and reads the same every time after. Cancelled orders are not invoiced.",
+		Tags:        []string{"orders", "payments"},
+		Security:    authenticated,
+		Parameters:  []openapi.Parameter{idPathParameter("id")},
+		Responses: withErrors(map[string]*openapi.Response{
+			"200": pdfResponse("The invoice."),
+		}, http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound, http.StatusConflict, http.StatusTooManyRequests),
+	})
+
+	doc.AddOperation(http.MethodGet, "/v1/orders/{id}/receipt.pdf", &openapi.Operation{
+		OperationID: "orderReceipt",
+		Summary:     "Download the receipt for a paid order",
+		Description: "Receipts are issued when the order is paid and show the M-Pesa receipt number. Unpaid orders get a 409.",
+		Tags:        []string{"orders", "payments"},
+		Security:    authenticated,
+		Parameters:  []openapi.Parameter{idPathParameter("id")},
+		Responses: withErrors(map[string]*openapi.Response{
+			"200": pdfResponse("The receipt."),
+		}, http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound, http.StatusConflict, http.StatusTooManyRequests),
+	})
+
+	doc.AddOperation(http.MethodPost, "/v1/webhooks/mpesa/callback", &openapi.Operation{
+		OperationID: "mpesaCallback",
+		Summary:     "Record the result of an M-Pesa STK Push",
+		Description: "M-Pesa posts here once the customer has answered the prompt. A result code of 0 marks the payment and its order paid " +
+			"and the customer is sent a confirmation; any other code fails them. Repeated callbacks are ignored.",
+		Tags:     []string{"payments"},
+		Security: callback,
+		RequestBody: &openapi.RequestBody{
+			Required: true,
+			Content:  openapi.JSONContent(doc.Schema("MPesaCallbackForm", forms.MPesaCallbackForm{})),
+		},
+		Responses: withErrors(map[string]*openapi.Response{
+			"200": jsonResponse("The callback was recorded.", &openapi.Schema{
+				Type: "object",
+				Properties: map[string]*openapi.Schema{
+					"ResultCode": {Type: "integer", Format: "int32"},
+					"ResultDesc": {Type: "string"},
+				},
+			}),
+		}, http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound, http.StatusTooManyRequests),
+	})
+
+	doc.AddOperation(http.MethodPatch, "/v1/admin/orders/{id}/status", &openapi.Operation{
+		OperationID: "updateOrderStatus",
+		Summary:     "Move an order to a new status",
+		Description: "Orders move from pending to confirmed, dispatched and delivered, and can be cancelled until delivered. " +
+			"Subscribers to " + model.EventOrderStatusChanged + " are notified.",
+		Tags:       []string{"admin", "orders"},
+		Security:   admin,
+		Parameters: []openapi.Parameter{idPathParameter("id")},
+		RequestBody: &openapi.RequestBody{
+			Required: true,
+			Content:  openapi.JSONContent(doc.Schema("UpdateOrderStatusForm", forms.UpdateOrderStatusForm{})),
+		},
+		Responses: withErrors(map[string]*openapi.Response{
+			"200": jsonResponse("The updated order.", order),
+		}, http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound, http.StatusConflict, http.StatusTooManyRequests),
+	})
+
+	doc.AddOperation(http.MethodPost, "/v1/admin/orders/{id}/refund", &openapi.Operation{
+		OperationID: "refundOrder",
+		Summary:     "Record that a paid order was refunded",
+		Description: "Record the refund once the money has been returned to the customer. " +
+			"The loyalty points the order earned are taken back, those spent on it are returned, and the customer is notified.",
+		Tags:       []string{"admin", "orders", "loyalty"},
+		Security:   admin,
+		Parameters: []openapi.Parameter{idPathParameter("id")},
+		Responses: withErrors(map[string]*openapi.Response{
+			"200": jsonResponse("The refunded order.", order),
+		}, http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound, http.StatusConflict, http.StatusTooManyRequests),
+	})
+
+	doc.AddOperation(http.MethodPost, "/v1/admin/webhooks", &openapi.Operation{
+		OperationID: "createWebhookSubscription",
+		Summary:     "Subscribe a URL to order events",
+		Description: "The response is the only time the signing secret is returned. One is generated when none is given.",
+		Tags:        []string{"admin", "webhooks"},
+		Security:    admin,
+		RequestBody: &openapi.RequestBody{
+			Required: true,
+			Content:  openapi.JSONContent(doc.Schema("CreateWebhookSubscriptionForm", forms.CreateWebhookSubscriptionForm{})),
+		},
+		Responses: withErrors(map[string]*openapi.Response{
+			"201": jsonResponse("The created subscription, including its secret.", subscription),
+		}, http.StatusBadRequest, http.StatusUnauthorized, http.StatusTooManyRequests),
+	})
+
+	doc.AddOperation(http.MethodGet, "/v1/admin/webhooks", &openapi.Operation{
+		OperationID: "webhookSubscriptions",
+		Summary:     "List webhook subscriptions",
+		Tags:        []string{"admin", "webhooks"},
+		Security:    admin,
+		Responses: withErrors(map[string]*openapi.Response{
+			"200": jsonResponse("Every subscription.", &openapi.Schema{Type: "array", Items: subscription}),
+		}, http.StatusUnauthorized, http.StatusTooManyRequests),
+	})
+
+	doc.AddOperation(http.MethodGet, "/v1/admin/webhooks/{id}", &openapi.Operation{
+		OperationID: "webhookSubscriptionByID",
+		Summary:     "Get a webhook subscription",
+		Tags:        []string{"admin", "webhooks"},
+		Security:    admin,
+		Parameters:  []openapi.Parameter{idPathParameter("id")},
+		Responses: withErrors(map[string]*openapi.Response{
+			"200": jsonResponse("The subscription.", subscription),
+		}, http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound, http.StatusTooManyRequests),
+	})
+
+	doc.AddOperation(http.MethodPatch, "/v1/admin/webhooks/{id}", &openapi.Operation{
+		OperationID: "updateWebhookSubscription",
+		Summary:     "Update or re-enable a webhook subscription",
+		Description: "Only the fields given are changed. Re-activating a subscription disabled after repeated failures resets its failure count.",
+		Tags:        []string{"admin", "webhooks"},
+		Security:    admin,
+		Parameters:  []openapi.Parameter{idPathParameter("id")},
+		RequestBody: &openapi.RequestBody{
+			Required: true,
+			Content:  openapi.JSONContent(doc.Schema("UpdateWebhookSubscriptionForm", forms.UpdateWebhookSubscriptionForm{})),
+		},
+		Responses: withErrors(map[string]*openapi.Response{
+			"200": jsonResponse("The updated subscription.", subscription),
+		}, http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound, http.StatusTooManyRequests),
+	})
+
+	doc.AddOperation(http.MethodDelete, "/v1/admin/webhooks/{id}", &openapi.Operation{
+		OperationID: "deleteWebhookSubscription",
+		Summary:     "Delete a webhook subscription and its delivery log",
+		Tags:        []string{"admin", "webhooks"},
+		Security:    admin,
+		Parameters:  []openapi.Parameter{idPathParameter("id")},
+		Responses: withErrors(map[string]*openapi.Response{
+			"204": {Description: "The subscription was deleted."},
+		}, http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound, http.StatusTooManyRequests),
+	})
+
+	doc.AddOperation(http.MethodGet, "/v1/admin/webhooks/{id}/deliveries", &openapi.Operation{
+		OperationID: "webhookDeliveries",
+		Summary:     "List recent deliveries to a subscription",
+		Tags:        []string{"admin", "webhooks"},
+		Security:    admin,
+		Parameters: []openapi.Parameter{
+			idPathParameter("id"),
+			{
+				Name:   "status",
+				In:     "query",
+				Schema: &openapi.Schema{Type: "string", Enum: []string{"pending", "succeeded", "failed"}},
+			},
+		},
+		Responses: withErrors(map[string]*openapi.Response{
+			"200": jsonResponse("The latest 100 deliveries, newest first.", &openapi.Schema{Type: "array", Items: delivery}),
+		}, http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound, http.StatusTooManyRequests),
+	})
+
+	doc.AddOperation(http.MethodPost, "/v1/admin/webhooks/{id}/deliveries/{delivery_id}/redeliver", &openapi.Operation{
+		OperationID: "redeliverWebhook",
+		Summary:     "Send a delivery again",
+		Description: "Queues a new delivery with the same event ID and payload.",
+		Tags:        []string{"admin", "webhooks"},
+		Security:    admin,
+		Parameters:  []openapi.Parameter{idPathParameter("id"), idPathParameter("delivery_id")},
+		Responses: withErrors(map[string]*openapi.Response{
+			"202": jsonResponse("The queued delivery.", delivery),
+		}, http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound, http.StatusTooManyRequests),
+	})
+
+	smsTemplate := doc.Schema("SMSTemplate", model.SMSTemplate{})
+	smsPreview := doc.Schema("SMSPreview", model.SMSPreview{})
+
+	templateParameters := []openapi.Parameter{
+		{
+			Name:     "event",
+			In:       "path",
+			Required: true,
+			Schema:   &openapi.Schema{Type: "string", Enum: notificationEvents()},
+		},
+		{
+			Name:     "locale",
+			In:       "path",
+			Required: true,
+			Schema:   &openapi.Schema{Type: "string", Enum: model.Locales},
+		},
+	}
+
+	doc.AddOperation(http.MethodGet, "/v1/admin/sms-templates", &openapi.Operation{
+		OperationID: "smsTemplates",
+		Summary:     "List the SMS template used for every event and locale",
+		Tags:        []string{"admin", "notifications"},
+		Security:    admin,
+		Responses: withErrors(map[string]*openapi.Response{
+			"200": jsonResponse("Customised templates, and the built in ones for everything else.", &openapi.Schema{Type: "array", Items: smsTemplate}),
+		}, http.StatusUnauthorized, http.StatusTooManyRequests),
+	})
+
+	doc.AddOperation(http.MethodPut, "/v1/admin/sms-templates/{event}/{locale}", &openapi.Operation{
+		OperationID: "saveSMSTemplate",
+		Summary:     "Customise the SMS template for an event and locale",
+		Description: "Templates use Go text/template syntax with the variables `{{.OrderID}}`, `{{.Item}}` and `{{.Amount}}`. " +
+			"A template that does not render with sample order details is rejected.",
+		Tags:       []string{"admin", "notifications"},
+		Security:   admin,
+		Parameters: templateParameters,
+		RequestBody: &openapi.RequestBody{
+			Required: true,
+			Content:  openapi.JSONContent(doc.Schema("SaveSMSTemplateForm", forms.SaveSMSTemplateForm{})),
+		},
+		Responses: withErrors(map[string]*openapi.Response{
+			"200": jsonResponse("The saved template.", smsTemplate),
+		}, http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound, http.StatusTooManyRequests),
+	})
+
+	doc.AddOperation(http.MethodDelete, "/v1/admin/sms-templates/{event}/{locale}", &openapi.Operation{
+		OperationID: "deleteSMSTemplate",
+		Summary:     "Revert an event and locale to the built in SMS template",
+		Tags:        []string{"admin", "notifications"},
+		Security:    admin,
+		Parameters:  templateParameters,
+		Responses: withErrors(map[string]*openapi.Response{
+			"204": {Description: "The customised template was deleted."},
+		}, http.StatusUnauthorized, http.StatusNotFound, http.StatusTooManyRequests),
+	})
+
+	doc.AddOperation(http.MethodPost, "/v1/admin/sms-templates/preview", &openapi.Operation{
+		OperationID: "previewSMSTemplate",
+		Summary:     "Render an SMS template with sample order details",
+		Description: "Reports the encoding and number of segments the message would be sent in, with warnings for UCS-2 and multi-part messages.",
+		Tags:        []string{"admin", "notifications"},
+		Security:    admin,
+		RequestBody: &openapi.RequestBody{
+			Required: true,
+			Content:  openapi.JSONContent(doc.Schema("PreviewSMSTemplateForm", forms.PreviewSMSTemplateForm{})),
+		},
+		Responses: withErrors(map[string]*openapi.Response{
+			"200": jsonResponse("The rendered message.", smsPreview),
+		}, http.StatusBadRequest, http.StatusUnauthorized, http.StatusTooManyRequests),
+	})
+
+	reconciliationRun := doc.Schema("ReconciliationRun", model.ReconciliationRun{})
+
+	doc.AddOperation(http.MethodGet, "/v1/admin/reconciliations", &openapi.Operation{
+		OperationID: "reconciliationRuns",
+		Summary:     "List imported payment statements",
+		Description: "Statements are imported with `sil-api reconcile`.",
+		Tags:        []string{"admin", "payments"},
+		Security:    admin,
+		Responses: withErrors(map[string]*openapi.Response{
+			"200": jsonResponse("The latest 100 reconciliations, newest first.", &openapi.Schema{Type: "array", Items: reconciliationRun}),
+		}, http.StatusUnauthorized, http.StatusTooManyRequests),
+	})
+
+	doc.AddOperation(http.MethodGet, "/v1/admin/reconciliations/{id}", &openapi.Operation{
+		OperationID: "reconciliationRunByID",
+		Summary:     "Get how many statement transactions were matched",
+		Tags:        []string{"admin", "payments"},
+		Security:    admin,
+		Parameters:  []openapi.Parameter{idPathParameter("id")},
+		Responses: withErrors(map[string]*openapi.Response{
+			"200": jsonResponse("The reconciliation.", reconciliationRun),
+		}, http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound, http.StatusTooManyRequests),
+	})
+
+	doc.AddOperation(http.MethodGet, "/v1/admin/reconciliations/{id}/report.csv", &openapi.Operation{
+		OperationID: "reconciliationReport",
+		Summary:     "Download a reconciliation report",
+		Description: "One row per statement transaction with its status (`matched`, `unmatched`, `duplicate` or `amount_mismatch`), " +
+			"the order and payment it was matched to and a note for finance.",
+		Tags:       []string{"admin", "payments"},
+		Security:   admin,
+		Parameters: []openapi.Parameter{idPathParameter("id")},
+		Responses: withErrors(map[string]*openapi.Response{
+			"200": {
+				Description: "The report as CSV.",
+				Content:     map[string]*openapi.MediaType{"text/csv": {Schema: &openapi.Schema{Type: "string"}}},
+			},
+		}, http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound, http.StatusTooManyRequests),
+	})
+
+	promotion := doc.Schema("Promotion", model.Promotion{})
+
+	doc.AddOperation(http.MethodPost, "/v1/admin/promotions", &openapi.Operation{
+		OperationID: "createPromotion",
+		Summary:     "Create a promo code",
+		Description: "Codes are stored in upper case and matched without regard to case. " +
+			"A promotion naming `items` or `tax_categories` only applies to orders for one of them.",
+		Tags:     []string{"admin", "promotions"},
+		Security: admin,
+		RequestBody: &openapi.RequestBody{
+			Required: true,
+			Content:  openapi.JSONContent(doc.Schema("CreatePromotionForm", forms.CreatePromotionForm{})),
+		},
+		Responses: withErrors(map[string]*openapi.Response{
+			"201": jsonResponse("The created promotion.", promotion),
+		}, http.StatusBadRequest, http.StatusUnauthorized, http.StatusConflict, http.StatusTooManyRequests),
+	})
+
+	doc.AddOperation(http.MethodGet, "/v1/admin/promotions", &openapi.Operation{
+		OperationID: "promotions",
+		Summary:     "List promotions",
+		Tags:        []string{"admin", "promotions"},
+		Security:    admin,
+		Responses: withErrors(map[string]*openapi.Response{
+			"200": jsonResponse("Every promotion, newest first.", &openapi.Schema{Type: "array", Items: promotion}),
+		}, http.StatusUnauthorized, http.StatusTooManyRequests),
+	})
+
+	doc.AddOperation(http.MethodGet, "/v1/admin/promotions/report", &openapi.Operation{
+		OperationID: "promotionReport",
+		Summary:     "Report how often each promotion was redeemed",
+		Description: "Totals the discount given and the amount charged for the orders each promotion was applied to, and how many of them are paid.",
+		Tags:        []string{"admin", "promotions"},
+		Security:    admin,
+		Responses: withErrors(map[string]*openapi.Response{
+			"200": jsonResponse("One row per promotion, newest first.", &openapi.Schema{
+				Type:  "array",
+				Items: doc.Schema("PromotionReport", model.PromotionReport{}),
+			}),
+		}, http.StatusUnauthorized, http.StatusTooManyRequests),
+	})
+
+	doc.AddOperation(http.MethodGet, "/v1/admin/promotions/{id}", &openapi.Operation{
+		OperationID: "promotionByID",
+		Summary:     "Get a promotion",
+		Tags:        []string{"admin", "promotions"},
+		Security:    admin,
+		Parameters:  []openapi.Parameter{idPathParameter("id")},
+		Responses: withErrors(map[string]*openapi.Response{
+			"200": jsonResponse("The promotion.", promotion),
+		}, http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound, http.StatusTooManyRequests),
+	})
+
+	doc.AddOperation(http.MethodPatch, "/v1/admin/promotions/{id}", &openapi.Operation{
+		OperationID: "updatePromotion",
+		Summary:     "Update or deactivate a promotion",
+		Description: "Only the fields given are changed. The code and discount type cannot be changed.",
+		Tags:        []string{"admin", "promotions"},
+		Security:    admin,
+		Parameters:  []openapi.Parameter{idPathParameter("id")},
+		RequestBody: &openapi.RequestBody{
+			Required: true,
+			Content:  openapi.JSONContent(doc.Schema("UpdatePromotionForm", forms.UpdatePromotionForm{})),
+		},
+		Responses: withErrors(map[string]*openapi.Response{
+			"200": jsonResponse("The updated promotion.", promotion),
+		}, http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound, http.StatusTooManyRequests),
+	})
+
+	doc.AddOperation(http.MethodDelete, "/v1/admin/promotions/{id}", &openapi.Operation{
+		OperationID: "deletePromotion",
+		Summary:     "Delete a promotion that was never redeemed",
+		Description: "Redeemed promotions are kept for reporting; deactivate them instead.",
+		Tags:        []string{"admin", "promotions"},
+		Security:    admin,
+		Parameters:  []openapi.Parameter{idPathParameter("id")},
+		Responses: withErrors(map[string]*openapi.Response{
+			"204": {Description: "The promotion was deleted."},
+		}, http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound, http.StatusConflict, http.StatusTooManyRequests),
+	})
+
+	doc.AddOperation(http.MethodGet, "/v1/admin/promotions/{id}/redemptions", &openapi.Operation{
+		OperationID: "promotionRedemptions",
+		Summary:     "List recent redemptions of a promotion",
+		Tags:        []string{"admin", "promotions"},
+		Security:    admin,
+		Parameters:  []openapi.Parameter{idPathParameter("id")},
+		Responses: withErrors(map[string]*openapi.Response{
+			"200": jsonResponse("The latest 100 redemptions, newest first.", &openapi.Schema{
+				Type:  "array",
+				Items: doc.Schema("PromotionRedemption", model.PromotionRedemption{}),
+			}),
+		}, http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound, http.StatusTooManyRequests),
+	})
+
+	product := doc.Schema("Product", model.Product{})
+
+	doc.AddOperation(http.MethodGet, "/v1/products", &openapi.Operation{
+		OperationID: "products",
+		Summary:     "List the products on sale",
+		Tags:        []string{"products"},
+		Security:    authenticated,
+		Responses: withErrors(map[string]*openapi.Response{
+			"200": jsonResponse("The products on sale by name. Prices include VAT.", &openapi.Schema{Type: "array", Items: product}),
+		}, http.StatusUnauthorized, http.StatusTooManyRequests),
+	})
+
+	cart := doc.Schema("Cart", model.Cart{})
+	productIDParameter := []openapi.Parameter{idPathParameter("product_id")}
+
+	doc.AddOperation(http.MethodGet, "/v1/cart", &openapi.Operation{
+		OperationID: "cart",
+		Summary:     "Get the customer's cart",
+		Description: "Lines are priced at the current product price. A line is not `available` once its product is withdrawn or there is too little stock. " +
+			"When the cart's promo code would not be applied at checkout, `promo_code_error` says why.",
+		Tags:     []string{"cart"},
+		Security: authenticated,
+		Responses: withErrors(map[string]*openapi.Response{
+			"200": jsonResponse("The cart, which is empty if nothing was ever added.", cart),
+		}, http.StatusUnauthorized, http.StatusTooManyRequests),
+	})
+
+	doc.AddOperation(http.MethodPost, "/v1/cart/lines", &openapi.Operation{
+		OperationID: "addCartLine",
+		Summary:     "Add a product to the cart",
+		Description: "The quantity is added to any already in the cart. Products that are withdrawn or short of stock are rejected with 409.",
+		Tags:        []string{"cart"},
+		Security:    authenticated,
+		RequestBody: &openapi.RequestBody{
+			Required: true,
+			Content:  openapi.JSONContent(doc.Schema("AddCartLineForm", forms.AddCartLineForm{})),
+		},
+		Responses: withErrors(map[string]*openapi.Response{
+			"200": jsonResponse("The updated cart.", cart),
+		}, http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound, http.StatusConflict, http.StatusTooManyRequests),
+	})
+
+	doc.AddOperation(http.MethodPatch, "/v1/cart/lines/{product_id}", &openapi.Operation{
+		OperationID: "updateCartLine",
+		Summary:     "Change the quantity of a product in the cart",
+		Tags:        []string{"cart"},
+		Security:    authenticated,
+		Parameters:  productIDParameter,
+		RequestBody: &openapi.RequestBody{
+			Required: true,
+			Content:  openapi.JSONContent(doc.Schema("UpdateCartLineForm", forms.UpdateCartLineForm{})),
+		},
+		Responses: withErrors(map[string]*openapi.Response{
+			"200": jsonResponse("The updated cart.", cart),
+		}, http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound, http.StatusConflict, http.StatusTooManyRequests),
+	})
+
+	doc.AddOperation(http.MethodDelete, "/v1/cart/lines/{product_id}", &openapi.Operation{
+		OperationID: "removeCartLine",
+		Summary:     "Remove a product from the cart",
+		Tags:        []string{"cart"},
+		Security:    authenticated,
+		Parameters:  productIDParameter,
+		Responses: withErrors(map[string]*openapi.Response{
+			"200": jsonResponse("The updated cart.", cart),
+		}, http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound, http.StatusTooManyRequests),
+	})
+
+	doc.AddOperation(http.MethodPut, "/v1/cart/promo-code", &openapi.Operation{
+		OperationID: "applyCartPromoCode",
+		Summary:     "Apply a promo code to the cart",
+		Description: "The code is redeemed at checkout. Unknown codes are rejected; a code that does not apply to the cart yet is kept, with the reason in `promo_code_error`.",
+		Tags:        []string{"cart", "promotions"},
+		Security:    authenticated,
+		RequestBody: &openapi.RequestBody{
+			Required: true,
+			Content:  openapi.JSONContent(doc.Schema("ApplyPromoCodeForm", forms.ApplyPromoCodeForm{})),
+		},
+		Responses: withErrors(map[string]*openapi.Response{
+			"200": jsonResponse("The updated cart.", cart),
+		}, http.StatusBadRequest, http.StatusUnauthorized, http.StatusTooManyRequests),
+	})
+
+	doc.AddOperation(http.MethodDelete, "/v1/cart/promo-code", &openapi.Operation{
+		OperationID: "removeCartPromoCode",
+		Summary:     "Remove the promo code from the cart",
+		Tags:        []string{"cart", "promotions"},
+		Security:    authenticated,
+		Responses: withErrors(map[string]*openapi.Response{
+			"200": jsonResponse("The updated cart.", cart),
+		}, http.StatusUnauthorized, http.StatusTooManyRequests),
+	})
+
+	doc.AddOperation(http.MethodPost, "/v1/cart/checkout", &openapi.Operation{
+		OperationID: "checkoutCart",
+		Summary:     "Place an order for the cart",
+		Description: "The order has a line per product, each taxed under its product's category, and the cart's promo code is redeemed against the lines it applies to. " +
+			"Stock is taken when the order is placed and returned if it is cancelled. " +
+			"If the total before `loyalty_points` is no longer `expected_total` the order is rejected with `cart_changed`, as are carts with products withdrawn or short of stock.",
+		Tags:     []string{"cart", "orders"},
+		Security: authenticated,
+		RequestBody: &openapi.RequestBody{
+			Required: true,
+			Content:  openapi.JSONContent(doc.Schema("CheckoutCartForm", forms.CheckoutCartForm{})),
+		},
+		Responses: withErrors(map[string]*openapi.Response{
+			"201": jsonResponse("The created order.", order),
+		}, http.StatusBadRequest, http.StatusUnauthorized, http.StatusConflict, http.StatusTooManyRequests),
+	})
+
+	doc.AddOperation(http.MethodPost, "/v1/admin/products", &openapi.Operation{
+		OperationID: "createProduct",
+		Summary:     "Add a product to the catalogue",
+		Description: "SKUs are stored in upper case and must be unique. The price includes VAT under the product's tax category.",
+		Tags:        []string{"admin", "products"},
+		Security:    admin,
+		RequestBody: &openapi.RequestBody{
+			Required: true,
+			Content:  openapi.JSONContent(doc.Schema("CreateProductForm", forms.CreateProductForm{})),
+		},
+		Responses: withErrors(map[string]*openapi.Response{
+			"201": jsonResponse("The created product.", product),
+		}, http.StatusBadRequest, http.StatusUnauthorized, http.StatusConflict, http.StatusTooManyRequests),
+	})
+
+	doc.AddOperation(http.MethodGet, "/v1/admin/products", &openapi.Operation{
+		OperationID: "adminProducts",
+		Summary:     "List every product",
+		Tags:        []string{"admin", "products"},
+		Security:    admin,
+		Responses: withErrors(map[string]*openapi.Response{
+			"200": jsonResponse("Every product by name, including those withdrawn from sale.", &openapi.Schema{Type: "array", Items: product}),
+		}, http.StatusUnauthorized, http.StatusTooManyRequests),
+	})
+
+	doc.AddOperation(http.MethodGet, "/v1/admin/products/{id}", &openapi.Operation{
+		OperationID: "productByID",
+		Summary:     "Get a product",
+		Tags:        []string{"admin", "products"},
+		Security:    admin,
+		Parameters:  []openapi.Parameter{idPathParameter("id")},
+		Responses: withErrors(map[string]*openapi.Response{
+			"200": jsonResponse("The product.", product),
+		}, http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound, http.StatusTooManyRequests),
+	})
+
+	doc.AddOperation(http.MethodPatch, "/v1/admin/products/{id}", &openapi.Operation{
+		OperationID: "updateProduct",
+		Summary:     "Update, restock or withdraw a product",
+		Description: "Only the fields given are changed. `stock` replaces the count on hand. Carts always show the current price.",
+		Tags:        []string{"admin", "products"},
+		Security:    admin,
+		Parameters:  []openapi.Parameter{idPathParameter("id")},
+		RequestBody: &openapi.RequestBody{
+			Required: true,
+			Content:  openapi.JSONContent(doc.Schema("UpdateProductForm", forms.UpdateProductForm{})),
+		},
+		Responses: withErrors(map[string]*openapi.Response{
+			"200": jsonResponse("The updated product.", product),
+		}, http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound, http.StatusTooManyRequests),
+	})
+
+	shipment := doc.Schema("Shipment", model.Shipment{})
+
+	doc.AddOperation(http.MethodGet, "/v1/orders/{id}/tracking", &openapi.Operation{
+		OperationID: "orderTracking",
+		Summary:     "Track the delivery of an order",
+		Description: "`shipment` is left out until a rider is assigned. Its events are oldest first.",
+		Tags:        []string{"orders", "shipments"},
+		Security:    authenticated,
+		Parameters:  []openapi.Parameter{idPathParameter("id")},
+		Responses: withErrors(map[string]*openapi.Response{
+			"200": jsonResponse("Where the order is.", doc.Schema("OrderTracking", model.OrderTracking{})),
+		}, http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound, http.StatusTooManyRequests),
+	})
+
+	doc.AddOperation(http.MethodPost, "/v1/admin/orders/{id}/shipment", &openapi.Operation{
+		OperationID: "assignShipment",
+		Summary:     "Assign a rider to deliver an order",
+		Description: "Confirmed orders are dispatched, and orders that are not confirmed or dispatched are rejected with 409, as are orders that already have a shipment. " +
+			"The customer is texted the rider's details and a six digit code to give the rider as proof of delivery, whatever their notification preferences. " +
+			"Orders of customers with no phone number are rejected with 409 `customer_phone_missing`.",
+		Tags:       []string{"admin", "shipments"},
+		Security:   admin,
+		Parameters: []openapi.Parameter{idPathParameter("id")},
+		RequestBody: &openapi.RequestBody{
+			Required: true,
+			Content:  openapi.JSONContent(doc.Schema("AssignShipmentForm", forms.AssignShipmentForm{})),
+		},
+		Responses: withErrors(map[string]*openapi.Response{
+			"201": jsonResponse("The created shipment.", shipment),
+		}, http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound, http.StatusConflict, http.StatusTooManyRequests),
+	})
+
+	doc.AddOperation(http.MethodGet, "/v1/admin/shipments/{id}", &openapi.Operation{
+		OperationID: "shipmentByID",
+		Summary:     "Get a shipment",
+		Tags:        []string{"admin", "shipments"},
+		Security:    admin,
+		Parameters:  []openapi.Parameter{idPathParameter("id")},
+		Responses: withErrors(map[string]*openapi.Response{
+			"200": jsonResponse("The shipment with its events, oldest first.", shipment),
+		}, http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound, http.StatusTooManyRequests),
+	})
+
+	doc.AddOperation(http.MethodPatch, "/v1/admin/shipments/{id}", &openapi.Operation{
+		OperationID: "updateShipment",
+		Summary:     "Update a shipment or change its rider",
+		Description: "Only the fields given are changed. When the rider changes, or `resend_code` is set, the customer is sent a new delivery code " +
+			"and the old one stops working. Delivered shipments, and new codes for customers with no phone number, are rejected with 409.",
+		Tags:       []string{"admin", "shipments"},
+		Security:   admin,
+		Parameters: []openapi.Parameter{idPathParameter("id")},
+		RequestBody: &openapi.RequestBody{
+			Required: true,
+			Content:  openapi.JSONContent(doc.Schema("UpdateShipmentForm", forms.UpdateShipmentForm{})),
+		},
+		Responses: withErrors(map[string]*openapi.Response{
+			"200": jsonResponse("The updated shipment.", shipment),
+		}, http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound, http.StatusConflict, http.StatusTooManyRequests),
+	})
+
+	doc.AddOperation(http.MethodPost, "/v1/admin/shipments/{id}/events", &openapi.Operation{
+		OperationID: "addShipmentEvent",
+		Summary:     "Record a step of a shipment's journey",
+		Description: "The shipment moves to the event's status. Delivered shipments are rejected with 409.",
+		Tags:        []string{"admin", "shipments"},
+		Security:    admin,
+		Parameters:  []openapi.Parameter{idPathParameter("id")},
+		RequestBody: &openapi.RequestBody{
+			Required: true,
+			Content:  openapi.JSONContent(doc.Schema("AddShipmentEventForm", forms.AddShipmentEventForm{})),
+		},
+		Responses: withErrors(map[string]*openapi.Response{
+			"200": jsonResponse("The updated shipment.", shipment),
+		}, http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound, http.StatusConflict, http.StatusTooManyRequests),
+	})
+
+	doc.AddOperation(http.MethodPost, "/v1/admin/shipments/{id}/deliver", &openapi.Operation{
+		OperationID: "confirmDelivery",
+		Summary:     "Record proof of delivery",
+		Description: "The `delivery_code` must be the one sent to the customer, and a wrong code is rejected with `invalid_delivery_code`. " +
+			"After five wrong codes the shipment is rejected with `delivery_code_attempts_exceeded` until the customer is sent a new code. " +
+			"The order is marked delivered, subscribers to " + model.EventOrderStatusChanged + " are notified and so is the customer.",
+		Tags:       []string{"admin", "shipments"},
+		Security:   admin,
+		Parameters: []openapi.Parameter{idPathParameter("id")},
+		RequestBody: &openapi.RequestBody{
+			Required: true,
+			Content:  openapi.JSONContent(doc.Schema("ConfirmDeliveryForm", forms.ConfirmDeliveryForm{})),
+		},
+		Responses: withErrors(map[string]*openapi.Response{
+			"200": jsonResponse("The delivered shipment.", shipment),
+		}, http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound, http.StatusConflict, http.StatusTooManyRequests),
+	})
+
+	doc.AddOperation(http.MethodGet, "/healthz", &openapi.Operation{
+		OperationID: "liveness",
+		Summary:     "Liveness probe",
+		Tags:        []string{"operations"},
+		Responses: map[string]*openapi.Response{
+			"200": jsonResponse("The process is up.", &openapi.Schema{
+				Type:       "object",
+				Properties: map[string]*openapi.Schema{"status": {Type: "string", Enum: []string{health.StatusOK}}},
+				Required:   []string{"status"},
+			}),
+		},
+	})
+
+	doc.AddOperation(http.MethodGet, "/readyz", &openapi.Operation{
+		OperationID: "readiness",
+		Summary:     "Readiness probe",
+		Tags:        []string{"operations"},
+		Responses: map[string]*openapi.Response{
+			"200": jsonResponse("Every dependency is reachable.", healthReport),
+			"503": jsonResponse("A dependency is failing or the server is shutting down.", healthReport),
+		},
+	})
+
+	doc.AddOperation(http.MethodGet, "/metrics", &openapi.Operation{
+		OperationID: "metrics",
+		Summary:     "Prometheus metrics",
+		Tags:        []string{"operations"},
+		Responses: map[string]*openapi.Response{
+			"200": {
+				Description: "Metrics in the Prometheus text exposition format.",
+				Content:     map[string]*openapi.MediaType{"text/plain": {Schema: &openapi.Schema{Type: "string"}}},
+			},
+		},
+	})
+
+	doc.AddOperation(http.MethodGet, "/openapi.json", &openapi.Operation{
+		OperationID: "openAPISpec",
+		Summary:     "This document",
+		Tags:        []string{"operations"},
+		Responses: map[string]*openapi.Response{
+			"200": jsonResponse("The OpenAPI document.", &openapi.Schema{Type: "object"}),
+		},
+	})
+
+	doc.AddOperation(http.MethodGet, "/docs", &openapi.Operation{
+		OperationID: "apiDocs",
+		Summary:     "Swagger UI for this document",
+		Tags:        []string{"operations"},
+		Responses: map[string]*openapi.Response{
+			"200": {
+				Description: "An HTML page.",
+				Content:     map[string]*openapi.MediaType{"text/html": {Schema: &openapi.Schema{Type: "string"}}},
+			},
+		},
+	})
+
+	return doc
+}
+
+func notificationEvents() []string {
+
+	events := make([]string, 0, len(model.NotificationEvents))
+
+	for _, event := range model.NotificationEvents {
+		events = append(events, string(event))
+	}
+
+	return events
+}
+
+func idPathParameter(name string) openapi.Parameter {
+	return openapi.Parameter{
+		Name:     name,
+		In:       "path",
+		Required: true,
+		Schema:   &openapi.Schema{Type: "integer", Format: "int64"},
+	}
+}
+
+func jsonResponse(description string, schema *openapi.Schema) *openapi.Response {
+	return &openapi.Response{
+		Description: description,
+		Content:     openapi.JSONContent(schema),
+	}
+}
+
+// withErrors adds problem+json responses for statuses, plus the internal
+// error every route can return.
+func withErrors(responses map[string]*openapi.Response, statuses ...int) map[string]*openapi.Response {
+
+	for _, status := range append(statuses, apperrors.KindInternal.HTTPStatus()) {
+
+		response := &openapi.Response{
+			Description: http.StatusText(status),
+			Content:     map[string]*openapi.MediaType{problemContentType: {Schema: openapi.Ref("Problem")}},
+		}
+
+		if status == http.StatusTooManyRequests {
+			response.Headers = map[string]*openapi.Header{
+				retryAfterHeader: {
+					Description: "Seconds until the request would be allowed.",
+					Schema:      &openapi.Schema{Type: "integer"},
+				},
+			}
+		}
+
+		responses[strconv.Itoa(status)] = response
+	}
+
+	return responses
+}
+
+func openAPISpec(doc *openapi.Document) func(c *gin.Context) {
+
+	body, err := json.Marshal(doc)
+	if err != nil {
+		panic(err)
+	}
+
+	return func(c *gin.Context) {
+		c.Data(http.StatusOK, "application/json", body)
+	}
+}
+
+// swaggerUI serves Swagger UI from a CDN pointed at /openapi.json, which keeps
+// its assets out of the binary.
+func swaggerUI() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(swaggerUIPage))
+	}
+}
+
+const swaggerUIPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>SIL API</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5.17.14/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@5.17.14/swagger-ui-bundle.js" crossorigin></script>
+  <script>
+    window.onload = () => {
+      window.ui = SwaggerUIBundle({ url: "/openapi.json", dom_id: "#swagger-ui" });
+    };
+  </script>
+</body>
+</html>
+`
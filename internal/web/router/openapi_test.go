@@ -0,0 +1,63 @@
+package router
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	"github.com/ernestngugi/sil-backend/internal/health"
+	"github.com/ernestngugi/sil-backend/internal/logging"
+	"github.com/ernestngugi/sil-backend/internal/metrics"
+	"github.com/ernestngugi/sil-backend/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+var ginPathParam = regexp.MustCompile(`[:*]([^/]+)`)
+
+func TestOpenAPISpec(t *testing.T) {
+
+	appRouter := BuildRouter(nil, nil, nil, nil, nil, nil, nil, mocks.NewMockOpenID(), health.NewReadiness(), metrics.New(), nil, nil, nil, logging.Discard())
+
+	doc := apiSpec()
+
+	t.Run("documents every registered route", func(t *testing.T) {
+
+		for _, route := range appRouter.Routes() {
+			path := ginPathParam.ReplaceAllString(route.Path, "{$1}")
+			assert.True(t, doc.HasOperation(route.Method, path), "%s %s is missing from the OpenAPI spec", route.Method, path)
+		}
+	})
+
+	t.Run("serves the spec", func(t *testing.T) {
+
+		w := httptest.NewRecorder()
+
+		req, err := http.NewRequest(http.MethodGet, "/openapi.json", nil)
+		assert.NoError(t, err)
+
+		appRouter.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var body map[string]any
+
+		err = json.Unmarshal(w.Body.Bytes(), &body)
+		assert.NoError(t, err)
+		assert.Equal(t, "3.1.0", body["openapi"])
+	})
+
+	t.Run("serves swagger ui", func(t *testing.T) {
+
+		w := httptest.NewRecorder()
+
+		req, err := http.NewRequest(http.MethodGet, "/docs", nil)
+		assert.NoError(t, err)
+
+		appRouter.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "/openapi.json")
+	})
+}
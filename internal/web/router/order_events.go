@@ -0,0 +1,181 @@
+package router
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ernestngugi/sil-backend/internal/apperrors"
+	"github.com/ernestngugi/sil-backend/internal/controller"
+	"github.com/ernestngugi/sil-backend/internal/db"
+	"github.com/ernestngugi/sil-backend/internal/model"
+	"github.com/ernestngugi/sil-backend/internal/orderevents"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	lastEventIDHeader = "Last-Event-ID"
+
+	// orderEventHeartbeat is how often an idle stream sends a comment, well
+	// inside the idle timeouts of common proxies and load balancers.
+	orderEventHeartbeat = 15 * time.Second
+
+	// orderEventBatchSize bounds how many events are read per query while a
+	// stream catches up.
+	orderEventBatchSize = 100
+
+	// orderEventRetry is the reconnection delay suggested to clients.
+	orderEventRetry = 3 * time.Second
+)
+
+// orderEvents streams the status changes of the signed in customer's orders,
+// or of the order in the id parameter, as Server-Sent Events. Each event's ID
+// is its position in the order_events table, and events commit in ID order,
+// so a client reconnecting with Last-Event-ID receives exactly the events it
+// missed.
+func orderEvents(
+	dB db.DB,
+	orderController controller.OrderController,
+	broker orderevents.Broker,
+	heartbeat time.Duration,
+	logger *slog.Logger,
+) func(c *gin.Context) {
+	return func(c *gin.Context) {
+
+		ctx := c.Request.Context()
+
+		var orderID int64
+
+		if c.Param("id") != "" {
+
+			var err error
+
+			orderID, err = idParam(c, "id")
+			if err != nil {
+				c.Error(err)
+				return
+			}
+		}
+
+		lastEventID, err := lastEventID(c)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		filter, err := orderController.OrderEventFilter(ctx, dB, orderID)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		// Subscribe before the first read so that an event committed in
+		// between still wakes the stream.
+		subscription := broker.Subscribe(filter.CustomerID)
+		defer subscription.Close()
+
+		// Streams outlive the server's write timeout. Writers that cannot
+		// clear it, such as test recorders, have no deadline to clear.
+		http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{})
+
+		header := c.Writer.Header()
+		header.Set("Content-Type", "text/event-stream")
+		header.Set("Cache-Control", "no-cache")
+		header.Set("Connection", "keep-alive")
+		header.Set("X-Accel-Buffering", "no")
+
+		c.Status(http.StatusOK)
+		fmt.Fprintf(c.Writer, "retry: %d\n\n", orderEventRetry.Milliseconds())
+
+		ticker := time.NewTicker(heartbeat)
+		defer ticker.Stop()
+
+		for {
+			for {
+				events, err := orderController.OrderEvents(ctx, dB, filter, lastEventID, orderEventBatchSize)
+				if err != nil {
+					// The client reconnects and resumes from the
+					// last event it received.
+					logger.ErrorContext(ctx, "failed to read order events", slog.String("error", err.Error()))
+					return
+				}
+
+				for _, event := range events {
+
+					err := writeOrderEvent(c.Writer, event)
+					if err != nil {
+						return
+					}
+
+					lastEventID = event.ID
+				}
+
+				if len(events) < orderEventBatchSize {
+					break
+				}
+			}
+
+			c.Writer.Flush()
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-subscription.Done:
+				return
+			case <-subscription.C:
+			case <-ticker.C:
+				_, err := io.WriteString(c.Writer, ": heartbeat\n\n")
+				if err != nil {
+					return
+				}
+			}
+		}
+	}
+}
+
+// lastEventID is where a stream resumes: the Last-Event-ID header sent by
+// reconnecting EventSource clients, or the last_event_id query parameter for
+// clients resuming a stream themselves.
+func lastEventID(c *gin.Context) (int64, error) {
+
+	value := c.GetHeader(lastEventIDHeader)
+	if value == "" {
+		value = c.Query("last_event_id")
+	}
+
+	if value == "" {
+		return 0, nil
+	}
+
+	id, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || id < 0 {
+		return 0, apperrors.Validation("invalid_last_event_id", "last event id must be a number", apperrors.FieldError{
+			Field:   "last_event_id",
+			Code:    "number",
+			Message: "last event id must be a number",
+		})
+	}
+
+	return id, nil
+}
+
+func writeOrderEvent(w io.Writer, event *model.OrderEvent) error {
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	eventType := model.EventOrderStatusChanged
+	if event.PreviousStatus == "" {
+		eventType = model.EventOrderCreated
+	}
+
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, eventType, data)
+
+	return err
+}
@@ -0,0 +1,182 @@
+package router
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ernestngugi/sil-backend/internal/apperrors"
+	"github.com/ernestngugi/sil-backend/internal/controller"
+	"github.com/ernestngugi/sil-backend/internal/db"
+	"github.com/ernestngugi/sil-backend/internal/logging"
+	"github.com/ernestngugi/sil-backend/internal/model"
+	"github.com/ernestngugi/sil-backend/internal/orderevents"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrderEvents(t *testing.T) {
+
+	logger := logging.Discard()
+
+	orderController := &fakeOrderEventController{
+		customerID: 1,
+		orderIDs:   map[int64]bool{5: true},
+	}
+
+	broker := orderevents.NewBroker("", logger)
+
+	testRouter := gin.New()
+	testRouter.Use(errorMiddleware(logger))
+	testRouter.GET("/orders/events", orderEvents(nil, orderController, broker, 10*time.Millisecond, logger))
+	testRouter.GET("/orders/:id/events", orderEvents(nil, orderController, broker, 10*time.Millisecond, logger))
+
+	server := httptest.NewServer(testRouter)
+	defer server.Close()
+
+	t.Run("resumes after the last event and streams new ones", func(t *testing.T) {
+
+		orderController.add(&model.OrderEvent{ID: 1, OrderID: 5, CustomerID: 1, Status: model.OrderStatusPending})
+		orderController.add(&model.OrderEvent{ID: 2, OrderID: 5, CustomerID: 1, Status: model.OrderStatusConfirmed, PreviousStatus: model.OrderStatusPending})
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/orders/5/events", nil)
+		assert.NoError(t, err)
+
+		req.Header.Set(lastEventIDHeader, "1")
+
+		res, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, "text/event-stream", res.Header.Get("Content-Type"))
+
+		stream := bufio.NewScanner(res.Body)
+
+		event := nextEvent(t, stream)
+		assert.Equal(t, "2", event["id"])
+		assert.Equal(t, model.EventOrderStatusChanged, event["event"])
+
+		var data model.OrderEvent
+		assert.NoError(t, json.Unmarshal([]byte(event["data"]), &data))
+		assert.Equal(t, model.OrderStatusConfirmed, data.Status)
+		assert.Equal(t, model.OrderStatusPending, data.PreviousStatus)
+
+		orderController.add(&model.OrderEvent{ID: 3, OrderID: 5, CustomerID: 1, Status: model.OrderStatusDispatched, PreviousStatus: model.OrderStatusConfirmed})
+
+		event = nextEvent(t, stream)
+		assert.Equal(t, "3", event["id"])
+
+		heartbeat := false
+		for !heartbeat && stream.Scan() {
+			heartbeat = stream.Text() == ": heartbeat"
+		}
+		assert.True(t, heartbeat, "sends heartbeats while idle")
+	})
+
+	t.Run("rejects an invalid last event id", func(t *testing.T) {
+
+		w := httptest.NewRecorder()
+
+		req, err := http.NewRequest(http.MethodGet, "/orders/events?last_event_id=abc", nil)
+		assert.NoError(t, err)
+
+		testRouter.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("does not stream other customers' orders", func(t *testing.T) {
+
+		w := httptest.NewRecorder()
+
+		req, err := http.NewRequest(http.MethodGet, "/orders/6/events", nil)
+		assert.NoError(t, err)
+
+		testRouter.ServeHTTP(w, req)
+
+		var body problem
+
+		err = json.Unmarshal(w.Body.Bytes(), &body)
+		assert.NoError(t, err)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Equal(t, "order_not_found", body.Code)
+	})
+}
+
+// nextEvent reads lines up to the next event, skipping comments.
+func nextEvent(t *testing.T, stream *bufio.Scanner) map[string]string {
+
+	event := make(map[string]string)
+
+	for stream.Scan() {
+
+		line := stream.Text()
+
+		if line == "" {
+			if _, ok := event["id"]; ok {
+				return event
+			}
+			continue
+		}
+
+		field, value, _ := strings.Cut(line, ": ")
+		event[field] = value
+	}
+
+	t.Fatalf("stream ended before the next event: %v", stream.Err())
+
+	return event
+}
+
+// fakeOrderEventController serves events from memory.
+type fakeOrderEventController struct {
+	controller.OrderController
+
+	customerID int64
+	orderIDs   map[int64]bool
+
+	mu     sync.Mutex
+	events []*model.OrderEvent
+}
+
+func (c *fakeOrderEventController) add(event *model.OrderEvent) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.events = append(c.events, event)
+}
+
+func (c *fakeOrderEventController) OrderEventFilter(ctx context.Context, dB db.DB, orderID int64) (*model.OrderEventFilter, error) {
+
+	if orderID != 0 && !c.orderIDs[orderID] {
+		return &model.OrderEventFilter{}, apperrors.NotFound("order_not_found", "order not found")
+	}
+
+	return &model.OrderEventFilter{CustomerID: c.customerID, OrderID: orderID}, nil
+}
+
+func (c *fakeOrderEventController) OrderEvents(ctx context.Context, dB db.DB, filter *model.OrderEventFilter, afterID int64, limit int) ([]*model.OrderEvent, error) {
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	events := make([]*model.OrderEvent, 0)
+
+	for _, event := range c.events {
+		if event.ID > afterID && (filter.OrderID == 0 || event.OrderID == filter.OrderID) && len(events) < limit {
+			events = append(events, event)
+		}
+	}
+
+	return events, nil
+}
@@ -0,0 +1,101 @@
+package router
+
+import (
+	"net/http"
+
+	"github.com/ernestngugi/sil-backend/internal/controller"
+	"github.com/ernestngugi/sil-backend/internal/db"
+	"github.com/ernestngugi/sil-backend/internal/forms"
+	"github.com/gin-gonic/gin"
+)
+
+// mpesaCallbackAccepted tells M-Pesa the callback was received.
+var mpesaCallbackAccepted = gin.H{"ResultCode": 0, "ResultDesc": "Accepted"}
+
+func createPayment(dB db.DB, paymentController controller.PaymentController) func(c *gin.Context) {
+	return func(c *gin.Context) {
+
+		orderID, err := idParam(c, "id")
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		var form forms.CreatePaymentForm
+
+		err = forms.DecodeJSON(c.Request.Body, &form, requestLocale(c))
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		payment, err := paymentController.CreatePayment(c.Request.Context(), dB, orderID, &form)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusAccepted, payment)
+	}
+}
+
+func orderPayments(dB db.DB, paymentController controller.PaymentController) func(c *gin.Context) {
+	return func(c *gin.Context) {
+
+		orderID, err := idParam(c, "id")
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		payments, err := paymentController.Payments(c.Request.Context(), dB, orderID)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, payments)
+	}
+}
+
+// mpesaCallback records the result M-Pesa posts once the customer has
+// answered an STK Push.
+func mpesaCallback(dB db.DB, paymentController controller.PaymentController) func(c *gin.Context) {
+	return func(c *gin.Context) {
+
+		var form forms.MPesaCallbackForm
+
+		err := forms.DecodeCallbackJSON(c.Request.Body, &form, requestLocale(c))
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		err = paymentController.MPesaCallback(c.Request.Context(), dB, &form)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, mpesaCallbackAccepted)
+	}
+}
+
+func refundOrder(dB db.DB, orderController controller.OrderController) func(c *gin.Context) {
+	return func(c *gin.Context) {
+
+		orderID, err := idParam(c, "id")
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		order, err := orderController.RefundOrder(c.Request.Context(), dB, orderID)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, order)
+	}
+}
@@ -0,0 +1,63 @@
+package router
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/ernestngugi/sil-backend/internal/apperrors"
+	"github.com/ernestngugi/sil-backend/internal/logging"
+	"github.com/gin-gonic/gin"
+)
+
+const problemContentType = "application/problem+json"
+
+// problem is an RFC 7807 problem details body extended with a stable error
+// code, per-field errors and the request ID for support queries.
+type problem struct {
+	Type      string                 `json:"type"`
+	Title     string                 `json:"title"`
+	Status    int                    `json:"status"`
+	Detail    string                 `json:"detail"`
+	Instance  string                 `json:"instance"`
+	Code      string                 `json:"code"`
+	RequestID string                 `json:"request_id,omitempty"`
+	Errors    []apperrors.FieldError `json:"errors,omitempty"`
+}
+
+// errorMiddleware renders the last error a handler attached with c.Error as
+// problem+json, unless the handler already wrote a response.
+func errorMiddleware(logger *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		c.Next()
+
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+
+		renderError(c, logger, c.Errors.Last().Err)
+	}
+}
+
+func renderError(c *gin.Context, logger *slog.Logger, err error) {
+
+	appErr := apperrors.From(err)
+	status := appErr.Kind.HTTPStatus()
+	ctx := c.Request.Context()
+
+	if status >= http.StatusInternalServerError {
+		logger.ErrorContext(ctx, "request failed", slog.String("code", appErr.Code), slog.Any("error", err))
+	}
+
+	c.Header("Content-Type", problemContentType)
+	c.AbortWithStatusJSON(status, &problem{
+		Type:      "about:blank",
+		Title:     http.StatusText(status),
+		Status:    status,
+		Detail:    appErr.Message,
+		Instance:  c.Request.URL.Path,
+		Code:      appErr.Code,
+		RequestID: logging.RequestIDFromContext(ctx),
+		Errors:    appErr.Fields,
+	})
+}
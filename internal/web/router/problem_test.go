@@ -0,0 +1,80 @@
+package router
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ernestngugi/sil-backend/internal/apperrors"
+	"github.com/ernestngugi/sil-backend/internal/logging"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorMiddleware(t *testing.T) {
+
+	logger := logging.Discard()
+
+	testRouter := gin.New()
+	testRouter.Use(requestIDMiddleware(), errorMiddleware(logger))
+
+	testRouter.GET("/missing", func(c *gin.Context) {
+		c.Error(apperrors.NotFound("order_not_found", "order not found"))
+	})
+	testRouter.GET("/invalid", func(c *gin.Context) {
+		c.Error(invalidIDError("id"))
+	})
+	testRouter.GET("/broken", func(c *gin.Context) {
+		c.Error(errors.New("connection reset by peer"))
+	})
+
+	request := func(path string) (*httptest.ResponseRecorder, *problem) {
+
+		w := httptest.NewRecorder()
+
+		req, err := http.NewRequest(http.MethodGet, path, nil)
+		assert.NoError(t, err)
+
+		testRouter.ServeHTTP(w, req)
+
+		var body problem
+
+		err = json.Unmarshal(w.Body.Bytes(), &body)
+		assert.NoError(t, err)
+
+		return w, &body
+	}
+
+	t.Run("renders not found errors", func(t *testing.T) {
+
+		w, body := request("/missing")
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Equal(t, problemContentType, w.Header().Get("Content-Type"))
+		assert.Equal(t, "order_not_found", body.Code)
+		assert.Equal(t, "order not found", body.Detail)
+		assert.Equal(t, "/missing", body.Instance)
+		assert.Equal(t, w.Header().Get(logging.RequestIDHeader), body.RequestID)
+	})
+
+	t.Run("renders field errors", func(t *testing.T) {
+
+		w, body := request("/invalid")
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, "invalid_id", body.Code)
+		assert.Len(t, body.Errors, 1)
+		assert.Equal(t, "id", body.Errors[0].Field)
+	})
+
+	t.Run("does not leak internal errors", func(t *testing.T) {
+
+		w, body := request("/broken")
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		assert.Equal(t, "internal_error", body.Code)
+		assert.NotContains(t, w.Body.String(), "connection reset")
+	})
+}
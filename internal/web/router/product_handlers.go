@@ -0,0 +1,92 @@
+package router
+
+import (
+	"net/http"
+
+	"github.com/ernestngugi/sil-backend/internal/controller"
+	"github.com/ernestngugi/sil-backend/internal/db"
+	"github.com/ernestngugi/sil-backend/internal/forms"
+	"github.com/gin-gonic/gin"
+)
+
+// listProducts returns the catalogue. Customers see only the products on
+// sale; admins see every product.
+func listProducts(dB db.DB, productController controller.ProductController, all bool) func(c *gin.Context) {
+	return func(c *gin.Context) {
+
+		products, err := productController.Products(c.Request.Context(), dB, all)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, products)
+	}
+}
+
+func createProduct(dB db.DB, productController controller.ProductController) func(c *gin.Context) {
+	return func(c *gin.Context) {
+
+		var form forms.CreateProductForm
+
+		err := forms.DecodeJSON(c.Request.Body, &form, requestLocale(c))
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		product, err := productController.CreateProduct(c.Request.Context(), dB, &form)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusCreated, product)
+	}
+}
+
+func productByID(dB db.DB, productController controller.ProductController) func(c *gin.Context) {
+	return func(c *gin.Context) {
+
+		productID, err := idParam(c, "id")
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		product, err := productController.ProductByID(c.Request.Context(), dB, productID)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, product)
+	}
+}
+
+func updateProduct(dB db.DB, productController controller.ProductController) func(c *gin.Context) {
+	return func(c *gin.Context) {
+
+		productID, err := idParam(c, "id")
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		var form forms.UpdateProductForm
+
+		err = forms.DecodeJSON(c.Request.Body, &form, requestLocale(c))
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		product, err := productController.UpdateProduct(c.Request.Context(), dB, productID, &form)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, product)
+	}
+}
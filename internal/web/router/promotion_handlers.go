@@ -0,0 +1,141 @@
+package router
+
+import (
+	"net/http"
+
+	"github.com/ernestngugi/sil-backend/internal/controller"
+	"github.com/ernestngugi/sil-backend/internal/db"
+	"github.com/ernestngugi/sil-backend/internal/forms"
+	"github.com/gin-gonic/gin"
+)
+
+func createPromotion(dB db.DB, promotionController controller.PromotionController) func(c *gin.Context) {
+	return func(c *gin.Context) {
+
+		var form forms.CreatePromotionForm
+
+		err := forms.DecodeJSON(c.Request.Body, &form, requestLocale(c))
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		promotion, err := promotionController.CreatePromotion(c.Request.Context(), dB, &form)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusCreated, promotion)
+	}
+}
+
+func listPromotions(dB db.DB, promotionController controller.PromotionController) func(c *gin.Context) {
+	return func(c *gin.Context) {
+
+		promotions, err := promotionController.Promotions(c.Request.Context(), dB)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, promotions)
+	}
+}
+
+func promotionByID(dB db.DB, promotionController controller.PromotionController) func(c *gin.Context) {
+	return func(c *gin.Context) {
+
+		promotionID, err := idParam(c, "id")
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		promotion, err := promotionController.PromotionByID(c.Request.Context(), dB, promotionID)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, promotion)
+	}
+}
+
+func updatePromotion(dB db.DB, promotionController controller.PromotionController) func(c *gin.Context) {
+	return func(c *gin.Context) {
+
+		promotionID, err := idParam(c, "id")
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		var form forms.UpdatePromotionForm
+
+		err = forms.DecodeJSON(c.Request.Body, &form, requestLocale(c))
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		promotion, err := promotionController.UpdatePromotion(c.Request.Context(), dB, promotionID, &form)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, promotion)
+	}
+}
+
+func deletePromotion(dB db.DB, promotionController controller.PromotionController) func(c *gin.Context) {
+	return func(c *gin.Context) {
+
+		promotionID, err := idParam(c, "id")
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		err = promotionController.DeletePromotion(c.Request.Context(), dB, promotionID)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.Status(http.StatusNoContent)
+	}
+}
+
+func promotionRedemptions(dB db.DB, promotionController controller.PromotionController) func(c *gin.Context) {
+	return func(c *gin.Context) {
+
+		promotionID, err := idParam(c, "id")
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		redemptions, err := promotionController.Redemptions(c.Request.Context(), dB, promotionID)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, redemptions)
+	}
+}
+
+func promotionReport(dB db.DB, promotionController controller.PromotionController) func(c *gin.Context) {
+	return func(c *gin.Context) {
+
+		report, err := promotionController.Report(c.Request.Context(), dB)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, report)
+	}
+}
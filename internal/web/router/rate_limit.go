@@ -0,0 +1,92 @@
+package router
+
+import (
+	"fmt"
+	"log/slog"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/ernestngugi/sil-backend/internal/apperrors"
+	"github.com/ernestngugi/sil-backend/internal/metrics"
+	"github.com/ernestngugi/sil-backend/internal/model"
+	"github.com/ernestngugi/sil-backend/internal/ratelimit"
+	"github.com/ernestngugi/sil-backend/internal/web/auth"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	rateLimitLimitHeader     = "RateLimit-Limit"
+	rateLimitRemainingHeader = "RateLimit-Remaining"
+	rateLimitResetHeader     = "RateLimit-Reset"
+	rateLimitPolicyHeader    = "RateLimit-Policy"
+	retryAfterHeader         = "Retry-After"
+)
+
+// rateLimitMiddleware takes a token from the client's bucket for policyName
+// and rejects the request once it is empty. A failing store lets requests
+// through: losing rate limiting briefly is better than losing the API.
+func rateLimitMiddleware(
+	limiter *ratelimit.Limiter,
+	apiKeys *auth.APIKeys,
+	policyName string,
+	appMetrics *metrics.Metrics,
+	logger *slog.Logger,
+) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		if limiter == nil {
+			c.Next()
+			return
+		}
+
+		ctx := c.Request.Context()
+		policy := limiter.Policy(policyName)
+
+		result, err := limiter.Allow(ctx, policy.Name, rateLimitClient(c, apiKeys))
+		if err != nil {
+			logger.WarnContext(ctx, "rate limit check failed", slog.String("policy", policy.Name), slog.String("error", err.Error()))
+			c.Next()
+			return
+		}
+
+		header := c.Writer.Header()
+		header.Set(rateLimitLimitHeader, strconv.Itoa(result.Limit))
+		header.Set(rateLimitRemainingHeader, strconv.Itoa(result.Remaining))
+		header.Set(rateLimitResetHeader, ceilSeconds(result.Reset))
+		header.Set(rateLimitPolicyHeader, fmt.Sprintf("%d;w=%s", policy.Limit, ceilSeconds(policy.Period)))
+
+		if !result.Allowed {
+			appMetrics.ObserveRateLimited(policy.Name)
+			header.Set(retryAfterHeader, ceilSeconds(result.RetryAfter))
+			c.Error(apperrors.RateLimited("rate_limited", "too many requests, retry later"))
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// rateLimitClient identifies whose bucket a request draws from: the signed in
+// customer, else the client owning a valid API key, else the client IP.
+// Unrecognised API keys fall back to the IP so that made up keys cannot be
+// used to get a fresh bucket.
+func rateLimitClient(c *gin.Context, apiKeys *auth.APIKeys) string {
+
+	customer, ok := c.Request.Context().Value(model.CustomerKeyName).(string)
+	if ok && customer != "" {
+		return "customer:" + customer
+	}
+
+	client, ok := apiKeys.Client(c.GetHeader(auth.APIKeyHeader))
+	if ok {
+		return "key:" + client
+	}
+
+	return "ip:" + c.ClientIP()
+}
+
+func ceilSeconds(d time.Duration) string {
+	return strconv.FormatInt(int64(math.Ceil(d.Seconds())), 10)
+}
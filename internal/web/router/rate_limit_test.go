@@ -0,0 +1,91 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ernestngugi/sil-backend/internal/logging"
+	"github.com/ernestngugi/sil-backend/internal/metrics"
+	"github.com/ernestngugi/sil-backend/internal/ratelimit"
+	"github.com/ernestngugi/sil-backend/internal/web/auth"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimitMiddleware(t *testing.T) {
+
+	logger := logging.Discard()
+
+	limiter := ratelimit.NewLimiter(ratelimit.NewMemoryStore(), map[string]ratelimit.Policy{
+		ratelimit.PolicyDefault: {Name: ratelimit.PolicyDefault, Limit: 2, Period: time.Minute},
+	})
+
+	apiKeys, err := auth.ParseAPIKeys("warehouse:secret-key")
+	assert.NoError(t, err)
+
+	testRouter := gin.New()
+	testRouter.Use(errorMiddleware(logger))
+	testRouter.GET("/limited", rateLimitMiddleware(limiter, apiKeys, ratelimit.PolicyDefault, metrics.New(), logger), func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+
+	request := func(remoteAddr, apiKey string) *httptest.ResponseRecorder {
+
+		w := httptest.NewRecorder()
+
+		req, err := http.NewRequest(http.MethodGet, "/limited", nil)
+		assert.NoError(t, err)
+
+		req.RemoteAddr = remoteAddr
+		if apiKey != "" {
+			req.Header.Set(auth.APIKeyHeader, apiKey)
+		}
+
+		testRouter.ServeHTTP(w, req)
+
+		return w
+	}
+
+	t.Run("sets rate limit headers", func(t *testing.T) {
+
+		w := request("10.0.0.1:1234", "")
+
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		assert.Equal(t, "2", w.Header().Get(rateLimitLimitHeader))
+		assert.Equal(t, "1", w.Header().Get(rateLimitRemainingHeader))
+		assert.Equal(t, "30", w.Header().Get(rateLimitResetHeader))
+		assert.Equal(t, "2;w=60", w.Header().Get(rateLimitPolicyHeader))
+	})
+
+	t.Run("rejects clients over the limit", func(t *testing.T) {
+
+		request("10.0.0.1:1234", "")
+		w := request("10.0.0.1:1234", "")
+
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		assert.Equal(t, problemContentType, w.Header().Get("Content-Type"))
+		assert.Equal(t, "30", w.Header().Get(retryAfterHeader))
+		assert.Contains(t, w.Body.String(), `"code":"rate_limited"`)
+	})
+
+	t.Run("limits clients independently", func(t *testing.T) {
+
+		w := request("10.0.0.2:1234", "")
+		assert.Equal(t, http.StatusNoContent, w.Code)
+	})
+
+	t.Run("keys valid api keys by client", func(t *testing.T) {
+
+		assert.Equal(t, http.StatusNoContent, request("10.0.0.1:1234", "secret-key").Code)
+		assert.Equal(t, http.StatusNoContent, request("10.0.0.3:1234", "secret-key").Code)
+		assert.Equal(t, http.StatusTooManyRequests, request("10.0.0.4:1234", "secret-key").Code)
+	})
+
+	t.Run("keys unknown api keys by ip", func(t *testing.T) {
+
+		w := request("10.0.0.1:1234", "made-up-key")
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	})
+}
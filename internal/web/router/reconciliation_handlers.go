@@ -0,0 +1,74 @@
+package router
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+
+	"github.com/ernestngugi/sil-backend/internal/controller"
+	"github.com/ernestngugi/sil-backend/internal/db"
+	"github.com/ernestngugi/sil-backend/internal/reconciliation"
+	"github.com/gin-gonic/gin"
+)
+
+func reconciliationRuns(dB db.DB, reconciliationController controller.ReconciliationController) func(c *gin.Context) {
+	return func(c *gin.Context) {
+
+		runs, err := reconciliationController.Runs(c.Request.Context(), dB)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, runs)
+	}
+}
+
+func reconciliationRunByID(dB db.DB, reconciliationController controller.ReconciliationController) func(c *gin.Context) {
+	return func(c *gin.Context) {
+
+		runID, err := idParam(c, "id")
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		run, err := reconciliationController.RunByID(c.Request.Context(), dB, runID)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, run)
+	}
+}
+
+func reconciliationReport(dB db.DB, reconciliationController controller.ReconciliationController) func(c *gin.Context) {
+	return func(c *gin.Context) {
+
+		runID, err := idParam(c, "id")
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		entries, err := reconciliationController.Entries(c.Request.Context(), dB, runID)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		// The report is built before anything is sent so a failure still
+		// gets an error response.
+		var report bytes.Buffer
+
+		err = reconciliation.WriteReport(&report, entries)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="reconciliation-%d.csv"`, runID))
+		c.Data(http.StatusOK, "text/csv; charset=utf-8", report.Bytes())
+	}
+}
@@ -1,45 +1,173 @@
 package router
 
 import (
-	"net/http"
+	"log/slog"
 
+	"github.com/ernestngugi/sil-backend/internal/apperrors"
 	"github.com/ernestngugi/sil-backend/internal/controller"
 	"github.com/ernestngugi/sil-backend/internal/db"
+	"github.com/ernestngugi/sil-backend/internal/health"
+	"github.com/ernestngugi/sil-backend/internal/invoices"
+	"github.com/ernestngugi/sil-backend/internal/loyalty"
+	"github.com/ernestngugi/sil-backend/internal/metrics"
+	"github.com/ernestngugi/sil-backend/internal/notifications"
+	"github.com/ernestngugi/sil-backend/internal/orderevents"
+	"github.com/ernestngugi/sil-backend/internal/payments"
+	"github.com/ernestngugi/sil-backend/internal/ratelimit"
 	"github.com/ernestngugi/sil-backend/internal/repos"
+	"github.com/ernestngugi/sil-backend/internal/tax"
 	"github.com/ernestngugi/sil-backend/internal/web/auth"
+	"github.com/ernestngugi/sil-backend/internal/webhooks"
 	"github.com/ernestngugi/sil-backend/providers"
 	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 )
 
+const serviceName = "sil-api"
+
 type AppRouter struct {
 	*gin.Engine
 }
 
 func BuildRouter(
 	dB db.DB,
-	atProvider providers.ATProvider,
+	notifier notifications.Notifier,
+	paymentProcessor payments.Processor,
+	invoicer invoices.Invoicer,
+	ledger loyalty.Ledger,
+	taxConfig *tax.Config,
+	orderEventBroker orderevents.Broker,
 	oidcProvider providers.OpenID,
+	appReadiness *health.Readiness,
+	appMetrics *metrics.Metrics,
+	limiter *ratelimit.Limiter,
+	apiKeys *auth.APIKeys,
+	adminKeys *auth.APIKeys,
+	logger *slog.Logger,
 ) *AppRouter {
 
 	customerRepository := repos.NewCustomerRepository()
 	orderRepository := repos.NewOrderRepository()
+	webhookRepository := repos.NewWebhookRepository()
+	promotionRepository := repos.NewPromotionRepository()
+	cartRepository := repos.NewCartRepository()
+	productRepository := repos.NewProductRepository()
+	orderEventRepository := repos.NewOrderEventRepository()
+
+	smsTemplateRepository := repos.NewSMSTemplateRepository()
+	preferenceRepository := repos.NewNotificationPreferenceRepository()
+
+	webhookPublisher := webhooks.NewPublisher(webhookRepository)
+	smsTemplates := notifications.NewSMSTemplates(smsTemplateRepository, logger)
 
 	customerController := controller.NewCustomerController(customerRepository)
-	orderController := controller.NewOrderController(atProvider, customerRepository, orderRepository)
+	orderController := controller.NewOrderController(customerRepository, orderRepository, orderEventRepository, promotionRepository, cartRepository, productRepository, ledger, notifier, webhookPublisher, taxConfig, appMetrics, logger)
+	webhookController := controller.NewWebhookController(webhookRepository)
+	smsTemplateController := controller.NewSMSTemplateController(smsTemplateRepository, smsTemplates)
+	notificationController := controller.NewNotificationController(customerRepository, preferenceRepository, repos.NewSMSSuppressionRepository())
+	paymentController := controller.NewPaymentController(customerRepository, orderRepository, repos.NewPaymentRepository(), paymentProcessor)
+	invoiceController := controller.NewInvoiceController(customerRepository, orderRepository, invoicer)
+	reconciliationController := controller.NewReconciliationController(repos.NewReconciliationRepository())
+	promotionController := controller.NewPromotionController(promotionRepository, taxConfig)
+	loyaltyController := controller.NewLoyaltyController(customerRepository, ledger)
+	productController := controller.NewProductController(productRepository, taxConfig)
+	cartController := controller.NewCartController(cartRepository, customerRepository, productRepository, promotionRepository, taxConfig)
+	shipmentController := controller.NewShipmentController(customerRepository, orderRepository, orderEventRepository, repos.NewShipmentRepository(), notifier, webhookPublisher, logger)
+
+	router := gin.New()
+	router.Use(
+		otelgin.Middleware(serviceName),
+		requestIDMiddleware(),
+		requestLogger(logger),
+		metricsMiddleware(appMetrics),
+		recoveryMiddleware(logger),
+		errorMiddleware(logger),
+	)
+
+	router.GET("/healthz", liveness())
+	router.GET("/readyz", readiness(appReadiness))
+	router.GET("/metrics", gin.WrapH(appMetrics.Handler()))
+	router.GET("/openapi.json", openAPISpec(apiSpec()))
+	router.GET("/docs", swaggerUI())
 
-	router := gin.Default()
 	appRouter := router.Group("/v1")
 	unauthenticatedUser := appRouter.Group("")
-	appRouter.Use(authMiddleware(auth.NewAuthenticator(oidcProvider)))
+	callbackRouter := appRouter.Group("/callbacks")
+	webhookRouter := appRouter.Group("/webhooks")
+	adminRouter := appRouter.Group("/admin")
+	appRouter.Use(authMiddleware(auth.NewAuthenticator(oidcProvider), logger))
+	adminRouter.Use(adminMiddleware(adminKeys, logger))
+	callbackRouter.Use(callbackMiddleware(apiKeys, logger))
+	webhookRouter.Use(callbackMiddleware(apiKeys, logger))
+
+	rateLimit := func(policyName string) gin.HandlerFunc {
+		return rateLimitMiddleware(limiter, apiKeys, policyName, appMetrics, logger)
+	}
+
+	appRouter.POST("/orders", rateLimit(ratelimit.PolicyCreateOrder), createOrder(dB, orderController))
+	appRouter.GET("/orders/:id", rateLimit(ratelimit.PolicyDefault), orderByID(dB, orderController))
+	appRouter.GET("/orders/events", rateLimit(ratelimit.PolicyDefault), orderEvents(dB, orderController, orderEventBroker, orderEventHeartbeat, logger))
+	appRouter.GET("/orders/:id/events", rateLimit(ratelimit.PolicyDefault), orderEvents(dB, orderController, orderEventBroker, orderEventHeartbeat, logger))
+	appRouter.POST("/orders/:id/payments", rateLimit(ratelimit.PolicyCreatePayment), createPayment(dB, paymentController))
+	appRouter.GET("/orders/:id/payments", rateLimit(ratelimit.PolicyDefault), orderPayments(dB, paymentController))
+	appRouter.GET("/orders/:id/invoice.pdf", rateLimit(ratelimit.PolicyDefault), orderInvoice(dB, invoiceController))
+	appRouter.GET("/orders/:id/receipt.pdf", rateLimit(ratelimit.PolicyDefault), orderReceipt(dB, invoiceController))
+	appRouter.GET("/orders/:id/tracking", rateLimit(ratelimit.PolicyDefault), orderTracking(dB, shipmentController))
+	appRouter.GET("/customers/me/notification-preferences", rateLimit(ratelimit.PolicyDefault), notificationPreferences(dB, notificationController))
+	appRouter.PATCH("/customers/me/notification-preferences", rateLimit(ratelimit.PolicyDefault), updateNotificationPreferences(dB, notificationController))
+	appRouter.GET("/customers/me/loyalty", rateLimit(ratelimit.PolicyDefault), loyaltyAccount(dB, loyaltyController))
+	appRouter.GET("/products", rateLimit(ratelimit.PolicyDefault), listProducts(dB, productController, false))
+	appRouter.GET("/cart", rateLimit(ratelimit.PolicyDefault), cart(dB, cartController))
+	appRouter.POST("/cart/lines", rateLimit(ratelimit.PolicyDefault), addCartLine(dB, cartController))
+	appRouter.PATCH("/cart/lines/:product_id", rateLimit(ratelimit.PolicyDefault), updateCartLine(dB, cartController))
+	appRouter.DELETE("/cart/lines/:product_id", rateLimit(ratelimit.PolicyDefault), removeCartLine(dB, cartController))
+	appRouter.PUT("/cart/promo-code", rateLimit(ratelimit.PolicyDefault), applyCartPromoCode(dB, cartController))
+	appRouter.DELETE("/cart/promo-code", rateLimit(ratelimit.PolicyDefault), removeCartPromoCode(dB, cartController))
+	appRouter.POST("/cart/checkout", rateLimit(ratelimit.PolicyCreateOrder), checkoutCart(dB, orderController))
+	appRouter.GET("/customers/:name", rateLimit(ratelimit.PolicyDefault), customerByName(dB, customerController))
+
+	unauthenticatedUser.POST("/callback", rateLimit(ratelimit.PolicyLogin), handleLogin(dB, customerController, oidcProvider))
+
+	callbackRouter.POST("/at/opt-out", rateLimit(ratelimit.PolicyDefault), smsOptOut(dB, notificationController))
 
-	appRouter.POST("/orders", createOrder(dB, orderController))
-	appRouter.GET("/orders/:id", orderByID(dB, orderController))
-	appRouter.GET("/customers/:name", customerByName(dB, customerController))
+	webhookRouter.POST("/mpesa/callback", rateLimit(ratelimit.PolicyDefault), mpesaCallback(dB, paymentController))
 
-	unauthenticatedUser.POST("/callback", handleLogin(dB, customerController, oidcProvider))
+	adminRouter.Use(rateLimit(ratelimit.PolicyDefault))
+	adminRouter.PATCH("/orders/:id/status", updateOrderStatus(dB, orderController))
+	adminRouter.POST("/orders/:id/refund", refundOrder(dB, orderController))
+	adminRouter.POST("/orders/:id/shipment", assignShipment(dB, shipmentController))
+	adminRouter.GET("/shipments/:id", shipmentByID(dB, shipmentController))
+	adminRouter.PATCH("/shipments/:id", updateShipment(dB, shipmentController))
+	adminRouter.POST("/shipments/:id/events", addShipmentEvent(dB, shipmentController))
+	adminRouter.POST("/shipments/:id/deliver", confirmDelivery(dB, shipmentController))
+	adminRouter.POST("/webhooks", createWebhookSubscription(dB, webhookController))
+	adminRouter.GET("/webhooks", webhookSubscriptions(dB, webhookController))
+	adminRouter.GET("/webhooks/:id", webhookSubscriptionByID(dB, webhookController))
+	adminRouter.PATCH("/webhooks/:id", updateWebhookSubscription(dB, webhookController))
+	adminRouter.DELETE("/webhooks/:id", deleteWebhookSubscription(dB, webhookController))
+	adminRouter.GET("/webhooks/:id/deliveries", webhookDeliveries(dB, webhookController))
+	adminRouter.POST("/webhooks/:id/deliveries/:delivery_id/redeliver", redeliverWebhook(dB, webhookController))
+	adminRouter.GET("/sms-templates", listSMSTemplates(dB, smsTemplateController))
+	adminRouter.PUT("/sms-templates/:event/:locale", saveSMSTemplate(dB, smsTemplateController))
+	adminRouter.DELETE("/sms-templates/:event/:locale", deleteSMSTemplate(dB, smsTemplateController))
+	adminRouter.POST("/sms-templates/preview", previewSMSTemplate(dB, smsTemplateController))
+	adminRouter.GET("/reconciliations", reconciliationRuns(dB, reconciliationController))
+	adminRouter.GET("/reconciliations/:id", reconciliationRunByID(dB, reconciliationController))
+	adminRouter.GET("/reconciliations/:id/report.csv", reconciliationReport(dB, reconciliationController))
+	adminRouter.POST("/promotions", createPromotion(dB, promotionController))
+	adminRouter.GET("/promotions", listPromotions(dB, promotionController))
+	adminRouter.GET("/promotions/report", promotionReport(dB, promotionController))
+	adminRouter.GET("/promotions/:id", promotionByID(dB, promotionController))
+	adminRouter.PATCH("/promotions/:id", updatePromotion(dB, promotionController))
+	adminRouter.DELETE("/promotions/:id", deletePromotion(dB, promotionController))
+	adminRouter.GET("/promotions/:id/redemptions", promotionRedemptions(dB, promotionController))
+	adminRouter.POST("/products", createProduct(dB, productController))
+	adminRouter.GET("/products", listProducts(dB, productController, true))
+	adminRouter.GET("/products/:id", productByID(dB, productController))
+	adminRouter.PATCH("/products/:id", updateProduct(dB, productController))
 
 	router.NoRoute(func(c *gin.Context) {
-		c.JSON(http.StatusNotFound, gin.H{"error_message": "Endpoint not found"})
+		c.Error(apperrors.NotFound("endpoint_not_found", "Endpoint not found"))
 	})
 
 	return &AppRouter{router}
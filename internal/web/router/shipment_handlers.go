@@ -0,0 +1,156 @@
+package router
+
+import (
+	"net/http"
+
+	"github.com/ernestngugi/sil-backend/internal/controller"
+	"github.com/ernestngugi/sil-backend/internal/db"
+	"github.com/ernestngugi/sil-backend/internal/forms"
+	"github.com/gin-gonic/gin"
+)
+
+func assignShipment(dB db.DB, shipmentController controller.ShipmentController) func(c *gin.Context) {
+	return func(c *gin.Context) {
+
+		orderID, err := idParam(c, "id")
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		var form forms.AssignShipmentForm
+
+		err = forms.DecodeJSON(c.Request.Body, &form, requestLocale(c))
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		shipment, err := shipmentController.AssignShipment(c.Request.Context(), dB, orderID, &form)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusCreated, shipment)
+	}
+}
+
+func shipmentByID(dB db.DB, shipmentController controller.ShipmentController) func(c *gin.Context) {
+	return func(c *gin.Context) {
+
+		shipmentID, err := idParam(c, "id")
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		shipment, err := shipmentController.ShipmentByID(c.Request.Context(), dB, shipmentID)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, shipment)
+	}
+}
+
+func updateShipment(dB db.DB, shipmentController controller.ShipmentController) func(c *gin.Context) {
+	return func(c *gin.Context) {
+
+		shipmentID, err := idParam(c, "id")
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		var form forms.UpdateShipmentForm
+
+		err = forms.DecodeJSON(c.Request.Body, &form, requestLocale(c))
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		shipment, err := shipmentController.UpdateShipment(c.Request.Context(), dB, shipmentID, &form)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, shipment)
+	}
+}
+
+func addShipmentEvent(dB db.DB, shipmentController controller.ShipmentController) func(c *gin.Context) {
+	return func(c *gin.Context) {
+
+		shipmentID, err := idParam(c, "id")
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		var form forms.AddShipmentEventForm
+
+		err = forms.DecodeJSON(c.Request.Body, &form, requestLocale(c))
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		shipment, err := shipmentController.AddEvent(c.Request.Context(), dB, shipmentID, &form)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, shipment)
+	}
+}
+
+func confirmDelivery(dB db.DB, shipmentController controller.ShipmentController) func(c *gin.Context) {
+	return func(c *gin.Context) {
+
+		shipmentID, err := idParam(c, "id")
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		var form forms.ConfirmDeliveryForm
+
+		err = forms.DecodeJSON(c.Request.Body, &form, requestLocale(c))
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		shipment, err := shipmentController.ConfirmDelivery(c.Request.Context(), dB, shipmentID, &form)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, shipment)
+	}
+}
+
+func orderTracking(dB db.DB, shipmentController controller.ShipmentController) func(c *gin.Context) {
+	return func(c *gin.Context) {
+
+		orderID, err := idParam(c, "id")
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		tracking, err := shipmentController.OrderTracking(c.Request.Context(), dB, orderID)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, tracking)
+	}
+}
@@ -0,0 +1,79 @@
+package router
+
+import (
+	"net/http"
+
+	"github.com/ernestngugi/sil-backend/internal/controller"
+	"github.com/ernestngugi/sil-backend/internal/db"
+	"github.com/ernestngugi/sil-backend/internal/forms"
+	"github.com/ernestngugi/sil-backend/internal/model"
+	"github.com/gin-gonic/gin"
+)
+
+func listSMSTemplates(dB db.DB, smsTemplateController controller.SMSTemplateController) func(c *gin.Context) {
+	return func(c *gin.Context) {
+
+		templates, err := smsTemplateController.Templates(c.Request.Context(), dB)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, templates)
+	}
+}
+
+func saveSMSTemplate(dB db.DB, smsTemplateController controller.SMSTemplateController) func(c *gin.Context) {
+	return func(c *gin.Context) {
+
+		var form forms.SaveSMSTemplateForm
+
+		err := forms.DecodeJSON(c.Request.Body, &form, requestLocale(c))
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		template, err := smsTemplateController.SaveTemplate(c.Request.Context(), dB, model.NotificationEvent(c.Param("event")), c.Param("locale"), &form)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, template)
+	}
+}
+
+func deleteSMSTemplate(dB db.DB, smsTemplateController controller.SMSTemplateController) func(c *gin.Context) {
+	return func(c *gin.Context) {
+
+		err := smsTemplateController.DeleteTemplate(c.Request.Context(), dB, model.NotificationEvent(c.Param("event")), c.Param("locale"))
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.Status(http.StatusNoContent)
+	}
+}
+
+func previewSMSTemplate(dB db.DB, smsTemplateController controller.SMSTemplateController) func(c *gin.Context) {
+	return func(c *gin.Context) {
+
+		var form forms.PreviewSMSTemplateForm
+
+		err := forms.DecodeJSON(c.Request.Body, &form, requestLocale(c))
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		preview, err := smsTemplateController.Preview(c.Request.Context(), dB, &form)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, preview)
+	}
+}
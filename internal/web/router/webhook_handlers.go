@@ -0,0 +1,195 @@
+package router
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/ernestngugi/sil-backend/internal/controller"
+	"github.com/ernestngugi/sil-backend/internal/db"
+	"github.com/ernestngugi/sil-backend/internal/forms"
+	"github.com/ernestngugi/sil-backend/internal/model"
+	"github.com/gin-gonic/gin"
+)
+
+func createWebhookSubscription(dB db.DB, webhookController controller.WebhookController) func(c *gin.Context) {
+	return func(c *gin.Context) {
+
+		var form forms.CreateWebhookSubscriptionForm
+
+		err := forms.DecodeJSON(c.Request.Body, &form, requestLocale(c))
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		subscription, err := webhookController.CreateSubscription(c.Request.Context(), dB, &form)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusCreated, subscription)
+	}
+}
+
+func webhookSubscriptions(dB db.DB, webhookController controller.WebhookController) func(c *gin.Context) {
+	return func(c *gin.Context) {
+
+		subscriptions, err := webhookController.Subscriptions(c.Request.Context(), dB)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, subscriptions)
+	}
+}
+
+func webhookSubscriptionByID(dB db.DB, webhookController controller.WebhookController) func(c *gin.Context) {
+	return func(c *gin.Context) {
+
+		subscriptionID, err := idParam(c, "id")
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		subscription, err := webhookController.SubscriptionByID(c.Request.Context(), dB, subscriptionID)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, subscription)
+	}
+}
+
+func updateWebhookSubscription(dB db.DB, webhookController controller.WebhookController) func(c *gin.Context) {
+	return func(c *gin.Context) {
+
+		subscriptionID, err := idParam(c, "id")
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		var form forms.UpdateWebhookSubscriptionForm
+
+		err = forms.DecodeJSON(c.Request.Body, &form, requestLocale(c))
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		subscription, err := webhookController.UpdateSubscription(c.Request.Context(), dB, subscriptionID, &form)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, subscription)
+	}
+}
+
+func deleteWebhookSubscription(dB db.DB, webhookController controller.WebhookController) func(c *gin.Context) {
+	return func(c *gin.Context) {
+
+		subscriptionID, err := idParam(c, "id")
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		err = webhookController.DeleteSubscription(c.Request.Context(), dB, subscriptionID)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.Status(http.StatusNoContent)
+	}
+}
+
+func webhookDeliveries(dB db.DB, webhookController controller.WebhookController) func(c *gin.Context) {
+	return func(c *gin.Context) {
+
+		subscriptionID, err := idParam(c, "id")
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		status := model.WebhookDeliveryStatus(c.Query("status"))
+
+		deliveries, err := webhookController.Deliveries(c.Request.Context(), dB, subscriptionID, status)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, deliveries)
+	}
+}
+
+func redeliverWebhook(dB db.DB, webhookController controller.WebhookController) func(c *gin.Context) {
+	return func(c *gin.Context) {
+
+		subscriptionID, err := idParam(c, "id")
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		deliveryID, err := idParam(c, "delivery_id")
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		delivery, err := webhookController.Redeliver(c.Request.Context(), dB, subscriptionID, deliveryID)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusAccepted, delivery)
+	}
+}
+
+func updateOrderStatus(dB db.DB, orderController controller.OrderController) func(c *gin.Context) {
+	return func(c *gin.Context) {
+
+		orderID, err := idParam(c, "id")
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		var form forms.UpdateOrderStatusForm
+
+		err = forms.DecodeJSON(c.Request.Body, &form, requestLocale(c))
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		order, err := orderController.UpdateOrderStatus(c.Request.Context(), dB, orderID, &form)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, order)
+	}
+}
+
+// idParam parses the numeric path parameter name.
+func idParam(c *gin.Context, name string) (int64, error) {
+
+	id, err := strconv.ParseInt(c.Param(name), 10, 64)
+	if err != nil {
+		return 0, invalidIDError(name)
+	}
+
+	return id, nil
+}
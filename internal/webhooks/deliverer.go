@@ -0,0 +1,342 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ernestngugi/sil-backend/internal/db"
+	"github.com/ernestngugi/sil-backend/internal/env"
+	"github.com/ernestngugi/sil-backend/internal/model"
+	"github.com/ernestngugi/sil-backend/internal/repos"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+)
+
+const (
+	userAgent = "sil-webhooks/1.0"
+
+	// maxErrorBody bounds how much of a failed response is kept in the log.
+	maxErrorBody = 512
+)
+
+type (
+	Config struct {
+		PollInterval time.Duration
+		Timeout      time.Duration
+		BatchSize    int
+		// MaxAttempts is how many times a delivery is tried before it is
+		// marked failed.
+		MaxAttempts    int
+		InitialBackoff time.Duration
+		MaxBackoff     time.Duration
+		// DisableAfter is how many failed attempts in a row, across all of
+		// its deliveries, disable a subscription.
+		DisableAfter int
+	}
+
+	// Deliverer posts pending deliveries to subscribers in the background,
+	// retrying failures with exponential backoff.
+	Deliverer interface {
+		Start()
+		Shutdown(ctx context.Context) error
+	}
+
+	deliverer struct {
+		dB                db.DB
+		webhookRepository repos.WebhookRepository
+		client            *http.Client
+		config            *Config
+		logger            *slog.Logger
+
+		once    sync.Once
+		stop    chan struct{}
+		stopped chan struct{}
+	}
+)
+
+// ConfigFromEnv reads the WEBHOOK_* settings.
+func ConfigFromEnv() (*Config, error) {
+
+	config := &Config{}
+
+	var err error
+
+	if config.PollInterval, err = env.Duration("WEBHOOK_POLL_INTERVAL", 5*time.Second); err != nil {
+		return &Config{}, err
+	}
+
+	if config.Timeout, err = env.Duration("WEBHOOK_TIMEOUT", 10*time.Second); err != nil {
+		return &Config{}, err
+	}
+
+	if config.BatchSize, err = env.Int("WEBHOOK_BATCH_SIZE", 20); err != nil {
+		return &Config{}, err
+	}
+
+	if config.MaxAttempts, err = env.Int("WEBHOOK_MAX_ATTEMPTS", 10); err != nil {
+		return &Config{}, err
+	}
+
+	if config.InitialBackoff, err = env.Duration("WEBHOOK_INITIAL_BACKOFF", 30*time.Second); err != nil {
+		return &Config{}, err
+	}
+
+	if config.MaxBackoff, err = env.Duration("WEBHOOK_MAX_BACKOFF", 6*time.Hour); err != nil {
+		return &Config{}, err
+	}
+
+	if config.DisableAfter, err = env.Int("WEBHOOK_DISABLE_AFTER", 20); err != nil {
+		return &Config{}, err
+	}
+
+	return config, nil
+}
+
+func NewDeliverer(
+	dB db.DB,
+	webhookRepository repos.WebhookRepository,
+	config *Config,
+	logger *slog.Logger,
+) Deliverer {
+	return &deliverer{
+		dB:                dB,
+		webhookRepository: webhookRepository,
+		client: &http.Client{
+			Timeout:   config.Timeout,
+			Transport: otelhttp.NewTransport(http.DefaultTransport),
+		},
+		config:  config,
+		logger:  logger,
+		stop:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+}
+
+// Start polls for due deliveries until Shutdown is called.
+func (d *deliverer) Start() {
+	go d.run()
+}
+
+// Shutdown stops polling and waits for in-flight deliveries. Deliveries cut
+// short are retried once their lease expires.
+func (d *deliverer) Shutdown(ctx context.Context) error {
+
+	d.once.Do(func() { close(d.stop) })
+
+	select {
+	case <-d.stopped:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (d *deliverer) run() {
+	defer close(d.stopped)
+
+	ticker := time.NewTicker(d.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		// Keep going while full batches come back so a backlog drains
+		// without waiting a poll interval per batch.
+		for d.deliverDue(context.Background()) == d.config.BatchSize {
+			select {
+			case <-d.stop:
+				return
+			default:
+			}
+		}
+
+		select {
+		case <-d.stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// deliverDue attempts a batch of due deliveries concurrently and returns how
+// many were claimed.
+func (d *deliverer) deliverDue(ctx context.Context) int {
+
+	// The lease must outlast an attempt so no other replica claims the
+	// delivery while it is in flight.
+	deliveries, err := d.webhookRepository.ClaimDueDeliveries(ctx, d.dB, d.config.BatchSize, 2*d.config.Timeout+time.Minute)
+	if err != nil {
+		d.logger.ErrorContext(ctx, "failed to claim webhook deliveries", slog.String("error", err.Error()))
+		return 0
+	}
+
+	var wg sync.WaitGroup
+
+	for _, delivery := range deliveries {
+		wg.Add(1)
+		go func(delivery *model.WebhookDelivery) {
+			defer wg.Done()
+			d.deliver(ctx, delivery)
+		}(delivery)
+	}
+
+	wg.Wait()
+
+	return len(deliveries)
+}
+
+func (d *deliverer) deliver(ctx context.Context, delivery *model.WebhookDelivery) {
+
+	logger := d.logger.With(
+		slog.Int64("delivery_id", delivery.ID),
+		slog.Int64("subscription_id", delivery.SubscriptionID),
+		slog.String("event_type", delivery.EventType),
+	)
+
+	subscription, err := d.webhookRepository.SubscriptionByID(ctx, d.dB, delivery.SubscriptionID)
+	if err != nil {
+		logger.ErrorContext(ctx, "failed to load webhook subscription", slog.String("error", err.Error()))
+		return
+	}
+
+	delivery.Attempts++
+
+	if !subscription.Active {
+		d.finish(ctx, logger, delivery, model.WebhookDeliveryFailed, nil, "subscription is disabled")
+		return
+	}
+
+	statusCode, err := d.post(ctx, subscription, delivery)
+	if err == nil {
+
+		d.finish(ctx, logger, delivery, model.WebhookDeliverySucceeded, &statusCode, "")
+
+		err = d.webhookRepository.RecordSuccess(ctx, d.dB, subscription.ID)
+		if err != nil {
+			logger.ErrorContext(ctx, "failed to reset webhook failures", slog.String("error", err.Error()))
+		}
+
+		return
+	}
+
+	var code *int
+	if statusCode != 0 {
+		code = &statusCode
+	}
+
+	status := model.WebhookDeliveryPending
+	if delivery.Attempts >= d.config.MaxAttempts {
+		status = model.WebhookDeliveryFailed
+	}
+
+	delivery.NextAttemptAt = time.Now().Add(d.backoff(delivery.Attempts))
+	d.finish(ctx, logger, delivery, status, code, err.Error())
+
+	active, err := d.webhookRepository.RecordFailure(ctx, d.dB, subscription.ID, d.config.DisableAfter)
+	if err != nil {
+		logger.ErrorContext(ctx, "failed to record webhook failure", slog.String("error", err.Error()))
+		return
+	}
+
+	if !active {
+		logger.WarnContext(ctx, "webhook subscription disabled after repeated failures")
+	}
+}
+
+// post sends the delivery and returns the response status code, if any.
+func (d *deliverer) post(ctx context.Context, subscription *model.WebhookSubscription, delivery *model.WebhookDelivery) (int, error) {
+
+	timestamp := time.Now()
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, subscription.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("User-Agent", userAgent)
+	request.Header.Set(EventHeader, delivery.EventType)
+	request.Header.Set(EventIDHeader, delivery.EventID)
+	request.Header.Set(DeliveryIDHeader, strconv.FormatInt(delivery.ID, 10))
+	request.Header.Set(TimestampHeader, strconv.FormatInt(timestamp.Unix(), 10))
+	request.Header.Set(SignatureHeader, Sign(subscription.Secret, timestamp, delivery.Payload))
+
+	response, err := d.client.Do(request)
+	if err != nil {
+		return 0, err
+	}
+
+	defer response.Body.Close()
+
+	if response.StatusCode < http.StatusOK || response.StatusCode >= http.StatusMultipleChoices {
+		body, _ := io.ReadAll(io.LimitReader(response.Body, maxErrorBody))
+		return response.StatusCode, fmt.Errorf("unexpected status %v: %s", response.StatusCode, body)
+	}
+
+	io.Copy(io.Discard, response.Body)
+
+	return response.StatusCode, nil
+}
+
+func (d *deliverer) finish(
+	ctx context.Context,
+	logger *slog.Logger,
+	delivery *model.WebhookDelivery,
+	status model.WebhookDeliveryStatus,
+	statusCode *int,
+	lastError string,
+) {
+
+	delivery.Status = status
+	delivery.LastStatusCode = statusCode
+	delivery.LastError = nil
+
+	if lastError != "" {
+		delivery.LastError = &lastError
+	}
+
+	if status == model.WebhookDeliverySucceeded {
+		timeNow := time.Now()
+		delivery.DateDelivered = &timeNow
+	}
+
+	err := d.webhookRepository.UpdateDelivery(ctx, d.dB, delivery)
+	if err != nil {
+		logger.ErrorContext(ctx, "failed to update webhook delivery", slog.String("error", err.Error()))
+		return
+	}
+
+	switch status {
+	case model.WebhookDeliverySucceeded:
+		logger.InfoContext(ctx, "webhook delivered", slog.Int("attempts", delivery.Attempts))
+	case model.WebhookDeliveryFailed:
+		logger.WarnContext(ctx, "webhook delivery failed permanently", slog.Int("attempts", delivery.Attempts), slog.String("error", lastError))
+	default:
+		logger.InfoContext(ctx, "webhook delivery failed, will retry",
+			slog.Int("attempts", delivery.Attempts),
+			slog.Time("next_attempt_at", delivery.NextAttemptAt),
+			slog.String("error", lastError),
+		)
+	}
+}
+
+// backoff is the wait before the attempt following attempts failures,
+// doubling from InitialBackoff up to MaxBackoff.
+func (d *deliverer) backoff(attempts int) time.Duration {
+
+	wait := d.config.InitialBackoff
+
+	for i := 1; i < attempts && wait < d.config.MaxBackoff; i++ {
+		wait *= 2
+	}
+
+	if wait > d.config.MaxBackoff {
+		return d.config.MaxBackoff
+	}
+
+	return wait
+}
@@ -0,0 +1,89 @@
+package webhooks
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"github.com/ernestngugi/sil-backend/internal/db"
+	"github.com/ernestngugi/sil-backend/internal/model"
+	"github.com/ernestngugi/sil-backend/internal/repos"
+)
+
+type (
+	// Publisher records an event for every subscription interested in it.
+	// Deliveries are written with the caller's operations, so publishing
+	// inside the transaction that changes an order means the event is sent
+	// if and only if the change is committed.
+	Publisher interface {
+		Publish(ctx context.Context, operations db.SQLOperations, eventType string, data any) error
+	}
+
+	publisher struct {
+		webhookRepository repos.WebhookRepository
+	}
+)
+
+func NewPublisher(webhookRepository repos.WebhookRepository) Publisher {
+	return &publisher{
+		webhookRepository: webhookRepository,
+	}
+}
+
+func (p *publisher) Publish(
+	ctx context.Context,
+	operations db.SQLOperations,
+	eventType string,
+	data any,
+) error {
+
+	subscriptions, err := p.webhookRepository.ActiveSubscriptions(ctx, operations, eventType)
+	if err != nil {
+		return err
+	}
+
+	if len(subscriptions) == 0 {
+		return nil
+	}
+
+	event := &model.WebhookEvent{
+		ID:          NewEventID(),
+		Type:        eventType,
+		DateCreated: time.Now().UTC(),
+		Data:        data,
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	for _, subscription := range subscriptions {
+
+		err := p.webhookRepository.SaveDelivery(ctx, operations, &model.WebhookDelivery{
+			SubscriptionID: subscription.ID,
+			EventID:        event.ID,
+			EventType:      event.Type,
+			Payload:        payload,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func NewEventID() string {
+
+	b := make([]byte, 16)
+
+	_, err := rand.Read(b)
+	if err != nil {
+		panic(err)
+	}
+
+	return "evt_" + hex.EncodeToString(b)
+}
@@ -0,0 +1,67 @@
+package webhooks
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Headers sent with every delivery. Receivers verify X-SIL-Signature against
+// X-SIL-Timestamp and the raw body, and use X-SIL-Event-ID to discard
+// duplicates since a delivery may be attempted more than once.
+const (
+	EventHeader      = "X-SIL-Event"
+	EventIDHeader    = "X-SIL-Event-ID"
+	DeliveryIDHeader = "X-SIL-Delivery-ID"
+	TimestampHeader  = "X-SIL-Timestamp"
+	SignatureHeader  = "X-SIL-Signature"
+
+	signaturePrefix = "sha256="
+)
+
+var (
+	ErrInvalidSignature = errors.New("webhook signature does not match")
+	ErrStaleTimestamp   = errors.New("webhook timestamp is outside the tolerance")
+)
+
+// Sign returns the X-SIL-Signature value for body sent at timestamp: the hex
+// HMAC-SHA256 of "<unix timestamp>.<body>" keyed with the subscription secret.
+// Including the timestamp stops a captured delivery being replayed later.
+func Sign(secret string, timestamp time.Time, body []byte) string {
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp.Unix(), 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks the signature and timestamp headers of a delivery, rejecting
+// timestamps further than tolerance from now.
+func Verify(secret, timestampHeader, signatureHeader string, body []byte, tolerance time.Duration, now time.Time) error {
+
+	unix, err := strconv.ParseInt(timestampHeader, 10, 64)
+	if err != nil {
+		return ErrStaleTimestamp
+	}
+
+	timestamp := time.Unix(unix, 0)
+	if now.Sub(timestamp) > tolerance || timestamp.Sub(now) > tolerance {
+		return ErrStaleTimestamp
+	}
+
+	if !strings.HasPrefix(signatureHeader, signaturePrefix) {
+		return ErrInvalidSignature
+	}
+
+	if !hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signatureHeader)) {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
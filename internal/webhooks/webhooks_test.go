@@ -0,0 +1,294 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ernestngugi/sil-backend/internal/apperrors"
+	"github.com/ernestngugi/sil-backend/internal/db"
+	"github.com/ernestngugi/sil-backend/internal/logging"
+	"github.com/ernestngugi/sil-backend/internal/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSignature(t *testing.T) {
+
+	body := []byte(`{"id":"evt_1"}`)
+	now := time.Unix(1792400000, 0)
+	signature := Sign("secret", now, body)
+	timestamp := "1792400000"
+
+	t.Run("verifies a valid signature", func(t *testing.T) {
+		assert.NoError(t, Verify("secret", timestamp, signature, body, 5*time.Minute, now.Add(time.Minute)))
+	})
+
+	t.Run("rejects a tampered body or wrong secret", func(t *testing.T) {
+		assert.ErrorIs(t, Verify("secret", timestamp, signature, []byte(`{"id":"evt_2"}`), 5*time.Minute, now), ErrInvalidSignature)
+		assert.ErrorIs(t, Verify("other", timestamp, signature, body, 5*time.Minute, now), ErrInvalidSignature)
+	})
+
+	t.Run("rejects stale timestamps", func(t *testing.T) {
+		assert.ErrorIs(t, Verify("secret", timestamp, signature, body, 5*time.Minute, now.Add(time.Hour)), ErrStaleTimestamp)
+		assert.ErrorIs(t, Verify("secret", "yesterday", signature, body, 5*time.Minute, now), ErrStaleTimestamp)
+	})
+}
+
+func TestDeliverer(t *testing.T) {
+
+	ctx := context.Background()
+
+	var mu sync.Mutex
+	var received []*http.Request
+	var bodies [][]byte
+	responseStatus := http.StatusOK
+
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		body, _ := io.ReadAll(r.Body)
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		received = append(received, r)
+		bodies = append(bodies, body)
+		w.WriteHeader(responseStatus)
+	}))
+	defer receiver.Close()
+
+	setResponseStatus := func(status int) {
+		mu.Lock()
+		defer mu.Unlock()
+		responseStatus = status
+	}
+
+	repository := newFakeWebhookRepository()
+
+	config := &Config{
+		PollInterval:   time.Hour,
+		Timeout:        time.Second,
+		BatchSize:      10,
+		MaxAttempts:    3,
+		InitialBackoff: time.Minute,
+		MaxBackoff:     time.Hour,
+		DisableAfter:   4,
+	}
+
+	d := NewDeliverer(nil, repository, config, logging.Discard()).(*deliverer)
+
+	subscription := &model.WebhookSubscription{
+		URL:        receiver.URL,
+		Secret:     "whsec_test",
+		EventTypes: []string{model.EventOrderCreated},
+		Active:     true,
+	}
+	assert.NoError(t, repository.SaveSubscription(ctx, nil, subscription))
+
+	publisher := NewPublisher(repository)
+
+	t.Run("publishes only to interested subscriptions", func(t *testing.T) {
+
+		err := publisher.Publish(ctx, nil, model.EventOrderStatusChanged, map[string]any{"id": 1})
+		assert.NoError(t, err)
+		assert.Len(t, repository.deliveries, 0)
+	})
+
+	t.Run("delivers signed events", func(t *testing.T) {
+
+		err := publisher.Publish(ctx, nil, model.EventOrderCreated, &model.Order{ID: 7, Item: "item", Amount: 100})
+		assert.NoError(t, err)
+
+		assert.Equal(t, 1, d.deliverDue(ctx))
+
+		assert.Len(t, received, 1)
+		request := received[0]
+
+		assert.Equal(t, model.EventOrderCreated, request.Header.Get(EventHeader))
+		assert.NoError(t, Verify("whsec_test", request.Header.Get(TimestampHeader), request.Header.Get(SignatureHeader), bodies[0], time.Minute, time.Now()))
+
+		var event model.WebhookEvent
+		assert.NoError(t, json.Unmarshal(bodies[0], &event))
+		assert.Equal(t, request.Header.Get(EventIDHeader), event.ID)
+		assert.Equal(t, model.EventOrderCreated, event.Type)
+
+		delivery := repository.deliveries[1]
+		assert.Equal(t, model.WebhookDeliverySucceeded, delivery.Status)
+		assert.Equal(t, 1, delivery.Attempts)
+		assert.Equal(t, http.StatusOK, *delivery.LastStatusCode)
+		assert.NotNil(t, delivery.DateDelivered)
+	})
+
+	t.Run("retries failures with backoff and gives up", func(t *testing.T) {
+
+		setResponseStatus(http.StatusInternalServerError)
+
+		err := publisher.Publish(ctx, nil, model.EventOrderCreated, &model.Order{ID: 8})
+		assert.NoError(t, err)
+
+		assert.Equal(t, 1, d.deliverDue(ctx))
+
+		delivery := repository.deliveries[2]
+		assert.Equal(t, model.WebhookDeliveryPending, delivery.Status)
+		assert.Equal(t, http.StatusInternalServerError, *delivery.LastStatusCode)
+		assert.WithinDuration(t, time.Now().Add(time.Minute), delivery.NextAttemptAt, 5*time.Second)
+
+		assert.Equal(t, 0, d.deliverDue(ctx), "not due before the backoff")
+
+		for attempt := 2; attempt <= config.MaxAttempts; attempt++ {
+			delivery.NextAttemptAt = time.Now()
+			assert.Equal(t, 1, d.deliverDue(ctx))
+		}
+
+		assert.Equal(t, model.WebhookDeliveryFailed, delivery.Status)
+		assert.Equal(t, config.MaxAttempts, delivery.Attempts)
+		assert.Equal(t, 3, subscription.ConsecutiveFailures)
+		assert.True(t, subscription.Active)
+	})
+
+	t.Run("disables subscriptions after repeated failures", func(t *testing.T) {
+
+		err := publisher.Publish(ctx, nil, model.EventOrderCreated, &model.Order{ID: 9})
+		assert.NoError(t, err)
+
+		assert.Equal(t, 1, d.deliverDue(ctx))
+		assert.False(t, subscription.Active)
+		assert.NotNil(t, subscription.DateDisabled)
+
+		err = publisher.Publish(ctx, nil, model.EventOrderCreated, &model.Order{ID: 10})
+		assert.NoError(t, err)
+		assert.Len(t, repository.deliveries, 3, "disabled subscriptions get no new deliveries")
+	})
+
+	t.Run("doubles the backoff up to the maximum", func(t *testing.T) {
+		assert.Equal(t, time.Minute, d.backoff(1))
+		assert.Equal(t, 4*time.Minute, d.backoff(3))
+		assert.Equal(t, time.Hour, d.backoff(20))
+	})
+}
+
+// fakeWebhookRepository keeps subscriptions and deliveries in memory.
+type fakeWebhookRepository struct {
+	mu            sync.Mutex
+	subscriptions map[int64]*model.WebhookSubscription
+	deliveries    map[int64]*model.WebhookDelivery
+	nextID        int64
+}
+
+func newFakeWebhookRepository() *fakeWebhookRepository {
+	return &fakeWebhookRepository{
+		subscriptions: make(map[int64]*model.WebhookSubscription),
+		deliveries:    make(map[int64]*model.WebhookDelivery),
+	}
+}
+
+func (r *fakeWebhookRepository) SaveSubscription(ctx context.Context, operations db.SQLOperations, subscription *model.WebhookSubscription) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.nextID++
+	subscription.ID = r.nextID
+	r.subscriptions[subscription.ID] = subscription
+	return nil
+}
+
+func (r *fakeWebhookRepository) SubscriptionByID(ctx context.Context, operations db.SQLOperations, subscriptionID int64) (*model.WebhookSubscription, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	subscription, ok := r.subscriptions[subscriptionID]
+	if !ok {
+		return &model.WebhookSubscription{}, apperrors.NotFound("webhook_subscription_not_found", "webhook subscription not found")
+	}
+	return subscription, nil
+}
+
+func (r *fakeWebhookRepository) Subscriptions(ctx context.Context, operations db.SQLOperations) ([]*model.WebhookSubscription, error) {
+	return r.ActiveSubscriptions(ctx, operations, "")
+}
+
+func (r *fakeWebhookRepository) ActiveSubscriptions(ctx context.Context, operations db.SQLOperations, eventType string) ([]*model.WebhookSubscription, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	subscriptions := make([]*model.WebhookSubscription, 0)
+	for _, subscription := range r.subscriptions {
+		for _, subscribed := range subscription.EventTypes {
+			if subscription.Active && (eventType == "" || subscribed == eventType) {
+				subscriptions = append(subscriptions, subscription)
+				break
+			}
+		}
+	}
+	return subscriptions, nil
+}
+
+func (r *fakeWebhookRepository) UpdateSubscription(ctx context.Context, operations db.SQLOperations, subscription *model.WebhookSubscription) error {
+	return nil
+}
+
+func (r *fakeWebhookRepository) DeleteSubscription(ctx context.Context, operations db.SQLOperations, subscriptionID int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.subscriptions, subscriptionID)
+	return nil
+}
+
+func (r *fakeWebhookRepository) RecordFailure(ctx context.Context, operations db.SQLOperations, subscriptionID int64, disableAfter int) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	subscription := r.subscriptions[subscriptionID]
+	subscription.ConsecutiveFailures++
+	if subscription.Active && subscription.ConsecutiveFailures >= disableAfter {
+		timeNow := time.Now()
+		subscription.Active = false
+		subscription.DateDisabled = &timeNow
+	}
+	return subscription.Active, nil
+}
+
+func (r *fakeWebhookRepository) RecordSuccess(ctx context.Context, operations db.SQLOperations, subscriptionID int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.subscriptions[subscriptionID].ConsecutiveFailures = 0
+	return nil
+}
+
+func (r *fakeWebhookRepository) SaveDelivery(ctx context.Context, operations db.SQLOperations, delivery *model.WebhookDelivery) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delivery.ID = int64(len(r.deliveries) + 1)
+	delivery.Status = model.WebhookDeliveryPending
+	delivery.NextAttemptAt = time.Now()
+	r.deliveries[delivery.ID] = delivery
+	return nil
+}
+
+func (r *fakeWebhookRepository) DeliveryByID(ctx context.Context, operations db.SQLOperations, subscriptionID, deliveryID int64) (*model.WebhookDelivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.deliveries[deliveryID], nil
+}
+
+func (r *fakeWebhookRepository) Deliveries(ctx context.Context, operations db.SQLOperations, subscriptionID int64, status model.WebhookDeliveryStatus, limit int) ([]*model.WebhookDelivery, error) {
+	return []*model.WebhookDelivery{}, nil
+}
+
+func (r *fakeWebhookRepository) ClaimDueDeliveries(ctx context.Context, operations db.SQLOperations, limit int, lease time.Duration) ([]*model.WebhookDelivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	timeNow := time.Now()
+	deliveries := make([]*model.WebhookDelivery, 0)
+	for _, delivery := range r.deliveries {
+		if delivery.Status == model.WebhookDeliveryPending && !delivery.NextAttemptAt.After(timeNow) && len(deliveries) < limit {
+			delivery.NextAttemptAt = timeNow.Add(lease)
+			deliveries = append(deliveries, delivery)
+		}
+	}
+	return deliveries, nil
+}
+
+func (r *fakeWebhookRepository) UpdateDelivery(ctx context.Context, operations db.SQLOperations, delivery *model.WebhookDelivery) error {
+	return nil
+}
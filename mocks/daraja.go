@@ -0,0 +1,275 @@
+package mocks
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+)
+
+// Credentials the Daraja stand-in accepts.
+const (
+	DarajaConsumerKey    = "consumer-key"
+	DarajaConsumerSecret = "consumer-secret"
+	DarajaShortCode      = "174379"
+	DarajaPassKey        = "pass-key"
+
+	darajaAccessToken = "stand-in-token"
+)
+
+type (
+	// DarajaServer stands in for Safaricom's Daraja API. STK pushes stay
+	// pending, and status queries report them as processing, until Resolve
+	// or Complete is called.
+	DarajaServer struct {
+		*httptest.Server
+
+		mu     sync.Mutex
+		pushes []*DarajaPush
+		// RejectPhoneNumber fails pushes to the number as Daraja does
+		// invalid numbers.
+		RejectPhoneNumber string
+		// LosePhoneNumber accepts pushes to the number but answers with
+		// a gateway timeout, so the caller never learns of them.
+		LosePhoneNumber string
+	}
+
+	// DarajaPush is an STK Push the stand-in accepted.
+	DarajaPush struct {
+		MerchantRequestID string
+		CheckoutRequestID string
+		PhoneNumber       string
+		Amount            int64
+		AccountReference  string
+		CallBackURL       string
+		resultCode        *int
+		receiptNumber     string
+	}
+)
+
+func NewDarajaServer() *DarajaServer {
+
+	server := &DarajaServer{}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/oauth/v1/generate", server.generateToken)
+	mux.HandleFunc("/mpesa/stkpush/v1/processrequest", server.authorized(server.stkPush))
+	mux.HandleFunc("/mpesa/stkpushquery/v1/query", server.authorized(server.stkQuery))
+
+	server.Server = httptest.NewServer(mux)
+
+	return server
+}
+
+// Pushes returns the STK pushes accepted so far.
+func (s *DarajaServer) Pushes() []DarajaPush {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pushes := make([]DarajaPush, 0, len(s.pushes))
+	for _, push := range s.pushes {
+		pushes = append(pushes, *push)
+	}
+
+	return pushes
+}
+
+// Resolve records the customer's answer to a push without sending the
+// callback, as when it is lost, so only status queries see it.
+func (s *DarajaServer) Resolve(checkoutRequestID string, resultCode int) (*DarajaPush, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, push := range s.pushes {
+		if push.CheckoutRequestID == checkoutRequestID {
+
+			push.resultCode = &resultCode
+			if resultCode == 0 {
+				push.receiptNumber = fmt.Sprintf("SIL%07d", i+1)
+			}
+
+			copied := *push
+			return &copied, nil
+		}
+	}
+
+	return nil, fmt.Errorf("no stk push %v", checkoutRequestID)
+}
+
+// Complete resolves a push and posts its callback, returning the callback
+// response status.
+func (s *DarajaServer) Complete(checkoutRequestID string, resultCode int) (int, error) {
+
+	push, err := s.Resolve(checkoutRequestID, resultCode)
+	if err != nil {
+		return 0, err
+	}
+
+	callback := map[string]any{
+		"MerchantRequestID": push.MerchantRequestID,
+		"CheckoutRequestID": push.CheckoutRequestID,
+		"ResultCode":        resultCode,
+		"ResultDesc":        darajaResultDescription(resultCode),
+	}
+
+	if resultCode == 0 {
+		callback["CallbackMetadata"] = map[string]any{
+			"Item": []map[string]any{
+				{"Name": "Amount", "Value": push.Amount},
+				{"Name": "MpesaReceiptNumber", "Value": push.receiptNumber},
+				{"Name": "TransactionDate", "Value": time.Now().Format("20060102150405")},
+				{"Name": "PhoneNumber", "Value": push.PhoneNumber},
+			},
+		}
+	}
+
+	payload, err := json.Marshal(map[string]any{"Body": map[string]any{"stkCallback": callback}})
+	if err != nil {
+		return 0, err
+	}
+
+	response, err := http.Post(push.CallBackURL, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		return 0, err
+	}
+
+	response.Body.Close()
+
+	return response.StatusCode, nil
+}
+
+func (s *DarajaServer) generateToken(w http.ResponseWriter, r *http.Request) {
+
+	key, secret, ok := r.BasicAuth()
+	if !ok || key != DarajaConsumerKey || secret != DarajaConsumerSecret {
+		darajaError(w, http.StatusBadRequest, "400.008.01", "Invalid Authentication passed")
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]string{
+		"access_token": darajaAccessToken,
+		"expires_in":   "3599",
+	})
+}
+
+func (s *DarajaServer) authorized(next func(http.ResponseWriter, map[string]any)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		if r.Header.Get("Authorization") != "Bearer "+darajaAccessToken {
+			darajaError(w, http.StatusUnauthorized, "404.001.03", "Invalid Access Token")
+			return
+		}
+
+		var body map[string]any
+
+		err := json.NewDecoder(r.Body).Decode(&body)
+		if err != nil {
+			darajaError(w, http.StatusBadRequest, "400.002.02", "Bad Request - Invalid Body")
+			return
+		}
+
+		timestamp, _ := body["Timestamp"].(string)
+		password := base64.StdEncoding.EncodeToString([]byte(DarajaShortCode + DarajaPassKey + timestamp))
+
+		if body["BusinessShortCode"] != DarajaShortCode || body["Password"] != password {
+			darajaError(w, http.StatusBadRequest, "400.002.02", "Bad Request - Invalid Password")
+			return
+		}
+
+		next(w, body)
+	}
+}
+
+func (s *DarajaServer) stkPush(w http.ResponseWriter, body map[string]any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	phoneNumber, _ := body["PhoneNumber"].(string)
+	if phoneNumber == "" || phoneNumber == s.RejectPhoneNumber {
+		darajaError(w, http.StatusBadRequest, "400.002.02", "Bad Request - Invalid PhoneNumber")
+		return
+	}
+
+	amount, _ := body["Amount"].(float64)
+	reference, _ := body["AccountReference"].(string)
+	callbackURL, _ := body["CallBackURL"].(string)
+
+	push := &DarajaPush{
+		MerchantRequestID: fmt.Sprintf("29115-%d", len(s.pushes)+1),
+		CheckoutRequestID: fmt.Sprintf("ws_CO_%d", len(s.pushes)+1),
+		PhoneNumber:       phoneNumber,
+		Amount:            int64(amount),
+		AccountReference:  reference,
+		CallBackURL:       callbackURL,
+	}
+
+	s.pushes = append(s.pushes, push)
+
+	if phoneNumber == s.LosePhoneNumber {
+		darajaError(w, http.StatusGatewayTimeout, "", "Gateway Timeout")
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]string{
+		"MerchantRequestID":   push.MerchantRequestID,
+		"CheckoutRequestID":   push.CheckoutRequestID,
+		"ResponseCode":        "0",
+		"ResponseDescription": "Success. Request accepted for processing",
+		"CustomerMessage":     "Success. Request accepted for processing",
+	})
+}
+
+func (s *DarajaServer) stkQuery(w http.ResponseWriter, body map[string]any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, push := range s.pushes {
+		if push.CheckoutRequestID != body["CheckoutRequestID"] {
+			continue
+		}
+
+		if push.resultCode == nil {
+			darajaError(w, http.StatusInternalServerError, "500.001.1001", "The transaction is being processed")
+			return
+		}
+
+		json.NewEncoder(w).Encode(map[string]string{
+			"ResponseCode":        "0",
+			"ResponseDescription": "The service request has been accepted successsfully",
+			"MerchantRequestID":   push.MerchantRequestID,
+			"CheckoutRequestID":   push.CheckoutRequestID,
+			"ResultCode":          fmt.Sprint(*push.resultCode),
+			"ResultDesc":          darajaResultDescription(*push.resultCode),
+		})
+		return
+	}
+
+	darajaError(w, http.StatusBadRequest, "400.002.02", "Bad Request - Invalid CheckoutRequestID")
+}
+
+func darajaError(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{
+		"requestId":    "stand-in",
+		"errorCode":    code,
+		"errorMessage": message,
+	})
+}
+
+func darajaResultDescription(resultCode int) string {
+	switch resultCode {
+	case 0:
+		return "The service request is processed successfully."
+	case 1032:
+		return "Request cancelled by user"
+	case 1037:
+		return "DS timeout user cannot be reached"
+	default:
+		return "The transaction failed"
+	}
+}
@@ -0,0 +1,34 @@
+package mocks
+
+import (
+	"context"
+	"sync"
+
+	"github.com/ernestngugi/sil-backend/internal/model"
+)
+
+type mockEmailSender struct {
+	mu   sync.Mutex
+	sent []*model.EmailMessage
+}
+
+func NewMockEmailSender() *mockEmailSender {
+	return &mockEmailSender{}
+}
+
+func (m *mockEmailSender) Send(ctx context.Context, message *model.EmailMessage) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.sent = append(m.sent, message)
+
+	return nil
+}
+
+// Sent returns the messages passed to Send so far.
+func (m *mockEmailSender) Sent() []*model.EmailMessage {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]*model.EmailMessage{}, m.sent...)
+}
@@ -0,0 +1,34 @@
+package mocks
+
+import (
+	"context"
+	"sync"
+
+	"github.com/ernestngugi/sil-backend/internal/model"
+)
+
+type mockSMSSender struct {
+	mu   sync.Mutex
+	sent []*model.SMSMessage
+}
+
+func NewMockSMSSender() *mockSMSSender {
+	return &mockSMSSender{}
+}
+
+func (m *mockSMSSender) Send(ctx context.Context, request *model.SMSMessage) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.sent = append(m.sent, request)
+
+	return nil
+}
+
+// Sent returns the requests passed to Send so far.
+func (m *mockSMSSender) Sent() []*model.SMSMessage {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]*model.SMSMessage{}, m.sent...)
+}
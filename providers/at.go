@@ -1,8 +1,12 @@
 package providers
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"net/url"
 	"os"
@@ -10,44 +14,95 @@ import (
 	"strings"
 	"time"
 
+	"github.com/ernestngugi/sil-backend/internal/logging"
 	"github.com/ernestngugi/sil-backend/internal/model"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
-type (
-	ATProvider interface {
-		Send(request *model.ATRequest) error
-	}
+const tracerName = "github.com/ernestngugi/sil-backend/providers"
+
+var smsProviderKey = attribute.Key("sms.provider")
+
+// ProviderAT is the name Africa's Talking is configured and reported under.
+const ProviderAT = "at"
+
+const defaultATSenderID = "SIL"
+
+// atRecipientSuccess is the status of a recipient Africa's Talking accepted
+// the message for. Any other status, InvalidPhoneNumber or
+// InsufficientBalance say, means it was not sent.
+const atRecipientSuccess = "Success"
+
+// atResponse is the part of Africa's Talking's reply the provider reads.
+// Recipients' numbers are left out so they are never logged.
+type atResponse struct {
+	SMSMessageData struct {
+		Recipients []struct {
+			StatusCode int    `json:"statusCode"`
+			Status     string `json:"status"`
+			MessageID  string `json:"messageId"`
+		} `json:"Recipients"`
+	} `json:"SMSMessageData"`
+}
+
+type atProvider struct {
+	atBaseURL,
+	username,
+	key,
+	senderID string
+	client *http.Client
+	logger *slog.Logger
+}
 
-	atProvider struct {
-		atBaseURL,
-		username,
-		key string
-		client *http.Client
+// NewATProvider sends messages through Africa's Talking, configured by
+// AT_BASE_URL, AT_USERNAME, AT_KEY and AT_SENDER_ID.
+func NewATProvider(logger *slog.Logger) SMSSender {
+
+	senderID := os.Getenv("AT_SENDER_ID")
+	if senderID == "" {
+		senderID = defaultATSenderID
 	}
-)
 
-func NewATProvider() ATProvider {
-	return newATProviderWithCredentials(os.Getenv("AT_BASE_URL"), os.Getenv("AT_USERNAME"), os.Getenv("AT_KEY"))
+	return newATProviderWithCredentials(os.Getenv("AT_BASE_URL"), os.Getenv("AT_USERNAME"), os.Getenv("AT_KEY"), senderID, logger)
 }
 
-func newATProviderWithCredentials(atBaseURL, username, key string) ATProvider {
+func newATProviderWithCredentials(atBaseURL, username, key, senderID string, logger *slog.Logger) SMSSender {
 	return &atProvider{
 		atBaseURL: atBaseURL,
 		username:  username,
 		key:       key,
+		senderID:  senderID,
 		client: &http.Client{
-			Timeout: 5 * time.Second,
+			Timeout:   5 * time.Second,
+			Transport: otelhttp.NewTransport(http.DefaultTransport),
 		},
+		logger: logger,
 	}
 }
 
-func (p *atProvider) Send(req *model.ATRequest) error {
+func (p *atProvider) Send(ctx context.Context, req *model.SMSMessage) (err error) {
+
+	ctx, span := otel.Tracer(tracerName).Start(ctx, "sms.send",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(smsProviderKey.String(ProviderAT)),
+	)
+	defer func() {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}()
 
 	atRequest := map[string]string{
 		"username": p.username,
 		"message":  req.Message,
 		"to":       req.Number,
-		"from":     "SIL",
+		"from":     p.senderID,
 		"enqueue":  "1",
 	}
 
@@ -57,7 +112,7 @@ func (p *atProvider) Send(req *model.ATRequest) error {
 		form.Add(key, value)
 	}
 
-	request, err := http.NewRequest(http.MethodPost, p.atBaseURL, strings.NewReader(form.Encode()))
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, p.atBaseURL, strings.NewReader(form.Encode()))
 	if err != nil {
 		return err
 	}
@@ -69,6 +124,10 @@ func (p *atProvider) Send(req *model.ATRequest) error {
 	header.Add("apikey", p.key)
 	header.Add("Accept", "application/json")
 
+	if requestID := logging.RequestIDFromContext(ctx); requestID != "" {
+		header.Set(logging.RequestIDHeader, requestID)
+	}
+
 	request.Header = header
 	request.Close = true
 
@@ -84,7 +143,40 @@ func (p *atProvider) Send(req *model.ATRequest) error {
 		return err
 	}
 
-	fmt.Printf("at response %v", string(body))
+	// The body repeats the recipients' numbers, so only the message ids
+	// are logged.
+	var atResponse atResponse
+	json.Unmarshal(body, &atResponse)
+
+	var messageIDs []string
+	for _, recipient := range atResponse.SMSMessageData.Recipients {
+		messageIDs = append(messageIDs, recipient.MessageID)
+	}
+
+	p.logger.DebugContext(
+		ctx,
+		"at response",
+		slog.String("phone_number", req.Number),
+		slog.Int("status", response.StatusCode),
+		slog.Any("message_ids", messageIDs),
+	)
+
+	if response.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("at request failed with status %v", response.StatusCode)
+	}
+
+	// Africa's Talking answers 201 even when it could not send to a
+	// recipient, and reports why on the recipient.
+	recipients := atResponse.SMSMessageData.Recipients
+	if len(recipients) == 0 {
+		return errors.New("at response listed no recipients")
+	}
+
+	for _, recipient := range recipients {
+		if recipient.Status != atRecipientSuccess {
+			return fmt.Errorf("at could not send to recipient: %v (%v)", recipient.Status, recipient.StatusCode)
+		}
+	}
 
 	return nil
 }
@@ -0,0 +1,97 @@
+package providers
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ernestngugi/sil-backend/internal/logging"
+	"github.com/ernestngugi/sil-backend/internal/model"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestATProvider(t *testing.T) {
+
+	exporter := tracetest.NewInMemoryExporter()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	var received *http.Request
+	status := http.StatusCreated
+	body := `{"SMSMessageData":{"Message":"Sent to 1/1","Recipients":[{"statusCode":101,"number":"+254728389583","status":"Success","messageId":"ATXid_1"}]}}`
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		received = r
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	atProvider := newATProviderWithCredentials(server.URL, "sandbox", "atsk_key", "SIL", logging.Discard())
+
+	ctx := logging.WithRequestID(context.Background(), "request-1")
+
+	t.Run("sends the message with trace and request ids", func(t *testing.T) {
+
+		exporter.Reset()
+
+		err := atProvider.Send(ctx, &model.SMSMessage{Number: "254728389583", Message: "hello"})
+		assert.NoError(t, err)
+
+		assert.Equal(t, "254728389583", received.PostForm.Get("to"))
+		assert.Equal(t, "hello", received.PostForm.Get("message"))
+		assert.Equal(t, "sandbox", received.PostForm.Get("username"))
+		assert.Equal(t, "atsk_key", received.Header.Get("apikey"))
+		assert.Equal(t, "request-1", received.Header.Get(logging.RequestIDHeader))
+		assert.NotEmpty(t, received.Header.Get("traceparent"))
+
+		var names []string
+		for _, span := range exporter.GetSpans() {
+			names = append(names, span.Name)
+		}
+		assert.Contains(t, names, "sms.send")
+	})
+
+	t.Run("logs message ids but not the numbers in the response", func(t *testing.T) {
+
+		var logs bytes.Buffer
+
+		atProvider := newATProviderWithCredentials(server.URL, "sandbox", "atsk_key", "SIL",
+			logging.New(&logs, &logging.Config{Level: slog.LevelDebug, Format: logging.FormatJSON}))
+
+		err := atProvider.Send(ctx, &model.SMSMessage{Number: "254728389583", Message: "hello"})
+		assert.NoError(t, err)
+
+		assert.Contains(t, logs.String(), "ATXid_1")
+		assert.NotContains(t, logs.String(), "254728389583")
+	})
+
+	t.Run("fails when a recipient was not sent to", func(t *testing.T) {
+
+		body = `{"SMSMessageData":{"Message":"Sent to 0/1","Recipients":[{"statusCode":405,"number":"+254728389583","status":"InsufficientBalance","messageId":"None"}]}}`
+
+		err := atProvider.Send(ctx, &model.SMSMessage{Number: "254728389583", Message: "hello"})
+		assert.EqualError(t, err, "at could not send to recipient: InsufficientBalance (405)")
+
+		body = `{"SMSMessageData":{"Message":"InvalidPhoneNumber","Recipients":[]}}`
+
+		err = atProvider.Send(ctx, &model.SMSMessage{Number: "254", Message: "hello"})
+		assert.EqualError(t, err, "at response listed no recipients")
+	})
+
+	t.Run("fails on an error response", func(t *testing.T) {
+
+		status = http.StatusUnauthorized
+
+		err := atProvider.Send(ctx, &model.SMSMessage{Number: "254728389583", Message: "hello"})
+		assert.Error(t, err)
+	})
+}
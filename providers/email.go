@@ -0,0 +1,24 @@
+package providers
+
+import (
+	"context"
+
+	"github.com/ernestngugi/sil-backend/internal/model"
+)
+
+// EmailSender sends an email through one email provider.
+type EmailSender interface {
+	Send(ctx context.Context, message *model.EmailMessage) error
+}
+
+type noopEmailSender struct{}
+
+// NewNoopEmailSender discards every email, for when no email provider is
+// configured.
+func NewNoopEmailSender() EmailSender {
+	return noopEmailSender{}
+}
+
+func (noopEmailSender) Send(ctx context.Context, message *model.EmailMessage) error {
+	return nil
+}
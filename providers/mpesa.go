@@ -0,0 +1,391 @@
+package providers
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ernestngugi/sil-backend/internal/logging"
+	"github.com/ernestngugi/sil-backend/internal/model"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	defaultMPesaBaseURL = "https://sandbox.safaricom.co.ke"
+
+	mpesaTokenPath    = "/oauth/v1/generate?grant_type=client_credentials"
+	mpesaSTKPushPath  = "/mpesa/stkpush/v1/processrequest"
+	mpesaSTKQueryPath = "/mpesa/stkpushquery/v1/query"
+
+	// mpesaProcessingCode is the error a status query returns while the
+	// customer has not answered the prompt.
+	mpesaProcessingCode = "500.001.1001"
+	// mpesaTimestampLayout is the format of Timestamp, in East Africa Time.
+	mpesaTimestampLayout = "20060102150405"
+	// M-Pesa truncates longer references and descriptions on the prompt.
+	mpesaAccountReferenceLength = 12
+	mpesaDescriptionLength      = 13
+	// mpesaTokenMargin renews access tokens before they expire.
+	mpesaTokenMargin = time.Minute
+)
+
+// eastAfricaTime is the zone Daraja timestamps are read in. Kenya does not
+// observe daylight saving.
+var eastAfricaTime = time.FixedZone("EAT", 3*60*60)
+
+// ErrSTKPushProcessing is returned by STKQuery while the customer has not yet
+// answered the prompt.
+var ErrSTKPushProcessing = errors.New("stk push is still being processed")
+
+// ErrSTKPushNotSent matches STKPush errors after which the customer was
+// certainly not prompted, because Daraja turned the push down. Other errors,
+// such as timeouts, may hide a push Daraja accepted.
+var ErrSTKPushNotSent = errors.New("stk push was not sent")
+
+type (
+	// MPesa collects payments through Safaricom's Daraja API.
+	MPesa interface {
+		STKPush(ctx context.Context, request *model.STKPushRequest) (*model.STKPushResponse, error)
+		STKQuery(ctx context.Context, checkoutRequestID string) (*model.PaymentResult, error)
+	}
+
+	mpesaProvider struct {
+		baseURL,
+		consumerKey,
+		consumerSecret,
+		shortCode,
+		passKey,
+		callbackURL string
+		client *http.Client
+		logger *slog.Logger
+
+		mu          sync.Mutex
+		token       string
+		tokenExpiry time.Time
+	}
+
+	// mpesaError is the body Daraja returns with failed requests.
+	mpesaError struct {
+		Status  int    `json:"-"`
+		Code    string `json:"errorCode"`
+		Message string `json:"errorMessage"`
+	}
+
+	// notSentError is an STKPush error that matches ErrSTKPushNotSent.
+	notSentError struct {
+		err error
+	}
+)
+
+// NewMPesaProvider collects payments with STK Push through the Daraja API
+// configured by MPESA_BASE_URL, MPESA_CONSUMER_KEY, MPESA_CONSUMER_SECRET,
+// MPESA_SHORTCODE, MPESA_PASSKEY and MPESA_CALLBACK_URL. Without a base URL
+// the sandbox is used.
+func NewMPesaProvider(logger *slog.Logger) (MPesa, error) {
+
+	baseURL := os.Getenv("MPESA_BASE_URL")
+	if baseURL == "" {
+		baseURL = defaultMPesaBaseURL
+	}
+
+	return newMPesaProviderWithCredentials(
+		baseURL,
+		os.Getenv("MPESA_CONSUMER_KEY"),
+		os.Getenv("MPESA_CONSUMER_SECRET"),
+		os.Getenv("MPESA_SHORTCODE"),
+		os.Getenv("MPESA_PASSKEY"),
+		os.Getenv("MPESA_CALLBACK_URL"),
+		logger,
+	)
+}
+
+func newMPesaProviderWithCredentials(baseURL, consumerKey, consumerSecret, shortCode, passKey, callbackURL string, logger *slog.Logger) (MPesa, error) {
+
+	for name, value := range map[string]string{
+		"MPESA_CONSUMER_KEY":    consumerKey,
+		"MPESA_CONSUMER_SECRET": consumerSecret,
+		"MPESA_SHORTCODE":       shortCode,
+		"MPESA_PASSKEY":         passKey,
+		"MPESA_CALLBACK_URL":    callbackURL,
+	} {
+		if value == "" {
+			return nil, fmt.Errorf("%v is not set", name)
+		}
+	}
+
+	return &mpesaProvider{
+		baseURL:        baseURL,
+		consumerKey:    consumerKey,
+		consumerSecret: consumerSecret,
+		shortCode:      shortCode,
+		passKey:        passKey,
+		callbackURL:    callbackURL,
+		client: &http.Client{
+			Timeout:   10 * time.Second,
+			Transport: otelhttp.NewTransport(http.DefaultTransport),
+		},
+		logger: logger,
+	}, nil
+}
+
+// STKPush prompts the customer to approve the payment on their phone. The
+// result arrives later at the callback URL.
+func (p *mpesaProvider) STKPush(ctx context.Context, request *model.STKPushRequest) (_ *model.STKPushResponse, err error) {
+
+	ctx, span := otel.Tracer(tracerName).Start(ctx, "mpesa.stk_push", trace.WithSpanKind(trace.SpanKindClient))
+	defer func() {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}()
+
+	password, timestamp := p.password(time.Now())
+
+	body := map[string]any{
+		"BusinessShortCode": p.shortCode,
+		"Password":          password,
+		"Timestamp":         timestamp,
+		"TransactionType":   "CustomerPayBillOnline",
+		"Amount":            request.Amount,
+		"PartyA":            request.PhoneNumber,
+		"PartyB":            p.shortCode,
+		"PhoneNumber":       request.PhoneNumber,
+		"CallBackURL":       p.callbackURL,
+		"AccountReference":  truncate(request.AccountReference, mpesaAccountReferenceLength),
+		"TransactionDesc":   truncate(request.Description, mpesaDescriptionLength),
+	}
+
+	var response struct {
+		MerchantRequestID   string `json:"MerchantRequestID"`
+		CheckoutRequestID   string `json:"CheckoutRequestID"`
+		ResponseCode        string `json:"ResponseCode"`
+		ResponseDescription string `json:"ResponseDescription"`
+		CustomerMessage     string `json:"CustomerMessage"`
+	}
+
+	err = p.post(ctx, mpesaSTKPushPath, body, &response)
+	if err != nil {
+
+		// Daraja only answers with a client error when it did not take
+		// the push. Server errors may come from a gateway in front of it
+		// that gave up waiting.
+		var mpesaErr *mpesaError
+		if errors.As(err, &mpesaErr) && mpesaErr.Status < http.StatusInternalServerError {
+			return &model.STKPushResponse{}, &notSentError{err: err}
+		}
+
+		return &model.STKPushResponse{}, err
+	}
+
+	if response.ResponseCode != "0" {
+		return &model.STKPushResponse{}, &notSentError{
+			err: fmt.Errorf("mpesa stk push rejected: %v (%v)", response.ResponseDescription, response.ResponseCode),
+		}
+	}
+
+	return &model.STKPushResponse{
+		MerchantRequestID: response.MerchantRequestID,
+		CheckoutRequestID: response.CheckoutRequestID,
+		CustomerMessage:   response.CustomerMessage,
+	}, nil
+}
+
+// STKQuery asks for the result of an STK Push whose callback has not
+// arrived. Queries do not report the receipt number.
+func (p *mpesaProvider) STKQuery(ctx context.Context, checkoutRequestID string) (_ *model.PaymentResult, err error) {
+
+	ctx, span := otel.Tracer(tracerName).Start(ctx, "mpesa.stk_query", trace.WithSpanKind(trace.SpanKindClient))
+	defer func() {
+		if err != nil && !errors.Is(err, ErrSTKPushProcessing) {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}()
+
+	password, timestamp := p.password(time.Now())
+
+	body := map[string]any{
+		"BusinessShortCode": p.shortCode,
+		"Password":          password,
+		"Timestamp":         timestamp,
+		"CheckoutRequestID": checkoutRequestID,
+	}
+
+	var response struct {
+		ResponseCode string `json:"ResponseCode"`
+		ResultCode   string `json:"ResultCode"`
+		ResultDesc   string `json:"ResultDesc"`
+	}
+
+	err = p.post(ctx, mpesaSTKQueryPath, body, &response)
+	if err != nil {
+
+		var mpesaErr *mpesaError
+		if errors.As(err, &mpesaErr) && mpesaErr.Code == mpesaProcessingCode {
+			return &model.PaymentResult{}, ErrSTKPushProcessing
+		}
+
+		return &model.PaymentResult{}, err
+	}
+
+	resultCode, err := strconv.Atoi(response.ResultCode)
+	if err != nil {
+		return &model.PaymentResult{}, fmt.Errorf("mpesa stk query returned result code %q", response.ResultCode)
+	}
+
+	return &model.PaymentResult{
+		CheckoutRequestID: checkoutRequestID,
+		ResultCode:        resultCode,
+		ResultDescription: response.ResultDesc,
+	}, nil
+}
+
+// password is the base64 of the short code, pass key and timestamp, which is
+// returned alongside it.
+func (p *mpesaProvider) password(now time.Time) (string, string) {
+
+	timestamp := now.In(eastAfricaTime).Format(mpesaTimestampLayout)
+
+	return base64.StdEncoding.EncodeToString([]byte(p.shortCode + p.passKey + timestamp)), timestamp
+}
+
+func (p *mpesaProvider) post(ctx context.Context, path string, body, out any) error {
+
+	token, err := p.accessToken(ctx)
+	if err != nil {
+		return err
+	}
+
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, p.baseURL+path, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+
+	request.Header.Set("Authorization", "Bearer "+token)
+	request.Header.Set("Content-Type", "application/json")
+
+	if requestID := logging.RequestIDFromContext(ctx); requestID != "" {
+		request.Header.Set(logging.RequestIDHeader, requestID)
+	}
+
+	return p.do(ctx, request, out)
+}
+
+// accessToken returns the cached OAuth token, generating a new one when it
+// is about to expire.
+func (p *mpesaProvider) accessToken(ctx context.Context) (string, error) {
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.token != "" && time.Now().Before(p.tokenExpiry) {
+		return p.token, nil
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, p.baseURL+mpesaTokenPath, nil)
+	if err != nil {
+		return "", err
+	}
+
+	request.SetBasicAuth(p.consumerKey, p.consumerSecret)
+
+	var response struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   string `json:"expires_in"`
+	}
+
+	err = p.do(ctx, request, &response)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate mpesa access token: %w", err)
+	}
+
+	expiresIn, err := strconv.Atoi(response.ExpiresIn)
+	if err != nil {
+		return "", fmt.Errorf("mpesa access token has invalid expiry %q", response.ExpiresIn)
+	}
+
+	p.token = response.AccessToken
+	p.tokenExpiry = time.Now().Add(time.Duration(expiresIn)*time.Second - mpesaTokenMargin)
+
+	return p.token, nil
+}
+
+func (p *mpesaProvider) do(ctx context.Context, request *http.Request, out any) error {
+
+	request.Header.Set("Accept", "application/json")
+
+	response, err := p.client.Do(request)
+	if err != nil {
+		return err
+	}
+
+	defer response.Body.Close()
+
+	p.logger.DebugContext(
+		ctx,
+		"mpesa response",
+		slog.String("path", request.URL.Path),
+		slog.Int("status", response.StatusCode),
+	)
+
+	if response.StatusCode >= http.StatusMultipleChoices {
+
+		mpesaErr := &mpesaError{Status: response.StatusCode}
+		json.NewDecoder(response.Body).Decode(mpesaErr)
+
+		return mpesaErr
+	}
+
+	return json.NewDecoder(response.Body).Decode(out)
+}
+
+func (e *mpesaError) Error() string {
+
+	if e.Code == "" {
+		return fmt.Sprintf("mpesa request failed with status %v", e.Status)
+	}
+
+	return fmt.Sprintf("mpesa request failed with status %v: %v (%v)", e.Status, e.Message, e.Code)
+}
+
+func (e *notSentError) Error() string {
+	return e.err.Error()
+}
+
+func (e *notSentError) Unwrap() error {
+	return e.err
+}
+
+func (e *notSentError) Is(target error) bool {
+	return target == ErrSTKPushNotSent
+}
+
+func truncate(value string, length int) string {
+
+	runes := []rune(value)
+	if len(runes) <= length {
+		return value
+	}
+
+	return string(runes[:length])
+}
@@ -0,0 +1,104 @@
+package providers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ernestngugi/sil-backend/internal/logging"
+	"github.com/ernestngugi/sil-backend/internal/model"
+	"github.com/ernestngugi/sil-backend/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMPesaProvider(t *testing.T) {
+
+	daraja := mocks.NewDarajaServer()
+	defer daraja.Close()
+
+	mpesa, err := newMPesaProviderWithCredentials(
+		daraja.URL,
+		mocks.DarajaConsumerKey,
+		mocks.DarajaConsumerSecret,
+		mocks.DarajaShortCode,
+		mocks.DarajaPassKey,
+		"https://sil.example/v1/webhooks/mpesa/callback",
+		logging.Discard(),
+	)
+	assert.NoError(t, err)
+
+	ctx := context.Background()
+
+	t.Run("requires credentials", func(t *testing.T) {
+
+		_, err := newMPesaProviderWithCredentials(daraja.URL, "", "", "", "", "", logging.Discard())
+		assert.Error(t, err)
+	})
+
+	t.Run("sends an stk push and queries its result", func(t *testing.T) {
+
+		response, err := mpesa.STKPush(ctx, &model.STKPushRequest{
+			PhoneNumber:      "254708374149",
+			Amount:           1250,
+			AccountReference: "Order 1024 for maize",
+			Description:      "Payment for order 1024",
+		})
+		assert.NoError(t, err)
+		assert.NotEmpty(t, response.CheckoutRequestID)
+
+		pushes := daraja.Pushes()
+		assert.Len(t, pushes, 1)
+		assert.Equal(t, "254708374149", pushes[0].PhoneNumber)
+		assert.Equal(t, int64(1250), pushes[0].Amount)
+		assert.Equal(t, "Order 1024 f", pushes[0].AccountReference)
+		assert.Equal(t, "https://sil.example/v1/webhooks/mpesa/callback", pushes[0].CallBackURL)
+
+		_, err = mpesa.STKQuery(ctx, response.CheckoutRequestID)
+		assert.ErrorIs(t, err, ErrSTKPushProcessing)
+
+		_, err = daraja.Resolve(response.CheckoutRequestID, 1032)
+		assert.NoError(t, err)
+
+		result, err := mpesa.STKQuery(ctx, response.CheckoutRequestID)
+		assert.NoError(t, err)
+		assert.Equal(t, 1032, result.ResultCode)
+		assert.Equal(t, "Request cancelled by user", result.ResultDescription)
+	})
+
+	t.Run("reports daraja errors", func(t *testing.T) {
+
+		daraja.RejectPhoneNumber = "254700000000"
+
+		_, err := mpesa.STKPush(ctx, &model.STKPushRequest{PhoneNumber: "254700000000", Amount: 1})
+		assert.EqualError(t, err, "mpesa request failed with status 400: Bad Request - Invalid PhoneNumber (400.002.02)")
+		assert.ErrorIs(t, err, ErrSTKPushNotSent)
+	})
+
+	t.Run("does not report pushes lost in a timeout as not sent", func(t *testing.T) {
+
+		daraja.LosePhoneNumber = "254700000001"
+
+		_, err := mpesa.STKPush(ctx, &model.STKPushRequest{PhoneNumber: "254700000001", Amount: 1})
+		assert.Error(t, err)
+		assert.NotErrorIs(t, err, ErrSTKPushNotSent)
+		assert.Len(t, daraja.Pushes(), 2)
+	})
+
+	t.Run("rejects invalid consumer credentials", func(t *testing.T) {
+
+		wrong, err := newMPesaProviderWithCredentials(daraja.URL, "key", "secret", mocks.DarajaShortCode, mocks.DarajaPassKey, "https://sil.example", logging.Discard())
+		assert.NoError(t, err)
+
+		_, err = wrong.STKPush(ctx, &model.STKPushRequest{PhoneNumber: "254708374149", Amount: 1})
+		assert.ErrorContains(t, err, "failed to generate mpesa access token")
+	})
+
+	t.Run("signs requests with east africa time", func(t *testing.T) {
+
+		provider := mpesa.(*mpesaProvider)
+
+		password, timestamp := provider.password(time.Date(2026, 10, 19, 21, 30, 0, 0, time.UTC))
+		assert.Equal(t, "20261020003000", timestamp)
+		assert.Equal(t, "MTc0Mzc5cGFzcy1rZXkyMDI2MTAyMDAwMzAwMA==", password)
+	})
+}
@@ -8,6 +8,10 @@ import (
 	"golang.org/x/oauth2"
 )
 
+// OIDCIssuerURL is the issuer used for customer login. Its discovery document
+// is served at OIDCIssuerURL + "/.well-known/openid-configuration".
+const OIDCIssuerURL = "https://accounts.google.com"
+
 type OpenID interface {
 	AuthCodeURL(code string, opts ...oauth2.AuthCodeOption) string
 	Exchange(ctx context.Context, token string, opts ...oauth2.AuthCodeOption) (*oauth2.Token, error)
@@ -25,7 +29,7 @@ func NewOpenID() *openID {
 
 func newOpenIDWithCredentials(clientID, clientSecret, redirectURL string) *openID {
 
-	provider, err := oidc.NewProvider(context.Background(), "https://accounts.google.com")
+	provider, err := oidc.NewProvider(context.Background(), OIDCIssuerURL)
 	if err != nil {
 		panic("failed to load oidc provider")
 	}
@@ -0,0 +1,12 @@
+package providers
+
+import (
+	"context"
+
+	"github.com/ernestngugi/sil-backend/internal/model"
+)
+
+// SMSSender sends a text message through one SMS provider.
+type SMSSender interface {
+	Send(ctx context.Context, message *model.SMSMessage) error
+}
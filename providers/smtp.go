@@ -0,0 +1,265 @@
+package providers
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ernestngugi/sil-backend/internal/model"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ProviderSMTP is the name the SMTP provider is reported under.
+const ProviderSMTP = "smtp"
+
+const (
+	defaultSMTPPort    = "587"
+	defaultSMTPTimeout = 10 * time.Second
+)
+
+var emailProviderKey = attribute.Key("email.provider")
+
+// ErrSMTPNotConfigured is returned by NewSMTPProvider when SMTP_HOST is not
+// set.
+var ErrSMTPNotConfigured = errors.New("SMTP_HOST is not set")
+
+type smtpProvider struct {
+	host,
+	port,
+	username,
+	password string
+	from    *mail.Address
+	timeout time.Duration
+	logger  *slog.Logger
+}
+
+// NewSMTPProvider sends email through the SMTP server configured by
+// SMTP_HOST, SMTP_PORT, SMTP_USERNAME, SMTP_PASSWORD and SMTP_FROM. STARTTLS
+// is used whenever the server offers it, and credentials are only sent over
+// TLS or to localhost.
+func NewSMTPProvider(logger *slog.Logger) (EmailSender, error) {
+
+	port := os.Getenv("SMTP_PORT")
+	if port == "" {
+		port = defaultSMTPPort
+	}
+
+	return newSMTPProviderWithCredentials(os.Getenv("SMTP_HOST"), port, os.Getenv("SMTP_USERNAME"), os.Getenv("SMTP_PASSWORD"), os.Getenv("SMTP_FROM"), logger)
+}
+
+func newSMTPProviderWithCredentials(host, port, username, password, from string, logger *slog.Logger) (EmailSender, error) {
+
+	if host == "" {
+		return nil, ErrSMTPNotConfigured
+	}
+
+	fromAddress, err := mail.ParseAddress(from)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SMTP_FROM %q: %w", from, err)
+	}
+
+	return &smtpProvider{
+		host:     host,
+		port:     port,
+		username: username,
+		password: password,
+		from:     fromAddress,
+		timeout:  defaultSMTPTimeout,
+		logger:   logger,
+	}, nil
+}
+
+func (p *smtpProvider) Send(ctx context.Context, message *model.EmailMessage) (err error) {
+
+	ctx, span := otel.Tracer(tracerName).Start(ctx, "email.send",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(emailProviderKey.String(ProviderSMTP)),
+	)
+	defer func() {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}()
+
+	to, err := mail.ParseAddress(message.To)
+	if err != nil {
+		return fmt.Errorf("invalid recipient: %w", err)
+	}
+
+	body, err := p.compose(to, message)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+
+	var dialer net.Dialer
+
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(p.host, p.port))
+	if err != nil {
+		return err
+	}
+
+	// net/smtp has no context support, the deadline bounds the whole
+	// conversation instead.
+	deadline, _ := ctx.Deadline()
+
+	err = conn.SetDeadline(deadline)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+
+	client, err := smtp.NewClient(conn, p.host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		err = client.StartTLS(&tls.Config{ServerName: p.host, MinVersion: tls.VersionTLS12})
+		if err != nil {
+			return err
+		}
+	}
+
+	if p.username != "" {
+		err = client.Auth(smtp.PlainAuth("", p.username, p.password, p.host))
+		if err != nil {
+			return err
+		}
+	}
+
+	err = client.Mail(p.from.Address)
+	if err != nil {
+		return err
+	}
+
+	err = client.Rcpt(to.Address)
+	if err != nil {
+		return err
+	}
+
+	data, err := client.Data()
+	if err != nil {
+		return err
+	}
+
+	_, err = data.Write(body)
+	if err != nil {
+		data.Close()
+		return err
+	}
+
+	err = data.Close()
+	if err != nil {
+		return err
+	}
+
+	p.logger.DebugContext(ctx, "email sent", slog.String("subject", message.Subject))
+
+	return client.Quit()
+}
+
+// compose builds a multipart/alternative message with the plain text part
+// first, so clients showing HTML prefer the last part.
+func (p *smtpProvider) compose(to *mail.Address, message *model.EmailMessage) ([]byte, error) {
+
+	var body bytes.Buffer
+
+	parts := multipart.NewWriter(&body)
+
+	messageID, err := p.messageID()
+	if err != nil {
+		return nil, err
+	}
+
+	headers := []struct{ name, value string }{
+		{"From", p.from.String()},
+		{"To", to.String()},
+		{"Subject", mime.QEncoding.Encode("utf-8", message.Subject)},
+		{"Date", time.Now().Format(time.RFC1123Z)},
+		{"Message-ID", messageID},
+		{"MIME-Version", "1.0"},
+		{"Content-Type", "multipart/alternative; boundary=" + parts.Boundary()},
+	}
+
+	var header bytes.Buffer
+
+	for _, h := range headers {
+		fmt.Fprintf(&header, "%v: %v\r\n", h.name, h.value)
+	}
+
+	header.WriteString("\r\n")
+
+	for _, part := range []struct{ contentType, content string }{
+		{"text/plain; charset=utf-8", message.Text},
+		{"text/html; charset=utf-8", message.HTML},
+	} {
+
+		writer, err := parts.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		encoder := quotedprintable.NewWriter(writer)
+
+		_, err = io.WriteString(encoder, part.content)
+		if err != nil {
+			return nil, err
+		}
+
+		err = encoder.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = parts.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	return append(header.Bytes(), body.Bytes()...), nil
+}
+
+func (p *smtpProvider) messageID() (string, error) {
+
+	random := make([]byte, 16)
+
+	_, err := rand.Read(random)
+	if err != nil {
+		return "", err
+	}
+
+	domain := p.from.Address[strings.LastIndex(p.from.Address, "@")+1:]
+
+	return fmt.Sprintf("<%v@%v>", hex.EncodeToString(random), domain), nil
+}
@@ -0,0 +1,203 @@
+package providers
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ernestngugi/sil-backend/internal/logging"
+	"github.com/ernestngugi/sil-backend/internal/model"
+	"github.com/stretchr/testify/assert"
+)
+
+// smtpStandIn speaks just enough SMTP to accept messages from net/smtp and
+// keeps what it was sent.
+type smtpStandIn struct {
+	listener net.Listener
+
+	mu         sync.Mutex
+	rejectRcpt bool
+	from       []string
+	to         []string
+	messages   []string
+}
+
+func newSMTPStandIn(t *testing.T) *smtpStandIn {
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+
+	server := &smtpStandIn{listener: listener}
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go server.serve(conn)
+		}
+	}()
+
+	t.Cleanup(func() { listener.Close() })
+
+	return server
+}
+
+func (s *smtpStandIn) serve(conn net.Conn) {
+	defer conn.Close()
+
+	text := textproto.NewConn(conn)
+
+	text.PrintfLine("220 localhost ESMTP stand-in")
+
+	for {
+		line, err := text.ReadLine()
+		if err != nil {
+			return
+		}
+
+		command, argument, _ := strings.Cut(line, " ")
+
+		switch strings.ToUpper(command) {
+		case "EHLO", "HELO":
+			text.PrintfLine("250 localhost")
+		case "MAIL":
+			s.record(&s.from, argument)
+			text.PrintfLine("250 OK")
+		case "RCPT":
+			s.mu.Lock()
+			reject := s.rejectRcpt
+			s.mu.Unlock()
+
+			if reject {
+				text.PrintfLine("550 mailbox unavailable")
+				continue
+			}
+
+			s.record(&s.to, argument)
+			text.PrintfLine("250 OK")
+		case "DATA":
+			text.PrintfLine("354 end data with <CR><LF>.<CR><LF>")
+
+			data, err := io.ReadAll(text.DotReader())
+			if err != nil {
+				return
+			}
+
+			s.record(&s.messages, string(data))
+			text.PrintfLine("250 OK")
+		case "RSET", "NOOP":
+			text.PrintfLine("250 OK")
+		case "QUIT":
+			text.PrintfLine("221 bye")
+			return
+		default:
+			text.PrintfLine("502 command not implemented")
+		}
+	}
+}
+
+func (s *smtpStandIn) record(values *[]string, value string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	*values = append(*values, value)
+}
+
+func (s *smtpStandIn) port() string {
+	_, port, _ := net.SplitHostPort(s.listener.Addr().String())
+	return port
+}
+
+func TestSMTPProvider(t *testing.T) {
+
+	ctx := context.Background()
+
+	server := newSMTPStandIn(t)
+
+	smtpProvider, err := newSMTPProviderWithCredentials("127.0.0.1", server.port(), "", "", "SIL Orders <orders@sil.example>", logging.Discard())
+	if err != nil {
+		t.Fatalf("failed to create smtp provider: %v", err)
+	}
+
+	t.Run("sends a multipart message with text and html", func(t *testing.T) {
+
+		err := smtpProvider.Send(ctx, &model.EmailMessage{
+			To:      "jane@example.com",
+			Subject: "Oda yako imetumwa ✓",
+			Text:    "Your order 12 has been dispatched.",
+			HTML:    "<p>Your order <strong>12</strong> has been dispatched.</p>",
+		})
+		assert.NoError(t, err)
+
+		server.mu.Lock()
+		defer server.mu.Unlock()
+
+		assert.Equal(t, []string{"FROM:<orders@sil.example>"}, server.from)
+		assert.Equal(t, []string{"TO:<jane@example.com>"}, server.to)
+		assert.Len(t, server.messages, 1)
+
+		message, err := mail.ReadMessage(bufio.NewReader(strings.NewReader(server.messages[0])))
+		assert.NoError(t, err)
+
+		subject, err := new(mime.WordDecoder).DecodeHeader(message.Header.Get("Subject"))
+		assert.NoError(t, err)
+		assert.Equal(t, "Oda yako imetumwa ✓", subject)
+		assert.Equal(t, `"SIL Orders" <orders@sil.example>`, message.Header.Get("From"))
+		assert.NotEmpty(t, message.Header.Get("Message-ID"))
+
+		mediaType, params, err := mime.ParseMediaType(message.Header.Get("Content-Type"))
+		assert.NoError(t, err)
+		assert.Equal(t, "multipart/alternative", mediaType)
+
+		parts := multipart.NewReader(message.Body, params["boundary"])
+
+		contents := make(map[string]string)
+
+		for {
+			part, err := parts.NextPart()
+			if err != nil {
+				break
+			}
+
+			content, err := io.ReadAll(quotedprintable.NewReader(part))
+			assert.NoError(t, err)
+
+			contents[part.Header.Get("Content-Type")] = string(content)
+		}
+
+		assert.Equal(t, "Your order 12 has been dispatched.", contents["text/plain; charset=utf-8"])
+		assert.Equal(t, "<p>Your order <strong>12</strong> has been dispatched.</p>", contents["text/html; charset=utf-8"])
+	})
+
+	t.Run("fails when the server rejects the recipient", func(t *testing.T) {
+
+		server.mu.Lock()
+		server.rejectRcpt = true
+		server.mu.Unlock()
+
+		err := smtpProvider.Send(ctx, &model.EmailMessage{To: "gone@example.com", Subject: "test", Text: "test", HTML: "test"})
+		assert.Error(t, err)
+	})
+
+	t.Run("rejects invalid addresses", func(t *testing.T) {
+
+		err := smtpProvider.Send(ctx, &model.EmailMessage{To: "not an address", Subject: "test"})
+		assert.Error(t, err)
+
+		_, err = newSMTPProviderWithCredentials("127.0.0.1", server.port(), "", "", "", logging.Discard())
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,148 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ernestngugi/sil-backend/internal/logging"
+	"github.com/ernestngugi/sil-backend/internal/model"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ProviderTwilio is the name the Twilio compatible provider is configured and
+// reported under.
+const ProviderTwilio = "twilio"
+
+const defaultTwilioBaseURL = "https://api.twilio.com"
+
+type twilioProvider struct {
+	baseURL,
+	accountSID,
+	authToken,
+	from string
+	client *http.Client
+	logger *slog.Logger
+}
+
+// NewTwilioProvider sends messages through the Twilio Messages API, or any
+// provider implementing it, configured by TWILIO_BASE_URL,
+// TWILIO_ACCOUNT_SID, TWILIO_AUTH_TOKEN and TWILIO_FROM.
+func NewTwilioProvider(logger *slog.Logger) SMSSender {
+
+	baseURL := os.Getenv("TWILIO_BASE_URL")
+	if baseURL == "" {
+		baseURL = defaultTwilioBaseURL
+	}
+
+	return newTwilioProviderWithCredentials(baseURL, os.Getenv("TWILIO_ACCOUNT_SID"), os.Getenv("TWILIO_AUTH_TOKEN"), os.Getenv("TWILIO_FROM"), logger)
+}
+
+func newTwilioProviderWithCredentials(baseURL, accountSID, authToken, from string, logger *slog.Logger) SMSSender {
+	return &twilioProvider{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		accountSID: accountSID,
+		authToken:  authToken,
+		from:       from,
+		client: &http.Client{
+			Timeout:   5 * time.Second,
+			Transport: otelhttp.NewTransport(http.DefaultTransport),
+		},
+		logger: logger,
+	}
+}
+
+func (p *twilioProvider) Send(ctx context.Context, req *model.SMSMessage) (err error) {
+
+	ctx, span := otel.Tracer(tracerName).Start(ctx, "sms.send",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(smsProviderKey.String(ProviderTwilio)),
+	)
+	defer func() {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}()
+
+	form := url.Values{}
+	form.Set("To", e164(req.Number))
+	form.Set("From", p.from)
+	form.Set("Body", req.Message)
+
+	endpoint := fmt.Sprintf("%v/2010-04-01/Accounts/%v/Messages.json", p.baseURL, url.PathEscape(p.accountSID))
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+
+	request.SetBasicAuth(p.accountSID, p.authToken)
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Accept", "application/json")
+
+	if requestID := logging.RequestIDFromContext(ctx); requestID != "" {
+		request.Header.Set(logging.RequestIDHeader, requestID)
+	}
+
+	response, err := p.client.Do(request)
+	if err != nil {
+		return err
+	}
+
+	defer response.Body.Close()
+
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		return err
+	}
+
+	// The body repeats the recipient's number, so only the message sid is
+	// logged.
+	var twilioResponse struct {
+		SID     string `json:"sid"`
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	}
+	json.Unmarshal(body, &twilioResponse)
+
+	p.logger.DebugContext(
+		ctx,
+		"twilio response",
+		slog.String("phone_number", req.Number),
+		slog.Int("status", response.StatusCode),
+		slog.String("message_sid", twilioResponse.SID),
+	)
+
+	if response.StatusCode >= http.StatusMultipleChoices {
+
+		if twilioResponse.Message != "" {
+			return fmt.Errorf("twilio request failed with status %v: %v (%v)", response.StatusCode, twilioResponse.Message, twilioResponse.Code)
+		}
+
+		return fmt.Errorf("twilio request failed with status %v", response.StatusCode)
+	}
+
+	return nil
+}
+
+// e164 adds the leading plus Twilio requires to international numbers.
+func e164(number string) string {
+
+	if strings.HasPrefix(number, "+") {
+		return number
+	}
+
+	return "+" + number
+}
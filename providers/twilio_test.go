@@ -0,0 +1,73 @@
+package providers
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ernestngugi/sil-backend/internal/logging"
+	"github.com/ernestngugi/sil-backend/internal/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTwilioProvider(t *testing.T) {
+
+	var received *http.Request
+	status := http.StatusCreated
+	body := `{"sid":"SM123","status":"queued","to":"+254728389583"}`
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		received = r
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	twilioProvider := newTwilioProviderWithCredentials(server.URL+"/", "AC123", "token", "+15005550006", logging.Discard())
+
+	ctx := logging.WithRequestID(context.Background(), "request-1")
+
+	t.Run("sends the message to the account's messages", func(t *testing.T) {
+
+		err := twilioProvider.Send(ctx, &model.SMSMessage{Number: "254728389583", Message: "hello"})
+		assert.NoError(t, err)
+
+		assert.Equal(t, "/2010-04-01/Accounts/AC123/Messages.json", received.URL.Path)
+		assert.Equal(t, "+254728389583", received.PostForm.Get("To"))
+		assert.Equal(t, "+15005550006", received.PostForm.Get("From"))
+		assert.Equal(t, "hello", received.PostForm.Get("Body"))
+		assert.Equal(t, "request-1", received.Header.Get(logging.RequestIDHeader))
+
+		accountSID, authToken, ok := received.BasicAuth()
+		assert.True(t, ok)
+		assert.Equal(t, "AC123", accountSID)
+		assert.Equal(t, "token", authToken)
+	})
+
+	t.Run("logs the message sid but not the number in the response", func(t *testing.T) {
+
+		var logs bytes.Buffer
+
+		twilioProvider := newTwilioProviderWithCredentials(server.URL+"/", "AC123", "token", "+15005550006",
+			logging.New(&logs, &logging.Config{Level: slog.LevelDebug, Format: logging.FormatJSON}))
+
+		err := twilioProvider.Send(ctx, &model.SMSMessage{Number: "254728389583", Message: "hello"})
+		assert.NoError(t, err)
+
+		assert.Contains(t, logs.String(), "SM123")
+		assert.NotContains(t, logs.String(), "254728389583")
+	})
+
+	t.Run("reports the provider's error", func(t *testing.T) {
+
+		status = http.StatusBadRequest
+		body = `{"code":21211,"message":"Invalid 'To' Phone Number"}`
+
+		err := twilioProvider.Send(ctx, &model.SMSMessage{Number: "254", Message: "hello"})
+		assert.EqualError(t, err, "twilio request failed with status 400: Invalid 'To' Phone Number (21211)")
+	})
+}